@@ -3,8 +3,11 @@ package agent
 import (
 	"time"
 
+	"github.com/benbjohnson/clock"
+
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/models"
 )
 
 type MetricMaker interface {
@@ -17,6 +20,7 @@ type accumulator struct {
 	maker     MetricMaker
 	metrics   chan<- telegraf.Metric
 	precision time.Duration
+	clk       clock.Clock
 }
 
 func NewAccumulator(
@@ -27,6 +31,7 @@ func NewAccumulator(
 		maker:     maker,
 		metrics:   metrics,
 		precision: time.Nanosecond,
+		clk:       clock.New(),
 	}
 	return &acc
 }
@@ -79,7 +84,7 @@ func (ac *accumulator) AddHistogram(
 func (ac *accumulator) AddMetric(m telegraf.Metric) {
 	m.SetTime(m.Time().Round(ac.precision))
 	if m := ac.maker.MakeMetric(m); m != nil {
-		ac.metrics <- m
+		ac.emit(m)
 	}
 }
 
@@ -92,8 +97,17 @@ func (ac *accumulator) addMeasurement(
 ) {
 	m := metric.New(measurement, tags, fields, ac.getTime(t), tp)
 	if m := ac.maker.MakeMetric(m); m != nil {
-		ac.metrics <- m
+		ac.emit(m)
+	}
+}
+
+// emit passes the metric on to the next stage ending the current span of
+// traced metrics.
+func (ac *accumulator) emit(m telegraf.Metric) {
+	if trace := models.TraceOf(m); trace != nil {
+		trace.Leave(time.Now())
 	}
+	ac.metrics <- m
 }
 
 // AddError passes a runtime error to the accumulator.
@@ -114,7 +128,7 @@ func (ac *accumulator) getTime(t []time.Time) time.Time {
 	if len(t) > 0 {
 		timestamp = t[0]
 	} else {
-		timestamp = time.Now()
+		timestamp = ac.clk.Now()
 	}
 	return timestamp.Round(ac.precision)
 }
@@ -0,0 +1,259 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf/models"
+)
+
+// adminPlugin is the representation of a running plugin in the admin API.
+type adminPlugin struct {
+	ID     string       `json:"id"`
+	Name   string       `json:"name"`
+	Alias  string       `json:"alias,omitempty"`
+	Buffer *adminBuffer `json:"buffer,omitempty"`
+}
+
+// adminBuffer is the state of an output buffer in the admin API.
+type adminBuffer struct {
+	Size            int64 `json:"size"`
+	Limit           int64 `json:"limit"`
+	MetricsAdded    int64 `json:"metrics_added"`
+	MetricsWritten  int64 `json:"metrics_written"`
+	MetricsRejected int64 `json:"metrics_rejected"`
+	MetricsDropped  int64 `json:"metrics_dropped"`
+}
+
+// adminPlugins is the plugin graph of the running agent.
+type adminPlugins struct {
+	Inputs        []adminPlugin `json:"inputs"`
+	Processors    []adminPlugin `json:"processors"`
+	Aggregators   []adminPlugin `json:"aggregators"`
+	AggProcessors []adminPlugin `json:"aggprocessors"`
+	Outputs       []adminPlugin `json:"outputs"`
+}
+
+// listenAdmin opens the listener for the admin API. Addresses starting with
+// "unix://" denote a unix socket, all other addresses must be a loopback
+// host and port, optionally prefixed with "tcp://".
+func listenAdmin(address string) (net.Listener, error) {
+	if path, found := strings.CutPrefix(address, "unix://"); found {
+		// Remove a stale socket left over by an unclean shutdown
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("removing stale admin socket: %w", err)
+		}
+		listener, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.Chmod(path, 0600); err != nil {
+			listener.Close()
+			return nil, fmt.Errorf("setting permissions of admin socket: %w", err)
+		}
+		return listener, nil
+	}
+
+	address = strings.TrimPrefix(address, "tcp://")
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid admin address %q: %w", address, err)
+	}
+	if host != "localhost" {
+		if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+			return nil, fmt.Errorf("admin address %q is not a loopback address", address)
+		}
+	}
+	return net.Listen("tcp", address)
+}
+
+// serveAdmin serves the admin API on the given listener until the context is
+// done.
+func (a *Agent) serveAdmin(ctx context.Context, listener net.Listener) {
+	server := &http.Server{
+		Handler:           a.adminHandler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Serve(listener)
+	}()
+	log.Printf("I! [agent] Serving admin API on %s", listener.Addr())
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("E! [agent] Stopping admin API failed: %v", err)
+		}
+	case err := <-done:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("E! [agent] Serving admin API failed: %v", err)
+		}
+	}
+}
+
+// adminHandler returns the handler for all admin API endpoints.
+func (a *Agent) adminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /plugins", a.handlePlugins)
+	mux.HandleFunc("POST /inputs/{id}/gather", a.handleGather)
+	mux.HandleFunc("POST /outputs/{id}/flush", a.handleFlush)
+	mux.HandleFunc("POST /reload", a.handleReload)
+	return mux
+}
+
+// handlePlugins lists the running plugins with their IDs and the state of
+// the output buffers.
+func (a *Agent) handlePlugins(w http.ResponseWriter, _ *http.Request) {
+	a.pipelineLock.Lock()
+	defer a.pipelineLock.Unlock()
+
+	p := a.pipeline
+	if p == nil {
+		http.Error(w, "agent is not running", http.StatusServiceUnavailable)
+		return
+	}
+
+	plugins := adminPlugins{
+		Inputs:        make([]adminPlugin, 0, len(p.inputs.inputs)),
+		Processors:    processorsInfo(a.Config.Processors),
+		Aggregators:   make([]adminPlugin, 0, len(a.Config.Aggregators)),
+		AggProcessors: processorsInfo(a.Config.AggProcessors),
+		Outputs:       make([]adminPlugin, 0, len(p.outputs.outputs)),
+	}
+
+	p.inputs.Lock()
+	for _, input := range p.inputs.inputs {
+		plugins.Inputs = append(plugins.Inputs, adminPlugin{
+			ID:    input.ID(),
+			Name:  input.Config.Name,
+			Alias: input.Config.Alias,
+		})
+	}
+	p.inputs.Unlock()
+
+	for _, aggregator := range a.Config.Aggregators {
+		plugins.Aggregators = append(plugins.Aggregators, adminPlugin{
+			ID:    aggregator.ID(),
+			Name:  aggregator.Config.Name,
+			Alias: aggregator.Config.Alias,
+		})
+	}
+
+	p.outputs.RLock()
+	for _, output := range p.outputs.outputs {
+		stats := output.BufferStats()
+		plugins.Outputs = append(plugins.Outputs, adminPlugin{
+			ID:    output.ID(),
+			Name:  output.Config.Name,
+			Alias: output.Config.Alias,
+			Buffer: &adminBuffer{
+				Size:            stats.BufferSize.Get(),
+				Limit:           stats.BufferLimit.Get(),
+				MetricsAdded:    stats.MetricsAdded.Get(),
+				MetricsWritten:  stats.MetricsWritten.Get(),
+				MetricsRejected: stats.MetricsRejected.Get(),
+				MetricsDropped:  stats.MetricsDropped.Get(),
+			},
+		})
+	}
+	p.outputs.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(plugins); err != nil {
+		log.Printf("E! [agent] Writing admin API response failed: %v", err)
+	}
+}
+
+// handleGather triggers an immediate gather of all inputs with the given ID.
+func (a *Agent) handleGather(w http.ResponseWriter, r *http.Request) {
+	a.pipelineLock.Lock()
+	defer a.pipelineLock.Unlock()
+
+	p := a.pipeline
+	if p == nil {
+		http.Error(w, "agent is not running", http.StatusServiceUnavailable)
+		return
+	}
+
+	id := r.PathValue("id")
+	var found bool
+	p.inputs.Lock()
+	for input, l := range p.inputs.loops {
+		if input.ID() == id {
+			l.run()
+			found = true
+		}
+	}
+	p.inputs.Unlock()
+
+	if !found {
+		http.Error(w, "input not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handleFlush triggers an immediate flush of all outputs with the given ID.
+func (a *Agent) handleFlush(w http.ResponseWriter, r *http.Request) {
+	a.pipelineLock.Lock()
+	defer a.pipelineLock.Unlock()
+
+	p := a.pipeline
+	if p == nil {
+		http.Error(w, "agent is not running", http.StatusServiceUnavailable)
+		return
+	}
+
+	id := r.PathValue("id")
+	var found bool
+	p.outputs.RLock()
+	for output, l := range p.outputs.loops {
+		if output.ID() == id {
+			l.run()
+			found = true
+		}
+	}
+	p.outputs.RUnlock()
+
+	if !found {
+		http.Error(w, "output not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handleReload requests reloading the configuration. The reload happens
+// asynchronously as it might restart the agent including the admin API.
+func (a *Agent) handleReload(w http.ResponseWriter, _ *http.Request) {
+	if a.RequestReload == nil {
+		http.Error(w, "reloading is not supported", http.StatusNotImplemented)
+		return
+	}
+	log.Printf("I! [agent] Config reload requested via admin API")
+	a.RequestReload()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func processorsInfo(processors models.RunningProcessors) []adminPlugin {
+	info := make([]adminPlugin, 0, len(processors))
+	for _, processor := range processors {
+		info = append(info, adminPlugin{
+			ID:    processor.ID(),
+			Name:  processor.Config.Name,
+			Alias: processor.Config.Alias,
+		})
+	}
+	return info
+}
@@ -0,0 +1,107 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/models"
+)
+
+func TestListenAdmin(t *testing.T) {
+	listener, err := listenAdmin("127.0.0.1:0")
+	require.NoError(t, err)
+	require.NoError(t, listener.Close())
+
+	listener, err = listenAdmin("tcp://localhost:0")
+	require.NoError(t, err)
+	require.NoError(t, listener.Close())
+
+	_, err = listenAdmin(":0")
+	require.ErrorContains(t, err, "not a loopback address")
+
+	_, err = listenAdmin("0.0.0.0:0")
+	require.ErrorContains(t, err, "not a loopback address")
+}
+
+func TestAdminAPI(t *testing.T) {
+	cfg := config.NewConfig()
+	require.NoError(t, cfg.LoadConfigData([]byte(`
+[[inputs.internal]]
+[[outputs.discard]]
+`), config.EmptySourcePath))
+	inputID := cfg.Inputs[0].ID()
+	outputID := cfg.Outputs[0].ID()
+
+	a := NewAgent(cfg)
+	handler := a.adminHandler()
+
+	// The API is unavailable without a running agent
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/plugins", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- a.Run(ctx)
+	}()
+	require.Eventually(t, func() bool {
+		a.pipelineLock.Lock()
+		defer a.pipelineLock.Unlock()
+		return a.pipeline != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/plugins", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var plugins adminPlugins
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &plugins))
+	require.Len(t, plugins.Inputs, 1)
+	require.Equal(t, inputID, plugins.Inputs[0].ID)
+	require.Equal(t, "internal", plugins.Inputs[0].Name)
+	require.Len(t, plugins.Outputs, 1)
+	require.Equal(t, outputID, plugins.Outputs[0].ID)
+	require.NotNil(t, plugins.Outputs[0].Buffer)
+	require.Equal(t, int64(models.DefaultMetricBufferLimit), plugins.Outputs[0].Buffer.Limit)
+
+	// The gather and flush loops are started asynchronously
+	require.Eventually(t, func() bool {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/inputs/"+inputID+"/gather", nil))
+		return rec.Code == http.StatusAccepted
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/outputs/"+outputID+"/flush", nil))
+		return rec.Code == http.StatusAccepted
+	}, 5*time.Second, 10*time.Millisecond)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/outputs/unknown/flush", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	// Reloading requires a handler
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/reload", nil))
+	require.Equal(t, http.StatusNotImplemented, rec.Code)
+
+	var reloaded bool
+	a.RequestReload = func() { reloaded = true }
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/reload", nil))
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	require.True(t, reloaded)
+
+	cancel()
+	require.NoError(t, <-errC)
+}
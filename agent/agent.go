@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net"
 	"os"
 	"runtime"
 	"sync"
@@ -25,6 +26,21 @@ import (
 // Agent runs a set of plugins.
 type Agent struct {
 	Config *config.Config
+
+	// Units of the running agent required for reloading the configuration
+	// without restarting the whole agent
+	pipeline     *pipeline
+	pipelineLock sync.Mutex
+
+	// Tracer sampling metrics for tracing the pipeline, nil if disabled
+	tracer *models.Tracer
+
+	// Backpressure state of the outputs, nil if disabled
+	backpressure *backpressure
+
+	// RequestReload is called by the admin API to trigger reloading the
+	// configuration. Reloading via the API is disabled if not set.
+	RequestReload func()
 }
 
 // NewAgent returns an Agent for the given Config.
@@ -35,7 +51,8 @@ func NewAgent(cfg *config.Config) *Agent {
 	return a
 }
 
-// inputUnit is a group of input plugins and the shared channel they write to.
+// inputUnit is a group of input plugins and the channels of the pipelines
+// they write to.
 //
 // ┌───────┐
 // │ Input │───┐
@@ -43,12 +60,22 @@ func NewAgent(cfg *config.Config) *Agent {
 // ┌───────┐   │     ______
 // │ Input │───┼──▶ ()_____)
 // └───────┘   │
-// ┌───────┐   │
-// │ Input │───┘
+// ┌───────┐         ______
+// │ Input │──────▶ ()_____)
 // └───────┘
 type inputUnit struct {
-	dst    chan<- telegraf.Metric
+	sync.Mutex
+
+	dst    map[string]chan<- telegraf.Metric
 	inputs []*models.RunningInput
+
+	// Gather loops of the inputs, used to add or remove inputs while the
+	// agent is running
+	ctx       context.Context
+	startTime time.Time
+	loops     map[*models.RunningInput]*loop
+	wg        sync.WaitGroup
+	stopped   bool
 }
 
 //  ______     ┌───────────┐     ______
@@ -82,10 +109,16 @@ type aggregatorUnit struct {
 	aggC        chan<- telegraf.Metric
 	outputC     chan<- telegraf.Metric
 	aggregators []*models.RunningAggregator
+
+	// Closed when the agent shuts down, nil if the state of the aggregators
+	// is not persisted
+	shutdown <-chan struct{}
 }
 
-// outputUnit is a group of Outputs and their source channel.  Metrics on the
-// channel are written to all outputs.
+// outputUnit is a group of Outputs and the source channels of the pipelines.
+// Metrics of a pipeline are written to all outputs receiving the pipeline not
+// being part of an output group and to one output selected by each output
+// group.
 
 //                            ┌────────┐
 //                       ┌──▶ │ Output │
@@ -98,8 +131,98 @@ type aggregatorUnit struct {
 //                            └────────┘
 
 type outputUnit struct {
-	src     <-chan telegraf.Metric
+	sync.RWMutex
+
+	sources map[string]<-chan telegraf.Metric
 	outputs []*models.RunningOutput
+
+	// Routing of the metrics to the outputs, rebuilt whenever the outputs
+	// of the unit change
+	groups    []*models.OutputGroup
+	ungrouped []*models.RunningOutput
+
+	// Flush loops of the outputs, used to add or remove outputs while the
+	// agent is running
+	ctx     context.Context
+	loops   map[*models.RunningOutput]*loop
+	wg      sync.WaitGroup
+	stopped bool
+}
+
+// segment is an exchangeable part of the processing chain between the inputs
+// and the outputs. The source and destination channels of a segment persist
+// for the lifetime of the agent while the plugins in-between, i.e. the stage,
+// can be replaced when reloading the configuration.
+//
+//  ______     ┌─────────────────┐     ______
+// ()_____)──▶ │ Stage (plugins) │──▶ ()_____)
+//             └─────────────────┘
+//                      ▲
+//                      └── replace
+
+type segment struct {
+	src     chan telegraf.Metric
+	dst     chan<- telegraf.Metric
+	replace chan *stage
+	done    chan struct{}
+}
+
+// stage is the set of plugins running within a segment. Metrics are written
+// to the 'in' channel and the plugins write their results to the 'out'
+// channel. The process function must run until 'in' is closed and all metrics
+// are handled and must close 'out' afterwards.
+type stage struct {
+	in      chan<- telegraf.Metric
+	out     <-chan telegraf.Metric
+	process func()
+}
+
+// chain is the processing chain of a pipeline with a segment for the
+// processors followed by a segment for the aggregators. The inputs of the
+// pipeline write to the source of the processor segment.
+//
+//  ______     ┌────────────┐     ______     ┌─────────────┐     ______
+// ()_____)──▶ │ Processors │──▶ ()_____)──▶ │ Aggregators │──▶ ()_____)
+//             └────────────┘                └─────────────┘
+
+type chain struct {
+	processors  *segment
+	aggregators *segment
+
+	// Stages the segments start with
+	processorStage  *stage
+	aggregatorStage *stage
+}
+
+// loop holds the handles to control a gather or flush loop of a single plugin.
+type loop struct {
+	cancel  context.CancelFunc
+	done    chan struct{}
+	trigger chan struct{}
+}
+
+// newLoop creates a loop using the given cancel function.
+func newLoop(cancel context.CancelFunc) *loop {
+	return &loop{
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		trigger: make(chan struct{}, 1),
+	}
+}
+
+// run requests an immediate gather or flush without waiting for the next
+// interval. Requests are coalesced if the loop is busy.
+func (l *loop) run() {
+	select {
+	case l.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// stop cancels the loop and waits for it to finish.
+func (l *loop) stop() {
+	l.cancel()
+	<-l.done
 }
 
 // Run starts and runs the Agent until the context is done.
@@ -123,6 +246,26 @@ func (a *Agent) Run(ctx context.Context) error {
 		return err
 	}
 
+	if a.Config.Agent.PipelineTraceSampleRate > 0 {
+		if ref := a.Config.Agent.PipelineTraceOutput; ref != "" {
+			if _, err := findTraceExporter(a.Config.Outputs, ref); err != nil {
+				return err
+			}
+		}
+		a.tracer = models.NewTracer(a.Config.Agent.PipelineTraceSampleRate)
+		for _, input := range a.Config.Inputs {
+			input.SetTracer(a.tracer)
+		}
+	}
+
+	if a.Config.Agent.BackpressureHighWaterMark > 0 {
+		bp, err := newBackpressure(a.Config.Agent)
+		if err != nil {
+			return err
+		}
+		a.backpressure = bp
+	}
+
 	if a.Config.Persister != nil {
 		log.Printf("D! [agent] Initializing plugin states")
 		if err := a.initPersister(); err != nil {
@@ -136,37 +279,33 @@ func (a *Agent) Run(ctx context.Context) error {
 		}
 	}
 
+	var adminListener net.Listener
+	if a.Config.Agent.AdminAddress != "" {
+		listener, err := listenAdmin(a.Config.Agent.AdminAddress)
+		if err != nil {
+			return fmt.Errorf("starting admin API: %w", err)
+		}
+		// The listener is already closed if the admin API was served
+		defer listener.Close()
+		adminListener = listener
+	}
+
 	startTime := time.Now()
 
 	log.Printf("D! [agent] Connecting outputs")
-	next, ou, err := a.startOutputs(ctx, a.Config.Outputs)
+	next, ou, err := a.startOutputs(ctx, a.Config.Outputs, a.Config.Pipelines())
 	if err != nil {
 		return err
 	}
 
-	var apu []*processorUnit
-	var au *aggregatorUnit
-	if len(a.Config.Aggregators) != 0 {
-		aggC := next
-		if len(a.Config.AggProcessors) != 0 && !*a.Config.Agent.SkipProcessorsAfterAggregators {
-			aggC, apu, err = a.startProcessors(next, a.Config.AggProcessors)
-			if err != nil {
-				return err
-			}
-		}
-
-		next, au = a.startAggregators(aggC, next, a.Config.Aggregators)
-	}
-
-	var pu []*processorUnit
-	if len(a.Config.Processors) != 0 {
-		next, pu, err = a.startProcessors(next, a.Config.Processors)
-		if err != nil {
-			return err
-		}
+	// Processors and aggregators run in segments to allow replacing them
+	// without touching the inputs and outputs when reloading the config.
+	chains, err := a.startChains(ctx, startTime, next)
+	if err != nil {
+		return err
 	}
 
-	iu, err := a.startInputs(next, a.Config.Inputs)
+	iu, err := a.startInputs(chainSources(chains), a.Config.Inputs)
 	if err != nil {
 		return err
 	}
@@ -178,36 +317,67 @@ func (a *Agent) Run(ctx context.Context) error {
 		a.runOutputs(ou)
 	}()
 
-	if au != nil {
+	for _, c := range chains {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			a.runProcessors(apu)
+			c.run()
 		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		a.runInputs(ctx, startTime, iu)
+	}()
 
+	a.pipelineLock.Lock()
+	a.pipeline = &pipeline{
+		ctx:     ctx,
+		inputs:  iu,
+		chains:  chains,
+		outputs: ou,
+	}
+	a.pipelineLock.Unlock()
+
+	if adminListener != nil {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			a.runAggregators(startTime, au)
+			a.serveAdmin(ctx, adminListener)
 		}()
 	}
 
-	if pu != nil {
+	if a.backpressure != nil {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			a.runProcessors(pu)
+			a.monitorBackpressure(ctx, iu, ou)
 		}()
 	}
 
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		a.runInputs(ctx, startTime, iu)
-	}()
+	if a.tracer != nil && a.Config.Agent.PipelineTraceOutput != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.exportTraces(ctx, ou, time.Duration(a.Config.Agent.FlushInterval))
+		}()
+	}
+
+	if a.Config.Persister != nil && a.Config.Agent.StatefileInterval > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.checkpointStates(ctx, time.Duration(a.Config.Agent.StatefileInterval))
+		}()
+	}
 
 	wg.Wait()
 
+	a.pipelineLock.Lock()
+	a.pipeline = nil
+	a.pipelineLock.Unlock()
+
 	if a.Config.Persister != nil {
 		log.Printf("D! [agent] Persisting plugin states")
 		if err := a.Config.Persister.Store(); err != nil {
@@ -219,9 +389,46 @@ func (a *Agent) Run(ctx context.Context) error {
 	return err
 }
 
+// checkpointStates periodically stores the plugin states until the context
+// is done to not lose the states if Telegraf terminates unexpectedly.
+func (a *Agent) checkpointStates(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			log.Printf("D! [agent] Checkpointing plugin states")
+			if err := a.Config.Persister.Store(); err != nil {
+				log.Printf("E! [agent] Checkpointing plugin states failed: %v", err)
+			}
+		}
+	}
+}
+
 // InitPlugins runs the Init function on plugins.
 func (a *Agent) InitPlugins() error {
-	for _, input := range a.Config.Inputs {
+	if err := a.initInputs(a.Config.Inputs); err != nil {
+		return err
+	}
+	if err := initProcessors(a.Config.Processors); err != nil {
+		return err
+	}
+	if err := initAggregators(a.Config.Aggregators); err != nil {
+		return err
+	}
+	if !*a.Config.Agent.SkipProcessorsAfterAggregators {
+		if err := initProcessors(a.Config.AggProcessors); err != nil {
+			return err
+		}
+	}
+	return initOutputs(a.Config.Outputs)
+}
+
+func (a *Agent) initInputs(inputs []*models.RunningInput) error {
+	for _, input := range inputs {
 		// Share the snmp translator setting with plugins that need it.
 		if tp, ok := input.Input.(snmp.TranslatorPlugin); ok {
 			tp.SetTranslator(a.Config.Agent.SnmpTranslator)
@@ -231,27 +438,31 @@ func (a *Agent) InitPlugins() error {
 			return fmt.Errorf("could not initialize input %s: %w", input.LogName(), err)
 		}
 	}
-	for _, processor := range a.Config.Processors {
+	return nil
+}
+
+func initProcessors(processors models.RunningProcessors) error {
+	for _, processor := range processors {
 		err := processor.Init()
 		if err != nil {
 			return fmt.Errorf("could not initialize processor %s: %w", processor.LogName(), err)
 		}
 	}
-	for _, aggregator := range a.Config.Aggregators {
+	return nil
+}
+
+func initAggregators(aggregators []*models.RunningAggregator) error {
+	for _, aggregator := range aggregators {
 		err := aggregator.Init()
 		if err != nil {
 			return fmt.Errorf("could not initialize aggregator %s: %w", aggregator.LogName(), err)
 		}
 	}
-	if !*a.Config.Agent.SkipProcessorsAfterAggregators {
-		for _, processor := range a.Config.AggProcessors {
-			err := processor.Init()
-			if err != nil {
-				return fmt.Errorf("could not initialize processor %s: %w", processor.LogName(), err)
-			}
-		}
-	}
-	for _, output := range a.Config.Outputs {
+	return nil
+}
+
+func initOutputs(outputs []*models.RunningOutput) error {
+	for _, output := range outputs {
 		err := output.Init()
 		if err != nil {
 			return fmt.Errorf("could not initialize output %s: %w", output.LogName(), err)
@@ -266,20 +477,43 @@ func (a *Agent) initPersister() error {
 		return err
 	}
 
-	for _, input := range a.Config.Inputs {
+	plugins := statefulPlugins(a.Config.Inputs, a.Config.Processors, a.Config.Aggregators, a.Config.AggProcessors, a.Config.Outputs)
+	for _, plugin := range plugins {
+		if err := a.Config.Persister.Register(plugin.id, plugin.plugin); err != nil {
+			return fmt.Errorf("could not register %s %s: %w", plugin.kind, plugin.name, err)
+		}
+	}
+
+	return nil
+}
+
+// statefulPlugin is a plugin with a state to persist.
+type statefulPlugin struct {
+	kind   string
+	name   string
+	id     string
+	plugin telegraf.StatefulPlugin
+}
+
+// statefulPlugins returns the plugins with a state to persist in the order
+// of registration.
+func statefulPlugins(
+	inputs []*models.RunningInput,
+	procs models.RunningProcessors,
+	aggregators []*models.RunningAggregator,
+	aggProcs models.RunningProcessors,
+	outputs []*models.RunningOutput,
+) []statefulPlugin {
+	var plugins []statefulPlugin
+	for _, input := range inputs {
 		plugin, ok := input.Input.(telegraf.StatefulPlugin)
 		if !ok {
 			continue
 		}
-
-		name := input.LogName()
-		id := input.ID()
-		if err := a.Config.Persister.Register(id, plugin); err != nil {
-			return fmt.Errorf("could not register input %s: %w", name, err)
-		}
+		plugins = append(plugins, statefulPlugin{"input", input.LogName(), input.ID(), plugin})
 	}
 
-	for _, processor := range a.Config.Processors {
+	for _, processor := range procs {
 		var plugin telegraf.StatefulPlugin
 		if p, ok := processor.Processor.(processors.HasUnwrap); ok {
 			plugin, ok = p.Unwrap().(telegraf.StatefulPlugin)
@@ -292,57 +526,39 @@ func (a *Agent) initPersister() error {
 				continue
 			}
 		}
-
-		name := processor.LogName()
-		id := processor.ID()
-		if err := a.Config.Persister.Register(id, plugin); err != nil {
-			return fmt.Errorf("could not register processor %s: %w", name, err)
-		}
+		plugins = append(plugins, statefulPlugin{"processor", processor.LogName(), processor.ID(), plugin})
 	}
 
-	for _, aggregator := range a.Config.Aggregators {
-		plugin, ok := aggregator.Aggregator.(telegraf.StatefulPlugin)
-		if !ok {
+	for _, aggregator := range aggregators {
+		if _, ok := aggregator.Aggregator.(telegraf.StatefulPlugin); !ok {
 			continue
 		}
 
-		name := aggregator.LogName()
-		id := aggregator.ID()
-		if err := a.Config.Persister.Register(id, plugin); err != nil {
-			return fmt.Errorf("could not register aggregator %s: %w", name, err)
-		}
+		// Register the running aggregator to persist the aggregation window
+		// along with the plugin state
+		plugins = append(plugins, statefulPlugin{"aggregator", aggregator.LogName(), aggregator.ID(), aggregator})
 	}
 
-	for _, processor := range a.Config.AggProcessors {
+	for _, processor := range aggProcs {
 		plugin, ok := processor.Processor.(telegraf.StatefulPlugin)
 		if !ok {
 			continue
 		}
-
-		name := processor.LogName()
-		id := processor.ID()
-		if err := a.Config.Persister.Register(id, plugin); err != nil {
-			return fmt.Errorf("could not register aggregating processor %s: %w", name, err)
-		}
+		plugins = append(plugins, statefulPlugin{"aggregating processor", processor.LogName(), processor.ID(), plugin})
 	}
 
-	for _, output := range a.Config.Outputs {
+	for _, output := range outputs {
 		plugin, ok := output.Output.(telegraf.StatefulPlugin)
 		if !ok {
 			continue
 		}
-
-		name := output.LogName()
-		id := output.ID()
-		if err := a.Config.Persister.Register(id, plugin); err != nil {
-			return fmt.Errorf("could not register output %s: %w", name, err)
-		}
+		plugins = append(plugins, statefulPlugin{"output", output.LogName(), output.ID(), plugin})
 	}
 
-	return nil
+	return plugins
 }
 
-func (*Agent) startInputs(dst chan<- telegraf.Metric, inputs []*models.RunningInput) (*inputUnit, error) {
+func (*Agent) startInputs(dst map[string]chan<- telegraf.Metric, inputs []*models.RunningInput) (*inputUnit, error) {
 	log.Printf("D! [agent] Starting service inputs")
 
 	unit := &inputUnit{
@@ -350,109 +566,166 @@ func (*Agent) startInputs(dst chan<- telegraf.Metric, inputs []*models.RunningIn
 	}
 
 	for _, input := range inputs {
-		// Service input plugins are not normally subject to timestamp
-		// rounding except for when precision is set on the input plugin.
-		//
-		// This only applies to the accumulator passed to Start(), the
-		// Gather() accumulator does apply rounding according to the
-		// precision and interval agent/plugin settings.
-		var interval time.Duration
-		var precision time.Duration
-		if input.Config.Precision != 0 {
-			precision = input.Config.Precision
-		}
-
-		acc := NewAccumulator(input, dst)
-		acc.SetPrecision(getPrecision(precision, interval))
-
-		if err := input.Start(acc); err != nil {
-			// If the model tells us to remove the plugin we do so without error
-			var fatalErr *internal.FatalError
-			if errors.As(err, &fatalErr) {
-				log.Printf("I! [agent] Failed to start %s, shutting down plugin: %s", input.LogName(), err)
-				continue
-			}
-
+		started, err := startInput(unit.dstOf(input), input)
+		if err != nil {
 			stopRunningInputs(unit.inputs)
-
-			return nil, fmt.Errorf("starting input %s: %w", input.LogName(), err)
+			return nil, err
 		}
-		if err := input.Probe(); err != nil {
-			// Probe failures are non-fatal to the agent but should only remove the plugin
-			log.Printf("I! [agent] Failed to probe %s, shutting down plugin: %s", input.LogName(), err)
-			input.Stop()
-			continue
+		if started {
+			unit.inputs = append(unit.inputs, input)
 		}
-		unit.inputs = append(unit.inputs, input)
 	}
 
 	return unit, nil
 }
 
+// startInput starts the given input if it is a service input. A plugin that
+// should be removed without failing the agent is reported as not started.
+func startInput(dst chan<- telegraf.Metric, input *models.RunningInput) (bool, error) {
+	// Service input plugins are not normally subject to timestamp
+	// rounding except for when precision is set on the input plugin.
+	//
+	// This only applies to the accumulator passed to Start(), the
+	// Gather() accumulator does apply rounding according to the
+	// precision and interval agent/plugin settings.
+	var interval time.Duration
+	var precision time.Duration
+	if input.Config.Precision != 0 {
+		precision = input.Config.Precision
+	}
+
+	acc := NewAccumulator(input, dst)
+	acc.SetPrecision(getPrecision(precision, interval))
+
+	if err := input.Start(acc); err != nil {
+		// If the model tells us to remove the plugin we do so without error
+		var fatalErr *internal.FatalError
+		if errors.As(err, &fatalErr) {
+			log.Printf("I! [agent] Failed to start %s, shutting down plugin: %s", input.LogName(), err)
+			return false, nil
+		}
+		return false, fmt.Errorf("starting input %s: %w", input.LogName(), err)
+	}
+	if err := input.Probe(); err != nil {
+		// Probe failures are non-fatal to the agent but should only remove the plugin
+		log.Printf("I! [agent] Failed to probe %s, shutting down plugin: %s", input.LogName(), err)
+		input.Stop()
+		return false, nil
+	}
+	return true, nil
+}
+
 // runInputs starts and triggers the periodic gather for Inputs.
 //
 // When the context is done the timers are stopped and this function returns
 // after all ongoing Gather calls complete.
 func (a *Agent) runInputs(ctx context.Context, startTime time.Time, unit *inputUnit) {
-	var wg sync.WaitGroup
+	unit.Lock()
+	unit.ctx = ctx
+	unit.startTime = startTime
+	unit.loops = make(map[*models.RunningInput]*loop, len(unit.inputs))
+	for _, input := range unit.inputs {
+		a.startGatherLoop(unit, input)
+	}
+	unit.Unlock()
+
+	<-ctx.Done()
+
+	unit.Lock()
+	unit.stopped = true
+	unit.Unlock()
+	unit.wg.Wait()
+
+	log.Printf("D! [agent] Stopping service inputs")
+	stopRunningInputs(unit.inputs)
+
+	unit.close()
+	log.Printf("D! [agent] Input channels closed")
+}
+
+// dstOf returns the channel of the pipeline the given input writes to.
+func (u *inputUnit) dstOf(input *models.RunningInput) chan<- telegraf.Metric {
+	return u.dst[input.Config.Pipeline]
+}
+
+// close closes the channels of all pipelines.
+func (u *inputUnit) close() {
+	for _, dst := range u.dst {
+		close(dst)
+	}
+}
+
+// startGatherLoop sets up the ticker of the given input and starts gathering.
+// The caller must hold the lock of the unit.
+func (a *Agent) startGatherLoop(unit *inputUnit, input *models.RunningInput) {
 	var options []clock.Option
 
 	// Initialize time rounding
 	if a.Config.Agent.RoundInterval {
-		options = append(options, clock.WithAlignment(startTime))
+		options = append(options, clock.WithAlignment(unit.startTime))
 	}
 
-	tickers := make([]*clock.Ticker, 0, len(unit.inputs))
-	for _, input := range unit.inputs {
-		// Overwrite agent interval if this plugin has its own.
-		interval := time.Duration(a.Config.Agent.Interval)
-		if input.Config.Interval != 0 {
-			interval = input.Config.Interval
-		}
-
-		// Overwrite agent precision if this plugin has its own.
-		precision := time.Duration(a.Config.Agent.Precision)
-		if input.Config.Precision != 0 {
-			precision = input.Config.Precision
-		}
+	// Overwrite agent interval if this plugin has its own.
+	interval := time.Duration(a.Config.Agent.Interval)
+	if input.Config.Interval != 0 {
+		interval = input.Config.Interval
+	}
 
-		// Overwrite agent collection_jitter if this plugin has its own.
-		jitter := time.Duration(a.Config.Agent.CollectionJitter)
-		if input.Config.CollectionJitterSet {
-			jitter = input.Config.CollectionJitter
-		}
+	// Overwrite agent precision if this plugin has its own.
+	precision := time.Duration(a.Config.Agent.Precision)
+	if input.Config.Precision != 0 {
+		precision = input.Config.Precision
+	}
 
-		// Overwrite agent collection_offset if this plugin has its own.
-		offset := time.Duration(a.Config.Agent.CollectionOffset)
-		if input.Config.CollectionOffset != 0 {
-			offset = input.Config.CollectionOffset
-		}
+	// Overwrite agent collection_jitter if this plugin has its own.
+	jitter := time.Duration(a.Config.Agent.CollectionJitter)
+	if input.Config.CollectionJitterSet {
+		jitter = input.Config.CollectionJitter
+	}
 
-		ticker := clock.NewTicker(interval, jitter, offset, options...)
-		tickers = append(tickers, ticker)
+	// Overwrite agent collection_offset if this plugin has its own.
+	offset := time.Duration(a.Config.Agent.CollectionOffset)
+	if input.Config.CollectionOffset != 0 {
+		offset = input.Config.CollectionOffset
+	}
 
-		acc := NewAccumulator(input, unit.dst)
-		acc.SetPrecision(getPrecision(precision, interval))
+	// Suppress collections outside the configured calendar windows
+	if input.Config.Windows != nil {
+		options = append(options, clock.WithWindows(input.Config.Windows))
+	}
 
-		wg.Add(1)
-		go func(input *models.RunningInput) {
-			defer wg.Done()
-			a.gatherLoop(ctx, acc, input, ticker, interval)
-		}(input)
+	// Use a cron ticker if the plugin has a schedule, estimating the interval
+	// for the precision and the slow-collection warning from the schedule.
+	var ticks <-chan time.Time
+	var stop func()
+	if input.Config.Schedule != nil {
+		interval = input.Config.Schedule.Interval(time.Now())
+		ticker := clock.NewCronTicker(input.Config.Schedule, jitter, offset, options...)
+		ticks, stop = ticker.C, ticker.Stop
+	} else {
+		ticker := clock.NewTicker(interval, jitter, offset, options...)
+		ticks, stop = ticker.C, ticker.Stop
 	}
-	defer stopTickers(tickers)
-	wg.Wait()
 
-	log.Printf("D! [agent] Stopping service inputs")
-	stopRunningInputs(unit.inputs)
+	acc := NewAccumulator(input, unit.dstOf(input))
+	acc.SetPrecision(getPrecision(precision, interval))
+
+	ctx, cancel := context.WithCancel(unit.ctx)
+	l := newLoop(cancel)
+	unit.loops[input] = l
 
-	close(unit.dst)
-	log.Printf("D! [agent] Input channel closed")
+	unit.wg.Add(1)
+	go func() {
+		defer unit.wg.Done()
+		defer close(l.done)
+		defer stop()
+		a.gatherLoop(ctx, acc, input, ticks, interval, l.trigger)
+	}()
 }
 
 // testStartInputs is a variation of startInputs for use in --test and --once mode.
 // It differs by logging Start errors and returning only plugins successfully started.
-func (*Agent) testStartInputs(dst chan<- telegraf.Metric, inputs []*models.RunningInput) *inputUnit {
+func (*Agent) testStartInputs(dst map[string]chan<- telegraf.Metric, inputs []*models.RunningInput) *inputUnit {
 	log.Printf("D! [agent] Starting service inputs")
 
 	unit := &inputUnit{
@@ -464,7 +737,7 @@ func (*Agent) testStartInputs(dst chan<- telegraf.Metric, inputs []*models.Runni
 		// This only applies to the accumulator passed to Start(), the
 		// Gather() accumulator does apply rounding according to the
 		// precision agent setting.
-		acc := NewAccumulator(input, dst)
+		acc := NewAccumulator(input, unit.dstOf(input))
 		acc.SetPrecision(time.Nanosecond)
 
 		if err := input.Start(acc); err != nil {
@@ -524,7 +797,7 @@ func (a *Agent) testRunInputs(
 				time.Sleep(500 * time.Millisecond)
 			}
 
-			acc := NewAccumulator(input, unit.dst)
+			acc := NewAccumulator(input, unit.dstOf(input))
 			acc.SetPrecision(getPrecision(precision, interval))
 
 			if err := input.Input.Gather(acc); err != nil {
@@ -541,8 +814,8 @@ func (a *Agent) testRunInputs(
 	log.Printf("D! [agent] Stopping service inputs")
 	stopRunningInputs(unit.inputs)
 
-	close(unit.dst)
-	log.Printf("D! [agent] Input channel closed")
+	unit.close()
+	log.Printf("D! [agent] Input channels closed")
 }
 
 // stopRunningInputs stops all service inputs.
@@ -565,13 +838,25 @@ func (a *Agent) gatherLoop(
 	ctx context.Context,
 	acc telegraf.Accumulator,
 	input *models.RunningInput,
-	ticker *clock.Ticker,
+	ticks <-chan time.Time,
 	interval time.Duration,
+	trigger <-chan struct{},
 ) {
+	// Number of intervals under backpressure
+	var pressured int
 	for {
 		select {
-		case <-ticker.C:
-			err := a.gatherOnce(acc, input, ticker, interval)
+		case <-ticks:
+			if a.backpressure.skipGather(&pressured) {
+				log.Printf("D! [%s] Collection skipped due to backpressure", input.LogName())
+				continue
+			}
+			err := a.gatherOnce(acc, input, ticks, interval)
+			if err != nil {
+				acc.AddError(err)
+			}
+		case <-trigger:
+			err := a.gatherOnce(acc, input, ticks, interval)
 			if err != nil {
 				acc.AddError(err)
 			}
@@ -582,7 +867,7 @@ func (a *Agent) gatherLoop(
 }
 
 // gatherOnce runs the input's Gather function once, logging a warning each interval it fails to complete before.
-func (*Agent) gatherOnce(acc telegraf.Accumulator, input *models.RunningInput, ticker *clock.Ticker, interval time.Duration) error {
+func (*Agent) gatherOnce(acc telegraf.Accumulator, input *models.RunningInput, ticks <-chan time.Time, interval time.Duration) error {
 	done := make(chan error)
 	go func() {
 		defer panicRecover(input)
@@ -603,7 +888,7 @@ func (*Agent) gatherOnce(acc telegraf.Accumulator, input *models.RunningInput, t
 			log.Printf("W! [%s] Collection took longer than expected; not complete after interval of %s",
 				input.LogName(), interval)
 			input.IncrGatherTimeouts()
-		case <-ticker.C:
+		case <-ticks:
 			log.Printf("D! [%s] Previous collection has not completed; scheduled collection skipped",
 				input.LogName())
 		}
@@ -656,6 +941,9 @@ func (*Agent) runProcessors(units []*processorUnit) {
 
 			acc := NewAccumulator(unit.processor, unit.dst)
 			for m := range unit.src {
+				if trace := models.TraceOf(m); trace != nil {
+					trace.Enter(models.TraceStageProcess, unit.processor.LogName(), unit.processor.ID(), time.Now())
+				}
 				if err := unit.processor.Add(m, acc); err != nil {
 					acc.AddError(err)
 					m.Drop()
@@ -690,8 +978,12 @@ func (a *Agent) runAggregators(
 	ctx, cancel := context.WithCancel(context.Background())
 
 	// Before calling Add, initialize the aggregation window.  This ensures
-	// that any metric created after start time will be aggregated.
-	for _, agg := range a.Config.Aggregators {
+	// that any metric created after start time will be aggregated. Windows
+	// restored from a persisted state are kept to continue the aggregation.
+	for _, agg := range unit.aggregators {
+		if !agg.EndPeriod().IsZero() {
+			continue
+		}
 		since, until := updateWindow(startTime, a.Config.Agent.RoundInterval, agg.Period())
 		agg.UpdateWindow(since, until)
 	}
@@ -701,11 +993,18 @@ func (a *Agent) runAggregators(
 	go func() {
 		defer wg.Done()
 		for metric := range unit.src {
+			trace := models.TraceOf(metric)
 			var dropOriginal bool
-			for _, agg := range a.Config.Aggregators {
+			for _, agg := range unit.aggregators {
+				if trace != nil {
+					trace.Enter(models.TraceStageAggregate, agg.LogName(), agg.ID(), time.Now())
+				}
 				if ok := agg.Add(metric); ok {
 					dropOriginal = true
 				}
+				if trace != nil {
+					trace.Leave(time.Now())
+				}
 			}
 
 			if !dropOriginal {
@@ -717,7 +1016,7 @@ func (a *Agent) runAggregators(
 		cancel()
 	}()
 
-	for _, agg := range a.Config.Aggregators {
+	for _, agg := range unit.aggregators {
 		wg.Add(1)
 		go func(agg *models.RunningAggregator) {
 			defer wg.Done()
@@ -727,7 +1026,7 @@ func (a *Agent) runAggregators(
 
 			acc := NewAccumulator(agg, unit.aggC)
 			acc.SetPrecision(getPrecision(precision, interval))
-			a.push(ctx, agg, acc)
+			a.push(ctx, agg, acc, unit.shutdown)
 		}(agg)
 	}
 
@@ -755,8 +1054,17 @@ func updateWindow(start time.Time, roundInterval bool, period time.Duration) (si
 	return since, until
 }
 
-// push runs the push for a single aggregator every period.
-func (*Agent) push(ctx context.Context, aggregator *models.RunningAggregator, acc telegraf.Accumulator) {
+// push runs the push for a single aggregator every period. Stateful
+// aggregators skip the final push if the agent is shutting down so the current
+// period can be persisted and continued after restarting.
+func (*Agent) push(
+	ctx context.Context,
+	aggregator *models.RunningAggregator,
+	acc telegraf.Accumulator,
+	shutdown <-chan struct{},
+) {
+	_, stateful := aggregator.Aggregator.(telegraf.StatefulPlugin)
+
 	for {
 		// Ensures that Push will be called for each period, even if it has
 		// already elapsed before this function is called.  This is guaranteed
@@ -768,20 +1076,265 @@ func (*Agent) push(ctx context.Context, aggregator *models.RunningAggregator, ac
 		case <-time.After(until):
 			aggregator.Push(acc)
 		case <-ctx.Done():
+			if stateful && isClosed(shutdown) {
+				aggregator.Log().Debug("Keeping current period for persisting the state")
+				return
+			}
 			aggregator.Push(acc)
 			return
 		}
 	}
 }
 
-// startOutputs calls Connect on all outputs and returns the source channel.
-// If an error occurs calling Connect, all started plugins have Close called.
+// isClosed checks if the given channel is closed without blocking.
+func isClosed(c <-chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
+
+// processorStage sets up the given processors as a stage. Without any
+// processors the stage passes all metrics unchanged.
+func (a *Agent) processorStage(processors models.RunningProcessors) (*stage, error) {
+	out := make(chan telegraf.Metric, 100)
+	if len(processors) == 0 {
+		return &stage{in: out, out: out}, nil
+	}
+
+	in, units, err := a.startProcessors(out, processors)
+	if err != nil {
+		return nil, err
+	}
+	return &stage{
+		in:      in,
+		out:     out,
+		process: func() { a.runProcessors(units) },
+	}, nil
+}
+
+// aggregatorStage sets up the given aggregators and the processors running
+// after the aggregators as a stage. Without any aggregators the stage passes
+// all metrics unchanged.
+func (a *Agent) aggregatorStage(
+	ctx context.Context,
+	startTime time.Time,
+	aggregators []*models.RunningAggregator,
+	aggProcessors models.RunningProcessors,
+) (*stage, error) {
+	out := make(chan telegraf.Metric, 100)
+	if len(aggregators) == 0 {
+		return &stage{in: out, out: out}, nil
+	}
+
+	aggC := chan<- telegraf.Metric(out)
+	var apu []*processorUnit
+	if len(aggProcessors) != 0 && !*a.Config.Agent.SkipProcessorsAfterAggregators {
+		var err error
+		aggC, apu, err = a.startProcessors(out, aggProcessors)
+		if err != nil {
+			return nil, err
+		}
+	}
+	in, au := a.startAggregators(aggC, out, aggregators)
+	if a.Config.Persister != nil {
+		au.shutdown = ctx.Done()
+	}
+
+	return &stage{
+		in:  in,
+		out: out,
+		process: func() {
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				a.runProcessors(apu)
+			}()
+			a.runAggregators(startTime, au)
+			wg.Wait()
+		},
+	}, nil
+}
+
+// newSegment creates a segment writing to the given destination.
+func newSegment(dst chan<- telegraf.Metric) *segment {
+	return &segment{
+		src:     make(chan telegraf.Metric, 100),
+		dst:     dst,
+		replace: make(chan *stage),
+		done:    make(chan struct{}),
+	}
+}
+
+// run passes the metrics from the source of the segment through the given
+// stage until the source channel is closed. Stages exchanged in the meantime
+// are run in turn. The destination channel is closed after all metrics are
+// written.
+func (s *segment) run(current *stage) {
+	for current != nil {
+		current = s.runStage(current)
+	}
+	close(s.dst)
+	close(s.done)
+}
+
+// runStage runs the given stage until either the source channel is closed or
+// the stage is replaced. In the latter case, all metrics already in the stage
+// are processed before returning the replacement.
+func (s *segment) runStage(current *stage) *stage {
+	var wg sync.WaitGroup
+	if current.process != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			current.process()
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for m := range current.out {
+			s.dst <- m
+		}
+	}()
+
+	var next *stage
+loop:
+	for {
+		select {
+		case m, ok := <-s.src:
+			if !ok {
+				break loop
+			}
+			current.in <- m
+		case next = <-s.replace:
+			break loop
+		}
+	}
+	close(current.in)
+	wg.Wait()
+
+	return next
+}
+
+// exchange replaces the currently running stage of the segment. The old stage
+// is stopped after handling all metrics it received.
+func (s *segment) exchange(next *stage) error {
+	select {
+	case s.replace <- next:
+		return nil
+	case <-s.done:
+		next.discard()
+		return errors.New("segment already stopped")
+	}
+}
+
+// discard stops the plugins of a stage that was never run and drops all
+// metrics emitted while stopping.
+func (s *stage) discard() {
+	go func() {
+		for m := range s.out {
+			m.Drop()
+		}
+	}()
+	close(s.in)
+	if s.process != nil {
+		s.process()
+	}
+}
+
+// startChains sets up the processing chains of the pipelines writing to the
+// given destination channels.
+func (a *Agent) startChains(
+	ctx context.Context,
+	startTime time.Time,
+	dst map[string]chan<- telegraf.Metric,
+) (map[string]*chain, error) {
+	chains := make(map[string]*chain, len(dst))
+	for name, out := range dst {
+		as, err := a.aggregatorStage(ctx, startTime,
+			inPipeline(a.Config.Aggregators, aggregatorPipeline, name),
+			inPipeline(a.Config.AggProcessors, processorPipeline, name),
+		)
+		if err != nil {
+			return nil, err
+		}
+		ps, err := a.processorStage(inPipeline(a.Config.Processors, processorPipeline, name))
+		if err != nil {
+			return nil, err
+		}
+
+		aggregators := newSegment(out)
+		chains[name] = &chain{
+			processors:      newSegment(aggregators.src),
+			aggregators:     aggregators,
+			processorStage:  ps,
+			aggregatorStage: as,
+		}
+	}
+	return chains, nil
+}
+
+// run runs the segments of the chain until the source channel is closed and
+// all metrics are written.
+func (c *chain) run() {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.aggregators.run(c.aggregatorStage)
+	}()
+	c.processors.run(c.processorStage)
+	wg.Wait()
+}
+
+// chainSources returns the source channels of the given chains by pipeline.
+func chainSources(chains map[string]*chain) map[string]chan<- telegraf.Metric {
+	sources := make(map[string]chan<- telegraf.Metric, len(chains))
+	for name, c := range chains {
+		sources[name] = c.processors.src
+	}
+	return sources
+}
+
+// inPipeline returns the plugins of the given pipeline keeping their order.
+func inPipeline[S ~[]E, E any](plugins S, pipeline func(E) string, name string) S {
+	var selected S
+	for _, p := range plugins {
+		if pipeline(p) == name {
+			selected = append(selected, p)
+		}
+	}
+	return selected
+}
+
+func processorPipeline(p *models.RunningProcessor) string {
+	return p.Config.Pipeline
+}
+
+func aggregatorPipeline(a *models.RunningAggregator) string {
+	return a.Config.Pipeline
+}
+
+// startOutputs calls Connect on all outputs and returns the source channels
+// of the given pipelines. If an error occurs calling Connect, all started
+// plugins have Close called.
 func (a *Agent) startOutputs(
 	ctx context.Context,
 	outputs []*models.RunningOutput,
-) (chan<- telegraf.Metric, *outputUnit, error) {
-	src := make(chan telegraf.Metric, 100)
-	unit := &outputUnit{src: src}
+	pipelines []string,
+) (map[string]chan<- telegraf.Metric, *outputUnit, error) {
+	dst := make(map[string]chan<- telegraf.Metric, len(pipelines))
+	unit := &outputUnit{sources: make(map[string]<-chan telegraf.Metric, len(pipelines))}
+	for _, name := range pipelines {
+		src := make(chan telegraf.Metric, 100)
+		dst[name] = src
+		unit.sources[name] = src
+	}
 	for _, output := range outputs {
 		if err := a.connectOutput(ctx, output); err != nil {
 			var fatalErr *internal.FatalError
@@ -800,8 +1353,109 @@ func (a *Agent) startOutputs(
 
 		unit.outputs = append(unit.outputs, output)
 	}
+	unit.updateRoutes(a.Config.OutputGroups)
+
+	return dst, unit, nil
+}
+
+// updateRoutes assigns the outputs of the unit to the configured output
+// groups and connects outputs to their dead-letter outputs. Dead-letter
+// outputs only receive the metrics rejected by other outputs. The caller must
+// hold the lock of the unit.
+func (u *outputUnit) updateRoutes(configs []*models.OutputGroupConfig) {
+	u.groups = make([]*models.OutputGroup, 0, len(configs))
+	u.ungrouped = make([]*models.RunningOutput, 0, len(u.outputs))
+	for _, output := range u.outputs {
+		output.LeaveGroup()
+	}
+
+	// Outputs used as dead-letter output are excluded from the routing
+	assigned := make(map[*models.RunningOutput]bool, len(u.outputs))
+	for _, output := range u.outputs {
+		var target *models.RunningOutput
+		if ref := output.DeadLetterReference(); ref != "" {
+			for _, o := range u.outputs {
+				if o != output && o.MatchesReference(ref) {
+					target = o
+					assigned[o] = true
+					break
+				}
+			}
+		}
+		output.SetDeadLetterOutput(target)
+	}
+
+	for _, cfg := range configs {
+		var members []*models.RunningOutput
+		for _, ref := range cfg.Outputs {
+			for _, output := range u.outputs {
+				if output.MatchesReference(ref) && !assigned[output] {
+					members = append(members, output)
+					assigned[output] = true
+				}
+			}
+		}
+		if len(members) > 0 {
+			u.groups = append(u.groups, models.NewOutputGroup(cfg, members))
+		}
+	}
+
+	for _, output := range u.outputs {
+		if !assigned[output] {
+			u.ungrouped = append(u.ungrouped, output)
+		}
+	}
+}
+
+// route appends the outputs to receive the given metric of the pipeline to
+// dst. All outputs of a group subscribe to the same pipelines as checked when
+// loading the configuration. The caller must hold the lock of the unit.
+func (u *outputUnit) route(dst []*models.RunningOutput, pipeline string, metric telegraf.Metric) []*models.RunningOutput {
+	for _, output := range u.ungrouped {
+		if output.Subscribes(pipeline) {
+			dst = append(dst, output)
+		}
+	}
+	for _, group := range u.groups {
+		if output := group.Select(metric); output != nil && output.Subscribes(pipeline) {
+			dst = append(dst, output)
+		}
+	}
+	return dst
+}
+
+// routed returns the outputs currently receiving metrics, i.e. the ungrouped
+// outputs and the active members of each group. Dead-letter outputs and idle
+// failover outputs are excluded. The caller must hold the lock of the unit.
+func (u *outputUnit) routed() []*models.RunningOutput {
+	outputs := make([]*models.RunningOutput, 0, len(u.outputs))
+	outputs = append(outputs, u.ungrouped...)
+	for _, group := range u.groups {
+		outputs = append(outputs, group.Active()...)
+	}
+	return outputs
+}
 
-	return src, unit, nil
+// distribute writes the metrics of the given pipeline to the outputs until
+// the source channel is closed. Metrics not received by any output are
+// dropped.
+func (u *outputUnit) distribute(pipeline string, src <-chan telegraf.Metric) {
+	var destinations []*models.RunningOutput
+	for metric := range src {
+		u.RLock()
+		destinations = u.route(destinations[:0], pipeline, metric)
+		if len(destinations) == 0 {
+			metric.Drop()
+		}
+		for i, output := range destinations {
+			if i == len(destinations)-1 {
+				output.AddMetricNoCopy(metric)
+			} else {
+				output.AddMetric(metric)
+			}
+		}
+		u.RUnlock()
+	}
 }
 
 // connectOutput connects to all outputs.
@@ -822,65 +1476,78 @@ func (*Agent) connectOutput(ctx context.Context, output *models.RunningOutput) e
 	return nil
 }
 
-// runOutputs begins processing metrics and returns until the source channel is
-// closed and all metrics have been written.  On shutdown metrics will be
+// runOutputs begins processing metrics and returns until the source channels
+// are closed and all metrics have been written.  On shutdown metrics will be
 // written one last time and dropped if unsuccessful.
 func (a *Agent) runOutputs(
 	unit *outputUnit,
 ) {
-	var wg sync.WaitGroup
-
-	// Start flush loop
-	interval := time.Duration(a.Config.Agent.FlushInterval)
-	jitter := time.Duration(a.Config.Agent.FlushJitter)
-
 	ctx, cancel := context.WithCancel(context.Background())
 
+	// Start flush loop
+	unit.Lock()
+	unit.ctx = ctx
+	unit.loops = make(map[*models.RunningOutput]*loop, len(unit.outputs))
 	for _, output := range unit.outputs {
-		interval := interval
-		// Overwrite agent flush_interval if this plugin has its own.
-		if output.Config.FlushInterval != 0 {
-			interval = output.Config.FlushInterval
-		}
-
-		jitter := jitter
-		// Overwrite agent flush_jitter if this plugin has its own.
-		if output.Config.FlushJitter != 0 {
-			jitter = output.Config.FlushJitter
-		}
+		a.startFlushLoop(unit, output)
+	}
+	unit.Unlock()
 
+	var wg sync.WaitGroup
+	for pipeline, src := range unit.sources {
 		wg.Add(1)
-		go func(output *models.RunningOutput) {
+		go func() {
 			defer wg.Done()
-
-			timer := clock.NewTimer(interval, jitter)
-			defer timer.Stop()
-
-			a.flushLoop(ctx, output, timer)
-		}(output)
-	}
-
-	for metric := range unit.src {
-		for i, output := range unit.outputs {
-			if i == len(unit.outputs)-1 {
-				output.AddMetricNoCopy(metric)
-			} else {
-				output.AddMetric(metric)
-			}
-		}
+			unit.distribute(pipeline, src)
+		}()
 	}
+	wg.Wait()
 
 	log.Println("I! [agent] Hang on, flushing any cached metrics before shutdown")
+	unit.Lock()
+	unit.stopped = true
+	unit.Unlock()
 	cancel()
-	wg.Wait()
+	unit.wg.Wait()
 
 	log.Println("I! [agent] Stopping running outputs")
 	stopRunningOutputs(unit.outputs)
 }
 
+// startFlushLoop starts the periodic flushing of the given output. The caller
+// must hold the lock of the unit.
+func (a *Agent) startFlushLoop(unit *outputUnit, output *models.RunningOutput) {
+	// Overwrite agent flush_interval if this plugin has its own.
+	interval := time.Duration(a.Config.Agent.FlushInterval)
+	if output.Config.FlushInterval != 0 {
+		interval = output.Config.FlushInterval
+	}
+
+	// Overwrite agent flush_jitter if this plugin has its own.
+	jitter := time.Duration(a.Config.Agent.FlushJitter)
+	if output.Config.FlushJitter != 0 {
+		jitter = output.Config.FlushJitter
+	}
+
+	ctx, cancel := context.WithCancel(unit.ctx)
+	l := newLoop(cancel)
+	unit.loops[output] = l
+
+	unit.wg.Add(1)
+	go func() {
+		defer unit.wg.Done()
+		defer close(l.done)
+
+		timer := clock.NewTimer(interval, jitter)
+		defer timer.Stop()
+
+		a.flushLoop(ctx, output, timer, l.trigger)
+	}()
+}
+
 // flushLoop runs an output's flush function periodically until the context is
 // done.
-func (a *Agent) flushLoop(ctx context.Context, output *models.RunningOutput, timer *clock.Timer) {
+func (a *Agent) flushLoop(ctx context.Context, output *models.RunningOutput, timer *clock.Timer, trigger <-chan struct{}) {
 	logError := func(err error) {
 		if err != nil {
 			log.Printf("E! [agent] Error writing to %s: %v", output.LogName(), err)
@@ -896,21 +1563,23 @@ func (a *Agent) flushLoop(ctx context.Context, output *models.RunningOutput, tim
 		// Favor shutdown over other methods.
 		select {
 		case <-ctx.Done():
-			logError(a.flushOnce(output, timer, output.Write))
+			logError(a.flushOnce(output, timer, output.WriteOnShutdown))
 			return
 		default:
 		}
 
 		select {
 		case <-ctx.Done():
-			logError(a.flushOnce(output, timer, output.Write))
+			logError(a.flushOnce(output, timer, output.WriteOnShutdown))
 			return
 		case <-timer.C:
 			logError(a.flushOnce(output, timer, output.Write))
 		case <-flushRequested:
 			logError(a.flushOnce(output, timer, output.Write))
+		case <-trigger:
+			logError(a.flushOnce(output, timer, output.Write))
 		case <-output.BatchReady:
-			logError(a.flushBatch(output, output.WriteBatch))
+			logError(a.flushBatch(ctx, output, output.WriteBatch))
 		}
 	}
 }
@@ -936,7 +1605,16 @@ func (*Agent) flushOnce(output *models.RunningOutput, timer *clock.Timer, writeF
 }
 
 // flushBatch runs the output's Write function once Unlike flushOnce the interval elapsing is not considered during these flushes.
-func (*Agent) flushBatch(output *models.RunningOutput, writeFunc func() error) error {
+// For rate-limited outputs the write is delayed until the limits allow
+// writing again, the metrics are kept in the buffer in the meantime.
+func (*Agent) flushBatch(ctx context.Context, output *models.RunningOutput, writeFunc func() error) error {
+	if delay := output.RateLimitDelay(); delay > 0 {
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
+	}
+
 	err := writeFunc()
 	output.LogBufferStatus()
 	return err
@@ -994,54 +1672,46 @@ func (a *Agent) runTest(ctx context.Context, wait time.Duration, outputC chan<-
 
 	startTime := time.Now()
 
-	next := outputC
-
-	var apu []*processorUnit
-	var au *aggregatorUnit
-	if len(a.Config.Aggregators) != 0 {
-		procC := next
-		if len(a.Config.AggProcessors) != 0 && !*a.Config.Agent.SkipProcessorsAfterAggregators {
-			var err error
-			procC, apu, err = a.startProcessors(next, a.Config.AggProcessors)
-			if err != nil {
-				return err
+	// Merge the metrics of all pipelines into the output channel
+	var wg sync.WaitGroup
+	var mergeWg sync.WaitGroup
+	pipelines := a.Config.Pipelines()
+	next := make(map[string]chan<- telegraf.Metric, len(pipelines))
+	for _, name := range pipelines {
+		src := make(chan telegraf.Metric, 100)
+		next[name] = src
+
+		mergeWg.Add(1)
+		go func() {
+			defer mergeWg.Done()
+			for m := range src {
+				outputC <- m
 			}
-		}
-
-		next, au = a.startAggregators(procC, next, a.Config.Aggregators)
+		}()
 	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		mergeWg.Wait()
+		close(outputC)
+	}()
 
-	var pu []*processorUnit
-	if len(a.Config.Processors) != 0 {
-		var err error
-		next, pu, err = a.startProcessors(next, a.Config.Processors)
-		if err != nil {
-			return err
+	chains, err := a.startChains(ctx, startTime, next)
+	if err != nil {
+		for _, src := range next {
+			close(src)
 		}
+		wg.Wait()
+		return err
 	}
 
-	iu := a.testStartInputs(next, a.Config.Inputs)
-
-	var wg sync.WaitGroup
-	if au != nil {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			a.runProcessors(apu)
-		}()
-
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			a.runAggregators(startTime, au)
-		}()
-	}
+	iu := a.testStartInputs(chainSources(chains), a.Config.Inputs)
 
-	if pu != nil {
+	for _, c := range chains {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			a.runProcessors(pu)
+			c.run()
 		}()
 	}
 
@@ -1100,34 +1770,17 @@ func (a *Agent) runOnce(ctx context.Context, wait time.Duration) error {
 	startTime := time.Now()
 
 	log.Printf("D! [agent] Connecting outputs")
-	next, ou, err := a.startOutputs(ctx, a.Config.Outputs)
+	next, ou, err := a.startOutputs(ctx, a.Config.Outputs, a.Config.Pipelines())
 	if err != nil {
 		return err
 	}
 
-	var apu []*processorUnit
-	var au *aggregatorUnit
-	if len(a.Config.Aggregators) != 0 {
-		procC := next
-		if len(a.Config.AggProcessors) != 0 && !*a.Config.Agent.SkipProcessorsAfterAggregators {
-			procC, apu, err = a.startProcessors(next, a.Config.AggProcessors)
-			if err != nil {
-				return err
-			}
-		}
-
-		next, au = a.startAggregators(procC, next, a.Config.Aggregators)
-	}
-
-	var pu []*processorUnit
-	if len(a.Config.Processors) != 0 {
-		next, pu, err = a.startProcessors(next, a.Config.Processors)
-		if err != nil {
-			return err
-		}
+	chains, err := a.startChains(ctx, startTime, next)
+	if err != nil {
+		return err
 	}
 
-	iu := a.testStartInputs(next, a.Config.Inputs)
+	iu := a.testStartInputs(chainSources(chains), a.Config.Inputs)
 
 	var wg sync.WaitGroup
 	wg.Add(1)
@@ -1136,25 +1789,11 @@ func (a *Agent) runOnce(ctx context.Context, wait time.Duration) error {
 		a.runOutputs(ou)
 	}()
 
-	if au != nil {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			a.runProcessors(apu)
-		}()
-
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			a.runAggregators(startTime, au)
-		}()
-	}
-
-	if pu != nil {
+	for _, c := range chains {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			a.runProcessors(pu)
+			c.run()
 		}()
 	}
 
@@ -1202,9 +1841,3 @@ func panicRecover(input *models.RunningInput) {
 			"https://github.com/influxdata/telegraf/issues/new/choose")
 	}
 }
-
-func stopTickers(tickers []*clock.Ticker) {
-	for _, ticker := range tickers {
-		ticker.Stop()
-	}
-}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/models"
 	_ "github.com/influxdata/telegraf/plugins/aggregators/all"
 	_ "github.com/influxdata/telegraf/plugins/inputs/all"
@@ -241,6 +242,126 @@ func TestAgent_SkipProcessorsAfterAggregatorsDefaultsFalse(t *testing.T) {
 	require.False(t, *c.Agent.SkipProcessorsAfterAggregators)
 }
 
+func TestAgent_OutputGroupRouting(t *testing.T) {
+	c := config.NewConfig()
+	require.NoError(t, c.LoadConfigData([]byte(`
+[[outputs.discard]]
+  alias = "all"
+[[outputs.discard]]
+  alias = "shard_a"
+[[outputs.discard]]
+  alias = "shard_b"
+
+[output_groups.shards]
+  mode = "round_robin"
+  outputs = ["shard_a", "shard_b"]
+`), config.EmptySourcePath))
+	require.Len(t, c.Outputs, 3)
+
+	unit := &outputUnit{outputs: c.Outputs}
+	unit.updateRoutes(c.OutputGroups)
+	require.Equal(t, []*models.RunningOutput{c.Outputs[0]}, unit.ungrouped)
+	require.Len(t, unit.groups, 1)
+
+	// Every metric goes to the ungrouped output and exactly one shard
+	m := testutil.TestMetric(1)
+	require.Equal(t, []*models.RunningOutput{c.Outputs[0], c.Outputs[1]}, unit.route(nil, models.DefaultPipeline, m))
+	require.Equal(t, []*models.RunningOutput{c.Outputs[0], c.Outputs[2]}, unit.route(nil, models.DefaultPipeline, m))
+
+	// Removing a shard routes all metrics to the remaining one
+	unit.outputs = c.Outputs[:2]
+	unit.updateRoutes(c.OutputGroups)
+	require.Equal(t, []*models.RunningOutput{c.Outputs[0], c.Outputs[1]}, unit.route(nil, models.DefaultPipeline, m))
+	require.Equal(t, []*models.RunningOutput{c.Outputs[0], c.Outputs[1]}, unit.route(nil, models.DefaultPipeline, m))
+}
+
+func TestAgent_DeadLetterRouting(t *testing.T) {
+	c := config.NewConfig()
+	require.NoError(t, c.LoadConfigData([]byte(`
+[[outputs.discard]]
+  dead_letter = "rejected"
+[[outputs.discard]]
+  alias = "rejected"
+`), config.EmptySourcePath))
+	require.Len(t, c.Outputs, 2)
+
+	// The dead-letter output must only receive rejected metrics
+	unit := &outputUnit{outputs: c.Outputs}
+	unit.updateRoutes(c.OutputGroups)
+	require.Equal(t, []*models.RunningOutput{c.Outputs[0]}, unit.route(nil, models.DefaultPipeline, testutil.TestMetric(1)))
+}
+
+func TestAgent_PipelineRouting(t *testing.T) {
+	c := config.NewConfig()
+	require.NoError(t, c.LoadConfigData([]byte(`
+[[outputs.discard]]
+  alias = "all"
+  pipelines = ["default", "tenant_b"]
+[[outputs.discard]]
+  alias = "tenant_b"
+  pipelines = ["tenant_b"]
+[[outputs.discard]]
+  alias = "default"
+`), config.EmptySourcePath))
+	require.Len(t, c.Outputs, 3)
+	require.Equal(t, []string{"default", "tenant_b"}, c.Pipelines())
+
+	unit := &outputUnit{outputs: c.Outputs}
+	unit.updateRoutes(c.OutputGroups)
+
+	// Outputs only receive the metrics of the pipelines they subscribe to
+	m := testutil.TestMetric(1)
+	require.Equal(t, []*models.RunningOutput{c.Outputs[0], c.Outputs[2]}, unit.route(nil, "default", m))
+	require.Equal(t, []*models.RunningOutput{c.Outputs[0], c.Outputs[1]}, unit.route(nil, "tenant_b", m))
+	require.Empty(t, unit.route(nil, "tenant_c", m))
+}
+
+func TestAgent_PipelineIsolation(t *testing.T) {
+	tmpdir := t.TempDir()
+	fileA := filepath.Join(tmpdir, "a.influx")
+	require.NoError(t, os.WriteFile(fileA, []byte("cpu value=1i 1689253834000000000\n"), 0600))
+	fileB := filepath.Join(tmpdir, "b.influx")
+	require.NoError(t, os.WriteFile(fileB, []byte("cpu value=2i 1689253834000000000\n"), 0600))
+
+	cfg := config.NewConfig()
+	require.NoError(t, cfg.LoadConfigData([]byte(fmt.Sprintf(`
+[agent]
+  omit_hostname = true
+  skip_processors_after_aggregators = true
+
+[[inputs.file]]
+  files = [%q]
+  data_format = "influx"
+
+[[inputs.file]]
+  files = [%q]
+  data_format = "influx"
+  pipeline = "tenant_b"
+
+[[processors.override]]
+  [processors.override.tags]
+    tenant = "a"
+
+[[processors.override]]
+  pipeline = "tenant_b"
+  [processors.override.tags]
+    tenant = "b"
+`, fileA, fileB)), config.EmptySourcePath))
+
+	a := NewAgent(cfg)
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	actual, err := collect(ctx, a, 0)
+	require.NoError(t, err)
+
+	// Each metric must only pass the processors of its own pipeline
+	expected := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"tenant": "a"}, map[string]interface{}{"value": int64(1)}, time.Unix(0, 1689253834000000000)),
+		metric.New("cpu", map[string]string{"tenant": "b"}, map[string]interface{}{"value": int64(2)}, time.Unix(0, 1689253834000000000)),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual, testutil.SortMetrics())
+}
+
 // Implement a "test-mode" like call but collect the metrics
 func collect(ctx context.Context, a *Agent, wait time.Duration) ([]telegraf.Metric, error) {
 	var received []telegraf.Metric
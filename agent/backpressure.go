@@ -0,0 +1,161 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+// Interval for checking the fill level of the output buffers
+const backpressureCheckInterval = 250 * time.Millisecond
+
+// backpressure signals the inputs to slow down if all outputs receiving
+// metrics fall behind writing them. Backpressure is applied as soon as the
+// buffers of all these outputs exceed the high-water mark and released as soon
+// as any buffer falls below the low-water mark. Dead-letter outputs and idle
+// outputs of failover groups do not receive metrics and are not considered.
+type backpressure struct {
+	high    float64
+	low     float64
+	policy  string
+	stretch int
+
+	active atomic.Bool
+	state  selfstat.Stat
+}
+
+func newBackpressure(cfg *config.AgentConfig) (*backpressure, error) {
+	b := &backpressure{
+		high:    cfg.BackpressureHighWaterMark,
+		low:     cfg.BackpressureLowWaterMark,
+		policy:  cfg.BackpressurePolicy,
+		stretch: cfg.BackpressureStretchFactor,
+	}
+	if b.high < 0 || b.high > 1 {
+		return nil, fmt.Errorf("invalid 'backpressure_high_water_mark' %v, must be between 0 and 1", b.high)
+	}
+	if b.low == 0 {
+		b.low = b.high / 2
+	}
+	if b.low < 0 || b.low > b.high {
+		return nil, fmt.Errorf("invalid 'backpressure_low_water_mark' %v, must be between 0 and the high-water mark", b.low)
+	}
+	switch b.policy {
+	case "":
+		b.policy = "skip"
+	case "skip", "stretch":
+	default:
+		return nil, fmt.Errorf("invalid 'backpressure_policy' %q", b.policy)
+	}
+	if b.stretch == 0 {
+		b.stretch = 2
+	}
+	if b.stretch < 1 {
+		return nil, fmt.Errorf("invalid 'backpressure_stretch_factor' %d", b.stretch)
+	}
+	b.state = selfstat.Register("agent", "backpressure", map[string]string{})
+	return b, nil
+}
+
+// Active returns true while backpressure is applied.
+func (b *backpressure) Active() bool {
+	return b != nil && b.active.Load()
+}
+
+// update determines the backpressure state from the fill level of the given
+// output buffers and returns true if the state changed.
+func (b *backpressure) update(outputs []*models.RunningOutput) bool {
+	if len(outputs) == 0 {
+		return false
+	}
+
+	// The outputs with the least filled buffer determines the state as
+	// metrics are still written to this output
+	fill := -1.0
+	for _, output := range outputs {
+		var f float64
+		if output.MetricBufferLimit > 0 {
+			f = float64(output.BufferLength()) / float64(output.MetricBufferLimit)
+		}
+		if fill < 0 || f < fill {
+			fill = f
+		}
+	}
+
+	active := b.active.Load()
+	switch {
+	case !active && fill >= b.high:
+		active = true
+	case active && fill < b.low:
+		active = false
+	default:
+		return false
+	}
+	b.active.Store(active)
+	if active {
+		b.state.Set(1)
+	} else {
+		b.state.Set(0)
+	}
+	return true
+}
+
+// skipGather returns true if gathering of a polling input should be skipped
+// due to backpressure. The given counter keeps track of the intervals under
+// backpressure for each input.
+func (b *backpressure) skipGather(ticks *int) bool {
+	if !b.Active() {
+		*ticks = 0
+		return false
+	}
+	if b.policy == "stretch" {
+		*ticks++
+		return *ticks%b.stretch != 0
+	}
+	return true
+}
+
+// monitorBackpressure periodically checks the fill level of the output
+// buffers and pauses or resumes the service inputs supporting it on changes
+// of the backpressure state until the context is done.
+func (a *Agent) monitorBackpressure(ctx context.Context, iu *inputUnit, ou *outputUnit) {
+	ticker := time.NewTicker(backpressureCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ou.RLock()
+			changed := a.backpressure.update(ou.routed())
+			ou.RUnlock()
+			if !changed {
+				continue
+			}
+
+			active := a.backpressure.Active()
+			if active {
+				log.Printf("W! [agent] Output buffers above high-water mark, applying backpressure to inputs")
+			} else {
+				log.Printf("I! [agent] Output buffers below low-water mark, releasing backpressure")
+			}
+
+			iu.Lock()
+			for _, input := range iu.inputs {
+				if !active {
+					input.Resume()
+				} else if input.Pause() {
+					log.Printf("D! [agent] Paused %s", input.LogName())
+				}
+			}
+			iu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/models"
+)
+
+func TestBackpressureInvalidSettings(t *testing.T) {
+	_, err := newBackpressure(&config.AgentConfig{BackpressureHighWaterMark: 1.5})
+	require.ErrorContains(t, err, "invalid 'backpressure_high_water_mark'")
+
+	_, err = newBackpressure(&config.AgentConfig{
+		BackpressureHighWaterMark: 0.5,
+		BackpressureLowWaterMark:  0.8,
+	})
+	require.ErrorContains(t, err, "invalid 'backpressure_low_water_mark'")
+
+	_, err = newBackpressure(&config.AgentConfig{
+		BackpressureHighWaterMark: 0.5,
+		BackpressurePolicy:        "foo",
+	})
+	require.ErrorContains(t, err, "invalid 'backpressure_policy'")
+}
+
+func TestBackpressureUpdate(t *testing.T) {
+	cfg := config.NewConfig()
+	require.NoError(t, cfg.LoadConfigData([]byte(`
+[[outputs.discard]]
+  alias = "a"
+  metric_buffer_limit = 10
+[[outputs.discard]]
+  alias = "b"
+  metric_buffer_limit = 10
+`), config.EmptySourcePath))
+	outputs := cfg.Outputs
+
+	b, err := newBackpressure(&config.AgentConfig{BackpressureHighWaterMark: 0.8})
+	require.NoError(t, err)
+	require.False(t, b.update(outputs))
+
+	m := metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42}, time.Unix(0, 0))
+	for range 8 {
+		outputs[0].AddMetric(m)
+	}
+
+	// Backpressure requires all outputs to exceed the high-water mark
+	require.False(t, b.update(outputs))
+	require.False(t, b.Active())
+	for range 8 {
+		outputs[1].AddMetric(m)
+	}
+	require.True(t, b.update(outputs))
+	require.True(t, b.Active())
+	require.Equal(t, int64(1), b.state.Get())
+
+	// Backpressure is released below the low-water mark of any output
+	require.NoError(t, outputs[0].Write())
+	require.True(t, b.update(outputs))
+	require.False(t, b.Active())
+	require.Equal(t, int64(0), b.state.Get())
+}
+
+func TestBackpressureRoutedOutputs(t *testing.T) {
+	cfg := config.NewConfig()
+	require.NoError(t, cfg.LoadConfigData([]byte(`
+[[outputs.discard]]
+  alias = "primary"
+  metric_buffer_limit = 10
+  dead_letter = "rejected"
+[[outputs.discard]]
+  alias = "secondary"
+  metric_buffer_limit = 10
+[[outputs.discard]]
+  alias = "rejected"
+  metric_buffer_limit = 10
+
+[output_groups.ha]
+  mode = "failover"
+  outputs = ["primary", "secondary"]
+`), config.EmptySourcePath))
+	require.Len(t, cfg.Outputs, 3)
+
+	unit := &outputUnit{outputs: cfg.Outputs}
+	unit.updateRoutes(cfg.OutputGroups)
+	require.Equal(t, []*models.RunningOutput{cfg.Outputs[0]}, unit.routed())
+
+	// The empty buffers of the idle secondary and the dead-letter output must
+	// not prevent backpressure
+	b, err := newBackpressure(&config.AgentConfig{BackpressureHighWaterMark: 0.8})
+	require.NoError(t, err)
+	m := metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42}, time.Unix(0, 0))
+	for range 8 {
+		cfg.Outputs[0].AddMetric(m)
+	}
+	require.True(t, b.update(unit.routed()))
+	require.True(t, b.Active())
+}
+
+func TestBackpressureSkipGather(t *testing.T) {
+	var disabled *backpressure
+	var ticks int
+	require.False(t, disabled.skipGather(&ticks))
+
+	b, err := newBackpressure(&config.AgentConfig{BackpressureHighWaterMark: 0.8})
+	require.NoError(t, err)
+	require.False(t, b.skipGather(&ticks))
+	b.active.Store(true)
+	require.True(t, b.skipGather(&ticks))
+	require.True(t, b.skipGather(&ticks))
+
+	b, err = newBackpressure(&config.AgentConfig{
+		BackpressureHighWaterMark: 0.8,
+		BackpressurePolicy:        "stretch",
+		BackpressureStretchFactor: 3,
+	})
+	require.NoError(t, err)
+	b.active.Store(true)
+	var gathered int
+	for range 9 {
+		if !b.skipGather(&ticks) {
+			gathered++
+		}
+	}
+	require.Equal(t, 3, gathered)
+
+	// Releasing the backpressure resets the counter
+	b.active.Store(false)
+	require.False(t, b.skipGather(&ticks))
+	require.Zero(t, ticks)
+}
+
+func TestBackpressurePausesAddedInputs(t *testing.T) {
+	b, err := newBackpressure(&config.AgentConfig{BackpressureHighWaterMark: 0.8})
+	require.NoError(t, err)
+	b.active.Store(true)
+	a := &Agent{backpressure: b}
+
+	// Inputs started while backpressure is applied must be paused as the
+	// inputs are only signaled on changes of the state
+	plugin := &pausableInput{}
+	input := models.NewRunningInput(plugin, &models.InputConfig{Name: "pausable"})
+	require.NoError(t, input.Init())
+	p := &pipeline{
+		inputs: &inputUnit{dst: map[string]chan<- telegraf.Metric{"": make(chan telegraf.Metric, 1)}},
+	}
+	started, err := a.addInput(p, input)
+	require.NoError(t, err)
+	require.True(t, started)
+	require.True(t, plugin.paused)
+	require.Equal(t, []*models.RunningInput{input}, p.inputs.inputs)
+}
+
+type pausableInput struct {
+	paused bool
+}
+
+func (*pausableInput) SampleConfig() string {
+	return ""
+}
+
+func (*pausableInput) Gather(telegraf.Accumulator) error {
+	return nil
+}
+
+func (*pausableInput) Start(telegraf.Accumulator) error {
+	return nil
+}
+
+func (*pausableInput) Stop() {}
+
+func (p *pausableInput) Pause() {
+	p.paused = true
+}
+
+func (p *pausableInput) Resume() {
+	p.paused = false
+}
@@ -0,0 +1,461 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/models"
+)
+
+// ErrRestartRequired is returned by Reload if the configuration changes
+// cannot be applied to the running agent, e.g. because agent settings or
+// global tags changed.
+var ErrRestartRequired = errors.New("configuration change requires a restart of the agent")
+
+// pipeline holds the units of a running agent with the processing chains of
+// the configured pipelines.
+type pipeline struct {
+	ctx     context.Context
+	inputs  *inputUnit
+	chains  map[string]*chain
+	outputs *outputUnit
+}
+
+// pluginDiff is the result of matching running plugins against the plugins
+// of a new configuration.
+type pluginDiff[T comparable] struct {
+	// merged contains the plugins of the new configuration with unchanged
+	// plugins replaced by the running instance
+	merged []T
+	// added contains the plugins of the new configuration to start
+	added []T
+	// removed contains the running plugins to stop
+	removed []T
+	// unused contains the instances of the new configuration superseded by
+	// the running plugins
+	unused []T
+}
+
+// diffPlugins matches the running plugins against the configured ones using
+// the plugin ID. Plugins with identical settings share the same ID, so IDs
+// occurring multiple times are matched in order.
+func diffPlugins[T comparable](running, configured []T, id func(T) string) *pluginDiff[T] {
+	available := make(map[string][]T, len(running))
+	for _, p := range running {
+		available[id(p)] = append(available[id(p)], p)
+	}
+
+	diff := &pluginDiff[T]{merged: make([]T, 0, len(configured))}
+	for _, p := range configured {
+		candidates := available[id(p)]
+		if len(candidates) == 0 {
+			diff.merged = append(diff.merged, p)
+			diff.added = append(diff.added, p)
+			continue
+		}
+		diff.merged = append(diff.merged, candidates[0])
+		diff.unused = append(diff.unused, p)
+		available[id(p)] = candidates[1:]
+	}
+
+	// Keep the order of the running plugins for the ones to remove
+	for _, p := range running {
+		if candidates := available[id(p)]; slices.Contains(candidates, p) {
+			diff.removed = append(diff.removed, p)
+		}
+	}
+
+	return diff
+}
+
+// sameIDs checks if both plugin lists contain the same plugins in the same
+// order.
+func sameIDs[T any](a, b []T, id func(T) string) bool {
+	return slices.EqualFunc(a, b, func(x, y T) bool { return id(x) == id(y) })
+}
+
+// mergePipelines returns the configured plugins with the plugins of the
+// unchanged pipelines replaced by the running instances in the same order.
+func mergePipelines[S ~[]E, E any](running, configured S, pipeline func(E) string, changed map[string]bool) S {
+	available := make(map[string]S)
+	for _, p := range running {
+		if name := pipeline(p); !changed[name] {
+			available[name] = append(available[name], p)
+		}
+	}
+
+	merged := make(S, 0, len(configured))
+	for _, p := range configured {
+		name := pipeline(p)
+		if changed[name] {
+			merged = append(merged, p)
+			continue
+		}
+		merged = append(merged, available[name][0])
+		available[name] = available[name][1:]
+	}
+	return merged
+}
+
+// Reload applies the given configuration to the running agent. Only plugins
+// with changed settings are stopped and started while all other plugins keep
+// running, preserving e.g. output buffers and service input listeners.
+// Processors and aggregators are chained, so a change in one of the plugins
+// restarts all processors or aggregators of the pipeline respectively.
+// ErrRestartRequired is returned if the changes cannot be applied without
+// restarting the agent, e.g. when adding or removing pipelines. If the new
+// plugins cannot be initialized, started or connected, the running agent is
+// left untouched.
+func (a *Agent) Reload(cfg *config.Config) error {
+	a.pipelineLock.Lock()
+	defer a.pipelineLock.Unlock()
+
+	p := a.pipeline
+	if p == nil || p.ctx.Err() != nil {
+		discardOutputs(cfg.Outputs)
+		return errors.New("agent is not running")
+	}
+
+	// Apply the same default as for the running agent before comparing
+	if cfg.Agent.SkipProcessorsAfterAggregators == nil {
+		skipProcessorsAfterAggregators := false
+		cfg.Agent.SkipProcessorsAfterAggregators = &skipProcessorsAfterAggregators
+	}
+	if !reflect.DeepEqual(a.Config.Agent, cfg.Agent) || !maps.Equal(a.Config.Tags, cfg.Tags) ||
+		!reflect.DeepEqual(a.Config.OutputGroups, cfg.OutputGroups) ||
+		!slices.Equal(a.Config.Pipelines(), cfg.Pipelines()) {
+		discardOutputs(cfg.Outputs)
+		return ErrRestartRequired
+	}
+
+	inputs := diffPlugins(a.Config.Inputs, cfg.Inputs, (*models.RunningInput).ID)
+	outputs := diffPlugins(a.Config.Outputs, cfg.Outputs, (*models.RunningOutput).ID)
+	processorsChanged := make(map[string]bool, len(p.chains))
+	aggregatorsChanged := make(map[string]bool, len(p.chains))
+	for name := range p.chains {
+		processorsChanged[name] = !sameIDs(
+			inPipeline(a.Config.Processors, processorPipeline, name),
+			inPipeline(cfg.Processors, processorPipeline, name),
+			(*models.RunningProcessor).ID,
+		)
+		aggregatorsChanged[name] = !sameIDs(
+			inPipeline(a.Config.Aggregators, aggregatorPipeline, name),
+			inPipeline(cfg.Aggregators, aggregatorPipeline, name),
+			(*models.RunningAggregator).ID,
+		) || !sameIDs(
+			inPipeline(a.Config.AggProcessors, processorPipeline, name),
+			inPipeline(cfg.AggProcessors, processorPipeline, name),
+			(*models.RunningProcessor).ID,
+		)
+	}
+
+	// Initialize and start all new plugins before touching the running ones
+	// to keep the agent untouched in case of errors
+	processorStages := make(map[string]*stage, len(p.chains))
+	aggregatorStages := make(map[string]*stage, len(p.chains))
+	var connected, failed []*models.RunningOutput
+	abort := func(err error) error {
+		for _, s := range processorStages {
+			s.discard()
+		}
+		for _, s := range aggregatorStages {
+			s.discard()
+		}
+		for _, output := range connected {
+			output.Close()
+		}
+		discardOutputs(slices.DeleteFunc(slices.Clone(cfg.Outputs), func(output *models.RunningOutput) bool {
+			return slices.Contains(connected, output) || slices.Contains(failed, output)
+		}))
+		return err
+	}
+
+	if err := a.initInputs(inputs.added); err != nil {
+		return abort(err)
+	}
+	if err := initOutputs(outputs.added); err != nil {
+		return abort(err)
+	}
+	for name := range p.chains {
+		if processorsChanged[name] {
+			if err := initProcessors(inPipeline(cfg.Processors, processorPipeline, name)); err != nil {
+				return abort(err)
+			}
+		}
+		if aggregatorsChanged[name] {
+			if err := initAggregators(inPipeline(cfg.Aggregators, aggregatorPipeline, name)); err != nil {
+				return abort(err)
+			}
+			if !*cfg.Agent.SkipProcessorsAfterAggregators {
+				if err := initProcessors(inPipeline(cfg.AggProcessors, processorPipeline, name)); err != nil {
+					return abort(err)
+				}
+			}
+		}
+	}
+
+	// Restore the persisted states of the new plugins before starting them
+	changedProcessors := func(c *config.Config) models.RunningProcessors {
+		return slices.DeleteFunc(slices.Clone(c.Processors), func(proc *models.RunningProcessor) bool {
+			return !processorsChanged[processorPipeline(proc)]
+		})
+	}
+	changedAggregators := func(c *config.Config) ([]*models.RunningAggregator, models.RunningProcessors) {
+		aggregators := slices.DeleteFunc(slices.Clone(c.Aggregators), func(agg *models.RunningAggregator) bool {
+			return !aggregatorsChanged[aggregatorPipeline(agg)]
+		})
+		aggProcessors := slices.DeleteFunc(slices.Clone(c.AggProcessors), func(proc *models.RunningProcessor) bool {
+			return !aggregatorsChanged[processorPipeline(proc)]
+		})
+		return aggregators, aggProcessors
+	}
+	if a.Config.Persister != nil {
+		addedAggregators, addedAggProcessors := changedAggregators(cfg)
+		plugins := statefulPlugins(inputs.added, changedProcessors(cfg), addedAggregators, addedAggProcessors, outputs.added)
+		if err := a.Config.Persister.Restore(pluginsByID(plugins)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return abort(fmt.Errorf("restoring plugin states: %w", err))
+		}
+	}
+
+	for name := range p.chains {
+		if processorsChanged[name] {
+			ps, err := a.processorStage(inPipeline(cfg.Processors, processorPipeline, name))
+			if err != nil {
+				return abort(err)
+			}
+			processorStages[name] = ps
+		}
+		if aggregatorsChanged[name] {
+			as, err := a.aggregatorStage(p.ctx, time.Now(),
+				inPipeline(cfg.Aggregators, aggregatorPipeline, name),
+				inPipeline(cfg.AggProcessors, processorPipeline, name),
+			)
+			if err != nil {
+				return abort(err)
+			}
+			aggregatorStages[name] = as
+		}
+	}
+	for _, output := range outputs.added {
+		if err := a.connectOutput(p.ctx, output); err != nil {
+			var fatalErr *internal.FatalError
+			if !errors.As(err, &fatalErr) {
+				return abort(fmt.Errorf("connecting output %s: %w", output.LogName(), err))
+			}
+			log.Printf("I! [agent] Failed to connect to [%s], error was %q;  shutting down plugin...", output.LogName(), err)
+			output.Close()
+			failed = append(failed, output)
+			continue
+		}
+		connected = append(connected, output)
+	}
+	discardOutputs(outputs.unused)
+
+	// From here on the changes are applied to the running agent. Errors only
+	// occur if the agent is stopped concurrently or inputs fail to start, so
+	// all remaining changes are applied to keep the configuration in sync
+	// with the running plugins.
+	var errs []error
+
+	// Add the new outputs first to not lose any metrics from new inputs
+	for _, output := range connected {
+		if err := a.addOutput(p, output); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Determine the stateful plugins to exchange before merging the
+	// configuration
+	var removedStateful []statefulPlugin
+	if a.Config.Persister != nil {
+		removedAggregators, removedAggProcessors := changedAggregators(a.Config)
+		removedStateful = statefulPlugins(inputs.removed, changedProcessors(a.Config), removedAggregators, removedAggProcessors, outputs.removed)
+	}
+
+	for name, c := range p.chains {
+		if ps, found := processorStages[name]; found {
+			log.Printf("D! [agent] Restarting processors of pipeline %q", name)
+			if err := c.processors.exchange(ps); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if as, found := aggregatorStages[name]; found {
+			log.Printf("D! [agent] Restarting aggregators of pipeline %q", name)
+			if err := c.aggregators.exchange(as); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	a.Config.Processors = mergePipelines(a.Config.Processors, cfg.Processors, processorPipeline, processorsChanged)
+	a.Config.Aggregators = mergePipelines(a.Config.Aggregators, cfg.Aggregators, aggregatorPipeline, aggregatorsChanged)
+	a.Config.AggProcessors = mergePipelines(a.Config.AggProcessors, cfg.AggProcessors, processorPipeline, aggregatorsChanged)
+
+	for _, input := range inputs.removed {
+		a.removeInput(p, input)
+	}
+	started := make(map[*models.RunningInput]bool, len(inputs.added))
+	for _, input := range inputs.added {
+		ok, err := a.addInput(p, input)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("starting input %s: %w", input.LogName(), err))
+		}
+		started[input] = ok
+	}
+	a.Config.Inputs = slices.DeleteFunc(inputs.merged, func(input *models.RunningInput) bool {
+		ok, found := started[input]
+		return found && !ok
+	})
+
+	// Remove outputs last to flush all metrics still in the pipeline
+	for _, output := range outputs.removed {
+		a.removeOutput(p, output)
+	}
+	a.Config.Outputs = slices.DeleteFunc(outputs.merged, func(output *models.RunningOutput) bool {
+		return slices.Contains(failed, output)
+	})
+
+	// Only exchange the changed plugins with persisted states as the running
+	// agent stores the states concurrently
+	if a.Config.Persister != nil {
+		startedInputs := slices.DeleteFunc(slices.Clone(inputs.added), func(input *models.RunningInput) bool {
+			return !started[input]
+		})
+		addedAggregators, addedAggProcessors := changedAggregators(cfg)
+		added := statefulPlugins(startedInputs, changedProcessors(cfg), addedAggregators, addedAggProcessors, connected)
+		removed := make([]string, 0, len(removedStateful))
+		for _, plugin := range removedStateful {
+			removed = append(removed, plugin.id)
+		}
+		if err := a.Config.Persister.Update(removed, pluginsByID(added)); err != nil {
+			errs = append(errs, fmt.Errorf("updating plugin states: %w", err))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("applying config partially failed: %w", err)
+	}
+
+	log.Printf("I! [agent] Reloaded config: %d inputs added, %d inputs removed, %d outputs added, %d outputs removed",
+		len(inputs.added), len(inputs.removed), len(outputs.added), len(outputs.removed))
+	return nil
+}
+
+// addInput starts the given input and its gather loop in the running agent.
+func (a *Agent) addInput(p *pipeline, input *models.RunningInput) (bool, error) {
+	unit := p.inputs
+	unit.Lock()
+	defer unit.Unlock()
+
+	if unit.stopped {
+		return false, errors.New("inputs already stopped")
+	}
+
+	input.SetTracer(a.tracer)
+	started, err := startInput(unit.dstOf(input), input)
+	if err != nil || !started {
+		return false, err
+	}
+
+	// Backpressure is only signaled to the inputs when the state changes, so
+	// pause new inputs while backpressure is applied. State changes after
+	// this check are applied to the input as the monitor needs the lock of
+	// the unit to pause or resume the inputs.
+	if a.backpressure.Active() && input.Pause() {
+		log.Printf("D! [agent] Paused %s", input.LogName())
+	}
+	unit.inputs = append(unit.inputs, input)
+	if unit.loops != nil {
+		a.startGatherLoop(unit, input)
+	}
+	log.Printf("D! [agent] Started %s", input.LogName())
+	return true, nil
+}
+
+// removeInput stops the gather loop of the given input and stops the plugin.
+func (*Agent) removeInput(p *pipeline, input *models.RunningInput) {
+	unit := p.inputs
+	unit.Lock()
+	defer unit.Unlock()
+
+	// Inputs failing to start are not part of the unit and must not be stopped
+	idx := slices.Index(unit.inputs, input)
+	if idx < 0 {
+		return
+	}
+	unit.inputs = slices.Delete(unit.inputs, idx, idx+1)
+
+	if l, found := unit.loops[input]; found {
+		l.stop()
+		delete(unit.loops, input)
+	}
+	input.Stop()
+	log.Printf("D! [agent] Stopped %s", input.LogName())
+}
+
+// addOutput adds the given connected output to the running agent. The output
+// is closed if the agent is already stopped.
+func (a *Agent) addOutput(p *pipeline, output *models.RunningOutput) error {
+	unit := p.outputs
+	unit.Lock()
+	defer unit.Unlock()
+
+	if unit.stopped {
+		output.Close()
+		return errors.New("outputs already stopped")
+	}
+
+	unit.outputs = append(unit.outputs, output)
+	unit.updateRoutes(a.Config.OutputGroups)
+	if unit.loops != nil {
+		a.startFlushLoop(unit, output)
+	}
+	return nil
+}
+
+// removeOutput removes the given output from the running agent. Buffered
+// metrics are written one last time before closing the output.
+func (a *Agent) removeOutput(p *pipeline, output *models.RunningOutput) {
+	unit := p.outputs
+	unit.Lock()
+	unit.outputs = slices.DeleteFunc(unit.outputs, func(o *models.RunningOutput) bool { return o == output })
+	unit.updateRoutes(a.Config.OutputGroups)
+	output.LeaveGroup()
+	l, found := unit.loops[output]
+	delete(unit.loops, output)
+	unit.Unlock()
+
+	if found {
+		l.stop()
+	}
+	output.Close()
+	log.Printf("D! [agent] Stopped %s", output.LogName())
+}
+
+// discardOutputs releases the resources of outputs that were created but
+// will never be connected.
+func discardOutputs(outputs []*models.RunningOutput) {
+	for _, output := range outputs {
+		if err := output.Discard(); err != nil {
+			output.Log().Errorf("Discarding output failed: %v", err)
+		}
+	}
+}
+
+// pluginsByID returns the given stateful plugins indexed by their ID.
+func pluginsByID(plugins []statefulPlugin) map[string]telegraf.StatefulPlugin {
+	byID := make(map[string]telegraf.StatefulPlugin, len(plugins))
+	for _, plugin := range plugins {
+		byID[plugin.id] = plugin.plugin
+	}
+	return byID
+}
@@ -0,0 +1,329 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+func TestDiffPlugins(t *testing.T) {
+	id := func(s string) string { return s[:1] }
+
+	running := []string{"a1", "b1", "b2", "c1"}
+	configured := []string{"b3", "d1", "a2", "b4", "b5"}
+	diff := diffPlugins(running, configured, id)
+
+	require.Equal(t, []string{"b1", "d1", "a1", "b2", "b5"}, diff.merged)
+	require.Equal(t, []string{"d1", "b5"}, diff.added)
+	require.Equal(t, []string{"c1"}, diff.removed)
+	require.Equal(t, []string{"b3", "a2", "b4"}, diff.unused)
+}
+
+func TestMergePipelines(t *testing.T) {
+	pipeline := func(s string) string { return s[:1] }
+
+	running := []string{"a1", "b1", "a2", "c1"}
+	configured := []string{"a3", "b2", "b3", "a4"}
+	merged := mergePipelines(running, configured, pipeline, map[string]bool{"b": true})
+
+	require.Equal(t, []string{"a1", "b2", "b3", "a2"}, merged)
+}
+
+func TestAgent_Reload(t *testing.T) {
+	cfg := config.NewConfig()
+	require.NoError(t, cfg.LoadConfigData([]byte(`
+[[inputs.internal]]
+[[outputs.discard]]
+  alias = "keep"
+[[outputs.discard]]
+  alias = "remove"
+`), config.EmptySourcePath))
+	kept := cfg.Outputs[0]
+
+	a := NewAgent(cfg)
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- a.Run(ctx)
+	}()
+	require.Eventually(t, func() bool {
+		a.pipelineLock.Lock()
+		defer a.pipelineLock.Unlock()
+		return a.pipeline != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Add an input and remove an output
+	update := config.NewConfig()
+	require.NoError(t, update.LoadConfigData([]byte(`
+[[inputs.internal]]
+[[inputs.internal]]
+  alias = "added"
+[[outputs.discard]]
+  alias = "keep"
+`), config.EmptySourcePath))
+	require.NoError(t, a.Reload(update))
+	require.Len(t, a.Config.Inputs, 2)
+	require.Len(t, a.Config.Outputs, 1)
+	require.Same(t, kept, a.Config.Outputs[0])
+
+	// Changing agent settings cannot be applied incrementally
+	restart := config.NewConfig()
+	require.NoError(t, restart.LoadConfigData([]byte(`
+[agent]
+  interval = "1s"
+[[inputs.internal]]
+[[outputs.discard]]
+`), config.EmptySourcePath))
+	require.ErrorIs(t, a.Reload(restart), ErrRestartRequired)
+
+	cancel()
+	require.NoError(t, <-errC)
+
+	// Reloading a stopped agent must fail
+	stopped := config.NewConfig()
+	require.NoError(t, stopped.LoadConfigData([]byte("[[outputs.discard]]"), config.EmptySourcePath))
+	require.Error(t, a.Reload(stopped))
+}
+
+func TestAgent_ReloadPipelines(t *testing.T) {
+	cfg := config.NewConfig()
+	require.NoError(t, cfg.LoadConfigData([]byte(`
+[[inputs.internal]]
+[[inputs.internal]]
+  pipeline = "tenant_b"
+[[processors.override]]
+  name_suffix = "_a"
+[[processors.override]]
+  pipeline = "tenant_b"
+  name_suffix = "_b"
+[[outputs.discard]]
+  pipelines = ["default", "tenant_b"]
+`), config.EmptySourcePath))
+	kept := cfg.Processors[0]
+
+	a := NewAgent(cfg)
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- a.Run(ctx)
+	}()
+	require.Eventually(t, func() bool {
+		a.pipelineLock.Lock()
+		defer a.pipelineLock.Unlock()
+		return a.pipeline != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Changing the processors of one pipeline keeps the other pipeline running
+	update := config.NewConfig()
+	require.NoError(t, update.LoadConfigData([]byte(`
+[[inputs.internal]]
+[[inputs.internal]]
+  pipeline = "tenant_b"
+[[processors.override]]
+  name_suffix = "_a"
+[[processors.override]]
+  pipeline = "tenant_b"
+  name_suffix = "_tenant_b"
+[[outputs.discard]]
+  pipelines = ["default", "tenant_b"]
+`), config.EmptySourcePath))
+	require.NoError(t, a.Reload(update))
+	require.Len(t, a.Config.Processors, 2)
+	require.Same(t, kept, a.Config.Processors[0])
+	require.Same(t, update.Processors[1], a.Config.Processors[1])
+
+	// Adding a pipeline cannot be applied incrementally
+	restart := config.NewConfig()
+	require.NoError(t, restart.LoadConfigData([]byte(`
+[[inputs.internal]]
+[[inputs.internal]]
+  pipeline = "tenant_c"
+[[outputs.discard]]
+  pipelines = ["default", "tenant_c"]
+`), config.EmptySourcePath))
+	require.ErrorIs(t, a.Reload(restart), ErrRestartRequired)
+
+	cancel()
+	require.NoError(t, <-errC)
+}
+
+func TestAgent_ReloadFailureKeepsAgent(t *testing.T) {
+	cfg := config.NewConfig()
+	require.NoError(t, cfg.LoadConfigData([]byte(`
+[[inputs.internal]]
+[[processors.override]]
+  name_suffix = "_a"
+[[outputs.discard]]
+`), config.EmptySourcePath))
+	input := cfg.Inputs[0]
+	processor := cfg.Processors[0]
+	output := cfg.Outputs[0]
+
+	a := NewAgent(cfg)
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- a.Run(ctx)
+	}()
+	require.Eventually(t, func() bool {
+		a.pipelineLock.Lock()
+		defer a.pipelineLock.Unlock()
+		return a.pipeline != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// A processor failing to start must leave the running agent untouched
+	update := config.NewConfig()
+	require.NoError(t, update.LoadConfigData([]byte(`
+[[inputs.internal]]
+[[inputs.internal]]
+  alias = "added"
+[[processors.override]]
+  name_suffix = "_b"
+[[processors.reload_failing]]
+[[outputs.discard]]
+[[outputs.discard]]
+  alias = "added"
+`), config.EmptySourcePath))
+	require.ErrorContains(t, a.Reload(update), "start failed")
+	require.Equal(t, []*models.RunningInput{input}, a.Config.Inputs)
+	require.Equal(t, models.RunningProcessors{processor}, a.Config.Processors)
+	require.Equal(t, []*models.RunningOutput{output}, a.Config.Outputs)
+
+	a.pipelineLock.Lock()
+	require.Equal(t, []*models.RunningInput{input}, a.pipeline.inputs.inputs)
+	require.Equal(t, []*models.RunningOutput{output}, a.pipeline.outputs.outputs)
+	a.pipelineLock.Unlock()
+
+	cancel()
+	require.NoError(t, <-errC)
+}
+
+func TestAgent_ReloadRestoresStates(t *testing.T) {
+	statefile := filepath.Join(t.TempDir(), "states.json")
+	data := fmt.Sprintf(`
+[agent]
+  statefile = %q
+[[inputs.internal]]
+[[processors.reload_stateful]]
+[[processors.override]]
+  name_suffix = %%q
+[[outputs.discard]]
+`, statefile)
+
+	cfg := config.NewConfig()
+	require.NoError(t, cfg.LoadConfigData([]byte(fmt.Sprintf(data, "_a")), config.EmptySourcePath))
+	running := cfg.Processors[0]
+
+	a := NewAgent(cfg)
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- a.Run(ctx)
+	}()
+	require.Eventually(t, func() bool {
+		a.pipelineLock.Lock()
+		defer a.pipelineLock.Unlock()
+		return a.pipeline != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	running.Processor.(*statefulProcessor).state["count"] = 42
+	require.NoError(t, a.Config.Persister.Store())
+
+	// Changing the pipeline restarts the stateful processor which must get
+	// the persisted state and replace the old instance in the persister
+	update := config.NewConfig()
+	require.NoError(t, update.LoadConfigData([]byte(fmt.Sprintf(data, "_b")), config.EmptySourcePath))
+	require.NoError(t, a.Reload(update))
+	restarted := a.Config.Processors[0]
+	require.NotSame(t, running, restarted)
+	require.Equal(t, running.ID(), restarted.ID())
+	plugin := restarted.Processor.(*statefulProcessor)
+	require.Equal(t, map[string]int64{"count": 42}, plugin.state)
+
+	plugin.state["count"] = 43
+	require.NoError(t, a.Config.Persister.Store())
+	states, err := a.Config.Persister.Backend.Load()
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{restarted.ID(): []byte(`{"count":43}`)}, states)
+
+	cancel()
+	require.NoError(t, <-errC)
+}
+
+// failingProcessor is a processor failing to start
+type failingProcessor struct{}
+
+func (*failingProcessor) SampleConfig() string {
+	return ""
+}
+
+func (*failingProcessor) Start(telegraf.Accumulator) error {
+	return errors.New("start failed")
+}
+
+func (*failingProcessor) Add(m telegraf.Metric, acc telegraf.Accumulator) error {
+	acc.AddMetric(m)
+	return nil
+}
+
+func (*failingProcessor) Stop() {}
+
+// statefulProcessor is a processor with a persisted state
+type statefulProcessor struct {
+	state map[string]int64
+}
+
+func (*statefulProcessor) SampleConfig() string {
+	return ""
+}
+
+func (*statefulProcessor) Start(telegraf.Accumulator) error {
+	return nil
+}
+
+func (*statefulProcessor) Add(m telegraf.Metric, acc telegraf.Accumulator) error {
+	acc.AddMetric(m)
+	return nil
+}
+
+func (*statefulProcessor) Stop() {}
+
+func (p *statefulProcessor) GetState() interface{} {
+	return p.state
+}
+
+func (p *statefulProcessor) SetState(state interface{}) error {
+	s, ok := state.(map[string]int64)
+	if !ok {
+		return fmt.Errorf("invalid state type %T", state)
+	}
+	p.state = s
+	return nil
+}
+
+func init() {
+	processors.AddStreaming("reload_failing", func() telegraf.StreamingProcessor {
+		return &failingProcessor{}
+	})
+	processors.AddStreaming("reload_stateful", func() telegraf.StreamingProcessor {
+		return &statefulProcessor{state: make(map[string]int64)}
+	})
+}
@@ -0,0 +1,173 @@
+package agent
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/models"
+)
+
+// Simulate passes the given metrics through the processors and aggregators of
+// a pipeline without running any inputs or outputs and returns the metrics
+// the outputs would receive.
+//
+// Time is simulated using a mocked clock starting at the given time or, if
+// zero, at the timestamp of the first metric leaving the processors. Before
+// adding a metric to the aggregators the clock is advanced to the timestamp
+// of the metric, pushing all aggregation windows ending before, as if the
+// metrics were collected in real time. After the last metric all aggregators
+// are pushed a final time as done when stopping the agent.
+func (a *Agent) Simulate(pipeline string, start time.Time, metrics []telegraf.Metric) ([]telegraf.Metric, error) {
+	if !slices.Contains(a.Config.Pipelines(), pipeline) {
+		return nil, fmt.Errorf("unknown pipeline %q", pipeline)
+	}
+
+	processors := inPipeline(a.Config.Processors, processorPipeline, pipeline)
+	aggregators := inPipeline(a.Config.Aggregators, aggregatorPipeline, pipeline)
+	var aggProcessors models.RunningProcessors
+	if skip := a.Config.Agent.SkipProcessorsAfterAggregators; skip == nil || !*skip {
+		aggProcessors = inPipeline(a.Config.AggProcessors, processorPipeline, pipeline)
+	}
+
+	if err := initProcessors(processors); err != nil {
+		return nil, err
+	}
+	if err := initAggregators(aggregators); err != nil {
+		return nil, err
+	}
+	if err := initProcessors(aggProcessors); err != nil {
+		return nil, err
+	}
+
+	// Run the processors on all metrics first as the start of the simulated
+	// time might depend on the processed metrics
+	ps, err := a.processorStage(processors)
+	if err != nil {
+		return nil, err
+	}
+	wait := collectStage(ps)
+	for _, m := range metrics {
+		ps.in <- m
+	}
+	processed := wait()
+	if len(aggregators) == 0 {
+		return processed, nil
+	}
+
+	// Metrics produced by the aggregators are passed through the processors
+	// running after the aggregators
+	as, err := a.processorStage(aggProcessors)
+	if err != nil {
+		return nil, err
+	}
+	wait = collectStage(as)
+
+	if start.IsZero() && len(processed) > 0 {
+		start = processed[0].Time()
+	}
+	clk := clock.NewMock()
+	clk.Set(start)
+
+	precision := getPrecision(time.Duration(a.Config.Agent.Precision), time.Duration(a.Config.Agent.Interval))
+	accumulators := make(map[*models.RunningAggregator]telegraf.Accumulator, len(aggregators))
+	for _, agg := range aggregators {
+		agg.SetClock(clk)
+		since, until := updateWindow(start, a.Config.Agent.RoundInterval, agg.Period())
+		agg.UpdateWindow(since, until)
+		accumulators[agg] = &accumulator{
+			maker:     agg,
+			metrics:   as.in,
+			precision: precision,
+			clk:       clk,
+		}
+	}
+
+	// Push the aggregation windows in the order of their end until reaching
+	// the given time
+	advance := func(t time.Time) {
+		for {
+			var next *models.RunningAggregator
+			for _, agg := range aggregators {
+				if agg.EndPeriod().Before(t) && (next == nil || agg.EndPeriod().Before(next.EndPeriod())) {
+					next = agg
+				}
+			}
+			if next == nil {
+				break
+			}
+			if next.EndPeriod().After(clk.Now()) {
+				clk.Set(next.EndPeriod())
+			}
+			next.Push(accumulators[next])
+		}
+		if t.After(clk.Now()) {
+			clk.Set(t)
+		}
+	}
+
+	output := make([]telegraf.Metric, 0, len(processed))
+	for _, m := range processed {
+		advance(m.Time())
+
+		var dropOriginal bool
+		for _, agg := range aggregators {
+			if ok := agg.Add(m); ok {
+				dropOriginal = true
+			}
+		}
+		if dropOriginal {
+			m.Drop()
+			continue
+		}
+		output = append(output, m)
+	}
+
+	// Final push of the current windows
+	pending := slices.Clone(aggregators)
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].EndPeriod().Before(pending[j].EndPeriod())
+	})
+	for _, agg := range pending {
+		if agg.EndPeriod().After(clk.Now()) {
+			clk.Set(agg.EndPeriod())
+		}
+		agg.Push(accumulators[agg])
+	}
+
+	return append(output, wait()...), nil
+}
+
+// collectStage runs the given stage in the background and collects the
+// metrics leaving the stage. The returned function closes the stage's input
+// and returns the collected metrics after the stage finished.
+func collectStage(s *stage) func() []telegraf.Metric {
+	var wg sync.WaitGroup
+	if s.process != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.process()
+		}()
+	}
+
+	var collected []telegraf.Metric
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for m := range s.out {
+			collected = append(collected, m)
+		}
+	}()
+
+	return func() []telegraf.Metric {
+		close(s.in)
+		wg.Wait()
+		return collected
+	}
+}
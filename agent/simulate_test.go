@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestSimulate(t *testing.T) {
+	cfg := config.NewConfig()
+	require.NoError(t, cfg.LoadConfigData([]byte(`
+[agent]
+  omit_hostname = true
+  skip_processors_after_aggregators = true
+
+[[processors.override]]
+  [processors.override.tags]
+    source = "sim"
+
+[[aggregators.minmax]]
+  period = "10s"
+  drop_original = true
+`), config.EmptySourcePath))
+
+	start := time.Unix(1700000000, 0)
+	input := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, start.Add(1*time.Second)),
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 5.0}, start.Add(5*time.Second)),
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 3.0}, start.Add(12*time.Second)),
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 7.0}, start.Add(25*time.Second)),
+	}
+
+	// Windows are pushed at their end in simulated time as metrics of later
+	// windows arrive and a final time after the last metric
+	expected := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"source": "sim"}, map[string]interface{}{"value_min": 1.0, "value_max": 5.0}, start.Add(10*time.Second)),
+		metric.New("cpu", map[string]string{"source": "sim"}, map[string]interface{}{"value_min": 3.0, "value_max": 3.0}, start.Add(20*time.Second)),
+		metric.New("cpu", map[string]string{"source": "sim"}, map[string]interface{}{"value_min": 7.0, "value_max": 7.0}, start.Add(30*time.Second)),
+	}
+
+	a := NewAgent(cfg)
+	actual, err := a.Simulate("default", time.Time{}, input)
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, expected, actual)
+
+	_, err = a.Simulate("unknown", time.Time{}, input)
+	require.ErrorContains(t, err, `unknown pipeline "unknown"`)
+}
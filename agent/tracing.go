@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/models"
+)
+
+// findTraceExporter returns the output the pipeline traces are exported to
+// given its alias or name.
+func findTraceExporter(outputs []*models.RunningOutput, ref string) (*models.RunningOutput, error) {
+	for _, output := range outputs {
+		if !output.MatchesReference(ref) {
+			continue
+		}
+		if _, ok := output.Output.(telegraf.TraceExporter); !ok {
+			return nil, fmt.Errorf("output %s does not support exporting traces", output.LogName())
+		}
+		return output, nil
+	}
+	return nil, fmt.Errorf("output %q for exporting traces not found", ref)
+}
+
+// exportTraces periodically exports the finished traces of sampled metrics
+// to the configured trace output until the context is done.
+func (a *Agent) exportTraces(ctx context.Context, unit *outputUnit, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.flushTraces(unit)
+		}
+	}
+}
+
+func (a *Agent) flushTraces(unit *outputUnit) {
+	traces := a.tracer.Drain()
+	if len(traces) == 0 {
+		return
+	}
+
+	// Look up the output on each flush as outputs might be replaced when
+	// reloading the configuration
+	unit.RLock()
+	output, err := findTraceExporter(unit.outputs, a.Config.Agent.PipelineTraceOutput)
+	unit.RUnlock()
+	if err != nil {
+		log.Printf("W! [agent] Dropping %d pipeline traces: %v", len(traces), err)
+		return
+	}
+
+	exporter := output.Output.(telegraf.TraceExporter)
+	if err := exporter.ExportTraces(traces); err != nil {
+		log.Printf("E! [agent] Exporting %d pipeline traces to %s failed: %v", len(traces), output.LogName(), err)
+		return
+	}
+	log.Printf("D! [agent] Exported %d pipeline traces to %s", len(traces), output.LogName())
+}
@@ -0,0 +1,444 @@
+// Command handling for disk buffers "buffer" command
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+// bufferSelection contains the criteria for selecting metrics of a buffer
+type bufferSelection struct {
+	before time.Time
+	after  time.Time
+	names  filter.Filter
+}
+
+func newBufferSelection(cCtx *cli.Context) (*bufferSelection, error) {
+	var s bufferSelection
+	if v := cCtx.String("before"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("parsing 'before' timestamp failed: %w", err)
+		}
+		s.before = t
+	}
+	if v := cCtx.String("after"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("parsing 'after' timestamp failed: %w", err)
+		}
+		s.after = t
+	}
+	if names := cCtx.StringSlice("name"); len(names) > 0 {
+		f, err := filter.Compile(names)
+		if err != nil {
+			return nil, fmt.Errorf("compiling name filter failed: %w", err)
+		}
+		s.names = f
+	}
+	return &s, nil
+}
+
+func (s *bufferSelection) empty() bool {
+	return s.before.IsZero() && s.after.IsZero() && s.names == nil
+}
+
+func (s *bufferSelection) match(m telegraf.Metric) bool {
+	if !s.before.IsZero() && !m.Time().Before(s.before) {
+		return false
+	}
+	if !s.after.IsZero() && !m.Time().After(s.after) {
+		return false
+	}
+	return s.names == nil || s.names.Match(m.Name())
+}
+
+// bufferLocation resolves the buffer directory and the output names either
+// from the given flags or from the configuration
+type bufferLocation struct {
+	directory string
+	outputs   map[string]string
+}
+
+func newBufferLocation(cCtx *cli.Context) (*bufferLocation, error) {
+	loc := &bufferLocation{
+		directory: cCtx.String("buffer-directory"),
+		outputs:   make(map[string]string),
+	}
+
+	configFiles, err := collectConfigFiles(cCtx.StringSlice("config"), cCtx.StringSlice("config-directory"))
+	if err != nil {
+		return nil, err
+	}
+	if len(configFiles) > 0 {
+		c, err := loadTestConfig(configFiles)
+		if err != nil {
+			return nil, err
+		}
+		if loc.directory == "" {
+			loc.directory = c.Agent.BufferDirectory
+		}
+		for _, o := range c.Outputs {
+			loc.outputs[o.Config.ID] = o.LogName()
+		}
+	}
+
+	if loc.directory == "" {
+		return nil, errors.New("no buffer directory, please specify '--buffer-directory' or a configuration")
+	}
+	return loc, nil
+}
+
+func (l *bufferLocation) path(cCtx *cli.Context) (string, error) {
+	if cCtx.NArg() != 1 {
+		return "", errors.New("expected exactly one buffer ID")
+	}
+	id := cCtx.Args().First()
+	if id == "" || id != filepath.Base(id) {
+		return "", fmt.Errorf("invalid buffer ID %q", id)
+	}
+	return filepath.Join(l.directory, id), nil
+}
+
+func collectConfigFiles(files, dirs []string) ([]string, error) {
+	configFiles := append([]string(nil), files...)
+	for _, dir := range dirs {
+		found, err := config.WalkDirectory(dir)
+		if err != nil {
+			return nil, err
+		}
+		configFiles = append(configFiles, found...)
+	}
+	return configFiles, nil
+}
+
+// loadTestConfig loads the given configuration without opening the output
+// buffers
+func loadTestConfig(files []string) (*config.Config, error) {
+	c := config.NewConfig()
+	c.TestMode = true
+	c.Agent.Quiet = true
+	if err := c.LoadAll(files...); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func getBufferCommands(outputBuffer io.Writer) []*cli.Command {
+	selectionFlags := []cli.Flag{
+		&cli.StringFlag{
+			Name:  "before",
+			Usage: "only select metrics with a timestamp before the given RFC3339 time",
+		},
+		&cli.StringFlag{
+			Name:  "after",
+			Usage: "only select metrics with a timestamp after the given RFC3339 time",
+		},
+		&cli.StringSliceFlag{
+			Name:  "name",
+			Usage: "only select metrics with a name matching the given glob pattern",
+		},
+	}
+
+	return []*cli.Command{
+		{
+			Name:  "buffer",
+			Usage: "commands for inspecting and recovering disk buffers of outputs",
+			Description: `
+The 'buffer' commands operate on the disk buffers of outputs using the
+'disk_write_through' or 'hybrid' buffer strategy. The buffer directory is
+either specified via '--buffer-directory' or taken from the 'buffer_directory'
+agent setting of the configuration given via '--config' or
+'--config-directory'. Each output stores its buffer in a subdirectory named
+by the output's ID.
+
+Make sure Telegraf is stopped before using the commands!
+`,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "buffer-directory",
+					Usage: "directory containing the disk buffers",
+				},
+			},
+			Subcommands: []*cli.Command{
+				{
+					Name:  "list",
+					Usage: "list disk buffers with their number of metrics",
+					Description: `
+The 'list' command shows the ID of each disk buffer in the buffer directory
+together with the number of entries, the size and the time range of the
+buffered metrics. If a configuration is given, the output owning the buffer
+is shown as well.
+
+> telegraf --config telegraf.conf buffer list
+`,
+					Action: func(cCtx *cli.Context) error {
+						loc, err := newBufferLocation(cCtx)
+						if err != nil {
+							return err
+						}
+
+						entries, err := os.ReadDir(loc.directory)
+						if err != nil {
+							return err
+						}
+						ids := make([]string, 0, len(entries))
+						for _, entry := range entries {
+							if entry.IsDir() && filepath.Ext(entry.Name()) == "" {
+								ids = append(ids, entry.Name())
+							}
+						}
+						sort.Strings(ids)
+
+						w := tabwriter.NewWriter(outputBuffer, 0, 0, 2, ' ', 0)
+						fmt.Fprintln(w, "ID\tOUTPUT\tENTRIES\tSIZE\tFIRST\tLAST")
+						for _, id := range ids {
+							info, err := models.StatDiskBuffer(filepath.Join(loc.directory, id))
+							if err != nil {
+								return fmt.Errorf("reading buffer %q failed: %w", id, err)
+							}
+							output := loc.outputs[id]
+							if output == "" {
+								output = "-"
+							}
+							fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%s\n",
+								id, output, info.Entries, info.Size, formatBufferTime(info.First), formatBufferTime(info.Last),
+							)
+						}
+						return w.Flush()
+					},
+				},
+				{
+					Name:      "print",
+					Usage:     "print the metrics of a disk buffer",
+					ArgsUsage: "<buffer ID>",
+					Description: `
+The 'print' command serializes the metrics of the given disk buffer using any
+of the available serializers and prints them on the console. Metrics can be
+selected by timestamp and name.
+
+To print all 'cpu' metrics of a buffer in JSON format use
+
+> telegraf buffer --buffer-directory /var/lib/telegraf print --data-format json --name cpu 0a1b2c3d
+`,
+					Flags: append([]cli.Flag{
+						&cli.StringFlag{
+							Name:  "data-format",
+							Usage: "serializer used for printing the metrics",
+							Value: "influx",
+						},
+					}, selectionFlags...),
+					Action: func(cCtx *cli.Context) error {
+						loc, err := newBufferLocation(cCtx)
+						if err != nil {
+							return err
+						}
+						path, err := loc.path(cCtx)
+						if err != nil {
+							return err
+						}
+						selection, err := newBufferSelection(cCtx)
+						if err != nil {
+							return err
+						}
+
+						format := cCtx.String("data-format")
+						creator, found := serializers.Serializers[format]
+						if !found {
+							return fmt.Errorf("unknown data format %q", format)
+						}
+						serializer := creator()
+						if p, ok := serializer.(telegraf.Initializer); ok {
+							if err := p.Init(); err != nil {
+								return fmt.Errorf("initializing serializer failed: %w", err)
+							}
+						}
+
+						skipped, err := models.ReadDiskBuffer(path, func(m telegraf.Metric) error {
+							if !selection.match(m) {
+								return nil
+							}
+							octets, err := serializer.Serialize(m)
+							if err != nil {
+								return err
+							}
+							_, err = outputBuffer.Write(octets)
+							return err
+						})
+						if skipped > 0 {
+							fmt.Fprintf(os.Stderr, "skipped %d stale tracking metrics\n", skipped)
+						}
+						return err
+					},
+				},
+				{
+					Name:      "drop",
+					Usage:     "remove metrics from a disk buffer",
+					ArgsUsage: "<buffer ID>",
+					Description: `
+The 'drop' command removes the selected metrics from the given disk buffer.
+At least one of the selection flags or '--all' must be given. Stale tracking
+metrics, which cannot be sent anymore, are removed as well.
+
+To remove all metrics older than a given time use
+
+> telegraf buffer --buffer-directory /var/lib/telegraf drop --before 2024-01-01T00:00:00Z 0a1b2c3d
+`,
+					Flags: append([]cli.Flag{
+						&cli.BoolFlag{
+							Name:  "all",
+							Usage: "remove all metrics",
+						},
+					}, selectionFlags...),
+					Action: func(cCtx *cli.Context) error {
+						loc, err := newBufferLocation(cCtx)
+						if err != nil {
+							return err
+						}
+						path, err := loc.path(cCtx)
+						if err != nil {
+							return err
+						}
+						selection, err := newBufferSelection(cCtx)
+						if err != nil {
+							return err
+						}
+
+						all := cCtx.Bool("all")
+						switch {
+						case all && !selection.empty():
+							return errors.New("flag --all cannot be used together with selection flags")
+						case !all && selection.empty():
+							return errors.New("no metrics selected, use --all to remove all metrics")
+						}
+
+						removed, err := models.RewriteDiskBuffer(path, func(m telegraf.Metric) bool {
+							return all || selection.match(m)
+						})
+						if err != nil {
+							return err
+						}
+						fmt.Fprintf(outputBuffer, "removed %d metrics\n", removed)
+						return nil
+					},
+				},
+				{
+					Name:      "replay",
+					Usage:     "write the metrics of a disk buffer to an output",
+					ArgsUsage: "<buffer ID>",
+					Description: `
+The 'replay' command writes the metrics of the given disk buffer to the output
+defined in the configuration file given via '--output-config'. The file must
+contain exactly one output. Metrics can be selected by timestamp and name and
+are written in batches of the output's 'metric_batch_size'. The buffer itself
+is not modified, use the 'drop' command to remove the replayed metrics
+afterwards.
+
+> telegraf buffer --buffer-directory /var/lib/telegraf replay --output-config recovery.conf 0a1b2c3d
+`,
+					Flags: append([]cli.Flag{
+						&cli.StringFlag{
+							Name:     "output-config",
+							Usage:    "configuration file containing the output to write to",
+							Required: true,
+						},
+					}, selectionFlags...),
+					Action: func(cCtx *cli.Context) error {
+						loc, err := newBufferLocation(cCtx)
+						if err != nil {
+							return err
+						}
+						path, err := loc.path(cCtx)
+						if err != nil {
+							return err
+						}
+						selection, err := newBufferSelection(cCtx)
+						if err != nil {
+							return err
+						}
+
+						c, err := loadTestConfig([]string{cCtx.String("output-config")})
+						if err != nil {
+							return err
+						}
+						if len(c.Outputs) != 1 {
+							return fmt.Errorf("expected exactly one output but found %d", len(c.Outputs))
+						}
+						replayed, err := replayBuffer(path, c.Outputs[0], selection)
+						fmt.Fprintf(outputBuffer, "replayed %d metrics to %s\n", replayed, c.Outputs[0].LogName())
+						return err
+					},
+				},
+			},
+		},
+	}
+}
+
+// replayBuffer writes the selected metrics of the buffer at the given path to
+// the output and returns the number of metrics written successfully.
+func replayBuffer(path string, output *models.RunningOutput, selection *bufferSelection) (int, error) {
+	if err := output.Init(); err != nil {
+		return 0, fmt.Errorf("initializing %s failed: %w", output.LogName(), err)
+	}
+	if err := output.Output.Connect(); err != nil {
+		return 0, fmt.Errorf("connecting %s failed: %w", output.LogName(), err)
+	}
+	defer output.Close()
+
+	var replayed int
+	batch := make([]telegraf.Metric, 0, output.MetricBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := output.Output.Write(batch); err != nil {
+			return fmt.Errorf("writing to %s failed: %w", output.LogName(), err)
+		}
+		replayed += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	_, err := models.ReadDiskBuffer(path, func(m telegraf.Metric) error {
+		if !selection.match(m) {
+			return nil
+		}
+		if ok, err := output.Config.Filter.Select(m); err != nil || !ok {
+			return err
+		}
+		output.Config.Filter.Modify(m)
+		if len(m.FieldList()) == 0 {
+			return nil
+		}
+		batch = append(batch, m)
+		if len(batch) < output.MetricBatchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err != nil {
+		return replayed, err
+	}
+	return replayed, flush()
+}
+
+func formatBufferTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
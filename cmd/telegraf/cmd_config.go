@@ -2,6 +2,7 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/fatih/color"
 	"github.com/urfave/cli/v2"
@@ -48,8 +50,18 @@ func getConfigCommands(configHandlingFlags []cli.Flag, outputBuffer io.Writer) [
 		To check the file 'mysettings.conf' use
 
 		> telegraf config check --config mysettings.conf
+
+		With '--schema' the files are additionally validated against the
+		configuration schema (see 'telegraf config schema') before loading,
+		reporting all unknown options and options of the wrong type together
+		with their line numbers.
 		`,
-					Flags: configHandlingFlags,
+					Flags: slices.Concat(configHandlingFlags, []cli.Flag{
+						&cli.BoolFlag{
+							Name:  "schema",
+							Usage: "validate the configuration files against the configuration schema",
+						},
+					}),
 					Action: func(cCtx *cli.Context) error {
 						// Setup logging
 						logConfig := &logger.Config{Debug: cCtx.Bool("debug")}
@@ -90,6 +102,13 @@ func getConfigCommands(configHandlingFlags []cli.Flag, outputBuffer io.Writer) [
 							configFiles = paths
 						}
 
+						// Validate the configuration files against the schema
+						if cCtx.Bool("schema") {
+							if err := validateSchema(configFiles); err != nil {
+								return err
+							}
+						}
+
 						// Load the config and try to initialize the plugins
 						c := config.NewConfig()
 						c.Agent.Quiet = cCtx.Bool("quiet")
@@ -136,6 +155,29 @@ InfluxDB v2 output plugin use
 						return nil
 					},
 				},
+				{
+					Name:  "schema",
+					Usage: "print the JSON schema of the configuration",
+					Description: `
+The 'schema' command prints a JSON Schema describing the agent settings and the
+options of all plugins available in this binary. The schema can be used by
+editors or CI pipelines to lint configurations without running Telegraf.
+Plugin options are derived from the plugin implementation including their
+default values and deprecations.
+
+To store the schema in 'telegraf-schema.json' use
+
+> telegraf config schema > telegraf-schema.json
+`,
+					Action: func(*cli.Context) error {
+						buf, err := json.MarshalIndent(config.GenerateSchema(), "", "  ")
+						if err != nil {
+							return err
+						}
+						_, err = outputBuffer.Write(append(buf, '\n'))
+						return err
+					},
+				},
 				{
 					Name:  "migrate",
 					Usage: "migrate deprecated plugins and options of the configuration(s)",
@@ -155,12 +197,12 @@ To migrate the file 'mysettings.conf' use
 
 > telegraf config migrate --config mysettings.conf
 `,
-					Flags: append(configHandlingFlags,
+					Flags: slices.Concat(configHandlingFlags, []cli.Flag{
 						&cli.BoolFlag{
 							Name:  "force",
 							Usage: "forces overwriting of an existing migration file",
 						},
-					),
+					}),
 					Action: func(cCtx *cli.Context) error {
 						// Setup logging
 						logConfig := &logger.Config{Debug: cCtx.Bool("debug")}
@@ -204,6 +246,10 @@ To migrate the file 'mysettings.conf' use
 						}
 
 						for _, fn := range configFiles {
+							if config.DetectFormat(fn) != "toml" {
+								log.Printf("I! Skipping %q, migrations are only supported for TOML files", fn)
+								continue
+							}
 							log.Printf("D! Trying to migrate %q...", fn)
 
 							// Read and parse the config file
@@ -256,3 +302,29 @@ To migrate the file 'mysettings.conf' use
 		},
 	}
 }
+
+// validateSchema checks the given configuration files against the schema of
+// the configuration and logs all violations found.
+func validateSchema(configFiles []string) error {
+	schema := config.GenerateSchema()
+
+	var violations int
+	for _, fn := range configFiles {
+		data, _, err := config.LoadConfigFile(fn)
+		if err != nil {
+			return fmt.Errorf("loading config file %s failed: %w", fn, err)
+		}
+		found, err := config.ValidateSchema(schema, data, fn)
+		if err != nil {
+			return fmt.Errorf("validating config file %s failed: %w", fn, err)
+		}
+		for _, v := range found {
+			log.Printf("E! %s", v)
+		}
+		violations += len(found)
+	}
+	if violations > 0 {
+		return fmt.Errorf("found %d schema violation(s)", violations)
+	}
+	return nil
+}
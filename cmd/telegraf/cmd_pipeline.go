@@ -0,0 +1,214 @@
+// Command handling for offline pipeline tests "pipeline" command
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/agent"
+	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+)
+
+func getPipelineCommands(outputBuffer io.Writer) []*cli.Command {
+	return []*cli.Command{
+		{
+			Name:  "pipeline",
+			Usage: "commands for testing the processing pipelines of a configuration",
+			Subcommands: []*cli.Command{
+				{
+					Name:  "test",
+					Usage: "pass recorded metrics through the processors and aggregators and check the result",
+					Description: `
+The 'test' command reads recorded metrics from the file given via '--input'
+in any of the available data formats and passes them through the processors
+and aggregators of a pipeline in the configuration given via '--config' or
+'--config-directory'. Inputs and outputs of the configuration are not run.
+
+Time is simulated starting at the timestamp of the first metric or the time
+given via '--start'. The simulated clock is advanced to the timestamp of each
+metric before adding it to the aggregators, so aggregation windows are pushed
+as if the metrics were collected in real time.
+
+The resulting metrics are compared against the metrics in the file given via
+'--expected' regardless of their order and the differences are reported. The
+command fails if the metrics do not match. Without '--expected' the resulting
+metrics are printed in InfluxDB line protocol, e.g. to create the expected
+file.
+
+> telegraf --config processors.conf pipeline test --input recorded.influx --expected expected.influx
+`,
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:     "input",
+							Usage:    "file containing the recorded input metrics",
+							Required: true,
+						},
+						&cli.StringFlag{
+							Name:  "input-format",
+							Usage: "data format of the recorded input metrics",
+							Value: "influx",
+						},
+						&cli.StringFlag{
+							Name:  "expected",
+							Usage: "file containing the expected output metrics",
+						},
+						&cli.StringFlag{
+							Name:  "expected-format",
+							Usage: "data format of the expected output metrics",
+							Value: "influx",
+						},
+						&cli.StringFlag{
+							Name:  "pipeline",
+							Usage: "name of the pipeline to test",
+							Value: models.DefaultPipeline,
+						},
+						&cli.StringFlag{
+							Name:  "start",
+							Usage: "RFC3339 start time of the simulated clock, defaults to the time of the first metric",
+						},
+					},
+					Action: func(cCtx *cli.Context) error {
+						configFiles, err := collectConfigFiles(cCtx.StringSlice("config"), cCtx.StringSlice("config-directory"))
+						if err != nil {
+							return err
+						}
+						if len(configFiles) == 0 {
+							return errors.New("no configuration given, please specify '--config' or '--config-directory'")
+						}
+
+						var start time.Time
+						if v := cCtx.String("start"); v != "" {
+							start, err = time.Parse(time.RFC3339, v)
+							if err != nil {
+								return fmt.Errorf("parsing 'start' timestamp failed: %w", err)
+							}
+						}
+
+						metrics, err := readMetricsFile(cCtx.String("input"), cCtx.String("input-format"))
+						if err != nil {
+							return err
+						}
+
+						c, err := loadTestConfig(configFiles)
+						if err != nil {
+							return err
+						}
+						result, err := agent.NewAgent(c).Simulate(cCtx.String("pipeline"), start, metrics)
+						if err != nil {
+							return err
+						}
+						actual, err := serializeSorted(result)
+						if err != nil {
+							return err
+						}
+
+						if cCtx.String("expected") == "" {
+							for _, line := range actual {
+								fmt.Fprintln(outputBuffer, line)
+							}
+							return nil
+						}
+
+						metrics, err = readMetricsFile(cCtx.String("expected"), cCtx.String("expected-format"))
+						if err != nil {
+							return err
+						}
+						expected, err := serializeSorted(metrics)
+						if err != nil {
+							return err
+						}
+
+						diff := diffLines(expected, actual)
+						if len(diff) == 0 {
+							fmt.Fprintf(outputBuffer, "all %d metrics match\n", len(actual))
+							return nil
+						}
+						for _, line := range diff {
+							fmt.Fprintln(outputBuffer, line)
+						}
+						return fmt.Errorf("result does not match the expected metrics, %d differences", len(diff))
+					},
+				},
+			},
+		},
+	}
+}
+
+// readMetricsFile parses the metrics in the given file using the parser of
+// the given data format. The name of the file is used as default metric name
+// for formats without metric names.
+func readMetricsFile(path, format string) ([]telegraf.Metric, error) {
+	creator, found := parsers.Parsers[format]
+	if !found {
+		return nil, fmt.Errorf("unknown data format %q", format)
+	}
+	parser := creator(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	if p, ok := parser.(telegraf.Initializer); ok {
+		if err := p.Init(); err != nil {
+			return nil, fmt.Errorf("initializing parser failed: %w", err)
+		}
+	}
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := parser.Parse(buf)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %q failed: %w", path, err)
+	}
+	return metrics, nil
+}
+
+// serializeSorted returns the sorted lines of the metrics in InfluxDB line
+// protocol with sorted fields, so metrics can be compared independent of
+// their order.
+func serializeSorted(metrics []telegraf.Metric) ([]string, error) {
+	serializer := &influx.Serializer{SortFields: true, UintSupport: true}
+	if err := serializer.Init(); err != nil {
+		return nil, err
+	}
+
+	lines := make([]string, 0, len(metrics))
+	for _, m := range metrics {
+		octets, err := serializer.Serialize(m)
+		if err != nil {
+			return nil, fmt.Errorf("serializing metric %q failed: %w", m.Name(), err)
+		}
+		lines = append(lines, strings.TrimSuffix(string(octets), "\n"))
+	}
+	sort.Strings(lines)
+	return lines, nil
+}
+
+// diffLines compares the sorted expected and actual lines and returns the
+// missing lines prefixed by '-' and the unexpected lines prefixed by '+'.
+func diffLines(expected, actual []string) []string {
+	var diff []string
+	var i, j int
+	for i < len(expected) || j < len(actual) {
+		switch {
+		case j >= len(actual) || (i < len(expected) && expected[i] < actual[j]):
+			diff = append(diff, "- "+expected[i])
+			i++
+		case i >= len(expected) || actual[j] < expected[i]:
+			diff = append(diff, "+ "+actual[j])
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+	return diff
+}
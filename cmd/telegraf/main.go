@@ -6,6 +6,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"slices"
 	"sort"
 	"strings"
 
@@ -146,7 +147,7 @@ func runApp(args []string, outputBuffer io.Writer, pprof Server, c TelegrafConfi
 		},
 	}
 
-	mainFlags := append(configHandlingFlags, cliFlags()...)
+	mainFlags := slices.Concat(configHandlingFlags, cliFlags())
 
 	// This function is used when Telegraf is run with only flags
 	action := func(cCtx *cli.Context) error {
@@ -263,6 +264,7 @@ func runApp(args []string, outputBuffer io.Writer, pprof Server, c TelegrafConfi
 			oldEnvBehavior:          cCtx.Bool("old-env-behavior"),
 			nonStrictEnvVars:        cCtx.Bool("non-strict-env-handling"),
 			printPluginConfigSource: cCtx.Bool("print-plugin-config-source"),
+			incrementalReload:       cCtx.Bool("incremental-reload"),
 			test:                    cCtx.Bool("test"),
 			debug:                   cCtx.Bool("debug"),
 			once:                    cCtx.Bool("once"),
@@ -289,6 +291,8 @@ func runApp(args []string, outputBuffer io.Writer, pprof Server, c TelegrafConfi
 	)
 	commands = append(commands, getPluginCommands(outputBuffer)...)
 	commands = append(commands, getServiceCommands(outputBuffer)...)
+	commands = append(commands, getBufferCommands(outputBuffer)...)
+	commands = append(commands, getPipelineCommands(outputBuffer)...)
 
 	app := &cli.App{
 		Name:   "Telegraf",
@@ -346,6 +350,11 @@ func runApp(args []string, outputBuffer io.Writer, pprof Server, c TelegrafConfi
 					Name:  "print-plugin-config-source",
 					Usage: "print the source for a given plugin",
 				},
+				&cli.BoolFlag{
+					Name: "incremental-reload",
+					Usage: "on config reload only restart plugins with changed settings instead of restarting " +
+						"the whole agent",
+				},
 				&cli.BoolFlag{
 					Name:  "once",
 					Usage: "run one gather and exit",
@@ -6,15 +6,19 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/models"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/outputs"
 )
@@ -523,6 +527,105 @@ func TestCommandConfig(t *testing.T) {
 	}
 }
 
+func TestCommandConfigSchema(t *testing.T) {
+	buf := new(bytes.Buffer)
+	args := append(os.Args[0:1], "config")
+	require.NoError(t, runApp(args, buf, NewMockServer(), NewMockConfig(buf), NewMockTelegraf()))
+
+	// The sample configurations of all plugins must comply with the schema.
+	// Plugins not supported on the current platform do not provide any
+	// options, so their sample configurations are skipped.
+	schema := config.GenerateSchema()
+	violations, err := config.ValidateSchema(schema, buf.Bytes(), "telegraf.conf")
+	require.NoError(t, err)
+	var messages []string
+	for _, v := range violations {
+		category, remainder, _ := strings.Cut(v.Path, ".")
+		name, _, _ := strings.Cut(remainder, "[")
+		if plugin, found := schema.Defs[category+"."+name]; found && len(plugin.Properties) == 0 {
+			continue
+		}
+		messages = append(messages, v.Error())
+	}
+	require.Empty(t, messages)
+}
+
+func TestCommandBuffer(t *testing.T) {
+	dir := t.TempDir()
+	buffer, err := models.NewBuffer("test", "id123", "", 0, "disk_write_through", dir, false)
+	require.NoError(t, err)
+	buffer.Add(metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1}, time.Unix(10, 0)))
+	buffer.Add(metric.New("mem", map[string]string{}, map[string]interface{}{"value": 2}, time.Unix(20, 0)))
+	buffer.Add(metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 3}, time.Unix(30, 0)))
+	require.NoError(t, buffer.Close())
+
+	run := func(args ...string) (string, error) {
+		buf := new(bytes.Buffer)
+		args = append([]string{os.Args[0], "buffer", "--buffer-directory", dir}, args...)
+		err := runApp(args, buf, NewMockServer(), NewMockConfig(buf), NewMockTelegraf())
+		return buf.String(), err
+	}
+
+	output, err := run("list")
+	require.NoError(t, err)
+	require.Contains(t, output, "id123")
+	require.Contains(t, output, "1970-01-01T00:00:10Z")
+	require.Contains(t, output, "1970-01-01T00:00:30Z")
+
+	output, err = run("print", "--after", "1970-01-01T00:00:15Z", "id123")
+	require.NoError(t, err)
+	require.Equal(t, "mem value=2i 20000000000\ncpu value=3i 30000000000\n", output)
+
+	_, err = run("drop", "id123")
+	require.ErrorContains(t, err, "no metrics selected")
+
+	output, err = run("drop", "--name", "cpu", "id123")
+	require.NoError(t, err)
+	require.Equal(t, "removed 2 metrics\n", output)
+
+	output, err = run("print", "id123")
+	require.NoError(t, err)
+	require.Equal(t, "mem value=2i 20000000000\n", output)
+
+	_, err = run("print", filepath.Join("..", "id123"))
+	require.ErrorContains(t, err, "invalid buffer ID")
+}
+
+func TestCommandPipeline(t *testing.T) {
+	dir := t.TempDir()
+	cfg := filepath.Join(dir, "telegraf.conf")
+	require.NoError(t, os.WriteFile(cfg, []byte(`
+[[processors.override]]
+  [processors.override.tags]
+    source = "test"
+`), 0600))
+	input := filepath.Join(dir, "input.influx")
+	require.NoError(t, os.WriteFile(input, []byte("cpu value=1i 10000000000\nmem value=2i 20000000000\n"), 0600))
+	expected := filepath.Join(dir, "expected.influx")
+	require.NoError(t, os.WriteFile(expected, []byte("mem,source=test value=2i 20000000000\ncpu,source=test value=1i 10000000000\n"), 0600))
+	wrong := filepath.Join(dir, "wrong.influx")
+	require.NoError(t, os.WriteFile(wrong, []byte("cpu,source=test value=1i 10000000000\nmem,source=test value=3i 20000000000\n"), 0600))
+
+	run := func(args ...string) (string, error) {
+		buf := new(bytes.Buffer)
+		args = append([]string{os.Args[0], "--config", cfg, "pipeline", "test", "--input", input}, args...)
+		err := runApp(args, buf, NewMockServer(), NewMockConfig(buf), NewMockTelegraf())
+		return buf.String(), err
+	}
+
+	output, err := run()
+	require.NoError(t, err)
+	require.Equal(t, "cpu,source=test value=1i 10000000000\nmem,source=test value=2i 20000000000\n", output)
+
+	output, err = run("--expected", expected)
+	require.NoError(t, err)
+	require.Equal(t, "all 2 metrics match\n", output)
+
+	output, err = run("--expected", wrong)
+	require.ErrorContains(t, err, "2 differences")
+	require.Equal(t, "+ mem,source=test value=2i 20000000000\n- mem,source=test value=3i 20000000000\n", output)
+}
+
 func TestCommandVersion(t *testing.T) {
 	tests := []struct {
 		Version        string
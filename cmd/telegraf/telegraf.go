@@ -10,6 +10,7 @@ import (
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -48,6 +49,7 @@ type GlobalFlags struct {
 	oldEnvBehavior          bool
 	nonStrictEnvVars        bool
 	printPluginConfigSource bool
+	incrementalReload       bool
 	test                    bool
 	debug                   bool
 	once                    bool
@@ -83,6 +85,10 @@ type Telegraf struct {
 
 	cfg *config.Config
 
+	// Currently running agent used for incremental reloads
+	agent   *agent.Agent
+	agentMu sync.Mutex
+
 	GlobalFlags
 	WindowFlags
 }
@@ -151,61 +157,47 @@ func (t *Telegraf) reloadLoop() error {
 		signals := make(chan os.Signal, 1)
 		signal.Notify(signals, os.Interrupt, syscall.SIGHUP,
 			syscall.SIGTERM, syscall.SIGINT)
-		if t.watchConfig != "" {
-			for _, fConfig := range t.configFiles {
-				if isURL(fConfig) {
-					continue
-				}
-
-				if _, err := os.Stat(fConfig); err != nil {
-					log.Printf("W! Cannot watch config %s: %s", fConfig, err)
-				} else {
-					go t.watchLocalConfig(ctx, signals, fConfig)
-				}
-			}
-			for _, fConfigDirectory := range t.configDir {
-				if _, err := os.Stat(fConfigDirectory); err != nil {
-					log.Printf("W! Cannot watch config directory %s: %s", fConfigDirectory, err)
-				} else {
-					go t.watchLocalConfig(ctx, signals, fConfigDirectory)
-				}
-			}
-		}
-		if t.configURLWatchInterval > 0 {
-			remoteConfigs := make([]string, 0)
-			for _, fConfig := range t.configFiles {
-				if isURL(fConfig) {
-					remoteConfigs = append(remoteConfigs, fConfig)
-				}
-			}
-			if len(remoteConfigs) > 0 {
-				go t.watchRemoteConfigs(ctx, signals, t.configURLWatchInterval, remoteConfigs)
-			}
-		}
+		watchCtx, watchCancel := context.WithCancel(ctx)
+		t.watchConfigs(watchCtx, signals)
 		go func() {
-			select {
-			case sig := <-signals:
-				if sig == syscall.SIGHUP {
-					log.Println("I! Reloading Telegraf config")
-					// May need to update the list of known config files
-					// if a delete or create occurred. That way on the reload
-					// we ensure we watch the correct files.
-					if err := t.getConfigFiles(); err != nil {
-						log.Println("E! Error loading config files: ", err)
+			for {
+				select {
+				case sig := <-signals:
+					if sig == syscall.SIGHUP {
+						log.Println("I! Reloading Telegraf config")
+						// May need to update the list of known config files
+						// if a delete or create occurred. That way on the reload
+						// we ensure we watch the correct files.
+						if err := t.getConfigFiles(); err != nil {
+							log.Println("E! Error loading config files: ", err)
+						}
+						if t.incrementalReload {
+							err := t.reloadAgent()
+							if err == nil {
+								// Restart the watchers to include config
+								// files added to the config directories
+								watchCancel()
+								watchCtx, watchCancel = context.WithCancel(ctx)
+								t.watchConfigs(watchCtx, signals)
+								continue
+							}
+							log.Printf("W! Incremental reload failed, restarting agent: %v", err)
+						}
+						<-reload
+						reload <- true
 					}
-					<-reload
-					reload <- true
+					cancel()
+				case err := <-t.pprofErr:
+					log.Printf("E! pprof server failed: %v", err)
+					cancel()
+				case <-stop:
+					cancel()
 				}
-				cancel()
-			case err := <-t.pprofErr:
-				log.Printf("E! pprof server failed: %v", err)
-				cancel()
-			case <-stop:
-				cancel()
+				return
 			}
 		}()
 
-		err := t.runAgent(ctx, reloadConfig)
+		err := t.runAgent(ctx, signals, reloadConfig)
 		if err != nil && !errors.Is(err, context.Canceled) {
 			return fmt.Errorf("[telegraf] Error running agent: %w", err)
 		}
@@ -215,6 +207,62 @@ func (t *Telegraf) reloadLoop() error {
 	return nil
 }
 
+// reloadAgent loads the configuration and applies it to the running agent
+// restarting only plugins with a changed configuration.
+func (t *Telegraf) reloadAgent() error {
+	t.agentMu.Lock()
+	ag := t.agent
+	t.agentMu.Unlock()
+	if ag == nil {
+		return errors.New("agent not running")
+	}
+
+	// The plugins kept running still hold their secrets, so only forget the
+	// secrets to link instead of resetting all secrets
+	config.ResetUnlinkedSecrets()
+	c, err := t.readConfiguration()
+	if err != nil {
+		return err
+	}
+	return ag.Reload(c)
+}
+
+// watchConfigs starts watching the local and remote configs for changes
+// until the given context is cancelled.
+func (t *Telegraf) watchConfigs(ctx context.Context, signals chan os.Signal) {
+	if t.watchConfig != "" {
+		for _, fConfig := range t.configFiles {
+			if isURL(fConfig) {
+				continue
+			}
+
+			if _, err := os.Stat(fConfig); err != nil {
+				log.Printf("W! Cannot watch config %s: %s", fConfig, err)
+			} else {
+				go t.watchLocalConfig(ctx, signals, fConfig)
+			}
+		}
+		for _, fConfigDirectory := range t.configDir {
+			if _, err := os.Stat(fConfigDirectory); err != nil {
+				log.Printf("W! Cannot watch config directory %s: %s", fConfigDirectory, err)
+			} else {
+				go t.watchLocalConfig(ctx, signals, fConfigDirectory)
+			}
+		}
+	}
+	if t.configURLWatchInterval > 0 {
+		remoteConfigs := make([]string, 0)
+		for _, fConfig := range t.configFiles {
+			if isURL(fConfig) {
+				remoteConfigs = append(remoteConfigs, fConfig)
+			}
+		}
+		if len(remoteConfigs) > 0 {
+			go t.watchRemoteConfigs(ctx, signals, t.configURLWatchInterval, remoteConfigs)
+		}
+	}
+}
+
 func (t *Telegraf) watchLocalConfig(ctx context.Context, signals chan os.Signal, fConfig string) {
 	var mytomb tomb.Tomb
 	var watcher watch.FileWatcher
@@ -337,7 +385,7 @@ func (t *Telegraf) watchLocalConfig(ctx context.Context, signals chan os.Signal,
 	}
 }
 
-func (*Telegraf) watchRemoteConfigs(ctx context.Context, signals chan os.Signal, interval time.Duration, remoteConfigs []string) {
+func (t *Telegraf) watchRemoteConfigs(ctx context.Context, signals chan os.Signal, interval time.Duration, remoteConfigs []string) {
 	configs := strings.Join(remoteConfigs, ", ")
 	log.Printf("I! Remote config watcher started for: %s\n", configs)
 
@@ -346,11 +394,12 @@ func (*Telegraf) watchRemoteConfigs(ctx context.Context, signals chan os.Signal,
 
 	lastModified := make(map[string]string, len(remoteConfigs))
 	for {
+		// Do not read from the signals channel here as the watcher would
+		// consume its own reload signal. The context is cancelled when the
+		// agent is stopped or restarted.
 		select {
 		case <-ctx.Done():
 			return
-		case <-signals:
-			return
 		case <-ticker.C:
 			for _, configURL := range remoteConfigs {
 				req, err := http.NewRequest("HEAD", configURL, nil)
@@ -383,8 +432,17 @@ func (*Telegraf) watchRemoteConfigs(ctx context.Context, signals chan os.Signal,
 					lastModified[configURL] = modified
 				} else if lastModified[configURL] != modified {
 					log.Printf("I! Remote config modified: %s\n", configURL)
-					signals <- syscall.SIGHUP
-					return
+					select {
+					case signals <- syscall.SIGHUP:
+					case <-ctx.Done():
+						return
+					}
+					// The agent and this watcher keep running with
+					// incremental reloads, so continue watching.
+					if !t.incrementalReload {
+						return
+					}
+					lastModified[configURL] = modified
 				}
 			}
 		}
@@ -395,6 +453,10 @@ func (t *Telegraf) loadConfiguration() (*config.Config, error) {
 	// Make sure secrets are cleared
 	config.ResetSecrets()
 
+	return t.readConfiguration()
+}
+
+func (t *Telegraf) readConfiguration() (*config.Config, error) {
 	// If no other options are specified, load the config file and run.
 	c := config.NewConfig()
 	c.Agent.Quiet = t.quiet
@@ -438,7 +500,7 @@ func (t *Telegraf) getConfigFiles() error {
 	return nil
 }
 
-func (t *Telegraf) runAgent(ctx context.Context, reloadConfig bool) error {
+func (t *Telegraf) runAgent(ctx context.Context, signals chan<- os.Signal, reloadConfig bool) error {
 	c := t.cfg
 	var err error
 	if reloadConfig {
@@ -530,6 +592,12 @@ func (t *Telegraf) runAgent(ctx context.Context, reloadConfig bool) error {
 		}
 	}
 	ag := agent.NewAgent(c)
+	ag.RequestReload = func() {
+		select {
+		case signals <- syscall.SIGHUP:
+		default:
+		}
+	}
 
 	// Notify systemd that telegraf is ready
 	// SdNotify() only tries to notify if the NOTIFY_SOCKET environment is set, so it's safe to call when systemd isn't present.
@@ -548,6 +616,15 @@ func (t *Telegraf) runAgent(ctx context.Context, reloadConfig bool) error {
 		return ag.Test(ctx, wait)
 	}
 
+	t.agentMu.Lock()
+	t.agent = ag
+	t.agentMu.Unlock()
+	defer func() {
+		t.agentMu.Lock()
+		t.agent = nil
+		t.agentMu.Unlock()
+	}()
+
 	if t.pidFile != "" {
 		f, err := os.OpenFile(t.pidFile, os.O_CREATE|os.O_WRONLY, 0640)
 		if err != nil {
@@ -27,6 +27,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/clock"
 	logging "github.com/influxdata/telegraf/logger"
 	"github.com/influxdata/telegraf/models"
 	"github.com/influxdata/telegraf/persister"
@@ -105,6 +106,10 @@ type Config struct {
 	fileProcessors    OrderedPlugins
 	fileAggProcessors OrderedPlugins
 
+	// OutputGroups route metrics to a single output of the group instead
+	// of all outputs
+	OutputGroups []*models.OutputGroupConfig
+
 	// Parsers are created by their inputs during gather. Config doesn't keep track of them
 	// like the other plugins because they need to be garbage collected (See issue #11809)
 
@@ -283,6 +288,15 @@ type AgentConfig struct {
 	// the state in the file will be restored for the plugins.
 	Statefile string `toml:"statefile"`
 
+	// Backend used for storing the plugin states. Can be "file" to store all
+	// states in a single JSON file, "directory" to store the state of each
+	// plugin in a separate file or "sqlite" to use a SQLite database.
+	StatefileBackend string `toml:"statefile_backend"`
+
+	// Interval for periodically storing the plugin states in addition to
+	// storing them on termination of Telegraf. Zero disables checkpointing.
+	StatefileInterval Duration `toml:"statefile_interval"`
+
 	// Flag to always keep tags explicitly defined in the plugin itself and
 	// ensure those tags always pass filtering.
 	AlwaysIncludeLocalTags bool `toml:"always_include_local_tags"`
@@ -291,6 +305,28 @@ type AgentConfig struct {
 	// and ensure those tags always pass filtering.
 	AlwaysIncludeGlobalTags bool `toml:"always_include_global_tags"`
 
+	// MaxSeriesPerInput limits the number of distinct series each input
+	// may produce. Zero disables the limit. Inputs can override the limit
+	// using the 'max_series' setting.
+	MaxSeriesPerInput int `toml:"max_series_per_input"`
+
+	// SeriesLimitPolicy determines the handling of metrics of new series
+	// exceeding the limit and can be "drop", "drop_tags" or "sample".
+	SeriesLimitPolicy string `toml:"series_limit_policy"`
+
+	// SeriesTTL is the time after which series not seen anymore are not
+	// counted towards the series limit. Zero keeps series forever.
+	SeriesTTL Duration `toml:"series_ttl"`
+
+	// PipelineTraceSampleRate enables tracing every n-th metric through the
+	// pipeline. Zero disables tracing.
+	PipelineTraceSampleRate int `toml:"pipeline_trace_sample_rate"`
+
+	// PipelineTraceOutput is the alias or name of the output the traces of
+	// sampled metrics are exported to. The output must support exporting
+	// traces. If empty, traces are only reported as internal statistics.
+	PipelineTraceOutput string `toml:"pipeline_trace_output"`
+
 	// Flag to skip running processors after aggregators
 	// By default, processors are run a second time after aggregators. Changing
 	// this setting to true will skip the second run of processors.
@@ -318,6 +354,49 @@ type AgentConfig struct {
 	// metrics buffered in the last `flush_interval` in the event of a power
 	// cut.
 	BufferDiskSync *bool `toml:"buffer_disk_sync"`
+
+	// BufferSpillThreshold is the number of metrics kept in memory by the
+	// "hybrid" buffer strategy before spilling metrics to disk. Defaults to
+	// the metric buffer limit.
+	BufferSpillThreshold int `toml:"buffer_spill_threshold"`
+
+	// BufferSpillAfter is the duration of failing writes after which the
+	// "hybrid" buffer strategy spills metrics to disk. Zero disables spilling
+	// due to failing writes.
+	BufferSpillAfter Duration `toml:"buffer_spill_after"`
+
+	// BufferMaxDiskSize limits the size of the metrics stored on disk for the
+	// "disk" and "hybrid" buffer strategies. The oldest metrics are dropped
+	// when exceeding the size.
+	BufferMaxDiskSize Size `toml:"buffer_max_disk_size"`
+
+	// BufferMaxAge is the maximum age of metrics stored on disk for the "disk"
+	// and "hybrid" buffer strategies. Older metrics are dropped.
+	BufferMaxAge Duration `toml:"buffer_max_age"`
+
+	// BackpressureHighWaterMark is the fill level of the output buffers, as
+	// fraction of the buffer limit, above which the agent applies
+	// backpressure to the inputs if all outputs exceed it. Zero disables
+	// backpressure.
+	BackpressureHighWaterMark float64 `toml:"backpressure_high_water_mark"`
+
+	// BackpressureLowWaterMark is the fill level of the output buffers below
+	// which backpressure is released again. Defaults to half of the
+	// high-water mark.
+	BackpressureLowWaterMark float64 `toml:"backpressure_low_water_mark"`
+
+	// BackpressurePolicy determines how polling inputs react to backpressure
+	// and can be "skip" to skip gathering or "stretch" to gather only every
+	// n-th interval with n being the BackpressureStretchFactor.
+	BackpressurePolicy string `toml:"backpressure_policy"`
+
+	// BackpressureStretchFactor is the factor the gather interval of polling
+	// inputs is stretched by when using the "stretch" policy.
+	BackpressureStretchFactor int `toml:"backpressure_stretch_factor"`
+
+	// AdminAddress is the address of the admin API to inspect and control the
+	// running agent. Only unix sockets and loopback addresses are allowed.
+	AdminAddress string `toml:"admin_address"`
 }
 
 // InputNames returns a list of strings of the configured inputs.
@@ -470,7 +549,12 @@ func sliceContains(name string, list []string) bool {
 	return false
 }
 
-// WalkDirectory collects all toml files that need to be loaded
+// configDirectorySuffixes are the suffixes of files loaded from configuration
+// directories. YAML and JSON files require a ".conf" infix to not load other
+// files stored alongside the configuration, e.g. template inventories.
+var configDirectorySuffixes = []string{".conf", ".conf.yaml", ".conf.yml", ".conf.json"}
+
+// WalkDirectory collects all TOML, YAML and JSON files that need to be loaded
 func WalkDirectory(path string) ([]string, error) {
 	// Check permissions of the directly specified directories and error
 	// out if those are not readable
@@ -494,7 +578,10 @@ func WalkDirectory(path string) ([]string, error) {
 			return nil
 		}
 		name := info.Name()
-		if len(name) < 6 || name[len(name)-5:] != ".conf" {
+		matches := func(suffix string) bool {
+			return len(name) > len(suffix) && strings.HasSuffix(name, suffix)
+		}
+		if !slices.ContainsFunc(configDirectorySuffixes, matches) {
 			return nil
 		}
 		files = append(files, thispath)
@@ -597,6 +684,14 @@ func (c *Config) LoadAll(configFiles ...string) error {
 		c.Processors = make(models.RunningProcessors, 0)
 	}
 
+	if err := c.checkOutputGroups(); err != nil {
+		return err
+	}
+	if err := c.checkDeadLetters(); err != nil {
+		return err
+	}
+	c.checkPipelines()
+
 	// Set snmp agent translator default
 	if c.Agent.SnmpTranslator == "" {
 		c.Agent.SnmpTranslator = "netsnmp"
@@ -626,9 +721,16 @@ func WithSourcePath(path string) cfgDataOption {
 	}
 }
 
-// LoadConfigData loads TOML-formatted config data
+// LoadConfigData loads TOML, YAML or JSON formatted config data where the
+// format is determined by the extension of the given path
 func (c *Config) LoadConfigData(data []byte, path string) error {
-	tbl, err := parseConfig(data)
+	var tbl *ast.Table
+	var err error
+	if format := DetectFormat(path); format != "toml" {
+		tbl, err = parseStructuredConfig(data, format)
+	} else {
+		tbl, err = parseConfig(data)
+	}
 	if err != nil {
 		return fmt.Errorf("error parsing data: %w", err)
 	}
@@ -691,8 +793,13 @@ func (c *Config) LoadConfigData(data []byte, path string) error {
 
 	// Set up the persister if requested
 	if c.Agent.Statefile != "" {
+		backend, err := persister.NewBackend(c.Agent.StatefileBackend, c.Agent.Statefile)
+		if err != nil {
+			return fmt.Errorf("setting up statefile failed: %w", err)
+		}
 		c.Persister = &persister.Persister{
 			Filename: c.Agent.Statefile,
+			Backend:  backend,
 		}
 	}
 
@@ -810,6 +917,26 @@ func (c *Config) LoadConfigData(data []byte, path string) error {
 						name, pluginName, subTable.Line, keys(c.UnusedFields))
 				}
 			}
+		case "output_groups":
+			for groupName, groupVal := range subTable.Fields {
+				groupTable, ok := groupVal.(*ast.Table)
+				if !ok {
+					return fmt.Errorf("invalid configuration, error parsing output group %q", groupName)
+				}
+				if err = c.addOutputGroup(groupName, groupTable); err != nil {
+					return fmt.Errorf("error parsing output group %q: %w", groupName, err)
+				}
+			}
+		case "templates":
+			for templateName, templateVal := range subTable.Fields {
+				templateTable, ok := templateVal.(*ast.Table)
+				if !ok {
+					return fmt.Errorf("invalid configuration, error parsing template %q", templateName)
+				}
+				if err = c.addTemplate(templateName, path, templateTable); err != nil {
+					return fmt.Errorf("error parsing template %q: %w", templateName, err)
+				}
+			}
 		case "secretstores":
 			for pluginName, pluginVal := range subTable.Fields {
 				switch pluginSubTable := pluginVal.(type) {
@@ -839,13 +966,14 @@ func (c *Config) LoadConfigData(data []byte, path string) error {
 	}
 
 	// Sort the processor according to the order they appeared in this file
-	// In a later stage, we sort them using the `order` option.
-	sort.Sort(c.fileProcessors)
+	// In a later stage, we sort them using the `order` option. Instances of
+	// the same template share the line so keep them in their order.
+	sort.Stable(c.fileProcessors)
 	for _, op := range c.fileProcessors {
 		c.Processors = append(c.Processors, op.plugin.(*models.RunningProcessor))
 	}
 
-	sort.Sort(c.fileAggProcessors)
+	sort.Stable(c.fileAggProcessors)
 	for _, op := range c.fileAggProcessors {
 		c.AggProcessors = append(c.AggProcessors, op.plugin.(*models.RunningProcessor))
 	}
@@ -924,7 +1052,14 @@ func fetchConfig(u *url.URL, urlRetryAttempts int) ([]byte, error) {
 	} else if v, exists := os.LookupEnv("INFLUX_TOKEN"); exists {
 		req.Header.Add("Authorization", "Token "+v)
 	}
-	req.Header.Add("Accept", "application/toml")
+	switch DetectFormat(u.Path) {
+	case "yaml":
+		req.Header.Add("Accept", "application/yaml")
+	case "json":
+		req.Header.Add("Accept", "application/json")
+	default:
+		req.Header.Add("Accept", "application/toml")
+	}
 	req.Header.Set("User-Agent", internal.ProductToken())
 
 	var totalAttempts int
@@ -1450,6 +1585,156 @@ func (c *Config) addOutput(name, source string, table *ast.Table) error {
 	return nil
 }
 
+func (c *Config) addOutputGroup(name string, table *ast.Table) error {
+	for _, g := range c.OutputGroups {
+		if g.Name == name {
+			return errors.New("group defined multiple times")
+		}
+	}
+
+	group := &models.OutputGroupConfig{Name: name}
+	if err := c.toml.UnmarshalTable(table, group); err != nil {
+		return err
+	}
+	if len(c.UnusedFields) > 0 {
+		return fmt.Errorf(
+			"line %d: configuration specified the fields %q, but they were not used; "+
+				"this is either a typo or this config option does not exist in this version",
+			table.Line, keys(c.UnusedFields))
+	}
+	if err := group.Validate(); err != nil {
+		return err
+	}
+	c.OutputGroups = append(c.OutputGroups, group)
+
+	return nil
+}
+
+// checkOutputGroups makes sure the outputs referenced by the output groups
+// can be resolved unambiguously and no output is part of multiple groups.
+// All outputs of a group must subscribe to the same pipelines as the metrics
+// are routed to a single output of the group regardless of the pipeline.
+// References to outputs not loaded, e.g. due to output filters, are ignored.
+func (c *Config) checkOutputGroups() error {
+	owner := make(map[*models.RunningOutput]string, len(c.Outputs))
+	for _, group := range c.OutputGroups {
+		var first *models.RunningOutput
+		var pipelines []string
+		for _, ref := range group.Outputs {
+			var found int
+			for _, output := range c.Outputs {
+				if !output.MatchesReference(ref) {
+					continue
+				}
+				found++
+				if g, exists := owner[output]; exists && g != group.Name {
+					return fmt.Errorf("output %q is part of output groups %q and %q", ref, g, group.Name)
+				}
+				owner[output] = group.Name
+
+				subscribed := slices.Compact(slices.Sorted(slices.Values(output.Config.Pipelines)))
+				if first == nil {
+					first, pipelines = output, subscribed
+				} else if !slices.Equal(pipelines, subscribed) {
+					return fmt.Errorf("outputs %s and %s of output group %q subscribe to different pipelines",
+						first.LogName(), output.LogName(), group.Name)
+				}
+			}
+			switch found {
+			case 0:
+				log.Printf("W! Output %q of output group %q not found", ref, group.Name)
+			case 1:
+			default:
+				return fmt.Errorf("output %q of output group %q is ambiguous, use an alias", ref, group.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// checkDeadLetters makes sure the outputs receiving rejected metrics of other
+// outputs can be resolved unambiguously and are not part of an output group.
+func (c *Config) checkDeadLetters() error {
+	for _, output := range c.Outputs {
+		ref := output.DeadLetterReference()
+		if ref == "" {
+			continue
+		}
+
+		var targets []*models.RunningOutput
+		for _, target := range c.Outputs {
+			if target.MatchesReference(ref) {
+				targets = append(targets, target)
+			}
+		}
+		switch len(targets) {
+		case 0:
+			log.Printf("W! Dead-letter output %q of %s not found", ref, output.LogName())
+			continue
+		case 1:
+		default:
+			return fmt.Errorf("dead-letter output %q of %s is ambiguous, use an alias", ref, output.LogName())
+		}
+
+		if targets[0] == output {
+			return fmt.Errorf("%s cannot be its own dead-letter output", output.LogName())
+		}
+		for _, group := range c.OutputGroups {
+			if group.Contains(targets[0]) {
+				return fmt.Errorf("dead-letter output %q of %s is part of output group %q", ref, output.LogName(), group.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Pipelines returns the sorted names of all pipelines referenced by the
+// plugins including the default pipeline.
+func (c *Config) Pipelines() []string {
+	pipelines := []string{models.DefaultPipeline}
+	for _, input := range c.Inputs {
+		pipelines = append(pipelines, input.Config.Pipeline)
+	}
+	for _, processor := range c.Processors {
+		pipelines = append(pipelines, processor.Config.Pipeline)
+	}
+	for _, aggregator := range c.Aggregators {
+		pipelines = append(pipelines, aggregator.Config.Pipeline)
+	}
+	for _, output := range c.Outputs {
+		pipelines = append(pipelines, output.Config.Pipelines...)
+	}
+	slices.Sort(pipelines)
+	return slices.Compact(pipelines)
+}
+
+// checkPipelines warns about pipelines not fed by any input and about inputs
+// feeding a pipeline without any output receiving the metrics.
+func (c *Config) checkPipelines() {
+	fed := make(map[string]bool, len(c.Inputs))
+	for _, input := range c.Inputs {
+		fed[input.Config.Pipeline] = true
+	}
+	subscribed := make(map[string]bool, len(c.Outputs))
+	for _, output := range c.Outputs {
+		for _, pipeline := range output.Config.Pipelines {
+			subscribed[pipeline] = true
+		}
+	}
+
+	for _, pipeline := range c.Pipelines() {
+		if !fed[pipeline] {
+			if pipeline != models.DefaultPipeline {
+				log.Printf("W! No input feeds pipeline %q", pipeline)
+			}
+			continue
+		}
+		if len(c.Outputs) > 0 && !subscribed[pipeline] {
+			log.Printf("W! No output receives the metrics of pipeline %q, metrics will be dropped", pipeline)
+		}
+	}
+}
+
 func (c *Config) addInput(name, source string, table *ast.Table) error {
 	if len(c.InputFilters) > 0 && !sliceContains(name, c.InputFilters) {
 		return nil
@@ -1571,6 +1856,7 @@ func (c *Config) buildAggregator(name, source string, tbl *ast.Table) (*models.A
 	conf.NameOverride = c.getFieldString(tbl, "name_override")
 	conf.Alias = c.getFieldString(tbl, "alias")
 	conf.LogLevel = c.getFieldString(tbl, "log_level")
+	conf.Pipeline = c.getFieldString(tbl, "pipeline")
 
 	conf.Tags = make(map[string]string)
 	if node, ok := tbl.Fields["tags"]; ok {
@@ -1585,6 +1871,13 @@ func (c *Config) buildAggregator(name, source string, tbl *ast.Table) (*models.A
 		return nil, c.firstErr()
 	}
 
+	if conf.Pipeline == "" {
+		conf.Pipeline = models.DefaultPipeline
+	}
+	if err := models.CheckPipelineName(conf.Pipeline); err != nil {
+		return nil, err
+	}
+
 	var err error
 	conf.Filter, err = c.buildFilter("aggregators."+name, tbl)
 	if err != nil {
@@ -1608,11 +1901,19 @@ func (c *Config) buildProcessor(category, name, source string, tbl *ast.Table) (
 	conf.Order = c.getFieldInt64(tbl, "order")
 	conf.Alias = c.getFieldString(tbl, "alias")
 	conf.LogLevel = c.getFieldString(tbl, "log_level")
+	conf.Pipeline = c.getFieldString(tbl, "pipeline")
 
 	if c.hasErrs() {
 		return nil, c.firstErr()
 	}
 
+	if conf.Pipeline == "" {
+		conf.Pipeline = models.DefaultPipeline
+	}
+	if err := models.CheckPipelineName(conf.Pipeline); err != nil {
+		return nil, err
+	}
+
 	var err error
 	conf.Filter, err = c.buildFilter(category+"."+name, tbl)
 	if err != nil {
@@ -1725,11 +2026,54 @@ func (c *Config) buildInput(name, source string, tbl *ast.Table) (*models.InputC
 	cp.StartupErrorBehavior = c.getFieldString(tbl, "startup_error_behavior")
 	cp.TimeSource = c.getFieldString(tbl, "time_source")
 
+	loc := time.Local
+	if tz := c.getFieldString(tbl, "schedule_timezone"); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return nil, fmt.Errorf("invalid schedule_timezone %q: %w", tz, err)
+		}
+	}
+	if expression := c.getFieldString(tbl, "schedule"); expression != "" {
+		schedule, err := clock.ParseSchedule(expression, loc)
+		if err != nil {
+			return nil, err
+		}
+		cp.Schedule = schedule
+	}
+	active := c.getFieldStringSlice(tbl, "active_windows")
+	inactive := c.getFieldStringSlice(tbl, "inactive_windows")
+	if len(active) > 0 || len(inactive) > 0 {
+		windows, err := clock.ParseWindows(active, inactive, loc)
+		if err != nil {
+			return nil, err
+		}
+		cp.Windows = windows
+	}
+
+	cp.MaxSeries = c.Agent.MaxSeriesPerInput
+	if _, found := tbl.Fields["max_series"]; found {
+		cp.MaxSeries = c.getFieldInt(tbl, "max_series")
+	}
+	cp.SeriesLimitPolicy = c.Agent.SeriesLimitPolicy
+	if policy := c.getFieldString(tbl, "series_limit_policy"); policy != "" {
+		cp.SeriesLimitPolicy = policy
+	}
+	cp.SeriesKeepTags = c.getFieldStringSlice(tbl, "series_keep_tags")
+	cp.SeriesSampleRate = c.getFieldInt(tbl, "series_sample_rate")
+	cp.SeriesTTL = time.Duration(c.Agent.SeriesTTL)
+	if ttl, found := c.getFieldDuration(tbl, "series_ttl"); found {
+		cp.SeriesTTL = ttl
+	}
+	if err := models.CheckSeriesLimitPolicy(cp.SeriesLimitPolicy); err != nil {
+		return nil, err
+	}
+
 	cp.MeasurementPrefix = c.getFieldString(tbl, "name_prefix")
 	cp.MeasurementSuffix = c.getFieldString(tbl, "name_suffix")
 	cp.NameOverride = c.getFieldString(tbl, "name_override")
 	cp.Alias = c.getFieldString(tbl, "alias")
 	cp.LogLevel = c.getFieldString(tbl, "log_level")
+	cp.Pipeline = c.getFieldString(tbl, "pipeline")
 
 	cp.Tags = make(map[string]string)
 	if node, ok := tbl.Fields["tags"]; ok {
@@ -1744,6 +2088,13 @@ func (c *Config) buildInput(name, source string, tbl *ast.Table) (*models.InputC
 		return nil, c.firstErr()
 	}
 
+	if cp.Pipeline == "" {
+		cp.Pipeline = models.DefaultPipeline
+	}
+	if err := models.CheckPipelineName(cp.Pipeline); err != nil {
+		return nil, err
+	}
+
 	var err error
 	cp.Filter, err = c.buildFilter("inputs."+name, tbl)
 	if err != nil {
@@ -1775,12 +2126,16 @@ func (c *Config) buildOutput(name, source string, tbl *ast.Table) (*models.Outpu
 	}
 
 	oc := &models.OutputConfig{
-		Name:            name,
-		Source:          source,
-		Filter:          filter,
-		BufferStrategy:  bufferStrategy,
-		BufferDirectory: c.Agent.BufferDirectory,
-		BufferDiskSync:  bufferDiskSync,
+		Name:                 name,
+		Source:               source,
+		Filter:               filter,
+		BufferStrategy:       bufferStrategy,
+		BufferDirectory:      c.Agent.BufferDirectory,
+		BufferDiskSync:       bufferDiskSync,
+		BufferSpillThreshold: c.Agent.BufferSpillThreshold,
+		BufferSpillAfter:     time.Duration(c.Agent.BufferSpillAfter),
+		BufferMaxDiskSize:    int64(c.Agent.BufferMaxDiskSize),
+		BufferMaxAge:         time.Duration(c.Agent.BufferMaxAge),
 	}
 
 	// TODO: support FieldPass/FieldDrop on outputs
@@ -1795,18 +2150,40 @@ func (c *Config) buildOutput(name, source string, tbl *ast.Table) (*models.Outpu
 	oc.NamePrefix = c.getFieldString(tbl, "name_prefix")
 	oc.StartupErrorBehavior = c.getFieldString(tbl, "startup_error_behavior")
 	oc.LogLevel = c.getFieldString(tbl, "log_level")
+	oc.RateLimitMetrics = c.getFieldInt(tbl, "metric_rate_limit")
+	oc.RateLimitBytes = c.getFieldSize(tbl, "byte_rate_limit")
+	oc.RateLimitBurst, _ = c.getFieldDuration(tbl, "rate_limit_burst")
+	oc.RetryBackoffInitial, _ = c.getFieldDuration(tbl, "retry_backoff_initial")
+	oc.RetryBackoffMax, _ = c.getFieldDuration(tbl, "retry_backoff_max")
+	oc.RetryBackoffJitter, _ = c.getFieldDuration(tbl, "retry_backoff_jitter")
+	oc.CircuitBreakerThreshold = c.getFieldInt(tbl, "circuit_breaker_threshold")
+	oc.CircuitBreakerResetTimeout, _ = c.getFieldDuration(tbl, "circuit_breaker_reset_timeout")
+	oc.DeadLetter = c.getFieldString(tbl, "dead_letter")
+	oc.Pipelines = c.getFieldStringSlice(tbl, "pipelines")
 
 	if c.hasErrs() {
 		return nil, c.firstErr()
 	}
 
+	if len(oc.Pipelines) == 0 {
+		oc.Pipelines = []string{models.DefaultPipeline}
+	}
+	for _, pipeline := range oc.Pipelines {
+		if err := models.CheckPipelineName(pipeline); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := models.CheckBufferSettings(oc.BufferStrategy); err != nil {
 		return nil, err
 	}
-	if c.TestMode {
+	switch {
+	case c.TestMode:
 		oc.BufferStrategy = "discard"
-	} else if oc.BufferStrategy == "disk_write_through" {
+	case oc.BufferStrategy == "disk_write_through":
 		log.Printf("W! Using disk-write-through buffer strategy for plugin outputs.%s, this is an experimental feature", name)
+	case oc.BufferStrategy == "hybrid":
+		log.Printf("W! Using hybrid buffer strategy for plugin outputs.%s, this is an experimental feature", name)
 	}
 
 	// Generate an ID for the plugin
@@ -1815,33 +2192,16 @@ func (c *Config) buildOutput(name, source string, tbl *ast.Table) (*models.Outpu
 }
 
 func (c *Config) missingTomlField(_ reflect.Type, key string) error {
-	switch key {
-	// General options to ignore
-	case "alias", "always_include_local_tags",
-		"buffer_strategy", "buffer_directory", "buffer_disk_sync",
-		"collection_jitter", "collection_offset",
-		"data_format", "delay", "drop", "drop_original",
-		"fielddrop", "fieldexclude", "fieldinclude", "fieldpass", "flush_interval", "flush_jitter",
-		"grace",
-		"interval",
-		"log_level", "lvm", // What is this used for?
-		"metric_batch_size", "metric_buffer_limit", "metricpass",
-		"name_override", "name_prefix", "name_suffix", "namedrop", "namedrop_separator", "namepass", "namepass_separator",
-		"order",
-		"pass", "period", "precision",
-		"tagdrop", "tagexclude", "taginclude", "tagpass", "tags", "startup_error_behavior", "labels":
-
-	// secret store options to ignore
-	case "id":
-
-	// Parser and serializer options to ignore
-	case "data_type", "influx_parser_type":
-
-	default:
-		c.unusedFieldsMutex.Lock()
-		c.UnusedFields[key] = true
-		c.unusedFieldsMutex.Unlock()
+	// Options parsed by the loader are described by the schema, so use the
+	// same source to not reject configurations valid for the schema and
+	// vice versa.
+	if isLoaderOption(key) {
+		return nil
 	}
+
+	c.unusedFieldsMutex.Lock()
+	c.UnusedFields[key] = true
+	c.unusedFieldsMutex.Unlock()
 	return nil
 }
 
@@ -1972,6 +2332,34 @@ func (c *Config) getFieldInt64(tbl *ast.Table, fieldName string) int64 {
 	return 0
 }
 
+func (c *Config) getFieldSize(tbl *ast.Table, fieldName string) int64 {
+	if node, ok := tbl.Fields[fieldName]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			var size Size
+			switch v := kv.Value.(type) {
+			case *ast.Integer:
+				i, err := v.Int()
+				if err != nil {
+					c.addError(tbl, fmt.Errorf("unexpected int type %q, expecting int", v.Value))
+					return 0
+				}
+				return i
+			case *ast.String:
+				if err := size.UnmarshalText([]byte(v.Value)); err != nil {
+					c.addError(tbl, fmt.Errorf("error parsing size %q: %w", v.Value, err))
+					return 0
+				}
+				return int64(size)
+			default:
+				c.addError(tbl, fmt.Errorf("found unexpected format while parsing %q, expecting size", fieldName))
+				return 0
+			}
+		}
+	}
+
+	return 0
+}
+
 func (c *Config) getFieldStringSlice(tbl *ast.Table, fieldName string) []string {
 	var target []string
 	if node, ok := tbl.Fields[fieldName]; ok {
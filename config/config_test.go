@@ -2,6 +2,7 @@ package config_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -92,6 +93,7 @@ func TestConfig_LoadSingleInputWithEnvVars(t *testing.T) {
 		Source:   confFile,
 		Filter:   filter,
 		Interval: 10 * time.Second,
+		Pipeline: models.DefaultPipeline,
 	}
 	inputConfig.Tags = make(map[string]string)
 
@@ -135,6 +137,7 @@ func TestConfig_LoadSingleInput(t *testing.T) {
 		Source:   confFile,
 		Filter:   filter,
 		Interval: 5 * time.Second,
+		Pipeline: models.DefaultPipeline,
 	}
 	inputConfig.Tags = make(map[string]string)
 
@@ -146,6 +149,157 @@ func TestConfig_LoadSingleInput(t *testing.T) {
 	require.Equal(t, inputConfig, c.Inputs[0].Config, "Testdata did not produce correct memcached metadata.")
 }
 
+func TestConfig_LoadSingleInputFormats(t *testing.T) {
+	reference := config.NewConfig()
+	require.NoError(t, reference.LoadConfig(filepath.Join("testdata", "single_plugin.toml")))
+	require.Len(t, reference.Inputs, 1)
+	expected := reference.Inputs[0]
+
+	for _, fn := range []string{"single_plugin.yaml", "single_plugin.json"} {
+		t.Run(fn, func(t *testing.T) {
+			c := config.NewConfig()
+			confFile := filepath.Join("testdata", fn)
+			require.NoError(t, c.LoadConfig(confFile))
+			require.Len(t, c.Inputs, 1)
+
+			actual := c.Inputs[0]
+			require.Equal(t, confFile, actual.Config.Source)
+			require.Equal(t, expected.Config.ID, actual.Config.ID)
+
+			// Ignore Log, Parser and Source
+			actual.Input.(*MockupInputPlugin).Log = expected.Input.(*MockupInputPlugin).Log
+			actual.Input.(*MockupInputPlugin).parser = expected.Input.(*MockupInputPlugin).parser
+			actual.Config.Source = expected.Config.Source
+			require.Equal(t, expected.Input, actual.Input)
+			require.Equal(t, expected.Config, actual.Config)
+		})
+	}
+}
+
+func TestConfig_LoadYAML(t *testing.T) {
+	c := config.NewConfig()
+	cfg := []byte(`
+global_tags:
+  region: eu
+processors:
+  processor:
+    - &common
+      alias: first
+      order: 2
+    - <<: *common
+      alias: second
+inputs:
+  memcached:
+    servers:
+      - localhost
+    port: 11211
+    timeout: 10s
+`)
+	require.NoError(t, c.LoadConfigData(cfg, "telegraf.yaml"))
+	require.Empty(t, c.UnusedFields)
+	require.Equal(t, "eu", c.Tags["region"])
+
+	// Merged keys are overridden by explicit ones
+	require.Len(t, c.Processors, 2)
+	require.Equal(t, "first", c.Processors[0].Config.Alias)
+	require.Equal(t, "second", c.Processors[1].Config.Alias)
+	require.Equal(t, int64(2), c.Processors[1].Config.Order)
+
+	// A single mapping is accepted as plugin instance
+	require.Len(t, c.Inputs, 1)
+	input := c.Inputs[0].Input.(*MockupInputPlugin)
+	require.Equal(t, []string{"localhost"}, input.Servers)
+	require.Equal(t, 11211, input.Port)
+	require.Equal(t, config.Duration(10*time.Second), input.Timeout)
+
+	c = config.NewConfig()
+	cfg = []byte(`
+inputs:
+  memcached: localhost
+`)
+	require.ErrorContains(t, c.LoadConfigData(cfg, "telegraf.yml"),
+		`line 3: expected a mapping or list of mappings for plugin "memcached"`)
+}
+
+func TestConfig_Schema(t *testing.T) {
+	schema := config.GenerateSchema()
+	require.Contains(t, schema.Properties, "agent")
+	require.Contains(t, schema.Properties["agent"].Properties, "flush_interval")
+	require.Equal(t, "10s", schema.Properties["agent"].Properties["interval"].Default)
+
+	input := schema.Defs["inputs.memcached"]
+	require.NotNil(t, input)
+	require.Equal(t, []string{"array"}, input.Properties["servers"].Type)
+	require.Equal(t, []string{"string"}, input.Properties["password"].Type)
+	require.Contains(t, input.Properties, "pid_file")
+	require.Contains(t, input.Properties, "tls_cert")
+
+	buf, err := json.Marshal(schema)
+	require.NoError(t, err)
+	require.Contains(t, string(buf), `"unevaluatedProperties":false`)
+
+	cfg := []byte(`
+[agent]
+  interval = "10s"
+  flush_intervall = "10s"
+
+[[inputs.memcached]]
+  servers = ["localhost"]
+  port = "11211"
+  pidfile = "/run/memcached.pid"
+  startup_error_behavior = "panic"
+
+[[inputs.unknown]]
+`)
+	violations, err := config.ValidateSchema(schema, cfg, "telegraf.conf")
+	require.NoError(t, err)
+	messages := make([]string, 0, len(violations))
+	for _, v := range violations {
+		messages = append(messages, v.Error())
+	}
+	expected := []string{
+		`telegraf.conf:4: agent.flush_intervall: unknown option "flush_intervall"`,
+		`telegraf.conf:8: inputs.memcached[0].port: expected integer but got string`,
+		`telegraf.conf:10: inputs.memcached[0].startup_error_behavior: invalid value "panic", ` +
+			`expected one of ["error" "retry" "ignore" "probe"]`,
+		`telegraf.conf:12: inputs.unknown: unknown plugin "unknown"`,
+	}
+	require.Equal(t, expected, messages)
+
+	cfg = []byte(`
+inputs:
+  memcached:
+    servers: localhost
+`)
+	violations, err = config.ValidateSchema(schema, cfg, "telegraf.yaml")
+	require.NoError(t, err)
+	require.Len(t, violations, 1)
+	require.Equal(t, `telegraf.yaml:4: inputs.memcached[0].servers: expected array but got string`, violations[0].Error())
+}
+
+func TestConfig_SchemaMatchesLoader(t *testing.T) {
+	cfg := []byte(`
+[[inputs.memcached]]
+  servers = ["localhost"]
+  time_source = "collection_start"
+  buffer_strategy = "disk"
+  buffer_directory = "/var/lib/telegraf"
+  buffer_disk_sync = true
+  data_format = "influx"
+
+[[outputs.http]]
+  flush_interval = "10s"
+  always_include_local_tags = true
+`)
+
+	// Options accepted by the loader must be known to the schema
+	c := config.NewConfig()
+	require.NoError(t, c.LoadConfigData(cfg, config.EmptySourcePath))
+	violations, err := config.ValidateSchema(config.GenerateSchema(), cfg, "telegraf.conf")
+	require.NoError(t, err)
+	require.Empty(t, violations)
+}
+
 func TestConfig_InputCollectionJitterExplicitZeroIsSet(t *testing.T) {
 	c := config.NewConfig()
 	cfg := []byte(`
@@ -169,6 +323,187 @@ func TestConfig_InputCollectionJitterExplicitZeroIsSet(t *testing.T) {
 	require.False(t, c.Inputs[1].Config.CollectionJitterSet)
 }
 
+func TestConfig_SeriesLimit(t *testing.T) {
+	c := config.NewConfig()
+	cfg := []byte(`
+[agent]
+  max_series_per_input = 1000
+  series_ttl = "1h"
+
+[[inputs.memcached]]
+  servers = ["localhost"]
+  max_series = 0
+
+[[inputs.memcached]]
+  servers = ["127.0.0.1"]
+  series_limit_policy = "drop_tags"
+  series_keep_tags = ["host"]
+`)
+	require.NoError(t, c.LoadConfigData(cfg, config.EmptySourcePath))
+	require.Len(t, c.Inputs, 2)
+
+	require.Zero(t, c.Inputs[0].Config.MaxSeries)
+
+	require.Equal(t, 1000, c.Inputs[1].Config.MaxSeries)
+	require.Equal(t, "drop_tags", c.Inputs[1].Config.SeriesLimitPolicy)
+	require.Equal(t, []string{"host"}, c.Inputs[1].Config.SeriesKeepTags)
+	require.Equal(t, time.Hour, c.Inputs[1].Config.SeriesTTL)
+
+	c = config.NewConfig()
+	cfg = []byte(`
+[[inputs.memcached]]
+  max_series = 10
+  series_limit_policy = "foo"
+`)
+	require.ErrorContains(t, c.LoadConfigData(cfg, config.EmptySourcePath), "invalid 'series_limit_policy' setting")
+}
+
+func TestConfig_Pipelines(t *testing.T) {
+	c := config.NewConfig()
+	cfg := []byte(`
+[[inputs.memcached]]
+  servers = ["localhost"]
+[[inputs.memcached]]
+  servers = ["127.0.0.1"]
+  pipeline = "tenant_b"
+[[processors.processor]]
+  pipeline = "tenant_b"
+[[outputs.http]]
+[[outputs.http]]
+  alias = "all"
+  pipelines = ["default", "tenant_b"]
+`)
+	require.NoError(t, c.LoadConfigData(cfg, config.EmptySourcePath))
+	require.Empty(t, c.UnusedFields)
+
+	require.Equal(t, models.DefaultPipeline, c.Inputs[0].Config.Pipeline)
+	require.Equal(t, "tenant_b", c.Inputs[1].Config.Pipeline)
+	require.NotEqual(t, c.Inputs[0].Config.ID, c.Inputs[1].Config.ID)
+	require.Equal(t, "tenant_b", c.Processors[0].Config.Pipeline)
+	require.Equal(t, []string{models.DefaultPipeline}, c.Outputs[0].Config.Pipelines)
+	require.Equal(t, []string{"default", "tenant_b"}, c.Outputs[1].Config.Pipelines)
+	require.Equal(t, []string{"default", "tenant_b"}, c.Pipelines())
+
+	c = config.NewConfig()
+	cfg = []byte(`
+[[inputs.memcached]]
+  pipeline = "tenant b"
+`)
+	require.ErrorContains(t, c.LoadConfigData(cfg, config.EmptySourcePath), `invalid pipeline name "tenant b"`)
+}
+
+func TestConfig_InputSchedule(t *testing.T) {
+	c := config.NewConfig()
+	cfg := []byte(`
+[[inputs.memcached]]
+  servers = ["localhost"]
+  schedule = "0 */6 * * *"
+  schedule_timezone = "UTC"
+  active_windows = ["Mon-Fri 08:00-18:00"]
+  inactive_windows = ["Mon 12:00-13:00"]
+`)
+	require.NoError(t, c.LoadConfigData(cfg, config.EmptySourcePath))
+	require.Len(t, c.Inputs, 1)
+	require.Empty(t, c.UnusedFields)
+
+	input := c.Inputs[0].Config
+	require.NotNil(t, input.Schedule)
+	require.Equal(t, "0 */6 * * *", input.Schedule.String())
+	require.Equal(t, time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC), input.Schedule.Next(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+	require.NotNil(t, input.Windows)
+	require.True(t, input.Windows.Contains(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)))
+	require.False(t, input.Windows.Contains(time.Date(2024, 1, 1, 12, 30, 0, 0, time.UTC)))
+
+	c = config.NewConfig()
+	cfg = []byte(`
+[[inputs.memcached]]
+  schedule = "every hour"
+`)
+	require.ErrorContains(t, c.LoadConfigData(cfg, config.EmptySourcePath), `parsing schedule "every hour" failed`)
+
+	c = config.NewConfig()
+	cfg = []byte(`
+[[inputs.memcached]]
+  active_windows = ["Mon-Fri 8-18"]
+`)
+	require.ErrorContains(t, c.LoadConfigData(cfg, config.EmptySourcePath), `parsing active window "Mon-Fri 8-18" failed`)
+
+	c = config.NewConfig()
+	cfg = []byte(`
+[[inputs.memcached]]
+  schedule = "@hourly"
+  schedule_timezone = "Mars/Olympus"
+`)
+	require.ErrorContains(t, c.LoadConfigData(cfg, config.EmptySourcePath), `invalid schedule_timezone "Mars/Olympus"`)
+}
+
+func TestConfig_Templates(t *testing.T) {
+	c := config.NewConfig()
+	confFile := filepath.Join("testdata", "templates.toml")
+	require.NoError(t, c.LoadConfig(confFile))
+	require.Len(t, c.Inputs, 3)
+
+	expected := []struct {
+		alias   string
+		server  string
+		timeout config.Duration
+	}{
+		{"memcached-cache1", "cache1:11211", config.Duration(5 * time.Second)},
+		{"memcached-cache2", "cache2:11212", config.Duration(10 * time.Second)},
+		{"memcached-cache3", "cache3:11213", config.Duration(time.Second)},
+	}
+	ids := make(map[string]bool, len(c.Inputs))
+	for i, input := range c.Inputs {
+		require.Equal(t, expected[i].alias, input.Config.Alias)
+		require.Equal(t, confFile+` (template "memcached")`, input.Config.Source)
+
+		plugin := input.Input.(*MockupInputPlugin)
+		require.Equal(t, []string{expected[i].server}, plugin.Servers)
+		require.Equal(t, expected[i].timeout, plugin.Timeout)
+
+		ids[input.Config.ID] = true
+	}
+	require.Len(t, ids, 3)
+
+	// The instances must get the same IDs when loading the config again
+	c = config.NewConfig()
+	require.NoError(t, c.LoadConfig(confFile))
+	for _, input := range c.Inputs {
+		require.Contains(t, ids, input.Config.ID)
+	}
+
+	c = config.NewConfig()
+	cfg := []byte(`
+[templates.memcached]
+  plugin = "inputs.memcached"
+  [templates.memcached.config]
+    servers = ["@{host}:@{port}"]
+  [[templates.memcached.instances]]
+    host = "cache1"
+`)
+	require.ErrorContains(t, c.LoadConfigData(cfg, config.EmptySourcePath),
+		`error parsing template "memcached": instance 1: option "servers": missing parameter "port"`)
+
+	c = config.NewConfig()
+	cfg = []byte(`
+[templates.memcached]
+  plugin = "memcached"
+`)
+	require.ErrorContains(t, c.LoadConfigData(cfg, config.EmptySourcePath), `invalid plugin "memcached"`)
+}
+
+func TestConfig_TemplatesInventoryRelativeToConfig(t *testing.T) {
+	confFile, err := filepath.Abs(filepath.Join("testdata", "templates.toml"))
+	require.NoError(t, err)
+
+	// The inventory is found independent of the working directory
+	t.Chdir(t.TempDir())
+	c := config.NewConfig()
+	require.NoError(t, c.LoadConfig(confFile))
+	require.Len(t, c.Inputs, 3)
+	require.Equal(t, "memcached-cache3", c.Inputs[2].Config.Alias)
+}
+
 func TestConfig_LoadSingleInput_WithSeparators(t *testing.T) {
 	c := config.NewConfig()
 	confFile := filepath.Join("testdata", "single_plugin_with_separators.toml")
@@ -203,6 +538,7 @@ func TestConfig_LoadSingleInput_WithSeparators(t *testing.T) {
 		Source:   confFile,
 		Filter:   filter,
 		Interval: 5 * time.Second,
+		Pipeline: models.DefaultPipeline,
 	}
 	inputConfig.Tags = make(map[string]string)
 
@@ -223,6 +559,32 @@ func TestConfig_LoadSingleInput_WithCommentInArray(t *testing.T) {
 	require.ElementsMatch(t, input.Servers, []string{"localhost"})
 }
 
+func TestConfig_WalkDirectoryFormats(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"a.conf",
+		"b.conf.yaml",
+		"c.conf.yml",
+		"d.conf.json",
+		"inventory.json",
+		"values.yaml",
+		".conf",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0600))
+	}
+
+	// Only files with the configuration naming convention are loaded
+	files, err := config.WalkDirectory(dir)
+	require.NoError(t, err)
+	expected := []string{
+		filepath.Join(dir, "a.conf"),
+		filepath.Join(dir, "b.conf.yaml"),
+		filepath.Join(dir, "c.conf.yml"),
+		filepath.Join(dir, "d.conf.json"),
+	}
+	require.Equal(t, expected, files)
+}
+
 func TestConfig_LoadDirectory(t *testing.T) {
 	c := config.NewConfig()
 
@@ -263,6 +625,7 @@ func TestConfig_LoadDirectory(t *testing.T) {
 		Source:   confFile,
 		Filter:   filterMockup,
 		Interval: 5 * time.Second,
+		Pipeline: models.DefaultPipeline,
 	}
 	expectedConfigs[0].Tags = make(map[string]string)
 
@@ -279,6 +642,7 @@ func TestConfig_LoadDirectory(t *testing.T) {
 		Name:              "exec",
 		Source:            filepath.Join("testdata", "subconfig", "exec.conf"), // This is the source of the input
 		MeasurementSuffix: "_myothercollector",
+		Pipeline:          models.DefaultPipeline,
 	}
 	expectedConfigs[1].Tags = make(map[string]string)
 
@@ -309,14 +673,16 @@ func TestConfig_LoadDirectory(t *testing.T) {
 		Source:   filepath.Join("testdata", "subconfig", "memcached.conf"), // This is the source of the input
 		Filter:   filterMemcached,
 		Interval: 5 * time.Second,
+		Pipeline: models.DefaultPipeline,
 	}
 	expectedConfigs[2].Tags = make(map[string]string)
 
 	expectedPlugins[3] = inputs.Inputs["procstat"]().(*MockupInputPlugin)
 	expectedPlugins[3].PidFile = "/var/run/grafana-server.pid"
 	expectedConfigs[3] = &models.InputConfig{
-		Name:   "procstat",
-		Source: filepath.Join("testdata", "subconfig", "procstat.conf"), // This is the source of the input
+		Name:     "procstat",
+		Source:   filepath.Join("testdata", "subconfig", "procstat.conf"), // This is the source of the input
+		Pipeline: models.DefaultPipeline,
 	}
 	expectedConfigs[3].Tags = make(map[string]string)
 
@@ -522,6 +888,70 @@ func TestConfig_TestModeSkipsOutputBuffer(t *testing.T) {
 	require.NotNil(t, output.Serializer)
 }
 
+func TestConfig_OutputGroups(t *testing.T) {
+	c := config.NewConfig()
+	require.NoError(t, c.LoadAll("./testdata/output_groups.toml"))
+	require.Len(t, c.Outputs, 3)
+
+	expected := []*models.OutputGroupConfig{
+		{
+			Name:             "ha",
+			Mode:             "failover",
+			Outputs:          []string{"primary", "secondary"},
+			FailoverAttempts: 5,
+		},
+	}
+	require.Equal(t, expected, c.OutputGroups)
+}
+
+func TestConfig_OutputGroupsInvalid(t *testing.T) {
+	c := config.NewConfig()
+	require.ErrorContains(t, c.LoadAll("./testdata/output_groups_ambiguous.toml"), "is ambiguous")
+
+	c = config.NewConfig()
+	cfg := []byte(`
+[output_groups.shards]
+  mode = "hash"
+  outputs = ["a", "b"]
+  weights = [1]
+`)
+	require.ErrorContains(t, c.LoadConfigData(cfg, config.EmptySourcePath), "number of weights")
+
+	c = config.NewConfig()
+	cfg = []byte(`
+[output_groups.shards]
+  mode = "random"
+  outputs = ["a", "b"]
+`)
+	require.ErrorContains(t, c.LoadConfigData(cfg, config.EmptySourcePath), "invalid mode")
+
+	c = config.NewConfig()
+	cfg = []byte(`
+[[outputs.http]]
+  alias = "a"
+  pipelines = ["default", "tenant_b"]
+[[outputs.http]]
+  alias = "b"
+
+[output_groups.ha]
+  mode = "failover"
+  outputs = ["a", "b"]
+`)
+	require.NoError(t, c.LoadConfigData(cfg, config.EmptySourcePath))
+	require.ErrorContains(t, c.LoadAll(), "subscribe to different pipelines")
+}
+
+func TestConfig_DeadLetterInvalid(t *testing.T) {
+	c := config.NewConfig()
+	require.NoError(t, c.LoadConfigData([]byte(`
+[[outputs.http]]
+  alias = "self"
+  dead_letter = "self"
+`), config.EmptySourcePath))
+	require.Equal(t, "self", c.Outputs[0].Config.DeadLetter)
+	require.ErrorContains(t, c.LoadAll(), "cannot be its own dead-letter output")
+}
+
 func TestConfig_SliceComment(t *testing.T) {
 	c := config.NewConfig()
 	require.NoError(t, c.LoadConfig("./testdata/slice_comment.toml"))
@@ -0,0 +1,448 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/toml/ast"
+	"go.yaml.in/yaml/v3"
+)
+
+// Plugin categories where a single table is accepted as plugin instance
+// instead of a list of instances in YAML and JSON configurations
+var pluginCategories = []string{"inputs", "outputs", "processors", "aggregators", "secretstores"}
+
+var bareKeyRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// DetectFormat returns the format of the configuration file or URL given by
+// path based on its extension, i.e. "yaml", "json" or "toml" as default.
+func DetectFormat(path string) string {
+	if u, err := url.Parse(path); err == nil && u.Scheme != "" && u.Host != "" {
+		path = u.Path
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".json":
+		return "json"
+	}
+	return "toml"
+}
+
+// parseStructuredConfig parses a YAML or JSON configuration by converting it
+// to the equivalent TOML configuration. This way the resulting AST is
+// identical to the one of a TOML file and all option decoding works the same.
+// The line numbers in the AST refer to the original document.
+func parseStructuredConfig(contents []byte, format string) (*ast.Table, error) {
+	var root *yaml.Node
+	var err error
+	switch format {
+	case "yaml":
+		var doc yaml.Node
+		if err := yaml.Unmarshal(trimBOM(contents), &doc); err != nil {
+			return nil, err
+		}
+		root = resolveNode(&doc)
+	case "json":
+		root, err = decodeJSON(trimBOM(contents))
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config format %q", format)
+	}
+
+	e := &tomlEmitter{}
+	if root != nil && root.Kind != 0 {
+		if root.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("line %d: expected a mapping at the top level", root.Line)
+		}
+		if err := e.table(nil, root); err != nil {
+			return nil, err
+		}
+	}
+
+	tbl, err := parseConfig(e.buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	// Map the line numbers of the generated TOML to the original document
+	err = walk(tbl, func(n interface{}) error {
+		switch v := n.(type) {
+		case *ast.Table:
+			v.Line = e.sourceLine(v.Line)
+		case *ast.KeyValue:
+			v.Line = e.sourceLine(v.Line)
+		}
+		return nil
+	})
+	return tbl, err
+}
+
+// tomlEmitter writes a YAML node tree as TOML and keeps track of the line
+// in the YAML document each TOML line originates from.
+type tomlEmitter struct {
+	buf   bytes.Buffer
+	lines []int
+}
+
+func (e *tomlEmitter) line(source int, format string, args ...interface{}) {
+	fmt.Fprintf(&e.buf, format, args...)
+	e.buf.WriteByte('\n')
+	e.lines = append(e.lines, source)
+}
+
+func (e *tomlEmitter) sourceLine(line int) int {
+	if line < 1 || line > len(e.lines) {
+		return line
+	}
+	return e.lines[line-1]
+}
+
+func (e *tomlEmitter) table(path []string, n *yaml.Node) error {
+	pairs, err := mappingPairs(n)
+	if err != nil {
+		return err
+	}
+
+	// TOML requires all values of a table to be defined before any sub-table
+	isCategory := len(path) == 1 && slices.Contains(pluginCategories, path[0])
+	tables := make([][2]*yaml.Node, 0, len(pairs))
+	for _, p := range pairs {
+		key, value := p[0], p[1]
+		if isCategory || isTable(value) || isTableArray(value) {
+			tables = append(tables, p)
+			continue
+		}
+		if isNull(value) {
+			continue
+		}
+		v, err := tomlValue(value)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid value for %q: %w", key.Line, key.Value, err)
+		}
+		e.line(key.Line, "%s = %s", tomlKey(key.Value), v)
+	}
+
+	for _, p := range tables {
+		key, value := p[0], p[1]
+		subpath := append(slices.Clone(path), key.Value)
+		header := make([]string, 0, len(subpath))
+		for _, k := range subpath {
+			header = append(header, tomlKey(k))
+		}
+
+		switch {
+		case value.Kind == yaml.SequenceNode:
+			for _, element := range value.Content {
+				element = resolveNode(element)
+				if element.Kind != yaml.MappingNode {
+					return fmt.Errorf("line %d: expected a mapping for the instances of %q", element.Line, key.Value)
+				}
+				e.line(element.Line, "[[%s]]", strings.Join(header, "."))
+				if err := e.table(subpath, element); err != nil {
+					return err
+				}
+			}
+		case isCategory:
+			// Accept a single plugin instance without wrapping it in a list
+			if !isTable(value) && !isNull(value) {
+				return fmt.Errorf("line %d: expected a mapping or list of mappings for plugin %q", key.Line, key.Value)
+			}
+			e.line(key.Line, "[[%s]]", strings.Join(header, "."))
+			if isTable(value) {
+				if err := e.table(subpath, value); err != nil {
+					return err
+				}
+			}
+		default:
+			e.line(key.Line, "[%s]", strings.Join(header, "."))
+			if err := e.table(subpath, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// mappingPairs returns the key-value pairs of a mapping node including the
+// pairs of merged mappings ('<<' keys). Explicitly specified keys take
+// precedence over merged ones.
+func mappingPairs(n *yaml.Node) ([][2]*yaml.Node, error) {
+	var explicit, merged [][2]*yaml.Node
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], resolveNode(n.Content[i+1])
+		if key.ShortTag() != "!!merge" {
+			explicit = append(explicit, [2]*yaml.Node{key, value})
+			continue
+		}
+
+		sources := []*yaml.Node{value}
+		if value.Kind == yaml.SequenceNode {
+			sources = value.Content
+		}
+		for _, source := range sources {
+			source = resolveNode(source)
+			if source.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("line %d: merged value must be a mapping", source.Line)
+			}
+			pairs, err := mappingPairs(source)
+			if err != nil {
+				return nil, err
+			}
+			merged = append(merged, pairs...)
+		}
+	}
+
+	seen := make(map[string]bool, len(explicit)+len(merged))
+	for _, p := range explicit {
+		if seen[p[0].Value] {
+			return nil, fmt.Errorf("line %d: duplicate key %q", p[0].Line, p[0].Value)
+		}
+		seen[p[0].Value] = true
+	}
+	pairs := make([][2]*yaml.Node, 0, len(explicit)+len(merged))
+	for _, p := range merged {
+		if !seen[p[0].Value] {
+			seen[p[0].Value] = true
+			pairs = append(pairs, p)
+		}
+	}
+	return append(pairs, explicit...), nil
+}
+
+func resolveNode(n *yaml.Node) *yaml.Node {
+	for n != nil {
+		switch n.Kind {
+		case yaml.DocumentNode:
+			if len(n.Content) == 0 {
+				return nil
+			}
+			n = n.Content[0]
+		case yaml.AliasNode:
+			n = n.Alias
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+func isNull(n *yaml.Node) bool {
+	return n.Kind == yaml.ScalarNode && n.ShortTag() == "!!null"
+}
+
+func isTable(n *yaml.Node) bool {
+	return n.Kind == yaml.MappingNode
+}
+
+func isTableArray(n *yaml.Node) bool {
+	if n.Kind != yaml.SequenceNode || len(n.Content) == 0 {
+		return false
+	}
+	for _, element := range n.Content {
+		if resolveNode(element).Kind != yaml.MappingNode {
+			return false
+		}
+	}
+	return true
+}
+
+func tomlValue(n *yaml.Node) (string, error) {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		switch n.ShortTag() {
+		case "!!null":
+			return "", errors.New("null values are not supported")
+		case "!!bool":
+			var v bool
+			if err := n.Decode(&v); err != nil {
+				return "", err
+			}
+			return strconv.FormatBool(v), nil
+		case "!!int":
+			var v int64
+			if err := n.Decode(&v); err != nil {
+				return "", err
+			}
+			return strconv.FormatInt(v, 10), nil
+		case "!!float":
+			var v float64
+			if err := n.Decode(&v); err != nil {
+				return "", err
+			}
+			switch {
+			case math.IsNaN(v):
+				return "nan", nil
+			case math.IsInf(v, 1):
+				return "inf", nil
+			case math.IsInf(v, -1):
+				return "-inf", nil
+			}
+			s := strconv.FormatFloat(v, 'g', -1, 64)
+			if !strings.ContainsAny(s, ".e") {
+				s += ".0"
+			}
+			return s, nil
+		}
+		return tomlString(n.Value), nil
+	case yaml.SequenceNode:
+		elements := make([]string, 0, len(n.Content))
+		for _, element := range n.Content {
+			v, err := tomlValue(resolveNode(element))
+			if err != nil {
+				return "", err
+			}
+			elements = append(elements, v)
+		}
+		return "[" + strings.Join(elements, ", ") + "]", nil
+	case yaml.MappingNode:
+		pairs, err := mappingPairs(n)
+		if err != nil {
+			return "", err
+		}
+		fields := make([]string, 0, len(pairs))
+		for _, p := range pairs {
+			if isNull(p[1]) {
+				continue
+			}
+			v, err := tomlValue(p[1])
+			if err != nil {
+				return "", err
+			}
+			fields = append(fields, tomlKey(p[0].Value)+" = "+v)
+		}
+		return "{" + strings.Join(fields, ", ") + "}", nil
+	}
+	return "", fmt.Errorf("unexpected node kind %v", n.Kind)
+}
+
+func tomlKey(key string) string {
+	if bareKeyRe.MatchString(key) {
+		return key
+	}
+	return tomlString(key)
+}
+
+// tomlString returns the given string as TOML basic string
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// decodeJSON parses a JSON document into a YAML node tree keeping the order
+// of the keys and the line numbers.
+func decodeJSON(contents []byte) (*yaml.Node, error) {
+	dec := json.NewDecoder(bytes.NewReader(contents))
+	dec.UseNumber()
+
+	// The decoder reports the offset after the previous token so skip the
+	// separators to get the line of the next token
+	nextLine := func() int {
+		offset := int(dec.InputOffset())
+		for offset < len(contents) && strings.IndexByte(" \t\r\n,:", contents[offset]) >= 0 {
+			offset++
+		}
+		return bytes.Count(contents[:offset], []byte("\n")) + 1
+	}
+
+	var decode func() (*yaml.Node, error)
+	decode = func() (*yaml.Node, error) {
+		line := nextLine()
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case json.Delim:
+			n := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Line: line}
+			if t == '{' {
+				n.Kind, n.Tag = yaml.MappingNode, "!!map"
+			}
+			for dec.More() {
+				if n.Kind == yaml.MappingNode {
+					keyLine := nextLine()
+					key, err := dec.Token()
+					if err != nil {
+						return nil, err
+					}
+					n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.(string), Line: keyLine})
+				}
+				element, err := decode()
+				if err != nil {
+					return nil, err
+				}
+				n.Content = append(n.Content, element)
+			}
+			// Consume the closing delimiter
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return n, nil
+		case string:
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: t, Line: line}, nil
+		case json.Number:
+			tag := "!!int"
+			if strings.ContainsAny(t.String(), ".eE") {
+				tag = "!!float"
+			}
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: t.String(), Line: line}, nil
+		case bool:
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(t), Line: line}, nil
+		case nil:
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null", Line: line}, nil
+		}
+		return nil, fmt.Errorf("unexpected token %v", token)
+	}
+
+	root, err := decode()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("line %d: unexpected data after the top-level value", nextLine())
+	}
+	return root, nil
+}
@@ -10,6 +10,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/influxdata/toml"
+	"github.com/influxdata/toml/ast"
 	"github.com/stretchr/testify/require"
 )
 
@@ -409,3 +411,18 @@ func TestURLRetries3FailsThenPasses(t *testing.T) {
 	require.NoError(t, c.LoadConfig(ts.URL))
 	require.Equal(t, 4, responseCounter)
 }
+
+func TestTemplateInstantiateString(t *testing.T) {
+	params := map[string]string{"value": "a\"b\\c\td\x7fe\af\u00e4"}
+	v, err := instantiateValue(&ast.String{Value: "@{value}"}, params)
+	require.NoError(t, err)
+
+	// The raw data must be a valid TOML string with the same value
+	table, err := toml.Parse([]byte("key = " + v.Source()))
+	require.NoError(t, err)
+	kv, ok := table.Fields["key"].(*ast.KeyValue)
+	require.True(t, ok)
+	parsed, ok := kv.Value.(*ast.String)
+	require.True(t, ok)
+	require.Equal(t, params["value"], parsed.Value)
+}
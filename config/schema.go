@@ -0,0 +1,812 @@
+package config
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/influxdata/toml"
+	"github.com/influxdata/toml/ast"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+const schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+var (
+	durationType             = reflect.TypeOf(Duration(0))
+	sizeType                 = reflect.TypeOf(Size(0))
+	secretType               = reflect.TypeOf(Secret{})
+	timeDurationType         = reflect.TypeOf(time.Duration(0))
+	tomlUnmarshalerType      = reflect.TypeOf((*toml.Unmarshaler)(nil)).Elem()
+	tomlUnmarshalerRecType   = reflect.TypeOf((*toml.UnmarshalerRec)(nil)).Elem()
+	textUnmarshalerType      = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	parserPluginType         = reflect.TypeOf((*telegraf.ParserPlugin)(nil)).Elem()
+	parserFuncPluginType     = reflect.TypeOf((*telegraf.ParserFuncPlugin)(nil)).Elem()
+	serializerPluginType     = reflect.TypeOf((*telegraf.SerializerPlugin)(nil)).Elem()
+	serializerFuncPluginType = reflect.TypeOf((*telegraf.SerializerFuncPlugin)(nil)).Elem()
+)
+
+// Schema is a JSON Schema describing the configuration or parts of it. Only
+// the subset of JSON Schema required to describe the configuration is
+// supported. Closed schemas do not accept any options not evaluated by the
+// schema itself or the schemas it refers to.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 []string           `json:"type,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Deprecated           bool               `json:"deprecated,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+
+	closed bool
+}
+
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	type plain Schema
+	if !s.closed {
+		return json.Marshal((*plain)(s))
+	}
+	return json.Marshal(struct {
+		*plain
+		UnevaluatedProperties bool `json:"unevaluatedProperties"`
+	}{plain: (*plain)(s)})
+}
+
+func typed(types ...string) *Schema {
+	return &Schema{Type: types}
+}
+
+func stringMap() *Schema {
+	return &Schema{Type: []string{"object"}, AdditionalProperties: typed("string")}
+}
+
+func ref(name string) *Schema {
+	return &Schema{Ref: "#/$defs/" + name}
+}
+
+// Options parsed by the config loader for all plugins of a category in
+// addition to the options of the plugin itself
+var (
+	filterOptions = map[string]*Schema{
+		"namepass":           {Type: []string{"array"}, Items: typed("string")},
+		"namepass_separator": typed("string"),
+		"namedrop":           {Type: []string{"array"}, Items: typed("string")},
+		"namedrop_separator": typed("string"),
+		"pass":               {Type: []string{"array"}, Items: typed("string"), Deprecated: true},
+		"drop":               {Type: []string{"array"}, Items: typed("string"), Deprecated: true},
+		"fieldpass":          {Type: []string{"array"}, Items: typed("string"), Deprecated: true},
+		"fielddrop":          {Type: []string{"array"}, Items: typed("string"), Deprecated: true},
+		"fieldinclude":       {Type: []string{"array"}, Items: typed("string")},
+		"fieldexclude":       {Type: []string{"array"}, Items: typed("string")},
+		"tagpass":            {Type: []string{"object"}, AdditionalProperties: &Schema{Type: []string{"array"}, Items: typed("string")}},
+		"tagdrop":            {Type: []string{"object"}, AdditionalProperties: &Schema{Type: []string{"array"}, Items: typed("string")}},
+		"taginclude":         {Type: []string{"array"}, Items: typed("string")},
+		"tagexclude":         {Type: []string{"array"}, Items: typed("string")},
+		"metricpass":         typed("string"),
+	}
+	pluginOptions = map[string]*Schema{
+		"alias":     typed("string"),
+		"log_level": typed("string"),
+		"labels":    stringMap(),
+	}
+	inputOptions = map[string]*Schema{
+		"interval":               typed("string", "integer", "number"),
+		"precision":              typed("string", "integer", "number"),
+		"collection_jitter":      typed("string", "integer", "number"),
+		"collection_offset":      typed("string", "integer", "number"),
+		"startup_error_behavior": {Type: []string{"string"}, Enum: []string{"error", "retry", "ignore", "probe"}},
+		"time_source":            {Type: []string{"string"}, Enum: []string{"metric", "collection_start", "collection_end"}},
+		"schedule":               typed("string"),
+		"schedule_timezone":      typed("string"),
+		"active_windows":         {Type: []string{"array"}, Items: typed("string")},
+		"inactive_windows":       {Type: []string{"array"}, Items: typed("string")},
+		"max_series":             typed("integer"),
+		"series_limit_policy":    typed("string"),
+		"series_keep_tags":       {Type: []string{"array"}, Items: typed("string")},
+		"series_sample_rate":     typed("integer"),
+		"series_ttl":             typed("string", "integer", "number"),
+		"name_prefix":            typed("string"),
+		"name_suffix":            typed("string"),
+		"name_override":          typed("string"),
+		"tags":                   stringMap(),
+		"pipeline":               typed("string"),
+	}
+	outputOptions = map[string]*Schema{
+		"flush_interval":                typed("string", "integer", "number"),
+		"flush_jitter":                  typed("string", "integer", "number"),
+		"metric_buffer_limit":           typed("integer"),
+		"metric_batch_size":             typed("integer"),
+		"name_prefix":                   typed("string"),
+		"name_suffix":                   typed("string"),
+		"name_override":                 typed("string"),
+		"startup_error_behavior":        {Type: []string{"string"}, Enum: []string{"error", "retry", "ignore"}},
+		"metric_rate_limit":             typed("integer"),
+		"byte_rate_limit":               typed("string", "integer"),
+		"rate_limit_burst":              typed("string", "integer", "number"),
+		"retry_backoff_initial":         typed("string", "integer", "number"),
+		"retry_backoff_max":             typed("string", "integer", "number"),
+		"retry_backoff_jitter":          typed("string", "integer", "number"),
+		"circuit_breaker_threshold":     typed("integer"),
+		"circuit_breaker_reset_timeout": typed("string", "integer", "number"),
+		"dead_letter":                   typed("string"),
+		"pipelines":                     {Type: []string{"array"}, Items: typed("string")},
+	}
+	processorOptions = map[string]*Schema{
+		"order":    typed("integer"),
+		"pipeline": typed("string"),
+	}
+	aggregatorOptions = map[string]*Schema{
+		"period":        typed("string", "integer", "number"),
+		"delay":         typed("string", "integer", "number"),
+		"grace":         typed("string", "integer", "number"),
+		"drop_original": typed("boolean"),
+		"name_prefix":   typed("string"),
+		"name_suffix":   typed("string"),
+		"name_override": typed("string"),
+		"tags":          stringMap(),
+		"pipeline":      typed("string"),
+	}
+	secretStoreOptions = map[string]*Schema{
+		"id": typed("string"),
+	}
+	// Options accepted for plugins of all categories without being used by
+	// the plugin, e.g. agent settings or data format options of plugins
+	// without parser or serializer
+	ignoredOptions = map[string]*Schema{
+		"always_include_local_tags": typed("boolean"),
+		"buffer_strategy":           typed("string"),
+		"buffer_directory":          typed("string"),
+		"buffer_disk_sync":          typed("boolean"),
+		"data_format":               typed("string"),
+		"data_type":                 typed("string"),
+		"influx_parser_type":        typed("string"),
+		"lvm":                       {},
+	}
+)
+
+// loaderOptions are all options parsed by the config loader itself instead
+// of the plugins. The loader does not report these options as unused.
+var loaderOptions = []map[string]*Schema{
+	filterOptions,
+	pluginOptions,
+	inputOptions,
+	outputOptions,
+	processorOptions,
+	aggregatorOptions,
+	secretStoreOptions,
+	ignoredOptions,
+}
+
+func isLoaderOption(key string) bool {
+	for _, options := range loaderOptions {
+		if _, found := options[key]; found {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateSchema returns the JSON Schema of the configuration covering the
+// agent settings and all registered plugins. The schema of the plugins is
+// derived from their structures including the default values of a new
+// plugin instance and the deprecated options.
+func GenerateSchema() *Schema {
+	root := &Schema{
+		Schema:     schemaDialect,
+		Title:      "Telegraf configuration",
+		Type:       []string{"object"},
+		Properties: make(map[string]*Schema),
+		Defs:       make(map[string]*Schema),
+		closed:     true,
+	}
+
+	agent := schemaOf(reflect.ValueOf(NewConfig().Agent))
+	agent.Title = "Agent settings"
+	root.Properties["agent"] = agent
+	root.Properties["global_tags"] = stringMap()
+	root.Properties["tags"] = stringMap()
+	root.Properties["output_groups"] = &Schema{
+		Type:                 []string{"object"},
+		AdditionalProperties: schemaOf(reflect.ValueOf(&models.OutputGroupConfig{})),
+	}
+	root.Properties["templates"] = &Schema{
+		Type: []string{"object"},
+		AdditionalProperties: &Schema{
+			Type: []string{"object"},
+			Properties: map[string]*Schema{
+				"plugin":     typed("string"),
+				"inventory":  typed("string"),
+				"parameters": {Type: []string{"object"}, AdditionalProperties: typed("string", "integer", "number", "boolean")},
+				"config":     typed("object"),
+				"instances": {
+					Type:  []string{"array"},
+					Items: &Schema{Type: []string{"object"}, AdditionalProperties: typed("string", "integer", "number", "boolean")},
+				},
+			},
+			closed: true,
+		},
+	}
+
+	// Options of the data formats shared by all plugins using a parser or
+	// serializer
+	root.Defs["parser_options"] = formatSchema(parsers.Parsers, func(name string) interface{} {
+		return parsers.Parsers[name]("")
+	})
+	root.Defs["parser_options"].Properties["influx_parser_type"] = &Schema{Type: []string{"string"}, Enum: []string{"internal", "upstream"}}
+	root.Defs["serializer_options"] = formatSchema(serializers.Serializers, func(name string) interface{} {
+		return serializers.Serializers[name]()
+	})
+	root.Defs["ignored_options"] = &Schema{
+		Type:        []string{"object"},
+		Description: "Options accepted for all plugins without effect",
+		Properties:  ignoredOptions,
+	}
+
+	categories := []struct {
+		name         string
+		options      []map[string]*Schema
+		names        []string
+		create       func(string) interface{}
+		deprecations map[string]telegraf.DeprecationInfo
+		single       bool
+	}{
+		{
+			name:         "inputs",
+			options:      []map[string]*Schema{pluginOptions, filterOptions, inputOptions},
+			names:        sortedKeys(inputs.Inputs),
+			create:       func(name string) interface{} { return inputs.Inputs[name]() },
+			deprecations: inputs.Deprecations,
+			single:       true,
+		},
+		{
+			name:         "outputs",
+			options:      []map[string]*Schema{pluginOptions, filterOptions, outputOptions},
+			names:        sortedKeys(outputs.Outputs),
+			create:       func(name string) interface{} { return outputs.Outputs[name]() },
+			deprecations: outputs.Deprecations,
+			single:       true,
+		},
+		{
+			name:    "processors",
+			options: []map[string]*Schema{pluginOptions, filterOptions, processorOptions},
+			names:   sortedKeys(processors.Processors),
+			create: func(name string) interface{} {
+				processor := processors.Processors[name]()
+				if p, ok := processor.(processors.HasUnwrap); ok {
+					return p.Unwrap()
+				}
+				return processor
+			},
+			deprecations: processors.Deprecations,
+		},
+		{
+			name:         "aggregators",
+			options:      []map[string]*Schema{pluginOptions, filterOptions, aggregatorOptions},
+			names:        sortedKeys(aggregators.Aggregators),
+			create:       func(name string) interface{} { return aggregators.Aggregators[name]() },
+			deprecations: aggregators.Deprecations,
+		},
+		{
+			name:         "secretstores",
+			options:      []map[string]*Schema{secretStoreOptions},
+			names:        sortedKeys(secretstores.SecretStores),
+			create:       func(name string) interface{} { return secretstores.SecretStores[name]("") },
+			deprecations: secretstores.Deprecations,
+		},
+	}
+	for _, category := range categories {
+		common := &Schema{Type: []string{"object"}, Properties: make(map[string]*Schema)}
+		for _, options := range category.options {
+			for k, v := range options {
+				common.Properties[k] = v
+			}
+		}
+		root.Defs["common_"+category.name] = common
+
+		section := &Schema{
+			Type:       []string{"object"},
+			Properties: make(map[string]*Schema, len(category.names)),
+			closed:     true,
+		}
+		for _, name := range category.names {
+			id := category.name + "." + name
+			plugin := category.create(name)
+			s := schemaOf(reflect.ValueOf(plugin))
+			s.Title = id
+			s.AllOf = append(s.AllOf, ref("common_"+category.name))
+
+			pt := reflect.TypeOf(plugin)
+			if pt.Implements(parserPluginType) || pt.Implements(parserFuncPluginType) {
+				s.AllOf = append(s.AllOf, ref("parser_options"))
+			}
+			if pt.Implements(serializerPluginType) || pt.Implements(serializerFuncPluginType) {
+				s.AllOf = append(s.AllOf, ref("serializer_options"))
+			}
+			// Refer to the ignored options last so the typed options of the
+			// data formats take precedence
+			s.AllOf = append(s.AllOf, ref("ignored_options"))
+			if di, found := category.deprecations[name]; found {
+				s.Deprecated = true
+				s.Description = deprecationDescription(di)
+			}
+			root.Defs[id] = s
+
+			instances := &Schema{Type: []string{"array"}, Items: ref(id)}
+			if category.single {
+				section.Properties[name] = &Schema{AnyOf: []*Schema{instances, ref(id)}}
+			} else {
+				section.Properties[name] = instances
+			}
+		}
+		root.Properties[category.name] = section
+	}
+	// Legacy name of the inputs section
+	root.Properties["plugins"] = root.Properties["inputs"]
+
+	return root
+}
+
+func sortedKeys[M ~map[string]V, V any](m M) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func deprecationDescription(di telegraf.DeprecationInfo) string {
+	description := "Deprecated since " + di.Since
+	if di.RemovalIn != "" {
+		description += " and will be removed in " + di.RemovalIn
+	}
+	if di.Notice != "" {
+		description += ": " + di.Notice
+	}
+	return description
+}
+
+// formatSchema returns the union of the options of all registered parsers or
+// serializers. Options of different types in different formats accept any
+// value.
+func formatSchema[M ~map[string]V, V any](registry M, create func(string) interface{}) *Schema {
+	names := sortedKeys(registry)
+	s := &Schema{
+		Type: []string{"object"},
+		Properties: map[string]*Schema{
+			"data_format": {Type: []string{"string"}, Enum: names},
+		},
+	}
+	for _, name := range names {
+		format := schemaOf(reflect.ValueOf(create(name)))
+		for k, option := range format.Properties {
+			// Defaults are specific to the format
+			option.Default = nil
+			if existing, found := s.Properties[k]; found && !slices.Equal(existing.Type, option.Type) {
+				s.Properties[k] = &Schema{}
+				continue
+			}
+			s.Properties[k] = option
+		}
+	}
+	return s
+}
+
+// schemaOf returns the schema of the given value with the values of the
+// options as defaults.
+func schemaOf(v reflect.Value) *Schema {
+	g := &schemaGenerator{visiting: make(map[reflect.Type]bool)}
+	if s := g.value(v.Type(), v); s != nil {
+		return s
+	}
+	return &Schema{}
+}
+
+type schemaGenerator struct {
+	visiting map[reflect.Type]bool
+}
+
+// value returns the schema of the given type using the value, if valid, to
+// determine the defaults. Types which cannot be configured return nil.
+func (g *schemaGenerator) value(t reflect.Type, v reflect.Value) *Schema {
+	set := v.IsValid() && !v.IsZero()
+
+	switch t {
+	case durationType, timeDurationType:
+		s := typed("string", "integer", "number")
+		if set {
+			s.Default = time.Duration(v.Int()).String()
+		}
+		return s
+	case sizeType:
+		s := typed("string", "integer")
+		if set {
+			s.Default = v.Int()
+		}
+		return s
+	case secretType:
+		return typed("string")
+	}
+
+	if t.Kind() == reflect.Pointer {
+		var elem reflect.Value
+		if v.IsValid() && !v.IsNil() {
+			elem = v.Elem()
+		}
+		return g.value(t.Elem(), elem)
+	}
+
+	// Types with custom decoding might accept any value
+	pt := reflect.PointerTo(t)
+	if pt.Implements(tomlUnmarshalerType) || pt.Implements(tomlUnmarshalerRecType) || pt.Implements(textUnmarshalerType) {
+		return &Schema{}
+	}
+
+	var s *Schema
+	switch t.Kind() {
+	case reflect.Bool:
+		s = typed("boolean")
+		if set {
+			s.Default = v.Bool()
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s = typed("integer")
+		if set {
+			s.Default = v.Int()
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s = typed("integer")
+		if set {
+			s.Default = v.Uint()
+		}
+	case reflect.Float32, reflect.Float64:
+		s = typed("number")
+		if set {
+			s.Default = v.Float()
+		}
+	case reflect.String:
+		s = typed("string")
+		if set {
+			s.Default = v.String()
+		}
+	case reflect.Slice, reflect.Array:
+		s = &Schema{Type: []string{"array"}, Items: g.element(t.Elem())}
+		if set && v.Len() > 0 {
+			var defaults []interface{}
+			for i := range v.Len() {
+				d := g.value(t.Elem(), v.Index(i))
+				if d == nil || d.Default == nil {
+					defaults = nil
+					break
+				}
+				defaults = append(defaults, d.Default)
+			}
+			if len(defaults) > 0 {
+				s.Default = defaults
+			}
+		}
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil
+		}
+		s = &Schema{Type: []string{"object"}, AdditionalProperties: g.element(t.Elem())}
+	case reflect.Struct:
+		if g.visiting[t] {
+			return typed("object")
+		}
+		g.visiting[t] = true
+		defer delete(g.visiting, t)
+
+		s = &Schema{Type: []string{"object"}, Properties: make(map[string]*Schema), closed: true}
+		g.fields(s, t, v)
+	}
+	return s
+}
+
+// element returns the schema of the elements of collections accepting any
+// value for types not representable in the schema.
+func (g *schemaGenerator) element(t reflect.Type) *Schema {
+	if s := g.value(t, reflect.Value{}); s != nil {
+		return s
+	}
+	return &Schema{}
+}
+
+// fields adds the options of the struct fields to the given schema with the
+// options of embedded structures being flattened the same way the decoder
+// does.
+func (g *schemaGenerator) fields(s *Schema, t reflect.Type, v reflect.Value) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("toml"), ",")
+		if name == "-" {
+			continue
+		}
+
+		var fv reflect.Value
+		if v.IsValid() {
+			fv = v.Field(i)
+		}
+
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+				if fv.IsValid() && !fv.IsNil() {
+					fv = fv.Elem()
+				} else {
+					fv = reflect.Value{}
+				}
+			}
+			if ft.Kind() == reflect.Struct {
+				g.fields(s, ft, fv)
+				continue
+			}
+		}
+		if !field.IsExported() || field.Type.Kind() == reflect.Interface {
+			continue
+		}
+		if name == "" {
+			name = toml.DefaultConfig.FieldToKey(t, field.Name)
+		}
+
+		option := g.value(field.Type, fv)
+		if option == nil {
+			continue
+		}
+		if tag := field.Tag.Get("deprecated"); tag != "" {
+			parts := strings.SplitN(tag, ";", 3)
+			di := telegraf.DeprecationInfo{Since: parts[0]}
+			if len(parts) > 1 {
+				di.Notice = parts[len(parts)-1]
+			}
+			if len(parts) > 2 {
+				di.RemovalIn = parts[1]
+			}
+			option.Deprecated = true
+			option.Description = deprecationDescription(di)
+		}
+		s.Properties[name] = option
+	}
+}
+
+// SchemaViolation describes a part of a configuration not matching the schema
+type SchemaViolation struct {
+	Source  string
+	Line    int
+	Path    string
+	Message string
+}
+
+func (v *SchemaViolation) Error() string {
+	return fmt.Sprintf("%s:%d: %s: %s", v.Source, v.Line, v.Path, v.Message)
+}
+
+// ValidateSchema checks the configuration data against the given schema and
+// returns the violations found. The format of the data is determined by the
+// given path as for loading the configuration.
+func ValidateSchema(schema *Schema, data []byte, path string) ([]*SchemaViolation, error) {
+	var tbl *ast.Table
+	var err error
+	if format := DetectFormat(path); format != "toml" {
+		tbl, err = parseStructuredConfig(data, format)
+	} else {
+		tbl, err = parseConfig(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing data: %w", err)
+	}
+
+	sv := &schemaValidator{root: schema, source: path}
+	sv.validate(schema, "", tbl.Line, tbl)
+	sort.SliceStable(sv.violations, func(i, j int) bool {
+		return sv.violations[i].Line < sv.violations[j].Line
+	})
+	return sv.violations, nil
+}
+
+type schemaValidator struct {
+	root       *Schema
+	source     string
+	violations []*SchemaViolation
+}
+
+func (sv *schemaValidator) report(line int, path, format string, args ...interface{}) {
+	sv.violations = append(sv.violations, &SchemaViolation{
+		Source:  sv.source,
+		Line:    line,
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (sv *schemaValidator) resolve(s *Schema) *Schema {
+	for s.Ref != "" {
+		def, found := sv.root.Defs[strings.TrimPrefix(s.Ref, "#/$defs/")]
+		if !found {
+			return &Schema{}
+		}
+		s = def
+	}
+	return s
+}
+
+func (sv *schemaValidator) validate(s *Schema, path string, line int, node interface{}) {
+	s = sv.resolve(s)
+
+	kind := nodeKind(node)
+
+	// Report the violations of the alternative matching the type of the
+	// node with the fewest violations
+	if len(s.AnyOf) > 0 {
+		var best []*SchemaViolation
+		var types []string
+		matched := false
+		for _, alternative := range s.AnyOf {
+			alternative = sv.resolve(alternative)
+			if !typeMatches(alternative.Type, kind) {
+				types = append(types, alternative.Type...)
+				continue
+			}
+			sub := &schemaValidator{root: sv.root, source: sv.source}
+			sub.validate(alternative, path, line, node)
+			if len(sub.violations) == 0 {
+				return
+			}
+			if !matched || len(sub.violations) < len(best) {
+				best = sub.violations
+			}
+			matched = true
+		}
+		if !matched {
+			sv.report(line, path, "expected %s but got %s", strings.Join(types, " or "), kind)
+			return
+		}
+		sv.violations = append(sv.violations, best...)
+		return
+	}
+
+	if !typeMatches(s.Type, kind) {
+		sv.report(line, path, "expected %s but got %s", strings.Join(s.Type, " or "), kind)
+		return
+	}
+	if str, ok := node.(*ast.String); ok && len(s.Enum) > 0 && !slices.Contains(s.Enum, str.Value) {
+		sv.report(line, path, "invalid value %q, expected one of %q", str.Value, s.Enum)
+		return
+	}
+
+	switch n := node.(type) {
+	case *ast.Table:
+		sv.validateTable(s, path, n)
+	case []*ast.Table:
+		if s.Items != nil {
+			for i, t := range n {
+				sv.validate(s.Items, fmt.Sprintf("%s[%d]", path, i), t.Line, t)
+			}
+		}
+	case *ast.Array:
+		if s.Items != nil {
+			for i, element := range n.Value {
+				sv.validate(s.Items, fmt.Sprintf("%s[%d]", path, i), line, element)
+			}
+		}
+	}
+}
+
+func (sv *schemaValidator) validateTable(s *Schema, path string, tbl *ast.Table) {
+	keys := make([]string, 0, len(tbl.Fields))
+	for k := range tbl.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var node interface{}
+		line := tbl.Line
+		switch field := tbl.Fields[key].(type) {
+		case *ast.KeyValue:
+			node, line = field.Value, field.Line
+		case *ast.Table:
+			node, line = field, field.Line
+		case []*ast.Table:
+			node = field
+			if len(field) > 0 {
+				line = field[0].Line
+			}
+		default:
+			node = field
+		}
+
+		optionPath := key
+		if path != "" {
+			optionPath = path + "." + key
+		}
+
+		option := sv.property(s, key)
+		if option == nil {
+			if s.closed {
+				what := "option"
+				if slices.Contains(pluginCategories, path) {
+					what = "plugin"
+				}
+				sv.report(line, optionPath, "unknown %s %q", what, key)
+				continue
+			}
+			if s.AdditionalProperties == nil {
+				continue
+			}
+			option = s.AdditionalProperties
+		}
+		sv.validate(option, optionPath, line, node)
+	}
+}
+
+// property returns the schema of the given option in the schema itself or
+// the schemas it refers to. Options are also matched in their normalized form
+// the same way the decoder matches options without explicit name.
+func (sv *schemaValidator) property(s *Schema, key string) *Schema {
+	if option, found := s.Properties[key]; found {
+		return option
+	}
+	normalized := toml.DefaultConfig.NormFieldName(nil, key)
+	for name, option := range s.Properties {
+		if toml.DefaultConfig.NormFieldName(nil, name) == normalized {
+			return option
+		}
+	}
+	for _, sub := range s.AllOf {
+		if option := sv.property(sv.resolve(sub), key); option != nil {
+			return option
+		}
+	}
+	return nil
+}
+
+// typeMatches checks if the given kind of node is accepted by the types of a
+// schema with integers being valid numbers.
+func typeMatches(types []string, kind string) bool {
+	if len(types) == 0 || slices.Contains(types, kind) {
+		return true
+	}
+	return kind == "integer" && slices.Contains(types, "number")
+}
+
+func nodeKind(node interface{}) string {
+	switch node.(type) {
+	case *ast.Table:
+		return "object"
+	case []*ast.Table, *ast.Array:
+		return "array"
+	case *ast.String, *ast.Datetime:
+		return "string"
+	case *ast.Integer:
+		return "integer"
+	case *ast.Float:
+		return "number"
+	case *ast.Boolean:
+		return "boolean"
+	}
+	return fmt.Sprintf("%T", node)
+}
@@ -45,6 +45,13 @@ func ResetSecrets() {
 	secretCount.Store(0)
 }
 
+// ResetUnlinkedSecrets clears the list of secrets to link but keeps the number
+// of secrets in use. Use this function instead of ResetSecrets when loading a
+// configuration while the plugins of the running agent still hold secrets.
+func ResetUnlinkedSecrets() {
+	unlinkedSecrets = make([]*Secret, 0)
+}
+
 func EnableSecretProtection() {
 	selectedImpl = &protectedSecretImpl{}
 }
@@ -376,6 +376,41 @@ func TestSecretCount(t *testing.T) {
 	require.Equal(t, int64(0), secretCount.Load())
 }
 
+func TestSecretResetUnlinked(t *testing.T) {
+	secretCount.Store(0)
+	cfg := []byte(`
+[[inputs.mockup]]
+  secret = "@{mock:secret1}"
+`)
+
+	store := &MockupSecretStore{Secrets: map[string][]byte{"secret1": []byte("Ood Bnar")}}
+	require.NoError(t, store.Init())
+
+	c := NewConfig()
+	require.NoError(t, c.LoadConfigData(cfg, EmptySourcePath))
+	c.SecretStores["mock"] = store
+	require.NoError(t, c.LinkSecrets())
+	require.Equal(t, int64(1), secretCount.Load())
+
+	// Loading another configuration must not touch the secrets of the first
+	// one and only link its own secrets
+	ResetUnlinkedSecrets()
+	cfg = []byte(`
+[[inputs.mockup]]
+  secret = "a secret"
+`)
+	reloaded := NewConfig()
+	require.NoError(t, reloaded.LoadConfigData(cfg, EmptySourcePath))
+	require.NoError(t, reloaded.LinkSecrets())
+	require.Equal(t, int64(2), secretCount.Load())
+
+	plugin := c.Inputs[0].Input.(*MockupSecretPlugin)
+	secret, err := plugin.Secret.Get()
+	require.NoError(t, err)
+	require.Equal(t, "Ood Bnar", secret.TemporaryString())
+	secret.Destroy()
+}
+
 func TestSecretStoreStatic(t *testing.T) {
 	cfg := []byte(
 		`
@@ -0,0 +1,343 @@
+package config
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/toml/ast"
+)
+
+// templatePlaceholder matches the parameter placeholders like '@{host}' in the
+// string values of plugin templates
+var templatePlaceholder = regexp.MustCompile(`@\{([a-zA-Z0-9_]+)\}`)
+
+// templateInstance is a set of parameters to instantiate a template with
+type templateInstance struct {
+	origin string
+	params map[string]string
+}
+
+// pluginTemplate is a plugin configuration containing parameter placeholders
+// which is instantiated once for each set of parameters given inline or in
+// an inventory file.
+type pluginTemplate struct {
+	category  string
+	plugin    string
+	defaults  map[string]string
+	config    *ast.Table
+	instances []templateInstance
+}
+
+// parseTemplate parses the given template table. Relative inventory paths are
+// resolved against the given directory if not empty.
+func parseTemplate(table *ast.Table, dir string) (*pluginTemplate, error) {
+	tmpl := &pluginTemplate{
+		defaults: make(map[string]string),
+		config:   &ast.Table{Line: table.Line, Fields: make(map[string]interface{})},
+	}
+
+	var inventory string
+	for key, val := range table.Fields {
+		switch key {
+		case "plugin", "inventory":
+			kv, ok := val.(*ast.KeyValue)
+			if !ok {
+				return nil, fmt.Errorf("%q must be a string", key)
+			}
+			s, ok := kv.Value.(*ast.String)
+			if !ok {
+				return nil, fmt.Errorf("%q must be a string", key)
+			}
+			if key == "plugin" {
+				category, name, found := strings.Cut(s.Value, ".")
+				switch category {
+				case "inputs", "outputs", "processors", "aggregators":
+				default:
+					found = false
+				}
+				if !found || name == "" {
+					return nil, fmt.Errorf("invalid plugin %q, expected e.g. 'inputs.cpu'", s.Value)
+				}
+				tmpl.category, tmpl.plugin = category, name
+			} else {
+				inventory = s.Value
+			}
+		case "parameters":
+			t, ok := val.(*ast.Table)
+			if !ok {
+				return nil, errors.New("'parameters' must be a table")
+			}
+			params, err := templateParameters(t)
+			if err != nil {
+				return nil, fmt.Errorf("invalid parameters: %w", err)
+			}
+			tmpl.defaults = params
+		case "config":
+			t, ok := val.(*ast.Table)
+			if !ok {
+				return nil, errors.New("'config' must be a table")
+			}
+			tmpl.config = t
+		case "instances":
+			tables, ok := val.([]*ast.Table)
+			if !ok {
+				return nil, errors.New("'instances' must be an array of tables")
+			}
+			for i, t := range tables {
+				origin := fmt.Sprintf("instance %d", i+1)
+				params, err := templateParameters(t)
+				if err != nil {
+					return nil, fmt.Errorf("invalid %s: %w", origin, err)
+				}
+				tmpl.instances = append(tmpl.instances, templateInstance{origin: origin, params: params})
+			}
+		default:
+			return nil, fmt.Errorf("unknown field %q", key)
+		}
+	}
+	if tmpl.plugin == "" {
+		return nil, errors.New("missing 'plugin'")
+	}
+
+	if inventory != "" {
+		if dir != "" && !filepath.IsAbs(inventory) {
+			inventory = filepath.Join(dir, inventory)
+		}
+		instances, err := loadInventory(inventory)
+		if err != nil {
+			return nil, fmt.Errorf("loading inventory %q failed: %w", inventory, err)
+		}
+		tmpl.instances = append(tmpl.instances, instances...)
+	}
+
+	return tmpl, nil
+}
+
+// templateParameters converts the scalar values of the given table to
+// parameters.
+func templateParameters(table *ast.Table) (map[string]string, error) {
+	params := make(map[string]string, len(table.Fields))
+	for key, val := range table.Fields {
+		kv, ok := val.(*ast.KeyValue)
+		if !ok {
+			return nil, fmt.Errorf("parameter %q is not a value", key)
+		}
+		switch v := kv.Value.(type) {
+		case *ast.String:
+			params[key] = v.Value
+		case *ast.Integer:
+			params[key] = v.Value
+		case *ast.Float:
+			params[key] = v.Value
+		case *ast.Boolean:
+			params[key] = v.Value
+		default:
+			return nil, fmt.Errorf("parameter %q must be a string, number or boolean", key)
+		}
+	}
+	return params, nil
+}
+
+// loadInventory reads the parameter sets from a CSV file with a header row
+// or from a JSON file containing an array of objects.
+func loadInventory(path string) ([]templateInstance, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]string
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv":
+		records, err := csv.NewReader(strings.NewReader(string(trimBOM(buf)))).ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, errors.New("missing header row")
+		}
+		header := records[0]
+		for _, record := range records[1:] {
+			row := make(map[string]string, len(header))
+			for i, column := range header {
+				row[strings.TrimSpace(column)] = record[i]
+			}
+			rows = append(rows, row)
+		}
+	case ".json":
+		var entries []map[string]interface{}
+		if err := json.Unmarshal(buf, &entries); err != nil {
+			return nil, err
+		}
+		for i, entry := range entries {
+			row := make(map[string]string, len(entry))
+			for key, val := range entry {
+				switch v := val.(type) {
+				case string:
+					row[key] = v
+				case float64:
+					row[key] = strconv.FormatFloat(v, 'f', -1, 64)
+				case bool:
+					row[key] = strconv.FormatBool(v)
+				default:
+					return nil, fmt.Errorf("entry %d: parameter %q must be a string, number or boolean", i+1, key)
+				}
+			}
+			rows = append(rows, row)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported inventory format %q, use '.csv' or '.json'", ext)
+	}
+
+	instances := make([]templateInstance, 0, len(rows))
+	for i, row := range rows {
+		instances = append(instances, templateInstance{
+			origin: fmt.Sprintf("%s entry %d", filepath.Base(path), i+1),
+			params: row,
+		})
+	}
+	return instances, nil
+}
+
+// instantiate returns a deep copy of the template configuration with all
+// placeholders replaced by the given parameters.
+func (t *pluginTemplate) instantiate(params map[string]string) (*ast.Table, error) {
+	return instantiateTable(t.config, params)
+}
+
+func instantiateTable(table *ast.Table, params map[string]string) (*ast.Table, error) {
+	instance := &ast.Table{
+		Position: table.Position,
+		Line:     table.Line,
+		Name:     table.Name,
+		Fields:   make(map[string]interface{}, len(table.Fields)),
+		Type:     table.Type,
+		Data:     table.Data,
+	}
+	for key, field := range table.Fields {
+		switch f := field.(type) {
+		case *ast.KeyValue:
+			v, err := instantiateValue(f.Value, params)
+			if err != nil {
+				return nil, fmt.Errorf("option %q: %w", key, err)
+			}
+			instance.Fields[key] = &ast.KeyValue{Key: f.Key, Value: v, Line: f.Line}
+		case *ast.Table:
+			t, err := instantiateTable(f, params)
+			if err != nil {
+				return nil, err
+			}
+			instance.Fields[key] = t
+		case []*ast.Table:
+			tables := make([]*ast.Table, 0, len(f))
+			for _, ft := range f {
+				t, err := instantiateTable(ft, params)
+				if err != nil {
+					return nil, err
+				}
+				tables = append(tables, t)
+			}
+			instance.Fields[key] = tables
+		default:
+			instance.Fields[key] = field
+		}
+	}
+	return instance, nil
+}
+
+func instantiateValue(value ast.Value, params map[string]string) (ast.Value, error) {
+	switch v := value.(type) {
+	case *ast.String:
+		var missing string
+		s := templatePlaceholder.ReplaceAllStringFunc(v.Value, func(match string) string {
+			name := match[2 : len(match)-1]
+			p, found := params[name]
+			if !found && missing == "" {
+				missing = name
+			}
+			return p
+		})
+		if missing != "" {
+			return nil, fmt.Errorf("missing parameter %q", missing)
+		}
+		if s == v.Value {
+			return v, nil
+		}
+		// The raw data is used for the plugin ID and by custom unmarshallers
+		// so we need to keep it in sync with the value.
+		return &ast.String{Position: v.Position, Value: s, Data: []rune(tomlString(s))}, nil
+	case *ast.Array:
+		array := &ast.Array{Position: v.Position, Value: make([]ast.Value, 0, len(v.Value))}
+		sources := make([]string, 0, len(v.Value))
+		for _, element := range v.Value {
+			e, err := instantiateValue(element, params)
+			if err != nil {
+				return nil, err
+			}
+			array.Value = append(array.Value, e)
+			sources = append(sources, e.Source())
+		}
+		array.Data = []rune("[" + strings.Join(sources, ", ") + "]")
+		return array, nil
+	case *ast.Table:
+		return instantiateTable(v, params)
+	}
+	return value, nil
+}
+
+// addTemplate instantiates the plugin template for all of its parameter sets.
+// The instances use the template as source.
+func (c *Config) addTemplate(name, path string, table *ast.Table) error {
+	// Inventory files are located relative to the configuration file
+	var dir string
+	if path != EmptySourcePath && !isURL(path) {
+		dir = filepath.Dir(path)
+	}
+	tmpl, err := parseTemplate(table, dir)
+	if err != nil {
+		return err
+	}
+
+	source := fmt.Sprintf("template %q", name)
+	if path != "" {
+		source = fmt.Sprintf("%s (template %q)", path, name)
+	}
+	for _, instance := range tmpl.instances {
+		params := maps.Clone(tmpl.defaults)
+		maps.Copy(params, instance.params)
+
+		pluginTable, err := tmpl.instantiate(params)
+		if err != nil {
+			return fmt.Errorf("%s: %w", instance.origin, err)
+		}
+		switch tmpl.category {
+		case "inputs":
+			err = c.addInput(tmpl.plugin, source, pluginTable)
+		case "outputs":
+			err = c.addOutput(tmpl.plugin, source, pluginTable)
+		case "processors":
+			err = c.addProcessor(tmpl.plugin, source, pluginTable)
+		case "aggregators":
+			err = c.addAggregator(tmpl.plugin, source, pluginTable)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", instance.origin, err)
+		}
+		if len(c.UnusedFields) > 0 {
+			return fmt.Errorf(
+				"%s: configuration specified the fields %q, but they were not used; "+
+					"this is either a typo or this config option does not exist in this version",
+				instance.origin, keys(c.UnusedFields))
+		}
+	}
+
+	return nil
+}
@@ -21,3 +21,17 @@ type ServiceInput interface {
 	// to the accumulator before returning.
 	Stop()
 }
+
+// PausableInput is implemented by service inputs able to pause consuming new
+// messages, e.g. message queue consumers, while the agent signals
+// backpressure due to outputs not keeping up with writing.
+type PausableInput interface {
+	ServiceInput
+
+	// Pause stops consuming new messages until Resume is called. Delivery of
+	// already consumed messages must continue while paused.
+	Pause()
+
+	// Resume continues consuming new messages.
+	Resume()
+}
@@ -11,6 +11,7 @@ type config struct {
 	start time.Time
 	align bool
 
+	windows  *Windows
 	notifier chan bool
 }
 
@@ -34,3 +35,10 @@ func WithStartupNotification(notifier chan bool) Option {
 		c.notifier = notifier
 	}
 }
+
+// WithWindows suppresses all ticks outside the given time windows.
+func WithWindows(windows *Windows) Option {
+	return func(c *config) {
+		c.windows = windows
+	}
+}
@@ -0,0 +1,144 @@
+package clock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/robfig/cron/v3"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+// Schedule determines the trigger times from a cron expression.
+type Schedule struct {
+	expression string
+	schedule   cron.Schedule
+}
+
+// ParseSchedule parses a standard cron expression with five fields (minute,
+// hour, day of month, month and day of week) or a descriptor like "@hourly"
+// or "@every 5m". The expression may be prefixed by a "CRON_TZ=<timezone>"
+// specification, otherwise the given location is used.
+func ParseSchedule(expression string, loc *time.Location) (*Schedule, error) {
+	parser := cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+	schedule, err := parser.Parse(expression)
+	if err != nil {
+		return nil, fmt.Errorf("parsing schedule %q failed: %w", expression, err)
+	}
+	if s, ok := schedule.(*cron.SpecSchedule); ok && loc != nil && s.Location == time.Local {
+		s.Location = loc
+	}
+	return &Schedule{expression: expression, schedule: schedule}, nil
+}
+
+// Next returns the first trigger time after the given time.
+func (s *Schedule) Next(t time.Time) time.Time {
+	return s.schedule.Next(t)
+}
+
+// Interval returns the duration between the next two trigger times after the
+// given time as an estimate of the interval of the schedule.
+func (s *Schedule) Interval(t time.Time) time.Duration {
+	next := s.schedule.Next(t)
+	return s.schedule.Next(next).Sub(next)
+}
+
+func (s *Schedule) String() string {
+	return s.expression
+}
+
+// CronTicker delivers ticks at the trigger times of a cron schedule shifted
+// by the given offset. The ticks are delayed by a random jitter if given
+// without affecting the schedule.
+//
+// Ticks are dropped for slow consumers.
+type CronTicker struct {
+	C chan time.Time
+
+	clk      clock.Clock
+	schedule *Schedule
+	next     time.Time
+	jitter   time.Duration
+	offset   time.Duration
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+
+	cfg *config
+}
+
+func NewCronTicker(schedule *Schedule, jitter, offset time.Duration, opt ...Option) *CronTicker {
+	// Apply the options
+	cfg := &config{
+		clk: clock.New(),
+	}
+	for _, o := range opt {
+		o(cfg)
+	}
+
+	// Initialize the ticker instance and start it
+	t := &CronTicker{
+		C:        make(chan time.Time, 1),
+		clk:      cfg.clk,
+		schedule: schedule,
+		next:     schedule.Next(cfg.clk.Now()),
+		jitter:   jitter,
+		offset:   offset,
+		cfg:      cfg,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		t.run(ctx)
+	}()
+
+	return t
+}
+
+func (t *CronTicker) Stop() {
+	t.cancel()
+	t.wg.Wait()
+}
+
+func (t *CronTicker) run(ctx context.Context) {
+	timer := t.clk.Timer(t.clk.Until(t.next.Add(t.offset)) + internal.RandomDuration(t.jitter))
+	defer timer.Stop()
+
+	if t.cfg.notifier != nil {
+		t.cfg.notifier <- true
+	}
+
+	for {
+		select {
+		case ts := <-timer.C:
+			// Compute the next trigger time based on the previous one to not
+			// drift by the jitter. In case we fell behind, e.g. due to clock
+			// changes, continue with the next trigger time from now on instead
+			// of catching up.
+			t.next = t.schedule.Next(t.next)
+			if now := t.clk.Now(); t.next.Add(t.offset).Before(now) {
+				t.next = t.schedule.Next(now)
+			}
+			timer.Reset(t.clk.Until(t.next.Add(t.offset)) + internal.RandomDuration(t.jitter))
+
+			if !t.cfg.windows.Contains(ts) {
+				continue
+			}
+
+			// Fire our event in a non-blocking fashion to avoid blocking the
+			// ticker if the agent code did not read the channel yet
+			select {
+			case t.C <- ts:
+			default:
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
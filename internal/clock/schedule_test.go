@@ -0,0 +1,109 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScheduleInvalid(t *testing.T) {
+	_, err := ParseSchedule("*/15 * *", time.UTC)
+	require.ErrorContains(t, err, `parsing schedule "*/15 * *" failed`)
+}
+
+func TestScheduleInterval(t *testing.T) {
+	schedule, err := ParseSchedule("0 */6 * * *", time.UTC)
+	require.NoError(t, err)
+	require.Equal(t, "0 */6 * * *", schedule.String())
+	require.Equal(t, time.Unix(6*3600, 0).UTC(), schedule.Next(time.Unix(0, 0).UTC()))
+	require.Equal(t, 6*time.Hour, schedule.Interval(time.Unix(0, 0).UTC()))
+
+	schedule, err = ParseSchedule("@every 5m", time.UTC)
+	require.NoError(t, err)
+	require.Equal(t, 5*time.Minute, schedule.Interval(time.Unix(0, 0).UTC()))
+}
+
+func TestScheduleTimezone(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	schedule, err := ParseSchedule("0 8 * * *", loc)
+	require.NoError(t, err)
+
+	next := schedule.Next(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	require.Equal(t, time.Date(2024, 1, 1, 13, 0, 0, 0, time.UTC), next.UTC())
+}
+
+func TestCronTicker(t *testing.T) {
+	schedule, err := ParseSchedule("*/15 * * * *", time.UTC)
+	require.NoError(t, err)
+
+	clk := clock.NewMock()
+	startup := make(chan bool, 1)
+
+	ticker := NewCronTicker(schedule, 0, 0, WithClock(clk), WithStartupNotification(startup))
+	defer ticker.Stop()
+
+	expected := []time.Time{
+		time.Unix(15*60, 0).UTC(),
+		time.Unix(30*60, 0).UTC(),
+		time.Unix(45*60, 0).UTC(),
+		time.Unix(60*60, 0).UTC(),
+	}
+
+	// Wait for the ticker to startup
+	<-startup
+
+	actual := make([]time.Time, 0, len(expected))
+	for range expected {
+		clk.Add(15 * time.Minute)
+		actual = append(actual, (<-ticker.C).UTC())
+	}
+	require.Equal(t, expected, actual)
+}
+
+func TestCronTickerOffset(t *testing.T) {
+	schedule, err := ParseSchedule("@hourly", time.UTC)
+	require.NoError(t, err)
+
+	clk := clock.NewMock()
+	startup := make(chan bool, 1)
+
+	ticker := NewCronTicker(schedule, 0, 30*time.Second, WithClock(clk), WithStartupNotification(startup))
+	defer ticker.Stop()
+
+	// Wait for the ticker to startup
+	<-startup
+
+	clk.Add(time.Hour + 30*time.Second)
+	require.Equal(t, time.Unix(3630, 0).UTC(), (<-ticker.C).UTC())
+	clk.Add(time.Hour)
+	require.Equal(t, time.Unix(7230, 0).UTC(), (<-ticker.C).UTC())
+}
+
+func TestCronTickerWindows(t *testing.T) {
+	schedule, err := ParseSchedule("*/15 * * * *", time.UTC)
+	require.NoError(t, err)
+	windows, err := ParseWindows(nil, []string{"00:20-00:50"}, time.UTC)
+	require.NoError(t, err)
+
+	clk := clock.NewMock()
+	startup := make(chan bool, 1)
+
+	ticker := NewCronTicker(schedule, 0, 0, WithClock(clk), WithWindows(windows), WithStartupNotification(startup))
+	defer ticker.Stop()
+
+	// Wait for the ticker to startup
+	<-startup
+
+	clk.Add(15 * time.Minute)
+	require.Equal(t, time.Unix(15*60, 0).UTC(), (<-ticker.C).UTC())
+
+	// The ticks at 00:30 and 00:45 are inside the inactive window
+	clk.Add(15 * time.Minute)
+	clk.Add(15 * time.Minute)
+	clk.Add(15 * time.Minute)
+	require.Equal(t, time.Unix(60*60, 0).UTC(), (<-ticker.C).UTC())
+}
@@ -92,6 +92,10 @@ func (t *Ticker) run(ctx context.Context) {
 			t.schedule = t.schedule.Add(t.interval)
 			timer.Reset(t.clk.Until(t.schedule) + internal.RandomDuration(t.jitter))
 
+			if !t.cfg.windows.Contains(ts) {
+				continue
+			}
+
 			// Fire our event in a non-blocking fashion to avoid blocking the
 			// ticker if the agent code did not read the channel yet
 			select {
@@ -0,0 +1,179 @@
+package clock
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// window is a recurring time range on a set of weekdays. The start and end
+// of the range are given as offsets since midnight. Ranges with the end
+// before the start span over midnight and belong to the day they start on.
+type window struct {
+	days  [7]bool
+	start time.Duration
+	end   time.Duration
+}
+
+func parseWindow(spec string) (*window, error) {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, errors.New("expected '[days] [HH:MM-HH:MM]'")
+	}
+
+	w := &window{end: 24 * time.Hour}
+	var daysSet, timeSet bool
+	for _, field := range fields {
+		if strings.Contains(field, ":") {
+			if timeSet {
+				return nil, errors.New("multiple time ranges")
+			}
+			start, end, found := strings.Cut(field, "-")
+			if !found {
+				return nil, fmt.Errorf("invalid time range %q", field)
+			}
+			var err error
+			if w.start, err = parseTimeOfDay(start); err != nil {
+				return nil, err
+			}
+			if w.end, err = parseTimeOfDay(end); err != nil {
+				return nil, err
+			}
+			if w.start == w.end {
+				return nil, fmt.Errorf("empty time range %q", field)
+			}
+			timeSet = true
+			continue
+		}
+
+		if daysSet {
+			return nil, errors.New("multiple day specifications")
+		}
+		for _, days := range strings.Split(field, ",") {
+			first, last, isRange := strings.Cut(days, "-")
+			from, ok := weekdays[strings.ToLower(first)]
+			if !ok {
+				return nil, fmt.Errorf("invalid weekday %q", first)
+			}
+			to := from
+			if isRange {
+				if to, ok = weekdays[strings.ToLower(last)]; !ok {
+					return nil, fmt.Errorf("invalid weekday %q", last)
+				}
+			}
+			// Ranges may wrap around the end of the week, e.g. "Fri-Mon"
+			for d := from; ; d = (d + 1) % 7 {
+				w.days[d] = true
+				if d == to {
+					break
+				}
+			}
+		}
+		daysSet = true
+	}
+
+	if !daysSet {
+		for d := range w.days {
+			w.days[d] = true
+		}
+	}
+	return w, nil
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	// Allow "24:00" to denote the end of the day
+	if s == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func (w *window) contains(t time.Time) bool {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := t.Sub(midnight)
+
+	if w.start < w.end {
+		return w.days[t.Weekday()] && offset >= w.start && offset < w.end
+	}
+
+	// The range spans over midnight so check the late part on the current day
+	// and the early part on the day before.
+	if w.days[t.Weekday()] && offset >= w.start {
+		return true
+	}
+	return w.days[(t.Weekday()+6)%7] && offset < w.end
+}
+
+// Windows restricts the times an input is gathered to a set of recurring
+// calendar windows.
+type Windows struct {
+	active   []*window
+	inactive []*window
+	loc      *time.Location
+}
+
+// ParseWindows parses the given active and inactive window specifications in
+// the form "[days] [HH:MM-HH:MM]", e.g. "Mon-Fri 08:00-18:00" or "Sat,Sun".
+// The times are interpreted in the given location.
+func ParseWindows(active, inactive []string, loc *time.Location) (*Windows, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	w := &Windows{loc: loc}
+	for _, spec := range active {
+		aw, err := parseWindow(spec)
+		if err != nil {
+			return nil, fmt.Errorf("parsing active window %q failed: %w", spec, err)
+		}
+		w.active = append(w.active, aw)
+	}
+	for _, spec := range inactive {
+		iw, err := parseWindow(spec)
+		if err != nil {
+			return nil, fmt.Errorf("parsing inactive window %q failed: %w", spec, err)
+		}
+		w.inactive = append(w.inactive, iw)
+	}
+	return w, nil
+}
+
+// Contains returns true if the given time is inside any of the active
+// windows and not inside any of the inactive windows. Without active windows
+// all times not inside an inactive window are accepted. A nil instance
+// contains all times.
+func (w *Windows) Contains(t time.Time) bool {
+	if w == nil {
+		return true
+	}
+
+	t = t.In(w.loc)
+	for _, iw := range w.inactive {
+		if iw.contains(t) {
+			return false
+		}
+	}
+	if len(w.active) == 0 {
+		return true
+	}
+	for _, aw := range w.active {
+		if aw.contains(t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWindowsInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		expected string
+	}{
+		{
+			name:     "empty",
+			spec:     "",
+			expected: "expected '[days] [HH:MM-HH:MM]'",
+		},
+		{
+			name:     "invalid weekday",
+			spec:     "Monday 08:00-18:00",
+			expected: `invalid weekday "Monday"`,
+		},
+		{
+			name:     "invalid time",
+			spec:     "Mon 08:00-25:00",
+			expected: `invalid time of day "25:00"`,
+		},
+		{
+			name:     "missing end",
+			spec:     "08:00",
+			expected: `invalid time range "08:00"`,
+		},
+		{
+			name:     "empty range",
+			spec:     "08:00-08:00",
+			expected: `empty time range "08:00-08:00"`,
+		},
+		{
+			name:     "multiple ranges",
+			spec:     "08:00-10:00 12:00-14:00",
+			expected: "multiple time ranges",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseWindows([]string{tt.spec}, nil, time.UTC)
+			require.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+func TestWindowsContains(t *testing.T) {
+	tests := []struct {
+		name     string
+		active   []string
+		inactive []string
+		time     time.Time
+		expected bool
+	}{
+		{
+			name:     "no windows",
+			time:     time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			name:     "business hours inside",
+			active:   []string{"Mon-Fri 08:00-18:00"},
+			time:     time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), // Monday
+			expected: true,
+		},
+		{
+			name:     "business hours end",
+			active:   []string{"Mon-Fri 08:00-18:00"},
+			time:     time.Date(2024, 1, 1, 18, 0, 0, 0, time.UTC),
+			expected: false,
+		},
+		{
+			name:     "business hours weekend",
+			active:   []string{"Mon-Fri 08:00-18:00"},
+			time:     time.Date(2024, 1, 6, 12, 0, 0, 0, time.UTC), // Saturday
+			expected: false,
+		},
+		{
+			name:     "multiple active windows",
+			active:   []string{"Mon-Fri 08:00-18:00", "Sat,Sun"},
+			time:     time.Date(2024, 1, 6, 12, 0, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			name:     "wrapping day range",
+			active:   []string{"Fri-Mon"},
+			time:     time.Date(2024, 1, 7, 12, 0, 0, 0, time.UTC), // Sunday
+			expected: true,
+		},
+		{
+			name:     "overnight window same day",
+			inactive: []string{"Sun 22:00-04:00"},
+			time:     time.Date(2024, 1, 7, 23, 0, 0, 0, time.UTC),
+			expected: false,
+		},
+		{
+			name:     "overnight window next day",
+			inactive: []string{"Sun 22:00-04:00"},
+			time:     time.Date(2024, 1, 8, 3, 0, 0, 0, time.UTC), // Monday
+			expected: false,
+		},
+		{
+			name:     "overnight window outside",
+			inactive: []string{"Sun 22:00-04:00"},
+			time:     time.Date(2024, 1, 9, 3, 0, 0, 0, time.UTC), // Tuesday
+			expected: true,
+		},
+		{
+			name:     "inactive overrides active",
+			active:   []string{"Mon-Fri 08:00-18:00"},
+			inactive: []string{"Mon 12:00-13:00"},
+			time:     time.Date(2024, 1, 1, 12, 30, 0, 0, time.UTC),
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := ParseWindows(tt.active, tt.inactive, time.UTC)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, w.Contains(tt.time))
+		})
+	}
+}
+
+func TestWindowsTimezone(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	w, err := ParseWindows([]string{"08:00-18:00"}, nil, loc)
+	require.NoError(t, err)
+
+	// 07:30 UTC is 08:30 in Berlin during winter time
+	require.True(t, w.Contains(time.Date(2024, 1, 1, 7, 30, 0, 0, time.UTC)))
+	require.False(t, w.Contains(time.Date(2024, 1, 1, 17, 30, 0, 0, time.UTC)))
+}
+
+func TestWindowsNil(t *testing.T) {
+	var w *Windows
+	require.True(t, w.Contains(time.Now()))
+}
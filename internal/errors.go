@@ -61,3 +61,18 @@ func (e *PartialWriteError) Error() string {
 func (e *PartialWriteError) Unwrap() error {
 	return e.Err
 }
+
+// PermanentWriteError indicates that a batch was rejected by the service and
+// retrying the write will never succeed, e.g. due to invalid data. The metrics
+// of the batch should be removed from the buffer instead of being retried.
+type PermanentWriteError struct {
+	Err error
+}
+
+func (e *PermanentWriteError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentWriteError) Unwrap() error {
+	return e.Err
+}
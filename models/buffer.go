@@ -3,6 +3,7 @@ package models
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
@@ -26,6 +27,9 @@ type Transaction struct {
 	// Reject denotes the indices of metrics that were not written but should
 	// not be requeued
 	Reject []int
+	// Move denotes the indices of metrics handed over to another output which
+	// are removed without recording them as written or rejected
+	Move []int
 
 	// Marks this transaction as valid
 	valid bool
@@ -41,6 +45,13 @@ func (tx *Transaction) AcceptAll() {
 	}
 }
 
+func (tx *Transaction) RejectAll() {
+	tx.Reject = make([]int, len(tx.Batch))
+	for i := range tx.Batch {
+		tx.Reject[i] = i
+	}
+}
+
 func (*Transaction) KeepAll() {}
 
 func (tx *Transaction) InferKeep() []int {
@@ -51,6 +62,9 @@ func (tx *Transaction) InferKeep() []int {
 	for _, idx := range tx.Reject {
 		used[idx] = true
 	}
+	for _, idx := range tx.Move {
+		used[idx] = true
+	}
 
 	keep := make([]int, 0, len(tx.Batch))
 	for i := range tx.Batch {
@@ -94,10 +108,54 @@ type BufferStats struct {
 	BufferLimit     selfstat.Stat
 }
 
+// BufferConfig contains the settings of a metric buffer.
+type BufferConfig struct {
+	// Strategy is the type of buffer such as "memory" or "disk_write_through"
+	Strategy string
+
+	// Directory to store the buffer files in for disk-backed strategies
+	Directory string
+
+	// DiskSync enables syncing each write to disk
+	DiskSync bool
+
+	// SpillThreshold is the number of metrics a "hybrid" buffer keeps in
+	// memory before spilling to disk. Defaults to the buffer capacity.
+	SpillThreshold int
+
+	// SpillAfter is the duration of failing writes after which a "hybrid"
+	// buffer spills to disk. Zero disables spilling due to failing writes.
+	SpillAfter time.Duration
+
+	// MaxDiskSize is the maximum size in bytes of the metrics stored on disk.
+	// Zero means unlimited.
+	MaxDiskSize int64
+
+	// MaxAge is the maximum age of metrics stored on disk, older metrics are
+	// dropped. Zero means unlimited.
+	MaxAge time.Duration
+}
+
+// persistent checks if the buffer stores metrics on disk.
+func (cfg *BufferConfig) persistent() bool {
+	return cfg.Strategy == "disk_write_through" || cfg.Strategy == "hybrid"
+}
+
 // NewBuffer returns a new empty Buffer with the given capacity.
 //
 //nolint:revive //will move to structs later
 func NewBuffer(name, id, alias string, capacity int, strategy, path string, diskSync bool) (Buffer, error) {
+	cfg := &BufferConfig{
+		Strategy:  strategy,
+		Directory: path,
+		DiskSync:  diskSync,
+	}
+	return NewBufferFromConfig(name, id, alias, capacity, cfg)
+}
+
+// NewBufferFromConfig returns a new empty Buffer with the given capacity
+// using the given buffer settings.
+func NewBufferFromConfig(name, id, alias string, capacity int, cfg *BufferConfig) (Buffer, error) {
 	registerGob()
 
 	tags := map[string]string{
@@ -109,22 +167,24 @@ func NewBuffer(name, id, alias string, capacity int, strategy, path string, disk
 	}
 	bs := NewBufferStats(tags, capacity)
 
-	switch strategy {
+	switch cfg.Strategy {
 	case "", "memory":
 		return NewMemoryBuffer(capacity, bs)
 	case "disk_write_through":
-		return NewDiskBuffer(id, path, bs, diskSync)
+		return NewDiskBuffer(id, cfg, bs)
+	case "hybrid":
+		return NewHybridBuffer(id, capacity, cfg, bs)
 	case "discard":
 		return newDiscardBuffer(bs), nil
 	}
-	return nil, fmt.Errorf("invalid buffer strategy %q", strategy)
+	return nil, fmt.Errorf("invalid buffer strategy %q", cfg.Strategy)
 }
 
 // CheckBufferSettings verifies that the buffer settings are valid without
 // opening or allocating the buffer.
 func CheckBufferSettings(strategy string) error {
 	switch strategy {
-	case "", "memory", "disk_write_through":
+	case "", "memory", "disk_write_through", "hybrid":
 		return nil
 	}
 	return fmt.Errorf("invalid buffer strategy %q", strategy)
@@ -1,18 +1,22 @@
 package models
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"log"
+	"os"
 	"path/filepath"
 	"slices"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/tidwall/wal"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/selfstat"
 )
 
 type DiskBuffer struct {
@@ -37,25 +41,42 @@ type DiskBuffer struct {
 	// Cache the buffer length for informatory calls to Len() e.g. when running
 	// Telegraf with --once. See https://github.com/influxdata/telegraf/issues/19248
 	closedLength *int
+
+	// Maximum size of the WAL in bytes and the current size of all entries
+	maxSize int64
+	size    int64
+
+	// Maximum age of metrics, older metrics are dropped
+	maxAge time.Duration
+
+	// Denotes if a transaction is in progress
+	txActive bool
 }
 
-func NewDiskBuffer(id, path string, stats BufferStats, diskSync bool) (*DiskBuffer, error) {
-	filePath := filepath.Join(path, id)
-	walFile, err := wal.Open(filePath, &wal.Options{
+func NewDiskBuffer(id string, cfg *BufferConfig, stats BufferStats) (*DiskBuffer, error) {
+	filePath := filepath.Join(cfg.Directory, id)
+	walFile, lost, err := openWAL(filePath, &wal.Options{
 		AllowEmpty: true,
-		NoSync:     !diskSync,
+		NoSync:     !cfg.DiskSync,
 	})
 	if err != nil {
-		if errors.Is(err, wal.ErrCorrupt) {
-			return nil, fmt.Errorf("wal file is corrupt, you have to manually delete the wal at %q and restart Telegraf", filePath)
-		}
 		return nil, fmt.Errorf("failed to open wal file: %w", err)
 	}
+	if lost > 0 {
+		log.Printf("W! Recovered corrupt wal file %q, lost %d metrics", filePath, lost)
+		selfstat.Register("write", "metrics_lost", stats.BufferSize.Tags()).Incr(int64(lost))
+	}
 
 	buf := &DiskBuffer{
 		BufferStats: stats,
 		file:        walFile,
 		path:        filePath,
+		maxSize:     cfg.MaxDiskSize,
+		maxAge:      cfg.MaxAge,
+	}
+	if err := buf.updateSize(); err != nil {
+		walFile.Close()
+		return nil, err
 	}
 	if buf.Len() > 0 {
 		buf.originalEnd = buf.writeIndex()
@@ -63,6 +84,86 @@ func NewDiskBuffer(id, path string, stats BufferStats, diskSync bool) (*DiskBuff
 	return buf, nil
 }
 
+// openWAL opens the WAL file at the given path. A corrupt WAL, e.g. due to a
+// partial write on power loss, is recovered by truncating the incomplete
+// entries from the end of the log until the log is valid. The number of lost
+// entries is returned.
+func openWAL(path string, opts *wal.Options) (*wal.Log, int, error) {
+	var lost int
+	for {
+		walFile, err := wal.Open(path, opts)
+		if err == nil {
+			return walFile, lost, nil
+		}
+		if !errors.Is(err, wal.ErrCorrupt) {
+			return nil, lost, err
+		}
+
+		n, err := truncateLastSegment(path)
+		if err != nil {
+			return nil, lost, fmt.Errorf("recovering corrupt wal failed: %w", err)
+		}
+		lost += n
+	}
+}
+
+// truncateLastSegment truncates the last segment file of the WAL at the given
+// path after the last complete entry and returns the number of lost entries.
+// If the segment does not contain an incomplete entry or no complete one, the
+// whole segment is removed.
+func truncateLastSegment(path string) (int, error) {
+	files, err := os.ReadDir(path)
+	if err != nil {
+		return 0, err
+	}
+
+	// Segments are named by their zero-padded first index so the files are
+	// sorted in the order of the log
+	var last string
+	for _, f := range files {
+		if !f.IsDir() && len(f.Name()) >= 20 {
+			last = f.Name()
+		}
+	}
+	if last == "" {
+		return 0, errors.New("no segment found")
+	}
+
+	filename := filepath.Join(path, last)
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return 0, err
+	}
+
+	// Find the end of the last complete entry
+	var valid, count int
+	for valid < len(data) {
+		size, n := binary.Uvarint(data[valid:])
+		if n <= 0 || uint64(len(data)-valid-n) < size {
+			break
+		}
+		valid += n + int(size)
+		count++
+	}
+
+	// Only the trailing incomplete data is lost when keeping the complete
+	// entries
+	if valid > 0 && valid < len(data) {
+		if err := os.Truncate(filename, int64(valid)); err != nil {
+			return 0, err
+		}
+		return 1, nil
+	}
+
+	if err := os.Remove(filename); err != nil {
+		return 0, err
+	}
+	if valid < len(data) {
+		count++
+	}
+	return count, nil
+}
+
 func (b *DiskBuffer) Len() int {
 	if b.closedLength != nil {
 		return *b.closedLength
@@ -106,14 +207,30 @@ func (b *DiskBuffer) Add(metrics ...telegraf.Metric) int {
 	b.Lock()
 	defer b.Unlock()
 
-	var batch wal.Batch
-	idx := b.writeIndex()
-	startIdx := idx
+	entries := make([][]byte, 0, len(metrics))
 	for _, m := range metrics {
 		data, err := metric.ToBytes(m)
 		if err != nil {
 			panic(err)
 		}
+		entries = append(entries, data)
+	}
+
+	var dropped int
+	if b.maxSize > 0 {
+		dropped = b.limitSize(metrics, entries)
+		metrics = metrics[dropped:]
+		entries = entries[dropped:]
+		if len(entries) == 0 {
+			b.BufferSize.Set(int64(b.length()))
+			return dropped
+		}
+	}
+
+	var batch wal.Batch
+	idx := b.writeIndex()
+	startIdx := idx
+	for _, data := range entries {
 		batch.Write(idx, data)
 		idx++
 	}
@@ -121,19 +238,169 @@ func (b *DiskBuffer) Add(metrics ...telegraf.Metric) int {
 	if err := b.file.WriteBatch(&batch); err != nil {
 		// This calculation assumes a single writer to the WAL, which is
 		// guaranteed by the mutex and one WAL per buffer instance.
-		dropped := uint64(len(metrics)) - (b.writeIndex() - startIdx)
-		return int(dropped)
+		written := b.writeIndex() - startIdx
+		for _, data := range entries[:written] {
+			b.size += entrySize(data)
+		}
+		return dropped + len(entries) - int(written)
 	}
 
+	for _, data := range entries {
+		b.size += entrySize(data)
+	}
 	b.metricAdded(int64(len(metrics)))
 	b.BufferSize.Set(int64(b.length()))
-	return 0
+	return dropped
+}
+
+// limitSize makes room for the given entries by dropping the oldest metrics
+// if adding the entries would exceed the maximum size. If no room can be
+// made, e.g. during a transaction, the new metrics are dropped instead. The
+// number of new metrics to drop from the front of the given metrics is
+// returned.
+func (b *DiskBuffer) limitSize(metrics []telegraf.Metric, entries [][]byte) int {
+	var required int64
+	for _, data := range entries {
+		required += entrySize(data)
+	}
+	if b.size+required <= b.maxSize {
+		return 0
+	}
+
+	// Drop the oldest metrics to free the space required. Metrics being part
+	// of a running transaction can only be removed after finishing it.
+	if !b.txActive {
+		b.dropFront(func(_ telegraf.Metric, used int64) bool {
+			return b.size-used+required > b.maxSize
+		})
+	}
+
+	// Drop the oldest of the new metrics if there is still not enough space
+	var dropped int
+	for dropped < len(entries) && b.size+required > b.maxSize {
+		required -= entrySize(entries[dropped])
+		b.metricDropped(metrics[dropped])
+		dropped++
+	}
+	return dropped
+}
+
+// expire drops all metrics older than the maximum age from the front of the
+// buffer.
+func (b *DiskBuffer) expire() {
+	cutoff := time.Now().Add(-b.maxAge)
+	b.dropFront(func(m telegraf.Metric, _ int64) bool {
+		return m.Time().Before(cutoff)
+	})
+}
+
+// dropFront removes metrics from the front of the buffer as long as the given
+// function returns true. The function is called with the metric and the size
+// of the already removed entries. Dropped metrics not removed by a previous
+// transaction are recorded in the statistics. Must not be called during a
+// transaction.
+func (b *DiskBuffer) dropFront(drop func(m telegraf.Metric, used int64) bool) {
+	if b.entries() == 0 {
+		return
+	}
+
+	readIndex := b.readIndex()
+	endIndex := b.writeIndex()
+	var count int
+	var used int64
+	for idx := readIndex; idx < endIndex; idx++ {
+		data, err := b.file.Read(idx)
+		if err != nil {
+			panic(err)
+		}
+		// Left-over tracking metrics of a previous instance are removed
+		// without recording them as those are not part of the buffer anymore
+		m, err := metric.FromBytes(data)
+		stale := errors.Is(err, metric.ErrSkipTracking)
+		if err != nil && !stale {
+			log.Printf("E! raw metric data: %v", data)
+			panic(err)
+		}
+		if !drop(m, used) {
+			break
+		}
+		if !stale && !slices.Contains(b.mask, count) {
+			b.metricDropped(m)
+		}
+		used += entrySize(data)
+		count++
+	}
+	if count == 0 {
+		return
+	}
+
+	if err := b.file.TruncateFront(readIndex + uint64(count)); err != nil {
+		panic(err)
+	}
+
+	// Remove the dropped metrics from the mask and update the relative offsets
+	mask := b.mask[:0]
+	for _, offset := range b.mask {
+		if offset >= count {
+			mask = append(mask, offset-count)
+		}
+	}
+	b.mask = mask
+
+	if b.originalEnd < b.readIndex() {
+		b.originalEnd = 0
+	}
+	if err := b.updateSize(); err != nil {
+		log.Printf("E! Determining size of wal file %q failed: %v", b.path, err)
+	}
+}
+
+// updateSize determines the size of all entries from the WAL segment files.
+func (b *DiskBuffer) updateSize() error {
+	size, err := walSize(b.path)
+	if err != nil {
+		return err
+	}
+	b.size = size
+	return nil
+}
+
+// walSize returns the size of all segment files of the WAL at the given path.
+func walSize(path string) (int64, error) {
+	files, err := os.ReadDir(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var size int64
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			return 0, err
+		}
+		size += info.Size()
+	}
+	return size, nil
+}
+
+// entrySize returns the size of the given data as stored in the WAL.
+func entrySize(data []byte) int64 {
+	var buf [binary.MaxVarintLen64]byte
+	return int64(binary.PutUvarint(buf[:], uint64(len(data))) + len(data))
 }
 
 func (b *DiskBuffer) BeginTransaction(batchSize int) *Transaction {
 	b.Lock()
 	defer b.Unlock()
 
+	if b.maxAge > 0 {
+		b.expire()
+		b.BufferSize.Set(int64(b.length()))
+	}
+
 	if b.length() == 0 {
 		return &Transaction{}
 	}
@@ -189,6 +456,7 @@ func (b *DiskBuffer) BeginTransaction(batchSize int) *Transaction {
 		b.batchSize++
 		batchSize--
 	}
+	b.txActive = len(metrics) > 0
 	return &Transaction{Batch: metrics, valid: true, state: offsets}
 }
 
@@ -208,9 +476,10 @@ func (b *DiskBuffer) EndTransaction(tx *Transaction) {
 
 	b.Lock()
 	defer b.Unlock()
+	b.txActive = false
 
 	// Mark metrics which should be removed in the internal mask
-	remove := make([]int, 0, len(tx.Accept)+len(tx.Reject))
+	remove := make([]int, 0, len(tx.Accept)+len(tx.Reject)+len(tx.Move))
 	for _, idx := range tx.Accept {
 		b.metricWritten(tx.Batch[idx])
 		remove = append(remove, offsets[idx])
@@ -219,6 +488,9 @@ func (b *DiskBuffer) EndTransaction(tx *Transaction) {
 		b.metricRejected(tx.Batch[idx])
 		remove = append(remove, offsets[idx])
 	}
+	for _, idx := range tx.Move {
+		remove = append(remove, offsets[idx])
+	}
 	b.mask = append(b.mask, remove...)
 	sort.Ints(b.mask)
 
@@ -260,6 +532,9 @@ func (b *DiskBuffer) EndTransaction(tx *Transaction) {
 	if b.originalEnd < b.readIndex() {
 		b.originalEnd = 0
 	}
+	if err := b.updateSize(); err != nil {
+		log.Printf("E! Determining size of wal file %q failed: %v", b.path, err)
+	}
 
 	b.resetBatch()
 	b.BufferSize.Set(int64(b.length()))
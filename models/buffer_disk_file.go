@@ -0,0 +1,304 @@
+package models
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/wal"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// The functions in this file provide offline access to the disk buffers of
+// outputs, e.g. for inspecting or recovering buffered metrics. They must not
+// be used on buffers of a running agent.
+
+// DiskBufferInfo describes the content of a disk buffer.
+type DiskBufferInfo struct {
+	// Number of entries in the buffer including stale tracking metrics
+	Entries int
+	// Size of the buffer files in bytes
+	Size int64
+	// Timestamps of the first and last metric in the buffer
+	First time.Time
+	Last  time.Time
+}
+
+// StatDiskBuffer returns information about the disk buffer stored at the given
+// path.
+func StatDiskBuffer(path string) (*DiskBufferInfo, error) {
+	file, err := openDiskBufferFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := walSize(path)
+	if err != nil {
+		return nil, err
+	}
+	info := &DiskBufferInfo{Size: size}
+
+	first, last := file.first, file.last
+	if first == 0 {
+		return info, nil
+	}
+	info.Entries = int(last - first + 1)
+
+	if m, err := readDiskBufferEntry(file, first); err != nil {
+		return nil, err
+	} else if m != nil {
+		info.First = m.Time()
+	}
+	if m, err := readDiskBufferEntry(file, last); err != nil {
+		return nil, err
+	} else if m != nil {
+		info.Last = m.Time()
+	}
+	return info, nil
+}
+
+// ReadDiskBuffer calls the given function for all metrics of the disk buffer
+// stored at the given path in order. Tracking metrics of a previous agent
+// instance cannot be restored and are skipped. The number of skipped metrics
+// is returned.
+func ReadDiskBuffer(path string, fn func(telegraf.Metric) error) (int, error) {
+	file, err := openDiskBufferFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	first, last := file.first, file.last
+	if first == 0 {
+		return 0, nil
+	}
+
+	var skipped int
+	for idx := first; idx <= last; idx++ {
+		m, err := readDiskBufferEntry(file, idx)
+		if err != nil {
+			return skipped, err
+		}
+		if m == nil {
+			skipped++
+			continue
+		}
+		if err := fn(m); err != nil {
+			return skipped, err
+		}
+	}
+	return skipped, nil
+}
+
+// RewriteDiskBuffer removes all metrics for which the given function returns
+// true from the disk buffer stored at the given path. Stale tracking metrics
+// are removed as well. The buffer is rewritten to a temporary location first
+// and replaces the original buffer only on success. The number of removed
+// metrics is returned.
+func RewriteDiskBuffer(path string, drop func(telegraf.Metric) bool) (int, error) {
+	src, err := openDiskBufferFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	first, last := src.first, src.last
+	if first == 0 {
+		return 0, nil
+	}
+
+	tmpPath := path + ".rewrite"
+	if err := os.RemoveAll(tmpPath); err != nil {
+		return 0, err
+	}
+	dst, err := wal.Open(tmpPath, &wal.Options{AllowEmpty: true, NoSync: true})
+	if err != nil {
+		return 0, fmt.Errorf("creating temporary buffer failed: %w", err)
+	}
+	defer os.RemoveAll(tmpPath)
+	defer dst.Close()
+
+	var removed int
+	next := uint64(1)
+	for idx := first; idx <= last; idx++ {
+		data, err := src.read(idx)
+		if err != nil {
+			return 0, fmt.Errorf("reading entry %d failed: %w", idx, err)
+		}
+		m, err := metric.FromBytes(data)
+		if err != nil && !errors.Is(err, metric.ErrSkipTracking) {
+			return 0, fmt.Errorf("decoding entry %d failed: %w", idx, err)
+		}
+		if m == nil || drop(m) {
+			removed++
+			continue
+		}
+		if err := dst.Write(next, data); err != nil {
+			return 0, fmt.Errorf("writing entry %d failed: %w", idx, err)
+		}
+		next++
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+
+	if err := dst.Sync(); err != nil {
+		return 0, err
+	}
+	if err := dst.Close(); err != nil {
+		return 0, err
+	}
+
+	// Swap the buffers keeping the original one until the new buffer is in
+	// place
+	oldPath := path + ".old"
+	if err := os.Rename(path, oldPath); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return 0, errors.Join(err, os.Rename(oldPath, path))
+	}
+	return removed, os.RemoveAll(oldPath)
+}
+
+// diskBufferFile provides read-only access to the segment files of a disk
+// buffer. Opening the WAL creates, cleans up and repairs segments, so the
+// segments are parsed directly instead to never modify the inspected buffer.
+// Corrupt or incomplete segments are reported as errors.
+type diskBufferFile struct {
+	path     string
+	segments []diskBufferSegment
+	first    uint64
+	last     uint64
+
+	// Entries of the most recently read segment
+	current int
+	entries [][]byte
+}
+
+type diskBufferSegment struct {
+	name  string
+	index uint64
+}
+
+func openDiskBufferFile(path string) (*diskBufferFile, error) {
+	registerGob()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%q is not a buffer directory", path)
+	}
+
+	files, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Segments are named by their zero-padded first index so the files are
+	// sorted in the order of the log. Segments with a suffix are leftovers of
+	// an interrupted truncation cleaned up when opening the buffer.
+	file := &diskBufferFile{path: path, current: -1}
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || len(name) < 20 {
+			continue
+		}
+		index, err := strconv.ParseUint(name[:20], 10, 64)
+		if err != nil || index == 0 {
+			continue
+		}
+		if strings.HasSuffix(name, ".START") || strings.HasSuffix(name, ".END") {
+			return nil, fmt.Errorf("buffer %q is incomplete due to segment %q: %w", path, name, wal.ErrCorrupt)
+		}
+		if len(name) == 20 {
+			file.segments = append(file.segments, diskBufferSegment{name: name, index: index})
+		}
+	}
+	if len(file.segments) == 0 {
+		return file, nil
+	}
+
+	// Determine the range of entries from the last segment which is also the
+	// segment affected by incomplete writes
+	if err := file.load(len(file.segments) - 1); err != nil {
+		return nil, err
+	}
+	file.first = file.segments[0].index
+	file.last = file.segments[len(file.segments)-1].index + uint64(len(file.entries)) - 1
+	if file.last < file.first {
+		file.first, file.last = 0, 0
+	}
+	return file, nil
+}
+
+// load reads the entries of the segment with the given position.
+func (f *diskBufferFile) load(i int) error {
+	if f.current == i {
+		return nil
+	}
+
+	segment := f.segments[i]
+	data, err := os.ReadFile(filepath.Join(f.path, segment.name))
+	if err != nil {
+		return err
+	}
+
+	var entries [][]byte
+	for len(data) > 0 {
+		size, n := binary.Uvarint(data)
+		if n <= 0 || uint64(len(data)-n) < size {
+			return fmt.Errorf("buffer %q has a corrupt entry in segment %q: %w", f.path, segment.name, wal.ErrCorrupt)
+		}
+		entries = append(entries, data[n:n+int(size)])
+		data = data[n+int(size):]
+	}
+	f.current = i
+	f.entries = entries
+	return nil
+}
+
+// read returns the data of the entry with the given index.
+func (f *diskBufferFile) read(idx uint64) ([]byte, error) {
+	if idx < f.first || idx > f.last {
+		return nil, wal.ErrNotFound
+	}
+
+	// Find the last segment starting at or before the index
+	i := sort.Search(len(f.segments), func(i int) bool {
+		return f.segments[i].index > idx
+	}) - 1
+	if err := f.load(i); err != nil {
+		return nil, err
+	}
+	offset := idx - f.segments[i].index
+	if offset >= uint64(len(f.entries)) {
+		return nil, fmt.Errorf("buffer %q misses entry %d: %w", f.path, idx, wal.ErrCorrupt)
+	}
+	return f.entries[offset], nil
+}
+
+// readDiskBufferEntry returns the metric at the given index. Stale tracking
+// metrics are returned as nil.
+func readDiskBufferEntry(file *diskBufferFile, idx uint64) (telegraf.Metric, error) {
+	data, err := file.read(idx)
+	if err != nil {
+		return nil, fmt.Errorf("reading entry %d failed: %w", idx, err)
+	}
+	m, err := metric.FromBytes(data)
+	if errors.Is(err, metric.ErrSkipTracking) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decoding entry %d failed: %w", idx, err)
+	}
+	return m, nil
+}
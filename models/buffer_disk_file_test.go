@@ -0,0 +1,130 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/wal"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func fillDiskBuffer(t *testing.T, dir, id string, metrics []telegraf.Metric) {
+	t.Helper()
+
+	buf, err := NewBuffer("test", id, "", 0, "disk_write_through", dir, false)
+	require.NoError(t, err)
+	defer buf.Close()
+	for _, m := range metrics {
+		buf.Add(m.Copy())
+	}
+}
+
+func TestDiskBufferFileStat(t *testing.T) {
+	dir := t.TempDir()
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1}, time.Unix(10, 0)),
+		metric.New("mem", map[string]string{}, map[string]interface{}{"value": 2}, time.Unix(20, 0)),
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 3}, time.Unix(30, 0)),
+	}
+	fillDiskBuffer(t, dir, "id123", metrics)
+
+	info, err := StatDiskBuffer(filepath.Join(dir, "id123"))
+	require.NoError(t, err)
+	require.Equal(t, 3, info.Entries)
+	require.Positive(t, info.Size)
+	require.Equal(t, time.Unix(10, 0), info.First)
+	require.Equal(t, time.Unix(30, 0), info.Last)
+
+	_, err = StatDiskBuffer(filepath.Join(dir, "missing"))
+	require.Error(t, err)
+}
+
+func TestDiskBufferFileRead(t *testing.T) {
+	dir := t.TempDir()
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 1}, time.Unix(10, 0)),
+		metric.New("mem", map[string]string{"host": "a"}, map[string]interface{}{"value": 2}, time.Unix(20, 0)),
+	}
+	fillDiskBuffer(t, dir, "id123", metrics)
+
+	var actual []telegraf.Metric
+	skipped, err := ReadDiskBuffer(filepath.Join(dir, "id123"), func(m telegraf.Metric) error {
+		actual = append(actual, m)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Zero(t, skipped)
+	testutil.RequireMetricsEqual(t, metrics, actual)
+}
+
+func TestDiskBufferFileRewrite(t *testing.T) {
+	dir := t.TempDir()
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1}, time.Unix(10, 0)),
+		metric.New("mem", map[string]string{}, map[string]interface{}{"value": 2}, time.Unix(20, 0)),
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 3}, time.Unix(30, 0)),
+	}
+	fillDiskBuffer(t, dir, "id123", metrics)
+
+	path := filepath.Join(dir, "id123")
+	removed, err := RewriteDiskBuffer(path, func(m telegraf.Metric) bool {
+		return m.Name() == "cpu"
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, removed)
+
+	// The agent must be able to pick up the rewritten buffer
+	buf, err := NewBuffer("test", "id123", "", 0, "disk_write_through", dir, false)
+	require.NoError(t, err)
+	defer buf.Close()
+	require.Equal(t, 1, buf.Len())
+	tx := buf.BeginTransaction(10)
+	testutil.RequireMetricsEqual(t, metrics[1:2], tx.Batch)
+	require.NoDirExists(t, path+".rewrite")
+	require.NoDirExists(t, path+".old")
+}
+
+func TestDiskBufferFileCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1}, time.Unix(10, 0)),
+		metric.New("mem", map[string]string{}, map[string]interface{}{"value": 2}, time.Unix(20, 0)),
+	}
+	fillDiskBuffer(t, dir, "id123", metrics)
+
+	// Simulate a partial write by appending an incomplete entry to the segment
+	path := filepath.Join(dir, "id123")
+	f, err := os.OpenFile(filepath.Join(path, "00000000000000000001"), os.O_APPEND|os.O_WRONLY, 0640)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0xff, 0x01, 0x42})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	snapshot := func() map[string][]byte {
+		files, err := os.ReadDir(path)
+		require.NoError(t, err)
+		contents := make(map[string][]byte, len(files))
+		for _, f := range files {
+			buf, err := os.ReadFile(filepath.Join(path, f.Name()))
+			require.NoError(t, err)
+			contents[f.Name()] = buf
+		}
+		return contents
+	}
+	expected := snapshot()
+
+	// Inspecting the buffer reports the corruption without repairing it
+	_, err = StatDiskBuffer(path)
+	require.ErrorIs(t, err, wal.ErrCorrupt)
+	_, err = ReadDiskBuffer(path, func(telegraf.Metric) error { return nil })
+	require.ErrorIs(t, err, wal.ErrCorrupt)
+	_, err = RewriteDiskBuffer(path, func(telegraf.Metric) bool { return true })
+	require.ErrorIs(t, err, wal.ErrCorrupt)
+	require.Equal(t, expected, snapshot())
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"os"
 	"path/filepath"
 	"sync"
 	"testing"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/selfstat"
 	"github.com/influxdata/telegraf/testutil"
 )
 
@@ -359,3 +361,105 @@ func TestDiskBufferTrackingOnOutputOutage(t *testing.T) {
 	defer mu.Unlock()
 	require.ElementsMatch(t, created, delivered, "tracking information mismatch")
 }
+
+func TestDiskBufferMaxSize(t *testing.T) {
+	registerGob()
+
+	metrics := make([]telegraf.Metric, 0, 5)
+	for i := range 5 {
+		m := metric.New("test", map[string]string{}, map[string]interface{}{"value": i}, time.Unix(0, 0))
+		metrics = append(metrics, m)
+	}
+	data, err := metric.ToBytes(metrics[0])
+	require.NoError(t, err)
+
+	// Create a disk buffer holding three metrics at most
+	cfg := &BufferConfig{
+		Strategy:    "disk_write_through",
+		Directory:   t.TempDir(),
+		DiskSync:    true,
+		MaxDiskSize: 3 * entrySize(data),
+	}
+	buf, err := NewBufferFromConfig("test", "id123", "", 0, cfg)
+	require.NoError(t, err)
+	defer buf.Close()
+	buf.Stats().MetricsDropped.Set(0)
+
+	// The oldest metrics are dropped when exceeding the size
+	for _, m := range metrics {
+		buf.Add(m)
+	}
+	require.Equal(t, 3, buf.Len())
+	require.Equal(t, int64(2), buf.Stats().MetricsDropped.Get())
+
+	// During a transaction, new metrics are dropped instead
+	tx := buf.BeginTransaction(5)
+	testutil.RequireMetricsEqual(t, metrics[2:], tx.Batch)
+	require.Equal(t, 1, buf.Add(metrics[0]))
+	require.Equal(t, int64(3), buf.Stats().MetricsDropped.Get())
+	tx.AcceptAll()
+	buf.EndTransaction(tx)
+	require.Zero(t, buf.Len())
+}
+
+func TestDiskBufferMaxAge(t *testing.T) {
+	expired := metric.New("test", map[string]string{}, map[string]interface{}{"value": 1}, time.Now().Add(-2*time.Hour))
+	current := metric.New("test", map[string]string{}, map[string]interface{}{"value": 2}, time.Now())
+
+	cfg := &BufferConfig{
+		Strategy:  "disk_write_through",
+		Directory: t.TempDir(),
+		DiskSync:  true,
+		MaxAge:    time.Hour,
+	}
+	buf, err := NewBufferFromConfig("test", "id123", "", 0, cfg)
+	require.NoError(t, err)
+	defer buf.Close()
+	buf.Stats().MetricsDropped.Set(0)
+
+	buf.Add(expired, current)
+	require.Equal(t, 2, buf.Len())
+
+	// Expired metrics are dropped when starting a new transaction
+	tx := buf.BeginTransaction(5)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{current}, tx.Batch)
+	require.Equal(t, int64(1), buf.Stats().MetricsDropped.Get())
+	tx.AcceptAll()
+	buf.EndTransaction(tx)
+	require.Zero(t, buf.Len())
+}
+
+func TestDiskBufferCorruptRecovery(t *testing.T) {
+	path := t.TempDir()
+	buf, err := NewBuffer("test", "corrupt123", "", 0, "disk_write_through", path, true)
+	require.NoError(t, err)
+	for i := range 3 {
+		buf.Add(metric.New("test", map[string]string{}, map[string]interface{}{"value": i}, time.Unix(0, 0)))
+	}
+	require.NoError(t, buf.Close())
+
+	// Simulate a partial write by appending an incomplete entry to the segment
+	segment := filepath.Join(path, "corrupt123", "00000000000000000001")
+	f, err := os.OpenFile(segment, os.O_APPEND|os.O_WRONLY, 0640)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0xff, 0x01, 0x42})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	// Reopening the buffer recovers the file by truncating the incomplete
+	// entry and keeps the complete ones
+	reopened, err := NewBuffer("test", "corrupt123", "", 0, "disk_write_through", path, true)
+	require.NoError(t, err)
+	defer reopened.Close()
+	require.Equal(t, 3, reopened.Len())
+
+	lost := selfstat.Register("write", "metrics_lost", reopened.Stats().BufferSize.Tags())
+	require.Equal(t, int64(1), lost.Get())
+
+	// The buffer must be usable after recovery
+	m := metric.New("test", map[string]string{}, map[string]interface{}{"value": 42}, time.Unix(0, 0))
+	require.Zero(t, reopened.Add(m))
+	tx := reopened.BeginTransaction(5)
+	require.Len(t, tx.Batch, 4)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{m}, tx.Batch[3:])
+}
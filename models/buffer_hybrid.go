@@ -0,0 +1,177 @@
+package models
+
+import (
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+// HybridBuffer keeps metrics in memory and spills them to a disk buffer if
+// the number of metrics in memory exceeds the spill threshold or if writing
+// to the output fails for longer than the configured duration. Metrics on
+// disk are older than the ones in memory and are thus written first.
+type HybridBuffer struct {
+	sync.Mutex
+	BufferStats
+
+	memory *MemoryBuffer
+	disk   *DiskBuffer
+
+	spillThreshold int
+	spillAfter     time.Duration
+
+	// Start of the currently failing writes, zero if the last write succeeded
+	failingSince time.Time
+
+	// Denotes if the current transaction was started on the disk buffer
+	txFromDisk bool
+
+	// Denotes if a transaction on the memory buffer is in progress. Spilling
+	// is delayed until the transaction ended, otherwise the kept metrics of
+	// the transaction would be written after newer metrics spilled to disk.
+	txMemory bool
+
+	// Metrics added during a transaction on the memory buffer to be spilled
+	// to disk after the transaction ended
+	pending []telegraf.Metric
+}
+
+func NewHybridBuffer(id string, capacity int, cfg *BufferConfig, stats BufferStats) (*HybridBuffer, error) {
+	memory, err := NewMemoryBuffer(capacity, stats)
+	if err != nil {
+		return nil, err
+	}
+	disk, err := NewDiskBuffer(id, cfg, stats)
+	if err != nil {
+		return nil, err
+	}
+
+	threshold := cfg.SpillThreshold
+	if threshold <= 0 || threshold > capacity {
+		threshold = capacity
+	}
+
+	b := &HybridBuffer{
+		BufferStats:    stats,
+		memory:         memory,
+		disk:           disk,
+		spillThreshold: threshold,
+		spillAfter:     cfg.SpillAfter,
+	}
+	b.BufferSize.Set(int64(b.length()))
+	return b, nil
+}
+
+func (b *HybridBuffer) Len() int {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.length()
+}
+
+func (b *HybridBuffer) Add(metrics ...telegraf.Metric) int {
+	b.Lock()
+	defer b.Unlock()
+
+	var dropped int
+	if len(b.pending) > 0 || b.memory.Len()+len(metrics) > b.spillThreshold || b.failing() {
+		if b.txMemory {
+			b.pending = append(b.pending, metrics...)
+		} else {
+			dropped = b.spill(metrics)
+		}
+	} else {
+		dropped = b.memory.Add(metrics...)
+	}
+
+	b.BufferSize.Set(int64(b.length()))
+	return dropped
+}
+
+func (b *HybridBuffer) BeginTransaction(batchSize int) *Transaction {
+	b.Lock()
+	defer b.Unlock()
+
+	// Drain the disk first as it contains the oldest metrics
+	if b.disk.Len() > 0 {
+		if tx := b.disk.BeginTransaction(batchSize); len(tx.Batch) > 0 {
+			b.txFromDisk = true
+			return tx
+		}
+	}
+
+	b.txFromDisk = false
+	tx := b.memory.BeginTransaction(batchSize)
+	b.txMemory = len(tx.Batch) > 0
+	return tx
+}
+
+func (b *HybridBuffer) EndTransaction(tx *Transaction) {
+	b.Lock()
+	defer b.Unlock()
+
+	// Ignore invalid transactions
+	if !tx.valid {
+		return
+	}
+	txMemory := b.txMemory
+	b.txMemory = false
+
+	// Track the time writes are failing i.e. if no metric was written. Moving
+	// metrics to another output is not a write attempt.
+	if len(tx.Batch) > 0 && len(tx.Move) == 0 {
+		if len(tx.Accept) > 0 {
+			b.failingSince = time.Time{}
+		} else if len(tx.Reject) < len(tx.Batch) && b.failingSince.IsZero() {
+			b.failingSince = time.Now()
+		}
+	}
+
+	if b.txFromDisk {
+		b.disk.EndTransaction(tx)
+	} else {
+		b.memory.EndTransaction(tx)
+	}
+
+	// Spill the metrics added during the transaction after the kept metrics
+	// of the transaction to preserve the order of metrics
+	if txMemory && len(b.pending) > 0 {
+		b.spill(b.pending)
+		b.pending = nil
+	}
+
+	b.BufferSize.Set(int64(b.length()))
+}
+
+func (b *HybridBuffer) Stats() BufferStats {
+	return b.BufferStats
+}
+
+func (b *HybridBuffer) Close() error {
+	return b.disk.Close()
+}
+
+func (b *HybridBuffer) length() int {
+	return b.memory.Len() + len(b.pending) + b.disk.Len()
+}
+
+// failing checks if writes are failing for longer than the spill duration.
+func (b *HybridBuffer) failing() bool {
+	return b.spillAfter > 0 && !b.failingSince.IsZero() && time.Since(b.failingSince) >= b.spillAfter
+}
+
+// spill moves the metrics in memory to disk followed by the given metrics
+// and returns the number of metrics dropped.
+func (b *HybridBuffer) spill(metrics []telegraf.Metric) int {
+	// Metrics moved from memory were already accounted for when adding them
+	// to the buffer so correct the statistics of the disk buffer for all
+	// moved metrics not dropped due to a full disk
+	var dropped int
+	if moved := b.memory.takeAll(); len(moved) > 0 {
+		n := b.disk.Add(moved...)
+		b.MetricsAdded.Incr(-int64(len(moved) - n))
+		dropped += n
+	}
+	return dropped + b.disk.Add(metrics...)
+}
@@ -0,0 +1,190 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func newTestHybridBuffer(t *testing.T, capacity int, cfg *BufferConfig) *HybridBuffer {
+	t.Helper()
+
+	cfg.Strategy = "hybrid"
+	cfg.Directory = t.TempDir()
+	cfg.DiskSync = true
+	buf, err := NewBufferFromConfig("test", "id123", "", capacity, cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, buf.Close()) })
+
+	hybridBuf, ok := buf.(*HybridBuffer)
+	require.True(t, ok, "buffer is not a hybrid buffer")
+	hybridBuf.MetricsAdded.Set(0)
+	hybridBuf.MetricsWritten.Set(0)
+	hybridBuf.MetricsDropped.Set(0)
+	return hybridBuf
+}
+
+func newTestMetrics(n int) []telegraf.Metric {
+	metrics := make([]telegraf.Metric, 0, n)
+	for i := range n {
+		m := metric.New("test", map[string]string{}, map[string]interface{}{"value": i}, time.Unix(int64(i), 0))
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+func TestHybridBufferSpillThreshold(t *testing.T) {
+	buf := newTestHybridBuffer(t, 5, &BufferConfig{SpillThreshold: 2})
+	expected := newTestMetrics(3)
+
+	// Metrics stay in memory below the threshold
+	buf.Add(expected[:2]...)
+	require.Equal(t, 2, buf.memory.Len())
+	require.Zero(t, buf.disk.Len())
+
+	// Exceeding the threshold moves all metrics to disk
+	buf.Add(expected[2])
+	require.Zero(t, buf.memory.Len())
+	require.Equal(t, 3, buf.disk.Len())
+	require.Equal(t, 3, buf.Len())
+	require.Equal(t, int64(3), buf.MetricsAdded.Get())
+	require.Equal(t, int64(3), buf.BufferSize.Get())
+
+	tx := buf.BeginTransaction(10)
+	testutil.RequireMetricsEqual(t, expected, tx.Batch)
+	tx.AcceptAll()
+	buf.EndTransaction(tx)
+	require.Zero(t, buf.Len())
+	require.Equal(t, int64(3), buf.MetricsWritten.Get())
+}
+
+func TestHybridBufferDefaultThresholdPreventsDropping(t *testing.T) {
+	buf := newTestHybridBuffer(t, 5, &BufferConfig{})
+	expected := newTestMetrics(8)
+
+	buf.Add(expected...)
+	require.Equal(t, 8, buf.Len())
+	require.Zero(t, buf.MetricsDropped.Get())
+
+	tx := buf.BeginTransaction(10)
+	testutil.RequireMetricsEqual(t, expected, tx.Batch)
+}
+
+func TestHybridBufferSpillOnFailure(t *testing.T) {
+	buf := newTestHybridBuffer(t, 5, &BufferConfig{SpillAfter: time.Minute})
+	expected := newTestMetrics(4)
+
+	// A failing write keeps the metrics in memory at first
+	buf.Add(expected[0])
+	tx := buf.BeginTransaction(10)
+	tx.KeepAll()
+	buf.EndTransaction(tx)
+	require.False(t, buf.failingSince.IsZero())
+	require.Equal(t, 1, buf.memory.Len())
+
+	buf.Add(expected[1])
+	require.Equal(t, 2, buf.memory.Len())
+	require.Zero(t, buf.disk.Len())
+
+	// Spill to disk after failing for longer than the configured duration
+	buf.failingSince = time.Now().Add(-time.Hour)
+	buf.Add(expected[2])
+	require.Zero(t, buf.memory.Len())
+	require.Equal(t, 3, buf.disk.Len())
+
+	// After recovering, new metrics are kept in memory and the metrics on
+	// disk are written first
+	tx = buf.BeginTransaction(2)
+	testutil.RequireMetricsEqual(t, expected[:2], tx.Batch)
+	tx.AcceptAll()
+	buf.EndTransaction(tx)
+	require.True(t, buf.failingSince.IsZero())
+
+	buf.Add(expected[3])
+	require.Equal(t, 1, buf.memory.Len())
+	require.Equal(t, 1, buf.disk.Len())
+
+	tx = buf.BeginTransaction(10)
+	testutil.RequireMetricsEqual(t, expected[2:3], tx.Batch)
+	tx.AcceptAll()
+	buf.EndTransaction(tx)
+
+	tx = buf.BeginTransaction(10)
+	testutil.RequireMetricsEqual(t, expected[3:], tx.Batch)
+	tx.AcceptAll()
+	buf.EndTransaction(tx)
+	require.Zero(t, buf.Len())
+}
+
+func TestHybridBufferSpillDuringTransaction(t *testing.T) {
+	buf := newTestHybridBuffer(t, 5, &BufferConfig{SpillThreshold: 2})
+	expected := newTestMetrics(4)
+
+	// Spilling is delayed while writing the metrics in memory
+	buf.Add(expected[:2]...)
+	tx := buf.BeginTransaction(10)
+	testutil.RequireMetricsEqual(t, expected[:2], tx.Batch)
+	buf.Add(expected[2:]...)
+	require.Equal(t, 2, buf.memory.Len())
+	require.Zero(t, buf.disk.Len())
+	require.Equal(t, 4, buf.Len())
+
+	// The kept metrics are spilled before the ones added during the
+	// transaction to keep the order of the metrics
+	tx.KeepAll()
+	buf.EndTransaction(tx)
+	require.Zero(t, buf.memory.Len())
+	require.Equal(t, 4, buf.disk.Len())
+	require.Equal(t, int64(4), buf.MetricsAdded.Get())
+	require.Equal(t, int64(4), buf.BufferSize.Get())
+
+	tx = buf.BeginTransaction(10)
+	testutil.RequireMetricsEqual(t, expected, tx.Batch)
+	tx.AcceptAll()
+	buf.EndTransaction(tx)
+	require.Zero(t, buf.Len())
+}
+
+func TestHybridBufferSpillStatsDiskFull(t *testing.T) {
+	registerGob()
+
+	metrics := make([]telegraf.Metric, 0, 6)
+	for range 6 {
+		m := metric.New("test", map[string]string{}, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+		metrics = append(metrics, m)
+	}
+	data, err := metric.ToBytes(metrics[0])
+	require.NoError(t, err)
+
+	// Create a disk buffer holding four metrics at most
+	buf := newTestHybridBuffer(t, 5, &BufferConfig{
+		SpillThreshold: 2,
+		MaxDiskSize:    4 * entrySize(data),
+	})
+
+	buf.Add(metrics[:2]...)
+	buf.Add(metrics[2])
+	require.Equal(t, 3, buf.disk.Len())
+	require.Equal(t, int64(3), buf.MetricsAdded.Get())
+
+	// During a transaction on disk only one of the moved metrics fits and
+	// the new metric is dropped
+	tx := buf.BeginTransaction(5)
+	require.Len(t, tx.Batch, 3)
+	buf.Add(metrics[3:5]...)
+	require.Equal(t, 2, buf.Add(metrics[5]))
+	require.Zero(t, buf.memory.Len())
+	require.Equal(t, 4, buf.disk.Len())
+	require.Equal(t, int64(5), buf.MetricsAdded.Get())
+	require.Equal(t, int64(2), buf.MetricsDropped.Get())
+	require.Equal(t, int64(4), buf.BufferSize.Get())
+
+	tx.AcceptAll()
+	buf.EndTransaction(tx)
+	require.Equal(t, 1, buf.Len())
+}
@@ -95,6 +95,8 @@ func (b *MemoryBuffer) EndTransaction(tx *Transaction) {
 		b.metricRejected(tx.Batch[idx])
 	}
 
+	// Moved metrics are owned by another output now and are not restored
+
 	// Keep metrics
 	keep := tx.InferKeep()
 	if len(keep) > 0 {
@@ -184,6 +186,25 @@ func (b *MemoryBuffer) prevby(index, count int) int {
 	return index
 }
 
+// takeAll removes all metrics not part of the current batch from the buffer
+// and returns them ordered from oldest to newest. The statistics are not
+// updated as the metrics are expected to be moved to another buffer.
+func (b *MemoryBuffer) takeAll() []telegraf.Metric {
+	b.Lock()
+	defer b.Unlock()
+
+	metrics := make([]telegraf.Metric, 0, b.size)
+	for i := 0; i < b.size; i++ {
+		idx := b.nextby(b.first, i)
+		metrics = append(metrics, b.buf[idx])
+		b.buf[idx] = nil
+	}
+	b.first = b.nextby(b.first, b.size)
+	b.size = 0
+
+	return metrics
+}
+
 func (b *MemoryBuffer) resetBatch() {
 	b.batchFirst = 0
 	b.batchSize = 0
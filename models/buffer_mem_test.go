@@ -32,7 +32,7 @@ func TestMemoryBufferAcceptCallsMetricAccept(t *testing.T) {
 }
 
 func TestCheckBufferSettings(t *testing.T) {
-	for _, strategy := range []string{"", "memory", "disk_write_through"} {
+	for _, strategy := range []string{"", "memory", "disk_write_through", "hybrid"} {
 		require.NoError(t, CheckBufferSettings(strategy))
 	}
 	require.ErrorContains(t, CheckBufferSettings("discard"), `invalid buffer strategy "discard"`)
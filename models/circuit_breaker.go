@@ -0,0 +1,135 @@
+package models
+
+import (
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+// Default maximum delay between retries of failed writes
+const DefaultRetryBackoffMax = 5 * time.Minute
+
+// Default time a circuit breaker stays open before trying to write again
+const DefaultCircuitBreakerResetTimeout = time.Minute
+
+// circuitState is the state of a circuit breaker
+type circuitState int64
+
+const (
+	// circuitClosed allows writes, failed writes are retried with backoff
+	circuitClosed circuitState = iota
+	// circuitOpen blocks all writes until the reset timeout elapsed
+	circuitOpen
+	// circuitHalfOpen allows a single write to probe the output
+	circuitHalfOpen
+)
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitClosed:
+		return "closed"
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// circuitBreaker delays writes after failures. Consecutive failures delay the
+// next write exponentially starting at the initial backoff up to the maximum
+// backoff with a random jitter added. After reaching the threshold of
+// consecutive failures the circuit opens and blocks writes until the reset
+// timeout elapsed. Afterwards a single write probes the output and either
+// closes the circuit on success or opens it again.
+// A zero initial backoff and threshold disable the respective mechanism.
+type circuitBreaker struct {
+	initial      time.Duration
+	maximum      time.Duration
+	jitter       time.Duration
+	threshold    int
+	resetTimeout time.Duration
+
+	state    circuitState
+	failures int
+	next     time.Time
+
+	sync.Mutex
+}
+
+func newCircuitBreaker(config *OutputConfig) *circuitBreaker {
+	cb := &circuitBreaker{
+		initial:      config.RetryBackoffInitial,
+		maximum:      config.RetryBackoffMax,
+		jitter:       config.RetryBackoffJitter,
+		threshold:    config.CircuitBreakerThreshold,
+		resetTimeout: config.CircuitBreakerResetTimeout,
+	}
+	if cb.maximum <= 0 {
+		cb.maximum = DefaultRetryBackoffMax
+	}
+	if cb.resetTimeout <= 0 {
+		cb.resetTimeout = DefaultCircuitBreakerResetTimeout
+	}
+	return cb
+}
+
+// Delay returns the time to wait from the given time on until writing is
+// allowed again. An open circuit transitions to half-open once the reset
+// timeout elapsed.
+func (cb *circuitBreaker) Delay(t time.Time) time.Duration {
+	cb.Lock()
+	defer cb.Unlock()
+
+	if t.Before(cb.next) {
+		return cb.next.Sub(t)
+	}
+	if cb.state == circuitOpen {
+		cb.state = circuitHalfOpen
+	}
+	return 0
+}
+
+// State returns the current state of the circuit.
+func (cb *circuitBreaker) State() circuitState {
+	cb.Lock()
+	defer cb.Unlock()
+
+	return cb.state
+}
+
+// Success records a successful write and closes the circuit.
+func (cb *circuitBreaker) Success() {
+	cb.Lock()
+	defer cb.Unlock()
+
+	cb.state = circuitClosed
+	cb.failures = 0
+	cb.next = time.Time{}
+}
+
+// Failure records a failed write at the given time and computes the time of
+// the next write attempt.
+func (cb *circuitBreaker) Failure(t time.Time) {
+	cb.Lock()
+	defer cb.Unlock()
+
+	cb.failures++
+	if cb.state == circuitHalfOpen || (cb.threshold > 0 && cb.failures >= cb.threshold) {
+		cb.state = circuitOpen
+		cb.next = t.Add(cb.resetTimeout)
+		return
+	}
+	if cb.initial > 0 {
+		cb.next = t.Add(cb.backoff())
+	}
+}
+
+func (cb *circuitBreaker) backoff() time.Duration {
+	delay := cb.initial
+	for i := 1; i < cb.failures && delay < cb.maximum; i++ {
+		delay *= 2
+	}
+	return min(delay, cb.maximum) + internal.RandomDuration(cb.jitter)
+}
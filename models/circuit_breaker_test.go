@@ -0,0 +1,88 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreakerBackoff(t *testing.T) {
+	cb := newCircuitBreaker(&OutputConfig{
+		RetryBackoffInitial: time.Second,
+		RetryBackoffMax:     5 * time.Second,
+	})
+
+	// The delay doubles with each failure up to the maximum
+	start := time.Now()
+	require.Zero(t, cb.Delay(start))
+	for _, expected := range []time.Duration{1, 2, 4, 5, 5} {
+		cb.Failure(start)
+		require.Equal(t, expected*time.Second, cb.Delay(start))
+	}
+	require.Equal(t, circuitClosed, cb.State())
+
+	// Succeeding resets the backoff
+	cb.Success()
+	require.Zero(t, cb.Delay(start))
+	cb.Failure(start)
+	require.Equal(t, time.Second, cb.Delay(start))
+}
+
+func TestCircuitBreakerJitter(t *testing.T) {
+	cb := newCircuitBreaker(&OutputConfig{
+		RetryBackoffInitial: time.Second,
+		RetryBackoffJitter:  time.Second,
+	})
+
+	start := time.Now()
+	cb.Failure(start)
+	delay := cb.Delay(start)
+	require.GreaterOrEqual(t, delay, time.Second)
+	require.Less(t, delay, 2*time.Second)
+}
+
+func TestCircuitBreakerStates(t *testing.T) {
+	cb := newCircuitBreaker(&OutputConfig{
+		CircuitBreakerThreshold:    2,
+		CircuitBreakerResetTimeout: 30 * time.Second,
+	})
+
+	// Without backoff, writes are allowed until reaching the threshold
+	start := time.Now()
+	cb.Failure(start)
+	require.Zero(t, cb.Delay(start))
+	require.Equal(t, circuitClosed, cb.State())
+
+	cb.Failure(start)
+	require.Equal(t, circuitOpen, cb.State())
+	require.Equal(t, 30*time.Second, cb.Delay(start))
+
+	// After the reset timeout a single write probes the output and a failure
+	// opens the circuit again
+	probe := start.Add(30 * time.Second)
+	require.Zero(t, cb.Delay(probe))
+	require.Equal(t, circuitHalfOpen, cb.State())
+	cb.Failure(probe)
+	require.Equal(t, circuitOpen, cb.State())
+	require.Equal(t, 30*time.Second, cb.Delay(probe))
+
+	// A successful probe closes the circuit
+	probe = probe.Add(30 * time.Second)
+	require.Zero(t, cb.Delay(probe))
+	require.Equal(t, circuitHalfOpen, cb.State())
+	cb.Success()
+	require.Equal(t, circuitClosed, cb.State())
+	require.Zero(t, cb.Delay(probe))
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	cb := newCircuitBreaker(&OutputConfig{})
+
+	start := time.Now()
+	for range 100 {
+		cb.Failure(start)
+		require.Zero(t, cb.Delay(start))
+	}
+	require.Equal(t, circuitClosed, cb.State())
+}
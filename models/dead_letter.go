@@ -0,0 +1,142 @@
+package models
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+)
+
+// Tags added to the metrics rejected by an output before passing them on to
+// the dead-letter sink
+const (
+	DeadLetterOutputTag = "dead_letter_output"
+	DeadLetterReasonTag = "dead_letter_reason"
+)
+
+// Prefix of dead-letter settings referring to a local file instead of an
+// output
+const deadLetterFilePrefix = "file://"
+
+// deadLetterFile appends rejected metrics to a local file in InfluxDB line
+// protocol. The file is opened on the first write.
+type deadLetterFile struct {
+	path       string
+	serializer *influx.Serializer
+	file       *os.File
+
+	sync.Mutex
+}
+
+func newDeadLetterFile(path string) (*deadLetterFile, error) {
+	if path == "" {
+		return nil, errors.New("empty dead-letter file path")
+	}
+	serializer := &influx.Serializer{SortFields: true, UintSupport: true}
+	if err := serializer.Init(); err != nil {
+		return nil, err
+	}
+	return &deadLetterFile{path: path, serializer: serializer}, nil
+}
+
+func (f *deadLetterFile) write(metrics []telegraf.Metric) error {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.file == nil {
+		file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0640)
+		if err != nil {
+			return err
+		}
+		f.file = file
+	}
+
+	octets, err := f.serializer.SerializeBatch(metrics)
+	if err != nil {
+		return err
+	}
+	_, err = f.file.Write(octets)
+	return err
+}
+
+func (f *deadLetterFile) close() error {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// DeadLetterReference returns the reference of the output receiving the
+// metrics rejected by this output or an empty string if rejected metrics are
+// not passed on to another output.
+func (r *RunningOutput) DeadLetterReference() string {
+	if strings.HasPrefix(r.Config.DeadLetter, deadLetterFilePrefix) {
+		return ""
+	}
+	return r.Config.DeadLetter
+}
+
+// SetDeadLetterOutput sets the output receiving the metrics rejected by this
+// output. Passing nil stops forwarding rejected metrics.
+func (r *RunningOutput) SetDeadLetterOutput(output *RunningOutput) {
+	r.deadLetterOutput.Store(output)
+}
+
+// deadLetter passes the metrics rejected in the transaction on to the
+// dead-letter sink. The metrics are annotated with the output and the reason
+// of the rejection. Metrics already rejected by another output are not passed
+// on again to avoid loops between outputs.
+func (r *RunningOutput) deadLetter(tx *Transaction, err error) {
+	target := r.deadLetterOutput.Load()
+	if len(tx.Reject) == 0 || (target == nil && r.deadLetterFile == nil) {
+		return
+	}
+
+	var writeErr *internal.PartialWriteError
+	errors.As(err, &writeErr)
+
+	metrics := make([]telegraf.Metric, 0, len(tx.Reject))
+	for i, idx := range tx.Reject {
+		m := tx.Batch[idx]
+		if m.HasTag(DeadLetterOutputTag) {
+			continue
+		}
+
+		reason := err
+		if writeErr != nil && i < len(writeErr.MetricsRejectErrors) && writeErr.MetricsRejectErrors[i] != nil {
+			reason = writeErr.MetricsRejectErrors[i]
+		}
+
+		// Do not copy tracking information to not delay the delivery
+		// notification of the rejected metric
+		dl := metric.FromMetric(m)
+		dl.AddTag(DeadLetterOutputTag, r.LogName())
+		if reason != nil {
+			dl.AddTag(DeadLetterReasonTag, reason.Error())
+		}
+		metrics = append(metrics, dl)
+	}
+	if len(metrics) == 0 {
+		return
+	}
+
+	if target != nil {
+		for _, m := range metrics {
+			target.AddMetricNoCopy(m)
+		}
+	} else if err := r.deadLetterFile.write(metrics); err != nil {
+		r.log.Errorf("Writing %d rejected metrics to dead-letter file failed: %v", len(metrics), err)
+		return
+	}
+	r.DeadLetters.Incr(int64(len(metrics)))
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestDeadLetterOutput(t *testing.T) {
+	idx := 1
+	m := &mockOutput{batchAcceptSize: 2, metricFatalIndex: &idx}
+	ro, err := NewRunningOutput(m, &OutputConfig{Name: "test", DeadLetter: "rejected"}, 1000, 10000)
+	require.NoError(t, err)
+
+	dlOutput := &mockOutput{}
+	dl, err := NewRunningOutput(dlOutput, &OutputConfig{Name: "file", Alias: "rejected"}, 1000, 10000)
+	require.NoError(t, err)
+	ro.SetDeadLetterOutput(dl)
+
+	now := time.Unix(0, 0)
+	ro.AddMetric(metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1}, now))
+	ro.AddMetric(metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 2}, now))
+	ro.AddMetric(metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 3}, now))
+
+	// The second metric is rejected and passed on to the dead-letter output
+	require.ErrorIs(t, ro.Write(), internal.ErrSizeLimitReached)
+	require.NoError(t, dl.Write())
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"cpu",
+			map[string]string{
+				DeadLetterOutputTag: "outputs.test",
+				DeadLetterReasonTag: internal.ErrSizeLimitReached.Error(),
+			},
+			map[string]interface{}{"value": 2},
+			now,
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, dlOutput.Metrics())
+}
+
+func TestDeadLetterOutputNoLoop(t *testing.T) {
+	m := &mockOutput{
+		preWriteHook: func([]telegraf.Metric) error {
+			return &internal.PermanentWriteError{Err: errors.New("invalid")}
+		},
+	}
+	ro, err := NewRunningOutput(m, &OutputConfig{Name: "test", DeadLetter: "rejected"}, 1000, 10000)
+	require.NoError(t, err)
+
+	dlOutput := &mockOutput{}
+	dl, err := NewRunningOutput(dlOutput, &OutputConfig{Name: "file", Alias: "rejected"}, 1000, 10000)
+	require.NoError(t, err)
+	ro.SetDeadLetterOutput(dl)
+
+	// Metrics already rejected by another output are not passed on again
+	rejected := testutil.TestMetric(1)
+	rejected.AddTag(DeadLetterOutputTag, "outputs.other")
+	ro.AddMetric(rejected)
+	ro.AddMetric(testutil.TestMetric(2))
+	require.Error(t, ro.Write())
+	require.Equal(t, 1, dl.BufferLength())
+	require.Zero(t, ro.BufferLength())
+}
+
+func TestDeadLetterFile(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "rejected.influx")
+
+	m := &mockOutput{
+		preWriteHook: func([]telegraf.Metric) error {
+			return &internal.PermanentWriteError{Err: errors.New("schema conflict")}
+		},
+	}
+	ro, err := NewRunningOutput(m, &OutputConfig{Name: "test", DeadLetter: "file://" + fn}, 1000, 10000)
+	require.NoError(t, err)
+	defer ro.Close()
+
+	ro.AddMetric(metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 42}, time.Unix(0, 0)))
+	require.Error(t, ro.Write())
+
+	buf, err := os.ReadFile(fn)
+	require.NoError(t, err)
+	require.Equal(t, `cpu,dead_letter_output=outputs.test,dead_letter_reason=schema\ conflict,host=a value=42i 0`+"\n", string(buf))
+}
+
+func TestDeadLetterInvalidFile(t *testing.T) {
+	_, err := NewRunningOutput(&mockOutput{}, &OutputConfig{Name: "test", DeadLetter: "file://"}, 1000, 10000)
+	require.ErrorContains(t, err, "empty dead-letter file path")
+}
@@ -0,0 +1,243 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync/atomic"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Default number of consecutive failed writes before a failover group
+// switches to the next output
+const DefaultFailoverAttempts = 3
+
+// OutputGroupConfig is the configuration of a group of outputs sharing the
+// metrics instead of each output receiving a copy of all metrics.
+type OutputGroupConfig struct {
+	Name string `toml:"-"`
+
+	// Mode of the group, either "failover", "hash" or "round_robin"
+	Mode string `toml:"mode"`
+	// Outputs of the group referenced by their alias or plugin name. For
+	// failover groups the order defines the priority of the outputs.
+	Outputs []string `toml:"outputs"`
+	// Relative share of metrics for each output in sharding modes
+	Weights []int `toml:"weights"`
+	// Tags used to compute the hash in addition to the metric name
+	HashTags []string `toml:"hash_tags"`
+	// Number of consecutive failed writes before switching to the next output
+	FailoverAttempts int `toml:"failover_attempts"`
+}
+
+// Validate checks the group settings and applies the defaults.
+func (c *OutputGroupConfig) Validate() error {
+	if len(c.Outputs) == 0 {
+		return errors.New("no outputs specified")
+	}
+	switch c.Mode {
+	case "failover":
+		if len(c.Weights) > 0 {
+			return errors.New("weights are not supported in failover mode")
+		}
+		if c.FailoverAttempts < 0 {
+			return errors.New("failover_attempts must not be negative")
+		}
+		if c.FailoverAttempts == 0 {
+			c.FailoverAttempts = DefaultFailoverAttempts
+		}
+	case "hash", "round_robin":
+		if len(c.Weights) > 0 && len(c.Weights) != len(c.Outputs) {
+			return fmt.Errorf("number of weights (%d) does not match number of outputs (%d)", len(c.Weights), len(c.Outputs))
+		}
+		for _, w := range c.Weights {
+			if w < 1 {
+				return fmt.Errorf("invalid weight %d, must be positive", w)
+			}
+		}
+	default:
+		return fmt.Errorf("invalid mode %q", c.Mode)
+	}
+	return nil
+}
+
+// Contains checks if the output is referenced by the group configuration.
+func (c *OutputGroupConfig) Contains(output *RunningOutput) bool {
+	return c.index(output) >= 0
+}
+
+func (c *OutputGroupConfig) index(output *RunningOutput) int {
+	for i, ref := range c.Outputs {
+		if output.MatchesReference(ref) {
+			return i
+		}
+	}
+	return -1
+}
+
+// MatchesReference checks if the given reference matches the alias of the
+// output or, for outputs without alias, the plugin name.
+func (r *RunningOutput) MatchesReference(ref string) bool {
+	if r.Config.Alias != "" {
+		return r.Config.Alias == ref
+	}
+	return r.Config.Name == ref
+}
+
+// OutputGroup routes each metric to a single output of the group.
+type OutputGroup struct {
+	Config  *OutputGroupConfig
+	Outputs []*RunningOutput
+
+	// Weighted distribution of the outputs with each output occupying as
+	// many slots as its weight
+	slots []*RunningOutput
+	next  atomic.Uint64
+}
+
+// NewOutputGroup creates a group for the given outputs. The outputs must be
+// in the order of the group configuration and may be a subset of the
+// configured outputs, e.g. if outputs failed to start.
+func NewOutputGroup(cfg *OutputGroupConfig, outputs []*RunningOutput) *OutputGroup {
+	g := &OutputGroup{
+		Config:  cfg,
+		Outputs: outputs,
+	}
+
+	for _, output := range outputs {
+		output.group.Store(g)
+		weight := 1
+		if i := cfg.index(output); i >= 0 && i < len(cfg.Weights) {
+			weight = cfg.Weights[i]
+		}
+		for range weight {
+			g.slots = append(g.slots, output)
+		}
+	}
+
+	return g
+}
+
+// Select returns the output to receive the given metric or nil if the group
+// has no outputs.
+func (g *OutputGroup) Select(metric telegraf.Metric) *RunningOutput {
+	if len(g.Outputs) == 0 {
+		return nil
+	}
+
+	switch g.Config.Mode {
+	case "failover":
+		// Use the first output not exceeding the allowed number of failed
+		// writes. If all outputs keep failing, fall back to the primary so the
+		// metrics queue up in its buffer.
+		for _, output := range g.Outputs {
+			if output.WriteFailures() < g.Config.FailoverAttempts {
+				return output
+			}
+		}
+		return g.Outputs[0]
+	case "hash":
+		return g.slots[g.hash(metric)%uint64(len(g.slots))]
+	case "round_robin":
+		return g.slots[(g.next.Add(1)-1)%uint64(len(g.slots))]
+	}
+	return nil
+}
+
+// Active returns the outputs currently receiving metrics, i.e. the selected
+// output in failover mode and all outputs in the sharding modes.
+func (g *OutputGroup) Active() []*RunningOutput {
+	if g.Config.Mode != "failover" {
+		return g.Outputs
+	}
+	if output := g.Select(nil); output != nil {
+		return []*RunningOutput{output}
+	}
+	return nil
+}
+
+// failoverTarget returns the output taking over the buffered metrics of the
+// given output of a failover group. This is the output currently selected for
+// new metrics if the given output exceeded the allowed number of failed
+// writes, and nil otherwise.
+func (g *OutputGroup) failoverTarget(output *RunningOutput) *RunningOutput {
+	if g.Config.Mode != "failover" || output.WriteFailures() < g.Config.FailoverAttempts {
+		return nil
+	}
+	if target := g.Select(nil); target != output {
+		return target
+	}
+	return nil
+}
+
+// LeaveGroup removes the output from the group it was assigned to when
+// creating the group.
+func (r *RunningOutput) LeaveGroup() {
+	r.group.Store(nil)
+}
+
+// failover moves the buffered metrics to the next output of the failover
+// group once the output exceeded the allowed number of failed writes, so the
+// metrics are not stuck with the failing output. The oldest metric is kept
+// as the failing output does not receive new metrics and needs a metric to
+// retry writing and to detect its recovery.
+func (r *RunningOutput) failover() {
+	group := r.group.Load()
+	if group == nil {
+		return
+	}
+	target := group.failoverTarget(r)
+	if target == nil {
+		return
+	}
+
+	// Each transaction starts with the kept metric so only move the others.
+	// Metrics added concurrently are not moved as new metrics are routed to
+	// the target anyway.
+	batchSize := max(r.MetricBatchSize, 2)
+	var moved int
+	for remaining := r.buffer.Len() - 1; remaining > 0; {
+		tx := r.buffer.BeginTransaction(min(remaining+1, batchSize))
+		if len(tx.Batch) < 2 {
+			r.buffer.EndTransaction(tx)
+			break
+		}
+		tx.Move = make([]int, 0, len(tx.Batch)-1)
+		for i := range tx.Batch[1:] {
+			tx.Move = append(tx.Move, i+1)
+		}
+		target.addMoved(tx.Batch[1:])
+		r.buffer.EndTransaction(tx)
+		moved += len(tx.Move)
+		remaining -= len(tx.Move)
+	}
+	if moved > 0 {
+		r.log.Warnf("Moved %d buffered metrics to %s after %d failed writes", moved, target.LogName(), r.WriteFailures())
+	}
+}
+
+// addMoved adds metrics moved from the buffer of another output of the group.
+// The metrics already passed the filters and modifiers of the other output so
+// they are added to the buffer without being filtered or modified again.
+func (r *RunningOutput) addMoved(metrics []telegraf.Metric) {
+	r.droppedMetrics.Add(int64(r.buffer.Add(metrics...)))
+	r.triggerBatchCheck()
+}
+
+// hash computes the hash of the metric's series. Without configured tags all
+// tags of the metric are used.
+func (g *OutputGroup) hash(metric telegraf.Metric) uint64 {
+	if len(g.Config.HashTags) == 0 {
+		return metric.HashID()
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(metric.Name()))
+	for _, key := range g.Config.HashTags {
+		value, _ := metric.GetTag(key)
+		h.Write([]byte{0})
+		h.Write([]byte(value))
+	}
+	return h.Sum64()
+}
@@ -0,0 +1,213 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestOutputGroupConfigValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      *OutputGroupConfig
+		expected string
+	}{
+		{
+			name:     "no outputs",
+			cfg:      &OutputGroupConfig{Mode: "failover"},
+			expected: "no outputs specified",
+		},
+		{
+			name:     "invalid mode",
+			cfg:      &OutputGroupConfig{Mode: "random", Outputs: []string{"a"}},
+			expected: `invalid mode "random"`,
+		},
+		{
+			name:     "weights in failover mode",
+			cfg:      &OutputGroupConfig{Mode: "failover", Outputs: []string{"a", "b"}, Weights: []int{1, 2}},
+			expected: "weights are not supported",
+		},
+		{
+			name:     "weights mismatch",
+			cfg:      &OutputGroupConfig{Mode: "hash", Outputs: []string{"a", "b"}, Weights: []int{1}},
+			expected: "number of weights (1) does not match number of outputs (2)",
+		},
+		{
+			name:     "zero weight",
+			cfg:      &OutputGroupConfig{Mode: "round_robin", Outputs: []string{"a", "b"}, Weights: []int{1, 0}},
+			expected: "invalid weight 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorContains(t, tt.cfg.Validate(), tt.expected)
+		})
+	}
+
+	cfg := &OutputGroupConfig{Mode: "failover", Outputs: []string{"a", "b"}}
+	require.NoError(t, cfg.Validate())
+	require.Equal(t, DefaultFailoverAttempts, cfg.FailoverAttempts)
+}
+
+func TestOutputGroupFailover(t *testing.T) {
+	primaryOutput := &mockOutput{batchAcceptSize: -1}
+	primary := newGroupTestOutput(t, primaryOutput, "primary")
+	secondary := newGroupTestOutput(t, &mockOutput{}, "secondary")
+
+	cfg := &OutputGroupConfig{Mode: "failover", Outputs: []string{"primary", "secondary"}, FailoverAttempts: 2}
+	g := NewOutputGroup(cfg, []*RunningOutput{primary, secondary})
+
+	m := first5[0]
+	require.Same(t, primary, g.Select(m))
+
+	// Keep the primary until it failed for the configured number of attempts
+	primary.AddMetric(m)
+	require.Error(t, primary.Write())
+	require.Same(t, primary, g.Select(m))
+	require.Error(t, primary.Write())
+	require.Same(t, secondary, g.Select(m))
+
+	// Switch back as soon as the primary recovers
+	primaryOutput.batchAcceptSize = 0
+	require.NoError(t, primary.Write())
+	require.Len(t, primaryOutput.Metrics(), 1)
+	require.Same(t, primary, g.Select(m))
+}
+
+func TestOutputGroupFailoverMovesBuffer(t *testing.T) {
+	primaryOutput := &mockOutput{batchAcceptSize: -1}
+	primary := newGroupTestOutput(t, primaryOutput, "primary")
+	secondaryOutput := &mockOutput{}
+	secondary := newGroupTestOutput(t, secondaryOutput, "secondary")
+
+	cfg := &OutputGroupConfig{Mode: "failover", Outputs: []string{"primary", "secondary"}, FailoverAttempts: 2}
+	g := NewOutputGroup(cfg, []*RunningOutput{primary, secondary})
+
+	for _, m := range first5 {
+		primary.AddMetric(m)
+	}
+	require.Error(t, primary.Write())
+	require.Equal(t, 5, primary.BufferLength())
+	require.Zero(t, secondary.BufferLength())
+
+	// When failing over, the buffered metrics are moved to the secondary
+	// except for the oldest one kept to detect the recovery of the primary
+	require.Error(t, primary.Write())
+	require.Same(t, secondary, g.Select(first5[0]))
+	require.Equal(t, 1, primary.BufferLength())
+	require.Equal(t, 4, secondary.BufferLength())
+	require.Zero(t, primary.BufferStats().MetricsWritten.Get())
+	require.Zero(t, primary.BufferStats().MetricsRejected.Get())
+
+	require.NoError(t, secondary.Write())
+	testutil.RequireMetricsEqual(t, first5[1:], secondaryOutput.Metrics())
+
+	primaryOutput.batchAcceptSize = 0
+	require.NoError(t, primary.Write())
+	testutil.RequireMetricsEqual(t, first5[:1], primaryOutput.Metrics())
+	require.Same(t, primary, g.Select(first5[0]))
+}
+
+func TestOutputGroupFailoverMovesModifiedMetrics(t *testing.T) {
+	primaryOutput := &mockOutput{batchAcceptSize: -1}
+	primary, err := NewRunningOutput(primaryOutput, &OutputConfig{Name: "test", Alias: "primary", NamePrefix: "a_"}, 1000, 10000)
+	require.NoError(t, err)
+	secondaryOutput := &mockOutput{}
+	secondary, err := NewRunningOutput(secondaryOutput, &OutputConfig{Name: "test", Alias: "secondary", NamePrefix: "b_"}, 1000, 10000)
+	require.NoError(t, err)
+
+	cfg := &OutputGroupConfig{Mode: "failover", Outputs: []string{"primary", "secondary"}, FailoverAttempts: 1}
+	NewOutputGroup(cfg, []*RunningOutput{primary, secondary})
+
+	now := time.Now()
+	primary.AddMetric(metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1}, now))
+	primary.AddMetric(metric.New("mem", map[string]string{}, map[string]interface{}{"value": 2}, now))
+	require.Error(t, primary.Write())
+	require.Equal(t, 1, secondary.BufferLength())
+
+	// The moved metric keeps the prefix of the primary without getting the
+	// prefix of the secondary in addition
+	require.NoError(t, secondary.Write())
+	expected := []telegraf.Metric{
+		metric.New("a_mem", map[string]string{}, map[string]interface{}{"value": 2}, now),
+	}
+	testutil.RequireMetricsEqual(t, expected, secondaryOutput.Metrics())
+}
+
+func TestOutputGroupFailoverAllFailing(t *testing.T) {
+	primary := newGroupTestOutput(t, &mockOutput{batchAcceptSize: -1}, "primary")
+	secondary := newGroupTestOutput(t, &mockOutput{batchAcceptSize: -1}, "secondary")
+
+	cfg := &OutputGroupConfig{Mode: "failover", Outputs: []string{"primary", "secondary"}, FailoverAttempts: 1}
+	g := NewOutputGroup(cfg, []*RunningOutput{primary, secondary})
+
+	for _, output := range g.Outputs {
+		output.AddMetric(first5[0])
+		require.Error(t, output.Write())
+	}
+	require.Same(t, primary, g.Select(first5[0]))
+}
+
+func TestOutputGroupRoundRobinWeighted(t *testing.T) {
+	a := newGroupTestOutput(t, &mockOutput{}, "a")
+	b := newGroupTestOutput(t, &mockOutput{}, "b")
+
+	cfg := &OutputGroupConfig{Mode: "round_robin", Outputs: []string{"a", "b"}, Weights: []int{3, 1}}
+	g := NewOutputGroup(cfg, []*RunningOutput{a, b})
+
+	counts := make(map[*RunningOutput]int)
+	for range 8 {
+		counts[g.Select(first5[0])]++
+	}
+	require.Equal(t, map[*RunningOutput]int{a: 6, b: 2}, counts)
+}
+
+func TestOutputGroupHash(t *testing.T) {
+	a := newGroupTestOutput(t, &mockOutput{}, "a")
+	b := newGroupTestOutput(t, &mockOutput{}, "b")
+
+	cfg := &OutputGroupConfig{Mode: "hash", Outputs: []string{"a", "b"}, HashTags: []string{"host"}}
+	g := NewOutputGroup(cfg, []*RunningOutput{a, b})
+
+	// Metrics of the same series must always be routed to the same output
+	now := time.Now()
+	selected := make(map[string]*RunningOutput)
+	for i := range 100 {
+		for _, host := range []string{"h1", "h2", "h3", "h4", "h5", "h6"} {
+			m := metric.New("cpu", map[string]string{"host": host, "cpu": "cpu0"}, map[string]interface{}{"value": i}, now)
+			output := g.Select(m)
+			if prev, found := selected[host]; found {
+				require.Same(t, prev, output)
+			}
+			selected[host] = output
+		}
+	}
+
+	// Tags not used for hashing must not change the selection
+	m1 := metric.New("cpu", map[string]string{"host": "h1", "cpu": "cpu0"}, map[string]interface{}{"value": 1}, now)
+	m2 := metric.New("cpu", map[string]string{"host": "h1", "cpu": "cpu1"}, map[string]interface{}{"value": 1}, now)
+	require.Same(t, g.Select(m1), g.Select(m2))
+}
+
+func TestOutputGroupMatchesReference(t *testing.T) {
+	unnamed := newGroupTestOutput(t, &mockOutput{}, "")
+	require.True(t, unnamed.MatchesReference("test"))
+
+	aliased := newGroupTestOutput(t, &mockOutput{}, "foo")
+	require.True(t, aliased.MatchesReference("foo"))
+	require.False(t, aliased.MatchesReference("test"))
+}
+
+func newGroupTestOutput(t *testing.T, output telegraf.Output, alias string) *RunningOutput {
+	t.Helper()
+
+	ro, err := NewRunningOutput(output, &OutputConfig{Name: "test", Alias: alias}, 1000, 10000)
+	require.NoError(t, err)
+	return ro
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// DefaultPipeline is the name of the pipeline of all plugins not assigned to
+// a pipeline explicitly.
+const DefaultPipeline = "default"
+
+var pipelineNameRe = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// CheckPipelineName checks if the given name is a valid pipeline name.
+func CheckPipelineName(name string) error {
+	if !pipelineNameRe.MatchString(name) {
+		return fmt.Errorf("invalid pipeline name %q", name)
+	}
+	return nil
+}
+
+// Subscribes checks if the output receives the metrics of the given pipeline.
+func (r *RunningOutput) Subscribes(pipeline string) bool {
+	return slices.Contains(r.Config.Pipelines, pipeline)
+}
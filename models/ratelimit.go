@@ -0,0 +1,78 @@
+package models
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// tokenBucket limits the rate of a resource, e.g. metrics or bytes, while
+// allowing bursts up to the capacity of the bucket. The bucket is refilled
+// continuously with the configured rate. Taking more tokens than available
+// results in a debt delaying subsequent operations, so a single request larger
+// than the capacity can still pass once the bucket is full.
+type tokenBucket struct {
+	rate     float64
+	capacity float64
+
+	tokens float64
+	last   time.Time
+
+	sync.Mutex
+}
+
+// newTokenBucket creates a bucket with the given rate per second and a
+// capacity for bursts of the given duration. A burst duration below one
+// second is raised to one second.
+func newTokenBucket(rate float64, burst time.Duration) *tokenBucket {
+	burst = max(burst, time.Second)
+	capacity := rate * burst.Seconds()
+	return &tokenBucket{
+		rate:     rate,
+		capacity: capacity,
+		tokens:   capacity,
+	}
+}
+
+// Available returns the number of tokens available at the given time.
+func (b *tokenBucket) Available(t time.Time) int64 {
+	b.Lock()
+	defer b.Unlock()
+
+	b.refill(t)
+	if b.tokens <= 0 {
+		return 0
+	}
+	return int64(math.Floor(b.tokens))
+}
+
+// Take removes the given number of tokens from the bucket.
+func (b *tokenBucket) Take(t time.Time, n int64) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.refill(t)
+	b.tokens -= float64(n)
+}
+
+// Delay returns the time to wait from the given time on until at least one
+// token is available.
+func (b *tokenBucket) Delay(t time.Time) time.Duration {
+	b.Lock()
+	defer b.Unlock()
+
+	b.refill(t)
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration(math.Ceil((1 - b.tokens) / b.rate * float64(time.Second)))
+}
+
+func (b *tokenBucket) refill(t time.Time) {
+	if !b.last.IsZero() && t.After(b.last) {
+		b.tokens = min(b.tokens+t.Sub(b.last).Seconds()*b.rate, b.capacity)
+	}
+	if t.After(b.last) {
+		b.last = t
+	}
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucketBurst(t *testing.T) {
+	start := time.Now()
+	b := newTokenBucket(10, 5*time.Second)
+
+	// The bucket starts full allowing a burst of five seconds worth of tokens
+	require.EqualValues(t, 50, b.Available(start))
+	b.Take(start, 50)
+	require.Zero(t, b.Available(start))
+	require.Equal(t, 100*time.Millisecond, b.Delay(start))
+
+	// Tokens are refilled with the configured rate
+	require.EqualValues(t, 5, b.Available(start.Add(500*time.Millisecond)))
+
+	// The bucket is never filled beyond its capacity
+	require.EqualValues(t, 50, b.Available(start.Add(time.Hour)))
+}
+
+func TestTokenBucketDebt(t *testing.T) {
+	start := time.Now()
+	b := newTokenBucket(100, time.Second)
+
+	// Taking more tokens than available delays further requests
+	b.Take(start, 300)
+	require.Zero(t, b.Available(start))
+	require.Equal(t, 2010*time.Millisecond, b.Delay(start))
+	require.Zero(t, b.Available(start.Add(2*time.Second)))
+	require.EqualValues(t, 100, b.Available(start.Add(3*time.Second)))
+}
+
+func TestTokenBucketMinimumBurst(t *testing.T) {
+	b := newTokenBucket(10, 0)
+	require.EqualValues(t, 10, b.Available(time.Now()))
+}
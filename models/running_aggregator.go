@@ -1,9 +1,14 @@
 package models
 
 import (
+	"encoding/json"
+	"fmt"
+	"reflect"
 	"sync"
 	"time"
 
+	"github.com/benbjohnson/clock"
+
 	"github.com/influxdata/telegraf"
 	logging "github.com/influxdata/telegraf/logger"
 	"github.com/influxdata/telegraf/metric"
@@ -17,6 +22,7 @@ type RunningAggregator struct {
 	periodStart time.Time
 	periodEnd   time.Time
 	log         telegraf.Logger
+	clk         clock.Clock
 
 	MetricsPushed   selfstat.Stat
 	MetricsFiltered selfstat.Stat
@@ -24,6 +30,14 @@ type RunningAggregator struct {
 	PushTime        selfstat.Stat
 }
 
+// aggregatorState is the persisted state of a stateful aggregator plugin
+// together with the aggregation window the state belongs to.
+type aggregatorState struct {
+	PeriodStart time.Time       `json:"period_start"`
+	PeriodEnd   time.Time       `json:"period_end"`
+	Plugin      json.RawMessage `json:"plugin,omitempty"`
+}
+
 func NewRunningAggregator(aggregator telegraf.Aggregator, config *AggregatorConfig) *RunningAggregator {
 	tags := map[string]string{
 		"_id":        config.ID,
@@ -68,6 +82,7 @@ func NewRunningAggregator(aggregator telegraf.Aggregator, config *AggregatorConf
 			tags,
 		),
 		log: logger,
+		clk: clock.New(),
 	}
 }
 
@@ -81,6 +96,7 @@ type AggregatorConfig struct {
 	Period       time.Duration
 	Delay        time.Duration
 	Grace        time.Duration
+	Pipeline     string
 	LogLevel     string
 
 	NameOverride      string
@@ -119,12 +135,69 @@ func (r *RunningAggregator) EndPeriod() time.Time {
 	return r.periodEnd
 }
 
+// SetClock replaces the clock used to determine the current aggregation
+// window, e.g. to simulate the passing of time when replaying metrics.
+func (r *RunningAggregator) SetClock(clk clock.Clock) {
+	r.Lock()
+	defer r.Unlock()
+	r.clk = clk
+}
+
 func (r *RunningAggregator) UpdateWindow(start, until time.Time) {
 	r.periodStart = start
 	r.periodEnd = until
 	r.log.Debugf("Updated aggregation range [%s, %s]", start, until)
 }
 
+// GetState returns the state of the aggregator plugin including the current
+// aggregation window for persisting it across restarts.
+func (r *RunningAggregator) GetState() interface{} {
+	r.Lock()
+	defer r.Unlock()
+
+	state := aggregatorState{
+		PeriodStart: r.periodStart,
+		PeriodEnd:   r.periodEnd,
+	}
+	if p, ok := r.Aggregator.(telegraf.StatefulPlugin); ok {
+		data, err := json.Marshal(p.GetState())
+		if err != nil {
+			r.log.Errorf("Serializing state failed: %v", err)
+		} else {
+			state.Plugin = data
+		}
+	}
+	return state
+}
+
+// SetState restores the aggregator plugin state and the aggregation window.
+// The restored window is kept when starting the aggregator to continue the
+// aggregation seamlessly.
+func (r *RunningAggregator) SetState(state interface{}) error {
+	s, ok := state.(aggregatorState)
+	if !ok {
+		return fmt.Errorf("invalid state type %T", state)
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	if p, ok := r.Aggregator.(telegraf.StatefulPlugin); ok && len(s.Plugin) > 0 {
+		nstate := reflect.New(reflect.TypeOf(p.GetState())).Interface()
+		if err := json.Unmarshal(s.Plugin, nstate); err != nil {
+			return fmt.Errorf("decoding plugin state failed: %w", err)
+		}
+		if err := p.SetState(reflect.ValueOf(nstate).Elem().Interface()); err != nil {
+			return fmt.Errorf("restoring plugin state failed: %w", err)
+		}
+	}
+
+	r.periodStart = s.PeriodStart
+	r.periodEnd = s.PeriodEnd
+	r.log.Debugf("Restored aggregation range [%s, %s]", s.PeriodStart, s.PeriodEnd)
+	return nil
+}
+
 func (r *RunningAggregator) MakeMetric(telegrafMetric telegraf.Metric) telegraf.Metric {
 	m := makeMetric(
 		telegrafMetric,
@@ -186,7 +259,7 @@ func (r *RunningAggregator) Push(acc telegraf.Accumulator) {
 	// not be the case if the machine's clock was adjusted or the machine
 	// hibernated as in those cases the clock might be advanced before or
 	// after the initial aggregation window.
-	nowWall := time.Now().Truncate(-1)
+	nowWall := r.clk.Now().Truncate(-1)
 	if nowWall.Before(since.Truncate(-1)) || nowWall.After(until.Truncate(-1)) {
 		since = nowWall.Truncate(r.Config.Period)
 		until = since.Add(r.Config.Period)
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
@@ -240,6 +242,38 @@ func TestRunningAggregatorAddDoesNotModifyMetric(t *testing.T) {
 	testutil.RequireMetricEqual(t, expected, m)
 }
 
+func TestRunningAggregatorState(t *testing.T) {
+	ra := NewRunningAggregator(&mockStatefulAggregator{}, &AggregatorConfig{
+		Name:   "TestRunningAggregator",
+		Period: time.Minute,
+	})
+	require.NoError(t, ra.Config.Filter.Compile())
+
+	start := time.Now().Truncate(time.Minute)
+	ra.UpdateWindow(start, start.Add(time.Minute))
+	ra.Add(metric.New("RITest", map[string]string{}, map[string]interface{}{"value": int64(101)}, start))
+
+	// Round-trip the state through JSON as done by the persister
+	data, err := json.Marshal(ra.GetState())
+	require.NoError(t, err)
+	var state aggregatorState
+	require.NoError(t, json.Unmarshal(data, &state))
+
+	restored := NewRunningAggregator(&mockStatefulAggregator{}, &AggregatorConfig{
+		Name:   "TestRunningAggregator",
+		Period: time.Minute,
+	})
+	require.NoError(t, restored.SetState(state))
+	require.True(t, start.Add(time.Minute).Equal(restored.EndPeriod()))
+
+	acc := testutil.Accumulator{}
+	restored.Push(&acc)
+	require.Len(t, acc.Metrics, 1)
+	require.Equal(t, int64(101), acc.Metrics[0].Fields["sum"])
+
+	require.ErrorContains(t, restored.SetState("invalid"), "invalid state type")
+}
+
 type mockAggregator struct {
 	sum int64
 }
@@ -266,3 +300,20 @@ func (t *mockAggregator) Add(in telegraf.Metric) {
 		}
 	}
 }
+
+type mockStatefulAggregator struct {
+	mockAggregator
+}
+
+func (t *mockStatefulAggregator) GetState() interface{} {
+	return t.sum
+}
+
+func (t *mockStatefulAggregator) SetState(state interface{}) error {
+	sum, ok := state.(int64)
+	if !ok {
+		return fmt.Errorf("invalid state type %T", state)
+	}
+	t.sum = sum
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/clock"
 	logging "github.com/influxdata/telegraf/logger"
 	"github.com/influxdata/telegraf/selfstat"
 )
@@ -29,12 +30,16 @@ type RunningInput struct {
 	retries     uint64
 	gatherStart time.Time
 	gatherEnd   time.Time
+	series      *seriesLimiter
+	tracer      *Tracer
 
 	MetricsGathered selfstat.Stat
 	GatherTime      selfstat.Stat
 	GatherTimeouts  selfstat.Stat
 	GatherErrors    selfstat.Stat
 	StartupErrors   selfstat.Stat
+	Series          selfstat.Stat
+	SeriesDropped   selfstat.Stat
 }
 
 func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
@@ -57,7 +62,7 @@ func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
 	SetLoggerOnPlugin(input, logger)
 	SetStatisticsOnPlugin(input, logger, tags)
 
-	return &RunningInput{
+	ri := &RunningInput{
 		Input:  input,
 		Config: config,
 		MetricsGathered: selfstat.Register(
@@ -65,7 +70,7 @@ func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
 			"metrics_gathered",
 			tags,
 		),
-		GatherTime: selfstat.RegisterTiming(
+		GatherTime: selfstat.RegisterQuantileTiming(
 			"gather",
 			"gather_time_ns",
 			tags,
@@ -87,6 +92,14 @@ func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
 		),
 		log: logger,
 	}
+
+	// Only track the series if limited to avoid the memory overhead
+	if config.MaxSeries > 0 {
+		ri.Series = selfstat.Register("gather", "series", tags)
+		ri.SeriesDropped = selfstat.Register("gather", "series_dropped", tags)
+		ri.series = newSeriesLimiter(config, logger, ri.Series)
+	}
+	return ri
 }
 
 // InputConfig is the common config for all inputs.
@@ -99,10 +112,19 @@ type InputConfig struct {
 	CollectionJitter     time.Duration
 	CollectionJitterSet  bool
 	CollectionOffset     time.Duration
+	Schedule             *clock.Schedule
+	Windows              *clock.Windows
 	Precision            time.Duration
 	TimeSource           string
 	StartupErrorBehavior string
 	LogLevel             string
+	Pipeline             string
+
+	MaxSeries         int
+	SeriesLimitPolicy string
+	SeriesKeepTags    []string
+	SeriesSampleRate  int
+	SeriesTTL         time.Duration
 
 	NameOverride            string
 	MeasurementPrefix       string
@@ -136,6 +158,10 @@ func (r *RunningInput) Init() error {
 		return fmt.Errorf("invalid 'time_source' setting %q", r.Config.TimeSource)
 	}
 
+	if err := CheckSeriesLimitPolicy(r.Config.SeriesLimitPolicy); err != nil {
+		return err
+	}
+
 	if p, ok := r.Input.(telegraf.Initializer); ok {
 		return p.Init()
 	}
@@ -195,6 +221,24 @@ func (r *RunningInput) Stop() {
 	}
 }
 
+// Pause pauses consuming new messages for service inputs supporting it and
+// returns false for all other inputs.
+func (r *RunningInput) Pause() bool {
+	plugin, ok := r.Input.(telegraf.PausableInput)
+	if ok {
+		plugin.Pause()
+	}
+	return ok
+}
+
+// Resume continues consuming new messages for service inputs supporting
+// pausing.
+func (r *RunningInput) Resume() {
+	if plugin, ok := r.Input.(telegraf.PausableInput); ok {
+		plugin.Resume()
+	}
+}
+
 func (r *RunningInput) ID() string {
 	if p, ok := r.Input.(telegraf.PluginWithID); ok {
 		return p.ID()
@@ -244,6 +288,21 @@ func (r *RunningInput) MakeMetric(metric telegraf.Metric) telegraf.Metric {
 	default:
 	}
 
+	if r.series != nil && !r.series.Admit(metric, time.Now()) {
+		r.SeriesDropped.Incr(1)
+		r.metricFiltered(metric)
+		return nil
+	}
+
+	if r.tracer != nil {
+		// Service inputs produce metrics independent of gathering
+		start := r.gatherStart
+		if _, ok := r.Input.(telegraf.ServiceInput); ok || start.IsZero() {
+			start = time.Now()
+		}
+		metric = r.tracer.Sample(metric, TraceStageGather, r.LogName(), r.ID(), start)
+	}
+
 	r.MetricsGathered.Incr(1)
 	GlobalMetricsGathered.Incr(1)
 	return metric
@@ -280,6 +339,12 @@ func (r *RunningInput) Gather(acc telegraf.Accumulator) error {
 	return nil
 }
 
+// SetTracer sets the tracer sampling the metrics of the input. Passing nil
+// disables tracing.
+func (r *RunningInput) SetTracer(tracer *Tracer) {
+	r.tracer = tracer
+}
+
 func (r *RunningInput) SetDefaultTags(tags map[string]string) {
 	r.defaultTags = tags
 }
@@ -274,6 +274,29 @@ func TestRunningInputMetricErrorCounters(t *testing.T) {
 	require.GreaterOrEqual(t, int64(1), GlobalGatherErrors.Get())
 }
 
+func TestRunningInputMakeMetricSeriesLimit(t *testing.T) {
+	ri := NewRunningInput(&mockInput{}, &InputConfig{
+		Name:      "TestMakeMetricSeriesLimit",
+		MaxSeries: 2,
+	})
+	require.NoError(t, ri.Init())
+
+	for _, host := range []string{"a", "b", "c", "a", "d"} {
+		m := metric.New("cpu",
+			map[string]string{"host": host},
+			map[string]interface{}{"value": 42},
+			time.Now(),
+		)
+		if host == "a" || host == "b" {
+			require.NotNil(t, ri.MakeMetric(m))
+		} else {
+			require.Nil(t, ri.MakeMetric(m))
+		}
+	}
+	require.Equal(t, int64(2), ri.Series.Get())
+	require.Equal(t, int64(2), ri.SeriesDropped.Get())
+}
+
 func TestRunningInputMakeMetricWithAlwaysKeepingPluginTagsDisabled(t *testing.T) {
 	now := time.Now()
 	ri := NewRunningInput(&mockInput{}, &InputConfig{
@@ -3,6 +3,7 @@ package models
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -10,11 +11,16 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	logging "github.com/influxdata/telegraf/logger"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/selfstat"
 )
 
 var GlobalWriteErrors = selfstat.Register("agent", "write_errors", make(map[string]string))
 
+// errRateLimited signals that a write was skipped due to the output's rate
+// limits and the metrics were kept in the buffer.
+var errRateLimited = errors.New("rate limit exceeded")
+
 const (
 	// Default size of metrics batch size.
 	DefaultMetricBatchSize = 1000
@@ -41,9 +47,33 @@ type OutputConfig struct {
 	NamePrefix   string
 	NameSuffix   string
 
-	BufferStrategy  string
-	BufferDirectory string
-	BufferDiskSync  bool
+	BufferStrategy       string
+	BufferDirectory      string
+	BufferDiskSync       bool
+	BufferSpillThreshold int
+	BufferSpillAfter     time.Duration
+	BufferMaxDiskSize    int64
+	BufferMaxAge         time.Duration
+
+	// Maximum number of metrics and bytes per second to write and the
+	// duration of bursts allowed above these rates
+	RateLimitMetrics int
+	RateLimitBytes   int64
+	RateLimitBurst   time.Duration
+
+	// Exponential backoff of retries after failed writes and the number of
+	// consecutive failures opening the circuit breaker for the reset timeout
+	RetryBackoffInitial        time.Duration
+	RetryBackoffMax            time.Duration
+	RetryBackoffJitter         time.Duration
+	CircuitBreakerThreshold    int
+	CircuitBreakerResetTimeout time.Duration
+
+	// Output or file receiving the metrics rejected by this output
+	DeadLetter string
+
+	// Pipelines the output receives the metrics of
+	Pipelines []string
 
 	LogLevel string
 }
@@ -54,6 +84,7 @@ type RunningOutput struct {
 	droppedMetrics  atomic.Int64
 	writeInFlight   atomic.Bool
 	lastWriteFailed atomic.Bool
+	writeFailures   atomic.Int64
 
 	Output            telegraf.Output
 	Config            *OutputConfig
@@ -64,11 +95,25 @@ type RunningOutput struct {
 	WriteTime       selfstat.Stat
 	WriteErrors     selfstat.Stat
 	StartupErrors   selfstat.Stat
+	RateLimited     selfstat.Stat
+	CircuitState    selfstat.Stat
+	DeadLetters     selfstat.Stat
 
 	BatchReady chan time.Time
 
-	buffer Buffer
-	log    telegraf.Logger
+	buffer       Buffer
+	bufferConfig *BufferConfig
+	log          telegraf.Logger
+
+	metricLimiter *tokenBucket
+	byteLimiter   *tokenBucket
+	sizer         *influx.Serializer
+	breaker       *circuitBreaker
+
+	deadLetterOutput atomic.Pointer[RunningOutput]
+	deadLetterFile   *deadLetterFile
+
+	group atomic.Pointer[OutputGroup]
 
 	started bool
 	retries uint64
@@ -109,13 +154,18 @@ func NewRunningOutput(output telegraf.Output, config *OutputConfig, batchSize, b
 		batchSize = DefaultMetricBatchSize
 	}
 
-	b, err := NewBuffer(config.Name, config.ID, config.Alias, bufferLimit, config.BufferStrategy, config.BufferDirectory, config.BufferDiskSync)
-	if err != nil {
-		return nil, fmt.Errorf("creating buffer failed: %w", err)
+	bufferConfig := &BufferConfig{
+		Strategy:       config.BufferStrategy,
+		Directory:      config.BufferDirectory,
+		DiskSync:       config.BufferDiskSync,
+		SpillThreshold: config.BufferSpillThreshold,
+		SpillAfter:     config.BufferSpillAfter,
+		MaxDiskSize:    config.BufferMaxDiskSize,
+		MaxAge:         config.BufferMaxAge,
 	}
 
 	ro := &RunningOutput{
-		buffer:            b,
+		bufferConfig:      bufferConfig,
 		BatchReady:        make(chan time.Time, 1),
 		Output:            output,
 		Config:            config,
@@ -126,7 +176,7 @@ func NewRunningOutput(output telegraf.Output, config *OutputConfig, batchSize, b
 			"metrics_filtered",
 			tags,
 		),
-		WriteTime: selfstat.RegisterTiming(
+		WriteTime: selfstat.RegisterQuantileTiming(
 			"write",
 			"write_time_ns",
 			tags,
@@ -141,12 +191,69 @@ func NewRunningOutput(output telegraf.Output, config *OutputConfig, batchSize, b
 			"startup_errors",
 			tags,
 		),
-		log: logger,
+		RateLimited: selfstat.Register(
+			"write",
+			"rate_limited",
+			tags,
+		),
+		CircuitState: selfstat.Register(
+			"write",
+			"circuit_state",
+			tags,
+		),
+		DeadLetters: selfstat.Register(
+			"write",
+			"dead_letters",
+			tags,
+		),
+		breaker: newCircuitBreaker(config),
+		log:     logger,
+	}
+
+	if config.RateLimitMetrics > 0 {
+		ro.metricLimiter = newTokenBucket(float64(config.RateLimitMetrics), config.RateLimitBurst)
+	}
+	if config.RateLimitBytes > 0 {
+		ro.byteLimiter = newTokenBucket(float64(config.RateLimitBytes), config.RateLimitBurst)
+		ro.sizer = &influx.Serializer{}
+		if err := ro.sizer.Init(); err != nil {
+			return nil, fmt.Errorf("creating serializer for rate-limiting failed: %w", err)
+		}
+	}
+
+	// Buffers persisted on disk are opened when initializing the output to
+	// not touch the files of a running instance of the same output, e.g. when
+	// building the outputs of a new configuration on reload.
+	if !bufferConfig.persistent() {
+		if err := ro.openBuffer(); err != nil {
+			return nil, err
+		}
 	}
 
 	return ro, nil
 }
 
+// openBuffer creates the buffer and the dead-letter file of the output if
+// not done already.
+func (r *RunningOutput) openBuffer() error {
+	if r.buffer == nil {
+		b, err := NewBufferFromConfig(r.Config.Name, r.Config.ID, r.Config.Alias, r.MetricBufferLimit, r.bufferConfig)
+		if err != nil {
+			return fmt.Errorf("creating buffer failed: %w", err)
+		}
+		r.buffer = b
+	}
+
+	if path, found := strings.CutPrefix(r.Config.DeadLetter, deadLetterFilePrefix); found && r.deadLetterFile == nil {
+		f, err := newDeadLetterFile(path)
+		if err != nil {
+			return fmt.Errorf("creating dead-letter file failed: %w", err)
+		}
+		r.deadLetterFile = f
+	}
+	return nil
+}
+
 func (r *RunningOutput) LogName() string {
 	return logName("outputs", r.Config.Name, r.Config.Alias)
 }
@@ -170,6 +277,10 @@ func (r *RunningOutput) Init() error {
 		return fmt.Errorf("invalid 'startup_error_behavior' setting %q", r.Config.StartupErrorBehavior)
 	}
 
+	if err := r.openBuffer(); err != nil {
+		return err
+	}
+
 	if p, ok := r.Output.(telegraf.Initializer); ok {
 		err := p.Init()
 		if err != nil {
@@ -215,9 +326,32 @@ func (r *RunningOutput) Close() {
 		r.log.Errorf("Error closing output: %v", err)
 	}
 
-	if err := r.buffer.Close(); err != nil {
-		r.log.Errorf("Error closing output buffer: %v", err)
+	if r.buffer != nil {
+		if err := r.buffer.Close(); err != nil {
+			r.log.Errorf("Error closing output buffer: %v", err)
+		}
+	}
+
+	if r.deadLetterFile != nil {
+		if err := r.deadLetterFile.close(); err != nil {
+			r.log.Errorf("Error closing dead-letter file: %v", err)
+		}
+	}
+}
+
+// Discard releases the buffer of an output that was never connected, e.g. a
+// duplicate of an already running output created when reloading the config.
+func (r *RunningOutput) Discard() error {
+	var errs []error
+	if r.buffer != nil {
+		errs = append(errs, r.buffer.Close())
+		r.buffer = nil
+	}
+	if r.deadLetterFile != nil {
+		errs = append(errs, r.deadLetterFile.close())
+		r.deadLetterFile = nil
 	}
+	return errors.Join(errs...)
 }
 
 // AddMetric adds a metric to the output.
@@ -300,13 +434,43 @@ func (r *RunningOutput) triggerBatchCheck() {
 // Write writes all metrics to the output, stopping when all have been sent on
 // or error.
 func (r *RunningOutput) Write() error {
-	// Try to connect if we are not yet started up
+	return r.write(false)
+}
+
+// WriteOnShutdown writes all metrics to the output a final time before
+// stopping. Unlike Write the retry backoff, an open circuit breaker and the
+// rate limits are ignored as there is no later write to deliver the buffered
+// metrics.
+func (r *RunningOutput) WriteOnShutdown() error {
+	err := r.write(true)
+	if n := r.buffer.Len(); n > 0 {
+		if r.bufferConfig.persistent() {
+			r.log.Warnf("%d metrics left in the buffer on shutdown", n)
+		} else {
+			r.log.Warnf("%d metrics left in the buffer on shutdown and will be lost", n)
+		}
+	}
+	return err
+}
+
+func (r *RunningOutput) write(force bool) error {
+	if !force && r.backingOff() {
+		r.writeInFlight.Store(false)
+		return nil
+	}
+
+	// Try to connect if we are not yet started up. Failing to connect is
+	// considered a failed write, so reconnecting is delayed by the backoff
+	// and opens the circuit breaker in the same way.
 	if !r.started {
 		r.retries++
 		if err := r.Output.Connect(); err != nil {
 			var serr *internal.StartupError
 			if !errors.As(err, &serr) || !serr.Retry || !serr.Partial {
 				r.StartupErrors.Incr(1)
+				r.writeInFlight.Store(false)
+				r.recordWrite(true)
+				r.failover()
 				return internal.ErrNotConnected
 			}
 			r.log.Debugf("Partially connected after %d attempts", r.retries)
@@ -338,7 +502,11 @@ func (r *RunningOutput) Write() error {
 	nBuffer := r.buffer.Len()
 	nBatches := nBuffer/r.MetricBatchSize + 1
 	for i := 0; i < nBatches; i++ {
-		if err := r.doTransaction(); err != nil {
+		if err := r.doTransaction(force); err != nil {
+			// Keep the remaining metrics for the next write if rate-limited
+			if errors.Is(err, errRateLimited) {
+				return nil
+			}
 			return err
 		}
 	}
@@ -347,11 +515,19 @@ func (r *RunningOutput) Write() error {
 
 // WriteBatch writes a single batch of metrics to the output.
 func (r *RunningOutput) WriteBatch() error {
+	if r.backingOff() {
+		r.writeInFlight.Store(false)
+		return nil
+	}
+
 	// Try to connect if we are not yet started up
 	if !r.started {
 		r.retries++
 		if err := r.Output.Connect(); err != nil {
 			r.StartupErrors.Incr(1)
+			r.writeInFlight.Store(false)
+			r.recordWrite(true)
+			r.failover()
 			return internal.ErrNotConnected
 		}
 		r.started = true
@@ -366,27 +542,94 @@ func (r *RunningOutput) WriteBatch() error {
 		r.triggerBatchCheck()
 	}()
 
-	return r.doTransaction()
+	if err := r.doTransaction(false); !errors.Is(err, errRateLimited) {
+		return err
+	}
+	return nil
+}
+
+// RateLimitDelay returns the time to wait until the rate limits of the
+// output allow to write metrics again.
+func (r *RunningOutput) RateLimitDelay() time.Duration {
+	var delay time.Duration
+	now := time.Now()
+	if r.metricLimiter != nil {
+		delay = r.metricLimiter.Delay(now)
+	}
+	if r.byteLimiter != nil {
+		delay = max(delay, r.byteLimiter.Delay(now))
+	}
+	return delay
 }
 
-func (r *RunningOutput) doTransaction() error {
-	tx := r.buffer.BeginTransaction(r.MetricBatchSize)
+func (r *RunningOutput) doTransaction(unlimited bool) error {
+	// Determine the number of metrics allowed by the rate limits unless the
+	// limits should be ignored
+	metricLimiter, byteLimiter := r.metricLimiter, r.byteLimiter
+	if unlimited {
+		metricLimiter, byteLimiter = nil, nil
+	}
+	pending := min(r.MetricBatchSize, r.buffer.Len())
+	batchSize := r.MetricBatchSize
+	now := time.Now()
+	if metricLimiter != nil {
+		batchSize = int(min(int64(batchSize), metricLimiter.Available(now)))
+	}
+	if batchSize == 0 || (byteLimiter != nil && byteLimiter.Available(now) == 0) {
+		if pending > 0 {
+			r.RateLimited.Incr(1)
+		}
+		return errRateLimited
+	}
+
+	tx := r.buffer.BeginTransaction(batchSize)
 	if len(tx.Batch) == 0 {
 		return nil
 	}
-	err := r.writeMetrics(tx.Batch)
-	r.updateTransaction(tx, err)
+
+	// Only write the part of the batch fitting into the byte budget, the
+	// remaining metrics are kept in the buffer
+	metrics := tx.Batch
+	if byteLimiter != nil {
+		n, size := r.fitBytes(metrics, byteLimiter.Available(now))
+		metrics = metrics[:n]
+		byteLimiter.Take(now, size)
+	}
+	if metricLimiter != nil {
+		metricLimiter.Take(now, int64(len(metrics)))
+	}
+
+	start := time.Now()
+	err := r.writeMetrics(metrics)
+	r.updateTransaction(tx, len(metrics), err)
+	r.traceWrite(tx, start, time.Now())
+	r.deadLetter(tx, err)
 	r.buffer.EndTransaction(tx)
 
 	if err != nil {
 		r.WriteErrors.Incr(1)
 		GlobalWriteErrors.Incr(1)
+		r.failover()
 		return err
 	}
 
+	if len(metrics) < pending {
+		r.RateLimited.Incr(1)
+		return errRateLimited
+	}
 	return nil
 }
 
+// traceWrite completes the traces of the sampled metrics written successfully
+// in the transaction.
+func (r *RunningOutput) traceWrite(tx *Transaction, start, end time.Time) {
+	for _, idx := range tx.Accept {
+		if trace := TraceOf(tx.Batch[idx]); trace != nil {
+			trace.Finish(TraceStageWrite, r.LogName(), r.ID(), start, end)
+		}
+	}
+}
+
 func (r *RunningOutput) writeMetrics(metrics []telegraf.Metric) error {
 	if dropped := r.droppedMetrics.Load(); dropped > 0 {
 		r.log.Warnf("Metric buffer overflow; %d metrics have been dropped", dropped)
@@ -404,11 +647,53 @@ func (r *RunningOutput) writeMetrics(metrics []telegraf.Metric) error {
 	return err
 }
 
-func (r *RunningOutput) updateTransaction(tx *Transaction, err error) {
+// fitBytes returns the number of metrics fitting into the given number of
+// bytes and their size. The first metric is always included to not block
+// metrics exceeding the budget on their own forever.
+func (r *RunningOutput) fitBytes(metrics []telegraf.Metric, budget int64) (int, int64) {
+	var total int64
+	for i, m := range metrics {
+		var size int64
+		if octets, err := r.sizer.Serialize(m); err == nil {
+			size = int64(len(octets))
+		}
+		if i > 0 && total+size > budget {
+			return i, total
+		}
+		total += size
+	}
+	return len(metrics), total
+}
+
+func (r *RunningOutput) updateTransaction(tx *Transaction, written int, err error) {
 	// No error indicates all metrics were written successfully
 	if err == nil {
-		r.lastWriteFailed.Store(false)
-		tx.AcceptAll()
+		r.setWriteFailed(false)
+		if written == len(tx.Batch) {
+			tx.AcceptAll()
+			return
+		}
+		tx.Accept = make([]int, written)
+		for i := range written {
+			tx.Accept[i] = i
+		}
+		return
+	}
+
+	// A permanent error indicates the output will never accept the metrics,
+	// so drop them instead of retrying the write forever
+	var permErr *internal.PermanentWriteError
+	if errors.As(err, &permErr) {
+		r.setWriteFailed(false)
+		r.log.Warnf("Dropping %d metrics permanently rejected by the output", written)
+		if written == len(tx.Batch) {
+			tx.RejectAll()
+			return
+		}
+		tx.Reject = make([]int, written)
+		for i := range written {
+			tx.Reject[i] = i
+		}
 		return
 	}
 
@@ -416,7 +701,7 @@ func (r *RunningOutput) updateTransaction(tx *Transaction, err error) {
 	// successfully and we should keep them for the next write cycle
 	var writeErr *internal.PartialWriteError
 	if !errors.As(err, &writeErr) {
-		r.lastWriteFailed.Store(true)
+		r.setWriteFailed(true)
 		tx.KeepAll()
 		return
 	}
@@ -424,14 +709,66 @@ func (r *RunningOutput) updateTransaction(tx *Transaction, err error) {
 	// Transfer the accepted and rejected indices based on the write error
 	// values. Only allow to retrigger before the flush interval if at least
 	// one metric was accepted in order to avoid
-	r.lastWriteFailed.Store(len(writeErr.MetricsAccept) == 0)
+	r.setWriteFailed(len(writeErr.MetricsAccept) == 0)
 	tx.Accept = writeErr.MetricsAccept
 	tx.Reject = writeErr.MetricsReject
 }
 
+func (r *RunningOutput) setWriteFailed(failed bool) {
+	r.lastWriteFailed.Store(failed)
+	r.recordWrite(failed)
+}
+
+// recordWrite tracks the outcome of a write attempt for selecting outputs of
+// failover groups and for delaying the retries of failed writes.
+func (r *RunningOutput) recordWrite(failed bool) {
+	prev := r.breaker.State()
+	if failed {
+		r.writeFailures.Add(1)
+		r.breaker.Failure(time.Now())
+	} else {
+		r.writeFailures.Store(0)
+		r.breaker.Success()
+	}
+
+	state := r.breaker.State()
+	r.CircuitState.Set(int64(state))
+	if state == prev {
+		return
+	}
+	switch state {
+	case circuitOpen:
+		r.log.Warnf("Circuit breaker opened after %d consecutive failed writes, pausing writes for %s",
+			r.WriteFailures(), r.breaker.resetTimeout)
+	case circuitClosed:
+		r.log.Info("Circuit breaker closed, writing succeeded again")
+	}
+}
+
+// backingOff checks if writes are delayed due to previously failed writes.
+func (r *RunningOutput) backingOff() bool {
+	delay := r.breaker.Delay(time.Now())
+	state := r.breaker.State()
+	r.CircuitState.Set(int64(state))
+	if delay > 0 {
+		r.log.Debugf("Skipping write after failures, retrying in %s", delay)
+		return true
+	}
+	if state == circuitHalfOpen {
+		r.log.Debug("Circuit breaker half-open, trying to write")
+	}
+	return false
+}
+
+// WriteFailures returns the number of consecutive failed write attempts of
+// the output. The count is reset by the first write accepting any metric.
+func (r *RunningOutput) WriteFailures() int {
+	return int(r.writeFailures.Load())
+}
+
 func (r *RunningOutput) LogBufferStatus() {
 	nBuffer := r.buffer.Len()
-	if r.Config.BufferStrategy == "disk_write_through" {
+	if r.bufferConfig.persistent() {
 		r.log.Debugf("Buffer fullness: %d metrics", nBuffer)
 	} else {
 		r.log.Debugf("Buffer fullness: %d / %d metrics", nBuffer, r.MetricBufferLimit)
@@ -445,3 +782,8 @@ func (r *RunningOutput) Log() telegraf.Logger {
 func (r *RunningOutput) BufferLength() int {
 	return r.buffer.Len()
 }
+
+// BufferStats returns the statistics of the output's metric buffer.
+func (r *RunningOutput) BufferStats() BufferStats {
+	return r.buffer.Stats()
+}
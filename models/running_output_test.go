@@ -3,6 +3,7 @@ package models
 import (
 	"context"
 	"errors"
+	"os"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -14,6 +15,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/selfstat"
 	"github.com/influxdata/telegraf/testutil"
 )
@@ -797,17 +799,24 @@ func TestRunningOutputInternalMetrics(t *testing.T) {
 				"alias":  "test_alias",
 			},
 			map[string]interface{}{
-				"buffer_limit":     10,
-				"buffer_size":      0,
-				"errors":           0,
-				"metrics_added":    0,
-				"metrics_rejected": 0,
-				"metrics_dropped":  0,
-				"metrics_filtered": 0,
-				"metrics_written":  0,
-				"write_errors":     0,
-				"write_time_ns":    0,
-				"startup_errors":   0,
+				"buffer_limit":      10,
+				"buffer_size":       0,
+				"errors":            0,
+				"metrics_added":     0,
+				"metrics_rejected":  0,
+				"metrics_dropped":   0,
+				"metrics_filtered":  0,
+				"metrics_written":   0,
+				"write_errors":      0,
+				"write_time_ns":     0,
+				"write_time_p50_ns": 0,
+				"write_time_p90_ns": 0,
+				"write_time_p99_ns": 0,
+				"write_time_max_ns": 0,
+				"startup_errors":    0,
+				"dead_letters":      0,
+				"circuit_state":     0,
+				"rate_limited":      0,
 			},
 			time.Unix(0, 0),
 		),
@@ -823,6 +832,38 @@ func TestRunningOutputInternalMetrics(t *testing.T) {
 	testutil.RequireMetricsEqual(t, expected, actual, testutil.IgnoreTime())
 }
 
+func TestRunningOutputDiskBufferOpenedOnInit(t *testing.T) {
+	dir := t.TempDir()
+	ro, err := NewRunningOutput(
+		&mockOutput{},
+		&OutputConfig{
+			Filter:          Filter{},
+			Name:            "test_name",
+			ID:              "disk_test",
+			BufferStrategy:  "disk_write_through",
+			BufferDirectory: dir,
+		},
+		5,
+		10,
+	)
+	require.NoError(t, err)
+
+	// The buffer files must not be touched before initializing the output
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	// Discarding an output never initialized must not fail
+	require.NoError(t, ro.Discard())
+
+	require.NoError(t, ro.Init())
+	entries, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "disk_test", entries[0].Name())
+	ro.Close()
+}
+
 func TestRunningOutputStartupBehaviorInvalid(t *testing.T) {
 	ro, err := NewRunningOutput(
 		&mockOutput{},
@@ -1337,6 +1378,260 @@ func TestRunningOutputStatisticsWriteErrorsCount(t *testing.T) {
 	require.Equal(t, int64(2), GlobalWriteErrors.Get())
 }
 
+func TestRunningOutputRateLimitMetrics(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:           Filter{},
+		RateLimitMetrics: 5,
+	}
+
+	m := &mockOutput{}
+	ro, err := NewRunningOutput(m, conf, 1000, 10000)
+	require.NoError(t, err)
+	ro.RateLimited.Set(0)
+
+	for _, mt := range first5 {
+		ro.AddMetric(mt)
+	}
+	for _, mt := range next5 {
+		ro.AddMetric(mt)
+	}
+
+	// Only the burst is written, the remaining metrics are kept
+	require.NoError(t, ro.Write())
+	require.Len(t, m.Metrics(), 5)
+	require.Equal(t, 5, ro.BufferLength())
+	require.Equal(t, int64(1), ro.RateLimited.Get())
+	require.Positive(t, ro.RateLimitDelay())
+
+	// Writing is possible again after refilling the bucket
+	ro.metricLimiter.tokens = ro.metricLimiter.capacity
+	require.Zero(t, ro.RateLimitDelay())
+	require.NoError(t, ro.WriteBatch())
+	require.Len(t, m.Metrics(), 10)
+	require.Zero(t, ro.BufferLength())
+}
+
+func TestRunningOutputRateLimitShutdown(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:           Filter{},
+		RateLimitMetrics: 2,
+		RateLimitBytes:   1,
+	}
+
+	m := &mockOutput{}
+	ro, err := NewRunningOutput(m, conf, 4, 10000)
+	require.NoError(t, err)
+
+	for _, mt := range first5 {
+		ro.AddMetric(mt)
+	}
+	for _, mt := range next5 {
+		ro.AddMetric(mt)
+	}
+
+	// The final write ignores the rate limits to not lose buffered metrics
+	require.NoError(t, ro.WriteOnShutdown())
+	require.Len(t, m.Metrics(), 10)
+	require.Zero(t, ro.BufferLength())
+}
+
+func TestRunningOutputRateLimitBytes(t *testing.T) {
+	serializer := &influx.Serializer{}
+	require.NoError(t, serializer.Init())
+	octets, err := serializer.Serialize(first5[0])
+	require.NoError(t, err)
+
+	conf := &OutputConfig{
+		Filter:         Filter{},
+		RateLimitBytes: int64(2 * len(octets)),
+	}
+
+	m := &mockOutput{}
+	ro, err := NewRunningOutput(m, conf, 1000, 10000)
+	require.NoError(t, err)
+
+	for _, mt := range first5 {
+		ro.AddMetric(mt)
+	}
+
+	require.NoError(t, ro.Write())
+	require.Len(t, m.Metrics(), 2)
+	require.Equal(t, 3, ro.BufferLength())
+
+	// The remaining metrics are written in order
+	ro.byteLimiter.tokens = ro.byteLimiter.capacity
+	require.NoError(t, ro.Write())
+	testutil.RequireMetricsEqual(t, first5[:4], m.Metrics())
+}
+
+func TestRunningOutputRateLimitOversizedMetric(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:         Filter{},
+		RateLimitBytes: 1,
+	}
+
+	m := &mockOutput{}
+	ro, err := NewRunningOutput(m, conf, 1000, 10000)
+	require.NoError(t, err)
+	ro.AddMetric(first5[0])
+
+	// A metric exceeding the budget on its own must not block the output
+	require.NoError(t, ro.Write())
+	require.Len(t, m.Metrics(), 1)
+	require.Positive(t, ro.RateLimitDelay())
+}
+
+func TestRunningOutputRetryBackoff(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:              Filter{},
+		RetryBackoffInitial: time.Hour,
+	}
+
+	m := &mockOutput{batchAcceptSize: -1}
+	ro, err := NewRunningOutput(m, conf, 1000, 10000)
+	require.NoError(t, err)
+	for _, mt := range first5 {
+		ro.AddMetric(mt)
+	}
+
+	// Writes are skipped while backing off after a failure
+	require.Error(t, ro.Write())
+	require.NoError(t, ro.Write())
+	require.NoError(t, ro.WriteBatch())
+	require.EqualValues(t, 1, m.writes.Load())
+	require.Equal(t, 5, ro.BufferLength())
+
+	// Writing is resumed after the backoff
+	m.batchAcceptSize = 0
+	ro.breaker.next = time.Time{}
+	require.NoError(t, ro.Write())
+	require.Len(t, m.Metrics(), 5)
+	require.Zero(t, ro.WriteFailures())
+}
+
+func TestRunningOutputCircuitBreaker(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:                     Filter{},
+		CircuitBreakerThreshold:    2,
+		CircuitBreakerResetTimeout: time.Hour,
+	}
+
+	m := &mockOutput{batchAcceptSize: -1}
+	ro, err := NewRunningOutput(m, conf, 1000, 10000)
+	require.NoError(t, err)
+	ro.AddMetric(first5[0])
+
+	// The circuit opens after reaching the threshold
+	require.Error(t, ro.Write())
+	require.Error(t, ro.Write())
+	require.EqualValues(t, circuitOpen, ro.CircuitState.Get())
+	require.NoError(t, ro.Write())
+	require.EqualValues(t, 2, m.writes.Load())
+
+	// A successful probe after the reset timeout closes the circuit
+	m.batchAcceptSize = 0
+	ro.breaker.next = time.Time{}
+	require.NoError(t, ro.Write())
+	require.EqualValues(t, circuitClosed, ro.CircuitState.Get())
+	require.Len(t, m.Metrics(), 1)
+}
+
+func TestRunningOutputWriteOnShutdown(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:              Filter{},
+		RetryBackoffInitial: time.Hour,
+	}
+
+	m := &mockOutput{batchAcceptSize: -1}
+	ro, err := NewRunningOutput(m, conf, 1000, 10000)
+	require.NoError(t, err)
+	for _, mt := range first5 {
+		ro.AddMetric(mt)
+	}
+
+	// Skipped writes do not block triggering later batches
+	require.Error(t, ro.Write())
+	ro.writeInFlight.Store(true)
+	require.NoError(t, ro.WriteBatch())
+	require.False(t, ro.writeInFlight.Load())
+	require.EqualValues(t, 1, m.writes.Load())
+
+	// The final write ignores the backoff
+	require.Error(t, ro.WriteOnShutdown())
+	require.EqualValues(t, 2, m.writes.Load())
+	require.Equal(t, 5, ro.BufferLength())
+
+	m.batchAcceptSize = 0
+	require.NoError(t, ro.WriteOnShutdown())
+	require.Len(t, m.Metrics(), 5)
+	require.Zero(t, ro.BufferLength())
+}
+
+func TestRunningOutputConnectFailureOpensCircuit(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:                     Filter{},
+		StartupErrorBehavior:       "retry",
+		CircuitBreakerThreshold:    2,
+		CircuitBreakerResetTimeout: time.Hour,
+	}
+
+	m := &mockOutput{
+		startupError: &internal.StartupError{
+			Err:   errors.New("connection refused"),
+			Retry: true,
+		},
+		startupErrorCount: -1,
+	}
+	ro, err := NewRunningOutput(m, conf, 1000, 10000)
+	require.NoError(t, err)
+	require.NoError(t, ro.Init())
+	require.NoError(t, ro.Connect())
+	ro.AddMetric(first5[0])
+
+	// Failed connection attempts count as failed writes
+	require.ErrorIs(t, ro.Write(), internal.ErrNotConnected)
+	require.ErrorIs(t, ro.WriteBatch(), internal.ErrNotConnected)
+	require.EqualValues(t, circuitOpen, ro.CircuitState.Get())
+	require.Equal(t, 2, ro.WriteFailures())
+
+	// No reconnection is attempted while the circuit is open
+	require.NoError(t, ro.Write())
+	require.EqualValues(t, 3, ro.StartupErrors.Get())
+	require.False(t, ro.started)
+
+	// The final write still tries to connect and deliver the metrics
+	m.startupErrorCount = 0
+	require.NoError(t, ro.WriteOnShutdown())
+	require.True(t, ro.started)
+	require.Len(t, m.Metrics(), 1)
+	require.EqualValues(t, circuitClosed, ro.CircuitState.Get())
+}
+
+func TestRunningOutputPermanentError(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:              Filter{},
+		RetryBackoffInitial: time.Hour,
+	}
+
+	m := &mockOutput{
+		preWriteHook: func([]telegraf.Metric) error {
+			return &internal.PermanentWriteError{Err: errors.New("invalid data")}
+		},
+	}
+	ro, err := NewRunningOutput(m, conf, 1000, 10000)
+	require.NoError(t, err)
+	for _, mt := range first5 {
+		ro.AddMetric(mt)
+	}
+
+	// Permanently rejected metrics are dropped and not considered as failure
+	var permErr *internal.PermanentWriteError
+	require.ErrorAs(t, ro.Write(), &permErr)
+	require.Zero(t, ro.BufferLength())
+	require.Zero(t, ro.WriteFailures())
+	require.Zero(t, ro.breaker.Delay(time.Now()))
+}
+
 // Benchmark adding metrics.
 func BenchmarkRunningOutputAddWrite(b *testing.B) {
 	conf := &OutputConfig{
@@ -28,6 +28,7 @@ type ProcessorConfig struct {
 	Alias    string
 	ID       string
 	Order    int64
+	Pipeline string
 	Filter   Filter
 	LogLevel string
 }
@@ -0,0 +1,156 @@
+package models
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+// Policies for handling metrics of new series exceeding the series limit of
+// an input
+const (
+	// SeriesLimitDrop drops all metrics of new series
+	SeriesLimitDrop = "drop"
+	// SeriesLimitDropTags removes all tags except the ones to keep and drops
+	// the metric only if the resulting series is still unknown
+	SeriesLimitDropTags = "drop_tags"
+	// SeriesLimitSample passes on every n-th metric of new series without
+	// tracking the series
+	SeriesLimitSample = "sample"
+)
+
+// Default rate of metrics passed on with the "sample" policy
+const DefaultSeriesSampleRate = 10
+
+// CheckSeriesLimitPolicy returns an error for unknown series limit policies.
+func CheckSeriesLimitPolicy(policy string) error {
+	switch policy {
+	case "", SeriesLimitDrop, SeriesLimitDropTags, SeriesLimitSample:
+		return nil
+	}
+	return fmt.Errorf("invalid 'series_limit_policy' setting %q", policy)
+}
+
+// seriesLimiter limits the number of distinct series, identified by the
+// metric's hash ID, of an input. Series not seen for the configured TTL are
+// forgotten, a zero TTL keeps series forever.
+type seriesLimiter struct {
+	limit       int
+	policy      string
+	keepTags    map[string]bool
+	sampleRate  uint64
+	ttl         time.Duration
+	log         telegraf.Logger
+	cardinality selfstat.Stat
+
+	series    map[uint64]time.Time
+	exceeded  bool
+	sampled   uint64
+	nextSweep time.Time
+
+	sync.Mutex
+}
+
+func newSeriesLimiter(config *InputConfig, log telegraf.Logger, cardinality selfstat.Stat) *seriesLimiter {
+	l := &seriesLimiter{
+		limit:       config.MaxSeries,
+		policy:      config.SeriesLimitPolicy,
+		keepTags:    make(map[string]bool, len(config.SeriesKeepTags)),
+		sampleRate:  uint64(config.SeriesSampleRate),
+		ttl:         config.SeriesTTL,
+		log:         log,
+		cardinality: cardinality,
+		series:      make(map[uint64]time.Time),
+	}
+	if l.policy == "" {
+		l.policy = SeriesLimitDrop
+	}
+	if l.sampleRate == 0 {
+		l.sampleRate = DefaultSeriesSampleRate
+	}
+	for _, tag := range config.SeriesKeepTags {
+		l.keepTags[tag] = true
+	}
+	return l
+}
+
+// Admit records the series of the given metric seen at the given time and
+// returns false if the metric should be dropped as it exceeds the series
+// limit. The "drop_tags" policy might modify the tags of the metric.
+func (l *seriesLimiter) Admit(m telegraf.Metric, t time.Time) bool {
+	l.Lock()
+	defer l.Unlock()
+
+	id := m.HashID()
+	if l.touch(id, t) {
+		return true
+	}
+	if len(l.series) >= l.limit {
+		l.expire(t)
+	}
+	if len(l.series) < l.limit {
+		l.series[id] = t
+		l.cardinality.Set(int64(len(l.series)))
+		return true
+	}
+
+	if !l.exceeded {
+		l.exceeded = true
+		l.log.Warnf("Limit of %d series reached, applying policy %q to new series", l.limit, l.policy)
+	}
+
+	switch l.policy {
+	case SeriesLimitDropTags:
+		var remove []string
+		for _, tag := range m.TagList() {
+			if !l.keepTags[tag.Key] {
+				remove = append(remove, tag.Key)
+			}
+		}
+		for _, key := range remove {
+			m.RemoveTag(key)
+		}
+		return l.touch(m.HashID(), t)
+	case SeriesLimitSample:
+		l.sampled++
+		if (l.sampled-1)%l.sampleRate == 0 {
+			l.log.Debugf("Passing sampled metric of new series %q", m.Name())
+			return true
+		}
+	}
+	return false
+}
+
+// touch updates the time a known series was seen last and returns false for
+// unknown series.
+func (l *seriesLimiter) touch(id uint64, t time.Time) bool {
+	if _, found := l.series[id]; !found {
+		return false
+	}
+	l.series[id] = t
+	return true
+}
+
+// expire removes the series not seen within the TTL. To limit the overhead
+// with a large number of series, sweeping is done in intervals of a tenth of
+// the TTL.
+func (l *seriesLimiter) expire(t time.Time) {
+	if l.ttl <= 0 || t.Before(l.nextSweep) {
+		return
+	}
+	l.nextSweep = t.Add(l.ttl / 10)
+
+	for id, last := range l.series {
+		if t.Sub(last) > l.ttl {
+			delete(l.series, id)
+		}
+	}
+	l.cardinality.Set(int64(len(l.series)))
+	if l.exceeded && len(l.series) < l.limit {
+		l.exceeded = false
+		l.log.Infof("Number of series dropped below the limit of %d", l.limit)
+	}
+}
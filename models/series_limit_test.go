@@ -0,0 +1,105 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/selfstat"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func newTestSeriesLimiter(cfg *InputConfig) *seriesLimiter {
+	cardinality := selfstat.Register("test", "series", map[string]string{"policy": cfg.SeriesLimitPolicy})
+	return newSeriesLimiter(cfg, &testutil.Logger{}, cardinality)
+}
+
+func seriesMetric(host string) telegraf.Metric {
+	return metric.New(
+		"cpu",
+		map[string]string{"host": host, "region": "eu"},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0),
+	)
+}
+
+func TestSeriesLimiterDrop(t *testing.T) {
+	l := newTestSeriesLimiter(&InputConfig{MaxSeries: 2})
+
+	now := time.Now()
+	require.True(t, l.Admit(seriesMetric("a"), now))
+	require.True(t, l.Admit(seriesMetric("b"), now))
+	require.False(t, l.Admit(seriesMetric("c"), now))
+
+	// Known series are still accepted
+	require.True(t, l.Admit(seriesMetric("a"), now))
+	require.Equal(t, int64(2), l.cardinality.Get())
+}
+
+func TestSeriesLimiterDropTags(t *testing.T) {
+	l := newTestSeriesLimiter(&InputConfig{
+		MaxSeries:         1,
+		SeriesLimitPolicy: SeriesLimitDropTags,
+		SeriesKeepTags:    []string{"region"},
+	})
+
+	now := time.Now()
+	reduced := metric.New(
+		"cpu",
+		map[string]string{"region": "eu"},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0),
+	)
+	require.True(t, l.Admit(reduced.Copy(), now))
+
+	// New series are reduced to the kept tags and pass as the resulting series
+	// is known
+	m := seriesMetric("a")
+	require.True(t, l.Admit(m, now))
+	testutil.RequireMetricEqual(t, reduced, m)
+
+	// Series unknown after removing the tags are dropped
+	other := seriesMetric("b")
+	other.AddTag("region", "us")
+	require.False(t, l.Admit(other, now))
+}
+
+func TestSeriesLimiterSample(t *testing.T) {
+	l := newTestSeriesLimiter(&InputConfig{
+		MaxSeries:         1,
+		SeriesLimitPolicy: SeriesLimitSample,
+		SeriesSampleRate:  3,
+	})
+
+	now := time.Now()
+	require.True(t, l.Admit(seriesMetric("a"), now))
+
+	var passed int
+	for range 9 {
+		if l.Admit(seriesMetric("b"), now) {
+			passed++
+		}
+	}
+	require.Equal(t, 3, passed)
+	require.Equal(t, int64(1), l.cardinality.Get())
+}
+
+func TestSeriesLimiterTTL(t *testing.T) {
+	l := newTestSeriesLimiter(&InputConfig{MaxSeries: 1, SeriesTTL: time.Minute})
+
+	now := time.Now()
+	require.True(t, l.Admit(seriesMetric("a"), now))
+	require.False(t, l.Admit(seriesMetric("b"), now.Add(30*time.Second)))
+
+	// The first series expired and makes room for the new one
+	require.True(t, l.Admit(seriesMetric("b"), now.Add(2*time.Minute)))
+	require.False(t, l.Admit(seriesMetric("a"), now.Add(2*time.Minute)))
+}
+
+func TestSeriesLimitPolicyInvalid(t *testing.T) {
+	ri := NewRunningInput(&mockInput{}, &InputConfig{Name: "test", MaxSeries: 1, SeriesLimitPolicy: "foo"})
+	require.ErrorContains(t, ri.Init(), "invalid 'series_limit_policy' setting")
+}
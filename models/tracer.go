@@ -0,0 +1,237 @@
+package models
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+// Stages of the pipeline reported in the spans of traced metrics
+const (
+	TraceStageGather    = "gather"
+	TraceStageProcess   = "process"
+	TraceStageAggregate = "aggregate"
+	TraceStageWrite     = "write"
+
+	// Stage used for reporting the end-to-end latency of metrics
+	traceStageTotal = "total"
+)
+
+// Maximum number of finished traces kept until they are exported, further
+// traces are discarded
+const maxPendingTraces = 10000
+
+// Tracer samples metrics for tracing their way through the pipeline. The time
+// each sampled metric spends in a stage and waits for a stage is recorded in
+// internal histograms per plugin. Traces of metrics written successfully are
+// kept for exporting.
+type Tracer struct {
+	rate    uint64
+	counter atomic.Uint64
+
+	stats   map[string]*traceStats
+	pending [][]telegraf.Span
+
+	sync.Mutex
+}
+
+type traceStats struct {
+	queueWait selfstat.Stat
+	latency   selfstat.Stat
+}
+
+// NewTracer creates a tracer sampling every n-th metric with n being the
+// given rate.
+func NewTracer(rate int) *Tracer {
+	return &Tracer{
+		rate:  uint64(max(rate, 1)),
+		stats: make(map[string]*traceStats),
+	}
+}
+
+// Sample starts tracing the given metric if it is sampled, beginning with a
+// span of the given stage and plugin started at the given time. Sampled
+// metrics are returned wrapped, all other metrics are returned unchanged.
+// Tracking metrics are never sampled to not interfere with the delivery
+// tracking.
+func (t *Tracer) Sample(m telegraf.Metric, stage, plugin, id string, start time.Time) telegraf.Metric {
+	if t == nil {
+		return m
+	}
+	if _, ok := m.(telegraf.TrackingMetric); ok {
+		return m
+	}
+	if (t.counter.Add(1)-1)%t.rate != 0 {
+		return m
+	}
+
+	trace := &Trace{tracer: t, last: start}
+	trace.Enter(stage, plugin, id, start)
+	return &tracedMetric{Metric: m, trace: trace}
+}
+
+// Drain returns the traces finished since the last call.
+func (t *Tracer) Drain() [][]telegraf.Span {
+	t.Lock()
+	defer t.Unlock()
+
+	traces := t.pending
+	t.pending = nil
+	return traces
+}
+
+func (t *Tracer) observe(span *telegraf.Span) {
+	stats := t.statsFor(span.Stage, span.Plugin, span.PluginID)
+	stats.queueWait.Incr(span.Start.Sub(span.Queued).Nanoseconds())
+	stats.latency.Incr(span.End.Sub(span.Start).Nanoseconds())
+}
+
+func (t *Tracer) finish(spans []telegraf.Span) {
+	first, last := spans[0], spans[len(spans)-1]
+	stats := t.statsFor(traceStageTotal, last.Plugin, last.PluginID)
+	stats.latency.Incr(last.End.Sub(first.Queued).Nanoseconds())
+
+	t.Lock()
+	defer t.Unlock()
+	if len(t.pending) < maxPendingTraces {
+		t.pending = append(t.pending, spans)
+	}
+}
+
+func (t *Tracer) statsFor(stage, plugin, id string) *traceStats {
+	key := stage + "\x00" + id
+	t.Lock()
+	defer t.Unlock()
+
+	if stats, found := t.stats[key]; found {
+		return stats
+	}
+
+	tags := map[string]string{
+		"_id":    id,
+		"stage":  stage,
+		"plugin": plugin,
+	}
+	stats := &traceStats{
+		latency: selfstat.RegisterHistogram("pipeline", "latency", tags),
+	}
+	// The end-to-end latency has no queue wait
+	if stage != traceStageTotal {
+		stats.queueWait = selfstat.RegisterHistogram("pipeline", "queue_wait", tags)
+	}
+	t.stats[key] = stats
+	return stats
+}
+
+// Trace holds the spans of a sampled metric.
+type Trace struct {
+	tracer *Tracer
+	spans  []telegraf.Span
+	last   time.Time
+	open   bool
+
+	sync.Mutex
+}
+
+// TraceOf returns the trace of the given metric or nil if the metric is not
+// sampled. All methods of the returned trace can be called on nil.
+func TraceOf(m telegraf.Metric) *Trace {
+	if tm, ok := m.(*tracedMetric); ok {
+		return tm.trace
+	}
+	return nil
+}
+
+// Enter starts a span of the given stage and plugin at the given time. The
+// metric is considered queued since the end of the previous span.
+func (tr *Trace) Enter(stage, plugin, id string, t time.Time) {
+	if tr == nil {
+		return
+	}
+	tr.Lock()
+	defer tr.Unlock()
+
+	tr.spans = append(tr.spans, telegraf.Span{
+		Stage:    stage,
+		Plugin:   plugin,
+		PluginID: id,
+		Queued:   tr.last,
+		Start:    t,
+	})
+	tr.open = true
+}
+
+// Leave ends the current span at the given time. Calling Leave without an
+// open span is a no-op.
+func (tr *Trace) Leave(t time.Time) {
+	if tr == nil {
+		return
+	}
+	tr.Lock()
+	if !tr.open {
+		tr.Unlock()
+		return
+	}
+	span := tr.spans[len(tr.spans)-1]
+	span.End = t
+	tr.spans[len(tr.spans)-1] = span
+	tr.last = t
+	tr.open = false
+	tr.Unlock()
+
+	tr.tracer.observe(&span)
+}
+
+// Finish adds the final span of the given stage and plugin and completes the
+// trace, i.e. the end-to-end latency is recorded and the trace is kept for
+// exporting.
+func (tr *Trace) Finish(stage, plugin, id string, start, end time.Time) {
+	if tr == nil {
+		return
+	}
+	tr.Enter(stage, plugin, id, start)
+	tr.Leave(end)
+
+	tr.Lock()
+	spans := tr.spans
+	tr.spans = nil
+	tr.Unlock()
+	if len(spans) > 0 {
+		tr.tracer.finish(spans)
+	}
+}
+
+func (tr *Trace) clone() *Trace {
+	tr.Lock()
+	defer tr.Unlock()
+
+	spans := make([]telegraf.Span, len(tr.spans))
+	copy(spans, tr.spans)
+	return &Trace{
+		tracer: tr.tracer,
+		spans:  spans,
+		last:   tr.last,
+		open:   tr.open,
+	}
+}
+
+// tracedMetric carries the trace of a sampled metric through the pipeline.
+// Copies of the metric continue with a copy of the trace.
+type tracedMetric struct {
+	telegraf.Metric
+	trace *Trace
+}
+
+func (m *tracedMetric) Copy() telegraf.Metric {
+	return &tracedMetric{
+		Metric: m.Metric.Copy(),
+		trace:  m.trace.clone(),
+	}
+}
+
+func (m *tracedMetric) Unwrap() telegraf.Metric {
+	return m.Metric
+}
@@ -0,0 +1,154 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+func traceMetric() telegraf.Metric {
+	return metric.New(
+		"cpu",
+		map[string]string{},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0),
+	)
+}
+
+func TestTracerSampleRate(t *testing.T) {
+	tracer := NewTracer(3)
+
+	var sampled int
+	for range 9 {
+		m := tracer.Sample(traceMetric(), TraceStageGather, "inputs.test", "a", time.Now())
+		if TraceOf(m) != nil {
+			sampled++
+		}
+	}
+	require.Equal(t, 3, sampled)
+}
+
+func TestTracerSkipsTrackingMetrics(t *testing.T) {
+	tracer := NewTracer(1)
+
+	m, _ := metric.WithTracking(traceMetric(), func(telegraf.DeliveryInfo) {})
+	require.Nil(t, TraceOf(tracer.Sample(m, TraceStageGather, "inputs.test", "a", time.Now())))
+}
+
+func TestTracerNil(t *testing.T) {
+	var tracer *Tracer
+
+	m := traceMetric()
+	require.Same(t, m, tracer.Sample(m, TraceStageGather, "inputs.test", "a", time.Now()))
+
+	// Untraced metrics can be used with all trace methods
+	trace := TraceOf(m)
+	require.Nil(t, trace)
+	trace.Enter(TraceStageProcess, "processors.test", "b", time.Now())
+	trace.Leave(time.Now())
+	trace.Finish(TraceStageWrite, "outputs.test", "c", time.Now(), time.Now())
+}
+
+func TestTracerSpans(t *testing.T) {
+	tracer := NewTracer(1)
+
+	start := time.Unix(100, 0)
+	m := tracer.Sample(traceMetric(), TraceStageGather, "inputs.test", "a", start)
+	trace := TraceOf(m)
+	require.NotNil(t, trace)
+	trace.Leave(start.Add(1 * time.Second))
+
+	trace.Enter(TraceStageProcess, "processors.test", "b", start.Add(3*time.Second))
+	trace.Leave(start.Add(4 * time.Second))
+
+	// Copies continue with their own trace
+	c := m.Copy()
+	require.NotSame(t, trace, TraceOf(c))
+	TraceOf(c).Finish(TraceStageWrite, "outputs.other", "d", start.Add(5*time.Second), start.Add(6*time.Second))
+
+	trace.Finish(TraceStageWrite, "outputs.test", "c", start.Add(7*time.Second), start.Add(9*time.Second))
+
+	expected := [][]telegraf.Span{
+		{
+			{
+				Stage:    TraceStageGather,
+				Plugin:   "inputs.test",
+				PluginID: "a",
+				Queued:   start,
+				Start:    start,
+				End:      start.Add(1 * time.Second),
+			},
+			{
+				Stage:    TraceStageProcess,
+				Plugin:   "processors.test",
+				PluginID: "b",
+				Queued:   start.Add(1 * time.Second),
+				Start:    start.Add(3 * time.Second),
+				End:      start.Add(4 * time.Second),
+			},
+			{
+				Stage:    TraceStageWrite,
+				Plugin:   "outputs.other",
+				PluginID: "d",
+				Queued:   start.Add(4 * time.Second),
+				Start:    start.Add(5 * time.Second),
+				End:      start.Add(6 * time.Second),
+			},
+		},
+		{
+			{
+				Stage:    TraceStageGather,
+				Plugin:   "inputs.test",
+				PluginID: "a",
+				Queued:   start,
+				Start:    start,
+				End:      start.Add(1 * time.Second),
+			},
+			{
+				Stage:    TraceStageProcess,
+				Plugin:   "processors.test",
+				PluginID: "b",
+				Queued:   start.Add(1 * time.Second),
+				Start:    start.Add(3 * time.Second),
+				End:      start.Add(4 * time.Second),
+			},
+			{
+				Stage:    TraceStageWrite,
+				Plugin:   "outputs.test",
+				PluginID: "c",
+				Queued:   start.Add(4 * time.Second),
+				Start:    start.Add(7 * time.Second),
+				End:      start.Add(9 * time.Second),
+			},
+		},
+	}
+	require.Equal(t, expected, tracer.Drain())
+	require.Empty(t, tracer.Drain())
+
+	// Observations are recorded per stage and plugin
+	require.Equal(t, int64(1), tracer.statsFor(TraceStageGather, "inputs.test", "a").latency.Get())
+	require.Equal(t, int64(1), tracer.statsFor(TraceStageWrite, "outputs.test", "c").latency.Get())
+	require.Equal(t, int64(1), tracer.statsFor(traceStageTotal, "outputs.test", "c").latency.Get())
+}
+
+func TestRunningOutputTraceWrite(t *testing.T) {
+	tracer := NewTracer(1)
+
+	ro, err := NewRunningOutput(&mockOutput{}, &OutputConfig{Name: "trace", ID: "trace-write"}, 1000, 10000)
+	require.NoError(t, err)
+
+	m := tracer.Sample(traceMetric(), TraceStageGather, "inputs.test", "trace-gather", time.Now())
+	TraceOf(m).Leave(time.Now())
+	ro.AddMetric(m)
+	require.NoError(t, ro.Write())
+
+	traces := tracer.Drain()
+	require.Len(t, traces, 1)
+	require.Len(t, traces[0], 2)
+	require.Equal(t, TraceStageWrite, traces[0][1].Stage)
+	require.Equal(t, ro.LogName(), traces[0][1].Plugin)
+}
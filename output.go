@@ -1,5 +1,7 @@
 package telegraf
 
+import "time"
+
 type Output interface {
 	PluginDescriber
 
@@ -27,3 +29,27 @@ type AggregatingOutput interface {
 	// Reset signals that the aggregator period is completed.
 	Reset()
 }
+
+// Span describes the time a metric sampled for tracing spent in a stage of the
+// agent's pipeline, e.g. within a processor.
+type Span struct {
+	// Stage of the pipeline, i.e. "gather", "process", "aggregate" or "write"
+	Stage string
+	// Name and ID of the plugin handling the metric in the stage
+	Plugin   string
+	PluginID string
+	// Time the metric was queued for the stage, e.g. handed over by the
+	// previous stage, and the time the stage started and finished handling
+	// the metric
+	Queued time.Time
+	Start  time.Time
+	End    time.Time
+}
+
+// TraceExporter is implemented by outputs able to export the traces of
+// metrics sampled by the agent.
+type TraceExporter interface {
+	// ExportTraces exports the given traces each containing the spans of a
+	// single metric in the order of the pipeline stages.
+	ExportTraces(traces [][]Span) error
+}
@@ -0,0 +1,53 @@
+package persister
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Backend stores the serialized plugin states indexed by the plugin ID.
+// Load must return an error wrapping os.ErrNotExist if no states were
+// stored yet.
+type Backend interface {
+	Load() (map[string][]byte, error)
+	Store(states map[string][]byte) error
+}
+
+// NewBackend creates the backend of the given type storing the states at
+// the given location.
+func NewBackend(kind, location string) (Backend, error) {
+	switch kind {
+	case "", "file":
+		return &FileBackend{Filename: location}, nil
+	case "directory":
+		return &DirectoryBackend{Directory: location}, nil
+	case "sqlite":
+		return &SQLiteBackend{Filename: location}, nil
+	}
+	return nil, fmt.Errorf("unknown state backend %q", kind)
+}
+
+// writeFileAtomic writes the data to a temporary file in the same directory
+// and renames it to the given filename after syncing so the file either
+// contains the old or the new data even if Telegraf crashes while writing.
+func writeFileAtomic(filename string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filename)
+}
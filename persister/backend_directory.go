@@ -0,0 +1,67 @@
+package persister
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DirectoryBackend stores the state of each plugin in a separate file named
+// after the plugin ID within the directory.
+type DirectoryBackend struct {
+	Directory string
+}
+
+func (b *DirectoryBackend) Load() (map[string][]byte, error) {
+	entries, err := os.ReadDir(b.Directory)
+	if err != nil {
+		return nil, fmt.Errorf("reading states directory failed: %w", err)
+	}
+
+	states := make(map[string][]byte, len(entries))
+	for _, entry := range entries {
+		id, found := strings.CutSuffix(entry.Name(), ".json")
+		if !found || entry.IsDir() || strings.HasPrefix(id, ".") {
+			continue
+		}
+		state, err := os.ReadFile(filepath.Join(b.Directory, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading state of %q failed: %w", id, err)
+		}
+		states[id] = state
+	}
+	return states, nil
+}
+
+func (b *DirectoryBackend) Store(states map[string][]byte) error {
+	if err := os.MkdirAll(b.Directory, 0750); err != nil {
+		return fmt.Errorf("creating states directory failed: %w", err)
+	}
+
+	for id, state := range states {
+		if err := writeFileAtomic(filepath.Join(b.Directory, id+".json"), state); err != nil {
+			return fmt.Errorf("writing state of %q failed: %w", id, err)
+		}
+	}
+
+	// Remove the states of plugins not existing anymore
+	entries, err := os.ReadDir(b.Directory)
+	if err != nil {
+		return fmt.Errorf("reading states directory failed: %w", err)
+	}
+	for _, entry := range entries {
+		id, found := strings.CutSuffix(entry.Name(), ".json")
+		if !found || entry.IsDir() || strings.HasPrefix(id, ".") {
+			continue
+		}
+		if _, exists := states[id]; exists {
+			continue
+		}
+		if err := os.Remove(filepath.Join(b.Directory, entry.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("removing state of %q failed: %w", id, err)
+		}
+	}
+	return nil
+}
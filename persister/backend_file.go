@@ -0,0 +1,37 @@
+package persister
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// FileBackend stores the states of all plugins in a single JSON file.
+type FileBackend struct {
+	Filename string
+}
+
+func (b *FileBackend) Load() (map[string][]byte, error) {
+	in, err := os.ReadFile(b.Filename)
+	if err != nil {
+		return nil, fmt.Errorf("reading states file failed: %w", err)
+	}
+
+	var states map[string][]byte
+	if err := json.Unmarshal(in, &states); err != nil {
+		return nil, fmt.Errorf("unmarshalling states failed: %w", err)
+	}
+	return states, nil
+}
+
+func (b *FileBackend) Store(states map[string][]byte) error {
+	serialized, err := json.Marshal(states)
+	if err != nil {
+		return fmt.Errorf("marshalling states failed: %w", err)
+	}
+
+	if err := writeFileAtomic(b.Filename, serialized); err != nil {
+		return fmt.Errorf("writing states file %q failed: %w", b.Filename, err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package persister
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// SQLiteBackend stores the states of all plugins in a SQLite database.
+type SQLiteBackend struct {
+	Filename string
+}
+
+func (b *SQLiteBackend) Load() (map[string][]byte, error) {
+	// Do not create an empty database when loading
+	if _, err := os.Stat(b.Filename); err != nil {
+		return nil, fmt.Errorf("accessing states database failed: %w", err)
+	}
+
+	db, err := b.open()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT id, state FROM states")
+	if err != nil {
+		return nil, fmt.Errorf("querying states failed: %w", err)
+	}
+	defer rows.Close()
+
+	states := make(map[string][]byte)
+	for rows.Next() {
+		var id string
+		var state []byte
+		if err := rows.Scan(&id, &state); err != nil {
+			return nil, fmt.Errorf("reading state failed: %w", err)
+		}
+		states[id] = state
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading states failed: %w", err)
+	}
+	return states, nil
+}
+
+func (b *SQLiteBackend) Store(states map[string][]byte) error {
+	db, err := b.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Replace all states within a single transaction so the database
+	// always contains a consistent set of states
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("starting transaction failed: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM states"); err != nil {
+		return errors.Join(fmt.Errorf("removing states failed: %w", err), tx.Rollback())
+	}
+	for id, state := range states {
+		if _, err := tx.Exec("INSERT INTO states (id, state) VALUES (?, ?)", id, state); err != nil {
+			return errors.Join(fmt.Errorf("writing state of %q failed: %w", id, err), tx.Rollback())
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing states failed: %w", err)
+	}
+	return nil
+}
+
+func (b *SQLiteBackend) open() (*sql.DB, error) {
+	db, err := sql.Open("sqlite", b.Filename)
+	if err != nil {
+		return nil, fmt.Errorf("opening states database failed: %w", err)
+	}
+	if _, err := db.Exec("CREATE TABLE IF NOT EXISTS states (id TEXT PRIMARY KEY, state BLOB NOT NULL)"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating states table failed: %w", err)
+	}
+	return db, nil
+}
@@ -0,0 +1,17 @@
+// According to the support matrix at https://pkg.go.dev/modernc.org/sqlite
+//go:build (darwin && (amd64 || arm64)) || (freebsd && (amd64 || arm64)) || (linux && (386 || amd64 || arm || arm64 || loong64 || ppc64le || riscv64 || s390x)) || (openbsd && (amd64 || arm64)) || (windows && (386 || amd64 || arm64))
+
+package persister
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersisterSQLiteBackend(t *testing.T) {
+	backend, err := NewBackend("sqlite", filepath.Join(t.TempDir(), "state.db"))
+	require.NoError(t, err)
+	testPersisterRoundtrip(t, backend)
+}
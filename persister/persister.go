@@ -3,25 +3,38 @@ package persister
 import (
 	"encoding/json"
 	"fmt"
-	"os"
+	"maps"
 	"reflect"
+	"slices"
+	"sync"
 
 	"github.com/influxdata/telegraf"
 )
 
 type Persister struct {
 	Filename string
+	Backend  Backend
 
 	register map[string]telegraf.StatefulPlugin
+	mu       sync.Mutex
 }
 
 func (p *Persister) Init() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.Backend == nil {
+		p.Backend = &FileBackend{Filename: p.Filename}
+	}
 	p.register = make(map[string]telegraf.StatefulPlugin)
 
 	return nil
 }
 
 func (p *Persister) Register(id string, plugin telegraf.StatefulPlugin) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	if _, found := p.register[id]; found {
 		return fmt.Errorf("plugin with ID %q already registered", id)
 	}
@@ -30,23 +43,54 @@ func (p *Persister) Register(id string, plugin telegraf.StatefulPlugin) error {
 	return nil
 }
 
-func (p *Persister) Load() error {
-	// Read the states from disk
-	in, err := os.ReadFile(p.Filename)
-	if err != nil {
-		return fmt.Errorf("reading states file failed: %w", err)
+// Update unregisters the plugins with the given IDs and registers the given
+// plugins in one step. This allows to exchange plugins of a running agent
+// without storing an incomplete set of states in between.
+func (p *Persister) Update(removed []string, added map[string]telegraf.StatefulPlugin) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for id := range added {
+		if _, found := p.register[id]; found && !slices.Contains(removed, id) {
+			return fmt.Errorf("plugin with ID %q already registered", id)
+		}
 	}
 
-	// Unmarshal the id to serialized states map
-	var states map[string][]byte
-	if err := json.Unmarshal(in, &states); err != nil {
-		return fmt.Errorf("unmarshalling states failed: %w", err)
+	for _, id := range removed {
+		delete(p.register, id)
+	}
+	maps.Copy(p.register, added)
+
+	return nil
+}
+
+func (p *Persister) Load() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.load(p.register)
+}
+
+// Restore sets the persisted states of the given plugins without registering
+// them, e.g. for plugins added to a running agent before starting them.
+func (p *Persister) Restore(plugins map[string]telegraf.StatefulPlugin) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.load(plugins)
+}
+
+func (p *Persister) load(plugins map[string]telegraf.StatefulPlugin) error {
+	// Read the id to serialized states map from the backend
+	states, err := p.Backend.Load()
+	if err != nil {
+		return err
 	}
 
 	// Get the initialized state as blueprint for unmarshalling
 	for id, serialized := range states {
 		// Check if we have a plugin with that ID
-		plugin, found := p.register[id]
+		plugin, found := plugins[id]
 		if !found {
 			continue
 		}
@@ -70,10 +114,13 @@ func (p *Persister) Load() error {
 }
 
 func (p *Persister) Store() error {
-	states := make(map[string][]byte)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	states := make(map[string][]byte, len(p.register))
 
 	// Collect the states and serialize the individual data chunks
-	// to later serialize all items in the id / serialized-states map
+	// to later store all items in the id / serialized-states map
 	for id, plugin := range p.register {
 		state, err := json.Marshal(plugin.GetState())
 		if err != nil {
@@ -82,22 +129,5 @@ func (p *Persister) Store() error {
 		states[id] = state
 	}
 
-	// Serialize the states
-	serialized, err := json.Marshal(states)
-	if err != nil {
-		return fmt.Errorf("marshalling states failed: %w", err)
-	}
-
-	// Write the states to disk
-	f, err := os.Create(p.Filename)
-	if err != nil {
-		return fmt.Errorf("creating states file %q failed: %w", p.Filename, err)
-	}
-	defer f.Close()
-
-	if _, err := f.Write(serialized); err != nil {
-		return fmt.Errorf("writing states failed: %w", err)
-	}
-
-	return nil
+	return p.Backend.Store(states)
 }
@@ -0,0 +1,133 @@
+package persister
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+)
+
+type mockPlugin struct {
+	state map[string]int64
+}
+
+func (m *mockPlugin) GetState() interface{} {
+	return m.state
+}
+
+func (m *mockPlugin) SetState(state interface{}) error {
+	s, ok := state.(map[string]int64)
+	if !ok {
+		return fmt.Errorf("invalid state type %T", state)
+	}
+	m.state = s
+	return nil
+}
+
+func TestPersisterBackends(t *testing.T) {
+	for _, kind := range []string{"file", "directory"} {
+		t.Run(kind, func(t *testing.T) {
+			location := filepath.Join(t.TempDir(), "state")
+			backend, err := NewBackend(kind, location)
+			require.NoError(t, err)
+			testPersisterRoundtrip(t, backend)
+		})
+	}
+}
+
+func TestNewBackendUnknown(t *testing.T) {
+	_, err := NewBackend("foo", "state")
+	require.ErrorContains(t, err, "unknown state backend")
+}
+
+func TestDirectoryBackendRemovesStaleStates(t *testing.T) {
+	dir := t.TempDir()
+	backend := &DirectoryBackend{Directory: dir}
+
+	require.NoError(t, backend.Store(map[string][]byte{"a": []byte("1"), "b": []byte("2")}))
+	require.FileExists(t, filepath.Join(dir, "a.json"))
+	require.FileExists(t, filepath.Join(dir, "b.json"))
+
+	require.NoError(t, backend.Store(map[string][]byte{"b": []byte("3")}))
+	require.NoFileExists(t, filepath.Join(dir, "a.json"))
+
+	states, err := backend.Load()
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{"b": []byte("3")}, states)
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(filename, []byte("old"), 0600))
+
+	require.NoError(t, writeFileAtomic(filename, []byte("new")))
+	buf, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, "new", string(buf))
+
+	// No temporary files must be left behind
+	entries, err := os.ReadDir(filepath.Dir(filename))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
+
+func testPersisterRoundtrip(t *testing.T, backend Backend) {
+	t.Helper()
+
+	// Loading without any stored states must signal a non-existing state
+	p := &Persister{Backend: backend}
+	require.NoError(t, p.Init())
+	require.NoError(t, p.Register("a", &mockPlugin{state: map[string]int64{}}))
+	require.ErrorIs(t, p.Load(), os.ErrNotExist)
+
+	plugin := &mockPlugin{state: map[string]int64{"foo": 42}}
+	p = &Persister{Backend: backend}
+	require.NoError(t, p.Init())
+	require.NoError(t, p.Register("a", plugin))
+	require.NoError(t, p.Register("b", &mockPlugin{state: map[string]int64{"bar": 23}}))
+	require.ErrorContains(t, p.Register("a", plugin), "already registered")
+	require.NoError(t, p.Store())
+
+	// Storing again must replace the existing states
+	plugin.state["foo"] = 43
+	require.NoError(t, p.Store())
+
+	restored := &mockPlugin{state: map[string]int64{}}
+	p = &Persister{Backend: backend}
+	require.NoError(t, p.Init())
+	require.NoError(t, p.Register("a", restored))
+	require.NoError(t, p.Load())
+	require.Equal(t, map[string]int64{"foo": 43}, restored.state)
+}
+
+func TestPersisterUpdate(t *testing.T) {
+	backend := &FileBackend{Filename: filepath.Join(t.TempDir(), "state.json")}
+
+	p := &Persister{Backend: backend}
+	require.NoError(t, p.Init())
+	require.NoError(t, p.Register("a", &mockPlugin{state: map[string]int64{"foo": 42}}))
+	require.NoError(t, p.Register("b", &mockPlugin{state: map[string]int64{"bar": 23}}))
+	require.NoError(t, p.Store())
+
+	// Restoring does not register the plugins
+	restored := &mockPlugin{state: map[string]int64{}}
+	added := &mockPlugin{state: map[string]int64{}}
+	require.NoError(t, p.Restore(map[string]telegraf.StatefulPlugin{"b": restored, "c": added}))
+	require.Equal(t, map[string]int64{"bar": 23}, restored.state)
+	require.Empty(t, added.state)
+
+	// Plugins must not be registered twice unless being removed
+	require.ErrorContains(t, p.Update(nil, map[string]telegraf.StatefulPlugin{"a": added}), "already registered")
+
+	restored.state["bar"] = 24
+	require.NoError(t, p.Update([]string{"a", "b"}, map[string]telegraf.StatefulPlugin{"b": restored, "c": added}))
+	require.NoError(t, p.Store())
+
+	states, err := backend.Load()
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{"b": []byte(`{"bar":24}`), "c": []byte(`{}`)}, states)
+}
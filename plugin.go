@@ -49,7 +49,9 @@ type StatefulPlugin interface {
 	// serialized to JSON. The best choice is a structure defined in
 	// your plugin.
 	// Note: This function has to be callable directly after the
-	// plugin's Init() function if there is any!
+	// plugin's Init() function if there is any! If periodic
+	// checkpointing is enabled, the function is also called while the
+	// plugin is running and must be safe for concurrent use.
 	GetState() interface{}
 
 	// SetState is called by the Persister once after loading and
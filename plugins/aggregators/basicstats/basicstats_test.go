@@ -1,10 +1,12 @@
 package basicstats
 
 import (
+	"encoding/json"
 	"math"
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/require"
 
 	"github.com/influxdata/telegraf/metric"
@@ -824,3 +826,38 @@ func TestBasicStatsWithOnlyFirst(t *testing.T) {
 	}
 	acc.AssertContainsTaggedFields(t, "m1", expectedFields, expectedTags)
 }
+
+func TestBasicStatsState(t *testing.T) {
+	expected := newBasicStats()
+	expected.Stats = []string{"count", "min", "max", "mean", "s2", "diff", "rate", "interval", "first", "last"}
+	expected.Log = testutil.Logger{}
+	require.NoError(t, expected.Init())
+	expected.Add(m1)
+	expected.Add(m2)
+
+	// Persist the state after the first metric and continue with a new
+	// instance of the plugin
+	plugin := newBasicStats()
+	plugin.Stats = expected.Stats
+	plugin.Log = testutil.Logger{}
+	require.NoError(t, plugin.Init())
+	plugin.Add(m1)
+
+	data, err := json.Marshal(plugin.GetState())
+	require.NoError(t, err)
+	var s state
+	require.NoError(t, json.Unmarshal(data, &s))
+
+	restored := newBasicStats()
+	restored.Stats = expected.Stats
+	restored.Log = testutil.Logger{}
+	require.NoError(t, restored.Init())
+	require.NoError(t, restored.SetState(s))
+	restored.Add(m2)
+
+	var expectedAcc, acc testutil.Accumulator
+	expected.Push(&expectedAcc)
+	restored.Push(&acc)
+	options := []cmp.Option{testutil.SortMetrics(), testutil.IgnoreTime()}
+	testutil.RequireMetricsEqual(t, expectedAcc.GetTelegrafMetrics(), acc.GetTelegrafMetrics(), options...)
+}
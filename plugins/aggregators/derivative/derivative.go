@@ -3,6 +3,7 @@ package derivative
 
 import (
 	_ "embed"
+	"fmt"
 	"strings"
 	"time"
 
@@ -36,6 +37,23 @@ type event struct {
 	time   time.Time
 }
 
+// state holds the first and last event of the period of all series indexed
+// by series ID
+type state map[uint64]aggregateState
+
+type aggregateState struct {
+	Name     string            `json:"name"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	First    eventState        `json:"first"`
+	Last     *eventState       `json:"last,omitempty"` // nil if first and last event are identical
+	RollOver uint              `json:"roll_over"`
+}
+
+type eventState struct {
+	Fields map[string]float64 `json:"fields"`
+	Time   time.Time          `json:"time"`
+}
+
 func (d *Derivative) Init() error {
 	d.Suffix = strings.TrimSpace(d.Suffix)
 	d.Variable = strings.TrimSpace(d.Variable)
@@ -125,6 +143,46 @@ func (d *Derivative) Reset() {
 	}
 }
 
+func (d *Derivative) GetState() interface{} {
+	s := make(state, len(d.cache))
+	for id, a := range d.cache {
+		as := aggregateState{
+			Name:     a.name,
+			Tags:     a.tags,
+			First:    eventState{Fields: a.first.fields, Time: a.first.time},
+			RollOver: a.rollOver,
+		}
+		if a.last != a.first {
+			as.Last = &eventState{Fields: a.last.fields, Time: a.last.time}
+		}
+		s[id] = as
+	}
+	return s
+}
+
+func (d *Derivative) SetState(st interface{}) error {
+	s, ok := st.(state)
+	if !ok {
+		return fmt.Errorf("state has wrong type %T", st)
+	}
+
+	d.cache = make(map[uint64]*aggregate, len(s))
+	for id, as := range s {
+		a := &aggregate{
+			name:     as.Name,
+			tags:     as.Tags,
+			first:    &event{fields: as.First.Fields, time: as.First.Time},
+			rollOver: as.RollOver,
+		}
+		a.last = a.first
+		if as.Last != nil {
+			a.last = &event{fields: as.Last.Fields, time: as.Last.Time}
+		}
+		d.cache[id] = a
+	}
+	return nil
+}
+
 func newAggregate(in telegraf.Metric) *aggregate {
 	event := newEvent(in)
 	return &aggregate{
@@ -1,6 +1,7 @@
 package derivative
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -416,3 +417,47 @@ func TestCalculatesCorrectDerivativeOnTwoConsecutivePeriods(t *testing.T) {
 		"value_rate": 2.0,
 	})
 }
+
+func TestDerivativeContinuesAfterRestoringState(t *testing.T) {
+	acc := testutil.Accumulator{}
+	derivative := &Derivative{
+		Variable:    "parameter",
+		Suffix:      "_wrt_parameter",
+		MaxRollOver: 10,
+		cache:       make(map[uint64]*aggregate),
+	}
+	derivative.Log = testutil.Logger{}
+	require.NoError(t, derivative.Init())
+
+	derivative.Add(start)
+	derivative.Push(&acc)
+	derivative.Reset()
+	acc.AssertDoesNotContainMeasurement(t, "TestMetric")
+
+	data, err := json.Marshal(derivative.GetState())
+	require.NoError(t, err)
+	var s state
+	require.NoError(t, json.Unmarshal(data, &s))
+
+	restored := &Derivative{
+		Variable:    "parameter",
+		Suffix:      "_wrt_parameter",
+		MaxRollOver: 10,
+	}
+	restored.Log = testutil.Logger{}
+	require.NoError(t, restored.Init())
+	require.NoError(t, restored.SetState(s))
+
+	restored.Add(finish)
+	restored.Push(&acc)
+
+	expectedFields := map[string]interface{}{
+		"increasing_wrt_parameter": 100.0,
+		"decreasing_wrt_parameter": -10.0,
+		"unchanged_wrt_parameter":  0.0,
+	}
+	expectedTags := map[string]string{
+		"state": "full",
+	}
+	acc.AssertContainsTaggedFields(t, "TestMetric", expectedFields, expectedTags)
+}
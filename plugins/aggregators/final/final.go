@@ -8,6 +8,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/aggregators"
 )
 
@@ -18,11 +19,33 @@ type Final struct {
 	OutputStrategy         string          `toml:"output_strategy"`
 	SeriesTimeout          config.Duration `toml:"series_timeout"`
 	KeepOriginalFieldNames bool            `toml:"keep_original_field_names"`
+	Log                    telegraf.Logger `toml:"-"`
 
 	// The last metric for all series which are active
 	metricCache map[uint64]telegraf.Metric
 }
 
+// state holds the last metric of all active series indexed by series ID
+type state map[uint64]metricState
+
+type metricState struct {
+	Name   string                `json:"name"`
+	Tags   map[string]string     `json:"tags,omitempty"`
+	Fields map[string]fieldState `json:"fields"`
+	Time   time.Time             `json:"time"`
+	Type   telegraf.ValueType    `json:"type,omitempty"`
+}
+
+// fieldState holds a field value with exactly one of the values set to
+// preserve the type of the field
+type fieldState struct {
+	Float  *float64 `json:"float,omitempty"`
+	Int    *int64   `json:"int,omitempty"`
+	Uint   *uint64  `json:"uint,omitempty"`
+	String *string  `json:"string,omitempty"`
+	Bool   *bool    `json:"bool,omitempty"`
+}
+
 func (*Final) SampleConfig() string {
 	return sampleConfig
 }
@@ -53,23 +76,23 @@ func (m *Final) Push(acc telegraf.Accumulator) {
 	// Preserve timestamp of original metric
 	acc.SetPrecision(time.Nanosecond)
 
-	for id, metric := range m.metricCache {
-		if m.OutputStrategy == "timeout" && time.Since(metric.Time()) <= time.Duration(m.SeriesTimeout) {
+	for id, last := range m.metricCache {
+		if m.OutputStrategy == "timeout" && time.Since(last.Time()) <= time.Duration(m.SeriesTimeout) {
 			// We output on timeout but the last metric of the series was
 			// younger than that. So skip the output for this period.
 			continue
 		}
 		var fields map[string]any
 		if m.KeepOriginalFieldNames {
-			fields = metric.Fields()
+			fields = last.Fields()
 		} else {
-			fields = make(map[string]any, len(metric.FieldList()))
-			for _, field := range metric.FieldList() {
+			fields = make(map[string]any, len(last.FieldList()))
+			for _, field := range last.FieldList() {
 				fields[field.Key+"_final"] = field.Value
 			}
 		}
 
-		acc.AddFields(metric.Name(), fields, metric.Tags(), metric.Time())
+		acc.AddFields(last.Name(), fields, last.Tags(), last.Time())
 		delete(m.metricCache, id)
 	}
 }
@@ -77,6 +100,68 @@ func (m *Final) Push(acc telegraf.Accumulator) {
 func (*Final) Reset() {
 }
 
+func (m *Final) GetState() interface{} {
+	s := make(state, len(m.metricCache))
+	for id, last := range m.metricCache {
+		fields := make(map[string]fieldState, len(last.FieldList()))
+		for _, field := range last.FieldList() {
+			var fs fieldState
+			switch v := field.Value.(type) {
+			case float64:
+				fs.Float = &v
+			case int64:
+				fs.Int = &v
+			case uint64:
+				fs.Uint = &v
+			case string:
+				fs.String = &v
+			case bool:
+				fs.Bool = &v
+			default:
+				m.Log.Errorf("Skipping field %q of %q with unsupported type %T", field.Key, last.Name(), v)
+				continue
+			}
+			fields[field.Key] = fs
+		}
+		s[id] = metricState{
+			Name:   last.Name(),
+			Tags:   last.Tags(),
+			Fields: fields,
+			Time:   last.Time(),
+			Type:   last.Type(),
+		}
+	}
+	return s
+}
+
+func (m *Final) SetState(st interface{}) error {
+	s, ok := st.(state)
+	if !ok {
+		return fmt.Errorf("state has wrong type %T", st)
+	}
+
+	m.metricCache = make(map[uint64]telegraf.Metric, len(s))
+	for id, ms := range s {
+		fields := make(map[string]interface{}, len(ms.Fields))
+		for k, fs := range ms.Fields {
+			switch {
+			case fs.Float != nil:
+				fields[k] = *fs.Float
+			case fs.Int != nil:
+				fields[k] = *fs.Int
+			case fs.Uint != nil:
+				fields[k] = *fs.Uint
+			case fs.String != nil:
+				fields[k] = *fs.String
+			case fs.Bool != nil:
+				fields[k] = *fs.Bool
+			}
+		}
+		m.metricCache[id] = metric.New(ms.Name, ms.Tags, fields, ms.Time, ms.Type)
+	}
+	return nil
+}
+
 func newFinal() *Final {
 	return &Final{
 		SeriesTimeout: config.Duration(5 * time.Minute),
@@ -1,6 +1,7 @@
 package final
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -306,3 +307,41 @@ func TestKeepOriginalFieldNames(t *testing.T) {
 
 	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.SortMetrics())
 }
+
+func TestState(t *testing.T) {
+	final := newFinal()
+	final.Log = testutil.Logger{}
+	require.NoError(t, final.Init())
+
+	tags := map[string]string{"foo": "bar"}
+	fields := map[string]interface{}{
+		"a": int64(9007199254740993),
+		"b": uint64(18446744073709551615),
+		"c": "value",
+		"d": 0.1,
+		"e": true,
+	}
+	m1 := metric.New("m1", tags, fields, time.Unix(1530939936, 123456789), telegraf.Counter)
+	final.Add(m1)
+
+	data, err := json.Marshal(final.GetState())
+	require.NoError(t, err)
+	var s state
+	require.NoError(t, json.Unmarshal(data, &s))
+
+	restored := newFinal()
+	restored.Log = testutil.Logger{}
+	require.NoError(t, restored.Init())
+	require.NoError(t, restored.SetState(s))
+	require.Equal(t, telegraf.Counter, restored.metricCache[m1.HashID()].Type())
+
+	// The field types and the timestamp are preserved
+	restored.KeepOriginalFieldNames = true
+	acc := testutil.Accumulator{}
+	restored.Push(&acc)
+
+	expected := []telegraf.Metric{
+		metric.New("m1", tags, fields, time.Unix(1530939936, 123456789)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
@@ -3,6 +3,7 @@ package histogram
 
 import (
 	_ "embed"
+	"fmt"
 	"sort"
 	"strconv"
 	"time"
@@ -67,6 +68,18 @@ type metricHistogramCollection struct {
 // counts is the number of hits in the bucket
 type counts []int64
 
+// state holds the bucket counts of the histograms of all series indexed by
+// series ID
+type state map[uint64]histogramState
+
+type histogramState struct {
+	Name       string             `json:"name"`
+	Tags       map[string]string  `json:"tags,omitempty"`
+	Counts     map[string][]int64 `json:"counts"`
+	ExpireTime time.Time          `json:"expire_time,omitempty"`
+	Updated    bool               `json:"updated,omitempty"`
+}
+
 // groupedByCountFields contains grouped fields by their count and fields values
 type groupedByCountFields struct {
 	name            string
@@ -156,6 +169,53 @@ func (h *Histogram) Reset() {
 	}
 }
 
+func (h *Histogram) GetState() interface{} {
+	s := make(state, len(h.cache))
+	for id, agr := range h.cache {
+		histograms := make(map[string][]int64, len(agr.histogramCollection))
+		for field, c := range agr.histogramCollection {
+			histograms[field] = c
+		}
+		s[id] = histogramState{
+			Name:       agr.name,
+			Tags:       agr.tags,
+			Counts:     histograms,
+			ExpireTime: agr.expireTime,
+			Updated:    agr.updated,
+		}
+	}
+	return s
+}
+
+func (h *Histogram) SetState(st interface{}) error {
+	s, ok := st.(state)
+	if !ok {
+		return fmt.Errorf("state has wrong type %T", st)
+	}
+
+	h.resetCache()
+	for id, hs := range s {
+		agr := metricHistogramCollection{
+			name:                hs.Name,
+			tags:                hs.Tags,
+			histogramCollection: make(map[string]counts, len(hs.Counts)),
+			expireTime:          hs.ExpireTime,
+			updated:             hs.Updated,
+		}
+		for field, c := range hs.Counts {
+			// Skip histograms not matching the configured buckets anymore
+			if buckets := h.getBuckets(hs.Name, field); buckets == nil || len(buckets)+1 != len(c) {
+				continue
+			}
+			agr.histogramCollection[field] = c
+		}
+		if len(agr.histogramCollection) > 0 {
+			h.cache[id] = agr
+		}
+	}
+	return nil
+}
+
 // groupFieldsByBuckets groups fields by metric buckets which are represented as tags
 func (h *Histogram) groupFieldsByBuckets(
 	metricsWithGroupedFields *[]groupedByCountFields, name, field string, tags map[string]string, counts []int64,
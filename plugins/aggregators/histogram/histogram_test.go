@@ -1,6 +1,7 @@
 package histogram
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
@@ -532,6 +533,49 @@ func TestHistogramMetricExpiration(t *testing.T) {
 	)
 }
 
+// TestHistogramState tests continuing a cumulative histogram after restoring the state
+func TestHistogramState(t *testing.T) {
+	cfg := []bucketConfig{
+		{
+			Metric:  "first_metric_name",
+			Fields:  []string{"a"},
+			Buckets: []float64{0.0, 10.0, 20.0, 30.0, 40.0},
+		},
+	}
+	previous := []bucketConfig{
+		cfg[0],
+		{
+			Metric:  "second_metric_name",
+			Buckets: []float64{0.0, 100.0},
+		},
+	}
+	histogram := newTestHistogram(previous, false, true, false).(*Histogram)
+	histogram.Add(firstMetric1)
+	histogram.Add(secondMetric)
+	require.Len(t, histogram.cache, 2)
+
+	data, err := json.Marshal(histogram.GetState())
+	require.NoError(t, err)
+	var s state
+	require.NoError(t, json.Unmarshal(data, &s))
+
+	// Histograms not configured anymore are not restored
+	restored := newTestHistogram(cfg, false, true, false).(*Histogram)
+	require.NoError(t, restored.SetState(s))
+	require.Len(t, restored.cache, 1)
+
+	acc := &testutil.Accumulator{}
+	restored.Add(firstMetric2)
+	restored.Push(acc)
+
+	require.Len(t, acc.Metrics, 6, "Incorrect number of metrics")
+	assertContainsTaggedField(t, acc, "first_metric_name", fields{"a_bucket": int64(0)}, tags{bucketRightTag: "10"})
+	assertContainsTaggedField(t, acc, "first_metric_name", fields{"a_bucket": int64(2)}, tags{bucketRightTag: "20"})
+	assertContainsTaggedField(t, acc, "first_metric_name", fields{"a_bucket": int64(2)}, tags{bucketRightTag: bucketPosInf})
+
+	require.Error(t, restored.SetState("invalid"))
+}
+
 // assertContainsTaggedField is help functions to test histogram data
 func assertContainsTaggedField(t *testing.T, acc *testutil.Accumulator, metricName string, fields map[string]interface{}, tags map[string]string) {
 	acc.Lock()
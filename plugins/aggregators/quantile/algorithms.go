@@ -1,6 +1,9 @@
 package quantile
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"math"
 	"sort"
 
@@ -12,10 +15,49 @@ type algorithm interface {
 	Quantile(q float64) float64
 }
 
+// algorithmState is the serializable state of an algorithm
+type algorithmState struct {
+	TDigest []byte    `json:"tdigest,omitempty"`
+	Values  []float64 `json:"values,omitempty"`
+}
+
 func newTDigest(compression float64) (algorithm, error) {
 	return tdigest.New(tdigest.Compression(compression))
 }
 
+func getAlgorithmState(algo algorithm) (algorithmState, error) {
+	switch a := algo.(type) {
+	case *tdigest.TDigest:
+		buf, err := a.AsBytes()
+		if err != nil {
+			return algorithmState{}, err
+		}
+		return algorithmState{TDigest: buf}, nil
+	case *exactAlgorithmR7:
+		return algorithmState{Values: a.xs}, nil
+	case *exactAlgorithmR8:
+		return algorithmState{Values: a.xs}, nil
+	}
+	return algorithmState{}, fmt.Errorf("unsupported algorithm %T", algo)
+}
+
+func (s algorithmState) restore(algo algorithm) (algorithm, error) {
+	switch a := algo.(type) {
+	case *tdigest.TDigest:
+		if s.TDigest == nil {
+			return nil, errors.New("state does not contain a t-digest")
+		}
+		return tdigest.FromBytes(bytes.NewReader(s.TDigest))
+	case *exactAlgorithmR7:
+		a.xs = append(a.xs, s.Values...)
+		return a, nil
+	case *exactAlgorithmR8:
+		a.xs = append(a.xs, s.Values...)
+		return a, nil
+	}
+	return nil, fmt.Errorf("unsupported algorithm %T", algo)
+}
+
 type exactAlgorithmR7 struct {
 	xs     []float64
 	sorted bool
@@ -32,6 +32,16 @@ type aggregate struct {
 
 type newAlgorithmFunc func(compression float64) (algorithm, error)
 
+// state holds the serialized estimators of the fields of all series indexed
+// by series ID
+type state map[uint64]aggregateState
+
+type aggregateState struct {
+	Name   string                    `json:"name"`
+	Tags   map[string]string         `json:"tags,omitempty"`
+	Fields map[string]algorithmState `json:"fields"`
+}
+
 func (*Quantile) SampleConfig() string {
 	return sampleConfig
 }
@@ -128,6 +138,53 @@ func (q *Quantile) Reset() {
 	q.cache = make(map[uint64]aggregate)
 }
 
+func (q *Quantile) GetState() interface{} {
+	s := make(state, len(q.cache))
+	for id, a := range q.cache {
+		fields := make(map[string]algorithmState, len(a.fields))
+		for k, algo := range a.fields {
+			as, err := getAlgorithmState(algo)
+			if err != nil {
+				q.Log.Errorf("serializing field %s of %q: %v", k, a.name, err)
+				continue
+			}
+			fields[k] = as
+		}
+		s[id] = aggregateState{Name: a.name, Tags: a.tags, Fields: fields}
+	}
+	return s
+}
+
+func (q *Quantile) SetState(st interface{}) error {
+	s, ok := st.(state)
+	if !ok {
+		return fmt.Errorf("state has wrong type %T", st)
+	}
+
+	q.Reset()
+	for id, as := range s {
+		a := aggregate{
+			name:   as.Name,
+			tags:   as.Tags,
+			fields: make(map[string]algorithm, len(as.Fields)),
+		}
+		for k, fs := range as.Fields {
+			algo, err := q.newAlgorithm(q.Compression)
+			if err != nil {
+				return fmt.Errorf("generating algorithm %s: %w", k, err)
+			}
+			// The state cannot be restored if the algorithm was changed
+			if algo, err = fs.restore(algo); err != nil {
+				q.Log.Warnf("Dropping state of field %s of %q: %v", k, as.Name, err)
+				continue
+			}
+			a.fields[k] = algo
+		}
+		q.cache[id] = a
+	}
+	return nil
+}
+
 func convert(in interface{}) (float64, bool) {
 	switch v := in.(type) {
 	case float64:
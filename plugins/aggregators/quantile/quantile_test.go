@@ -1,6 +1,7 @@
 package quantile
 
 import (
+	"encoding/json"
 	"math/rand"
 	"testing"
 	"time"
@@ -440,6 +441,54 @@ func TestMultipleMetricsExactR8(t *testing.T) {
 	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime(), epsilon, sort)
 }
 
+func TestState(t *testing.T) {
+	for _, algorithm := range []string{"t-digest", "exact R7", "exact R8"} {
+		t.Run(algorithm, func(t *testing.T) {
+			metrics := make([]telegraf.Metric, 0, 100)
+			for i := 0; i < 100; i++ {
+				metrics = append(metrics, metric.New(
+					"test",
+					map[string]string{"foo": "bar"},
+					map[string]interface{}{"a": float64(i)},
+					time.Now(),
+				))
+			}
+
+			// Aggregate all metrics without interruption
+			expected := Quantile{AlgorithmType: algorithm, Compression: 100, Log: testutil.Logger{}}
+			require.NoError(t, expected.Init())
+			for _, m := range metrics {
+				expected.Add(m)
+			}
+			var expectedAcc testutil.Accumulator
+			expected.Push(&expectedAcc)
+
+			// Persist the state half-way and continue with a new instance
+			q := Quantile{AlgorithmType: algorithm, Compression: 100, Log: testutil.Logger{}}
+			require.NoError(t, q.Init())
+			for _, m := range metrics[:50] {
+				q.Add(m)
+			}
+			data, err := json.Marshal(q.GetState())
+			require.NoError(t, err)
+			var s state
+			require.NoError(t, json.Unmarshal(data, &s))
+
+			restored := Quantile{AlgorithmType: algorithm, Compression: 100, Log: testutil.Logger{}}
+			require.NoError(t, restored.Init())
+			require.NoError(t, restored.SetState(s))
+			for _, m := range metrics[50:] {
+				restored.Add(m)
+			}
+			var acc testutil.Accumulator
+			restored.Push(&acc)
+
+			epsilon := cmpopts.EquateApprox(0, 1e-3)
+			testutil.RequireMetricsEqual(t, expectedAcc.GetTelegrafMetrics(), acc.GetTelegrafMetrics(), testutil.IgnoreTime(), epsilon)
+		})
+	}
+}
+
 func BenchmarkDefaultTDigest(b *testing.B) {
 	metrics := make([]telegraf.Metric, 0, 100)
 	for i := 0; i < 100; i++ {
@@ -24,6 +24,16 @@ type aggregate struct {
 	fieldCount map[string]int
 }
 
+// state holds the number of occurrences of the field values of all series
+// indexed by series ID
+type state map[uint64]aggregateState
+
+type aggregateState struct {
+	Name       string            `json:"name"`
+	Tags       map[string]string `json:"tags,omitempty"`
+	FieldCount map[string]int    `json:"field_count"`
+}
+
 func (*ValueCounter) SampleConfig() string {
 	return sampleConfig
 }
@@ -68,6 +78,39 @@ func (vc *ValueCounter) Reset() {
 	vc.cache = make(map[uint64]aggregate)
 }
 
+func (vc *ValueCounter) GetState() interface{} {
+	s := make(state, len(vc.cache))
+	for id, agg := range vc.cache {
+		s[id] = aggregateState{
+			Name:       agg.name,
+			Tags:       agg.tags,
+			FieldCount: agg.fieldCount,
+		}
+	}
+	return s
+}
+
+func (vc *ValueCounter) SetState(st interface{}) error {
+	s, ok := st.(state)
+	if !ok {
+		return fmt.Errorf("state has wrong type %T", st)
+	}
+
+	vc.cache = make(map[uint64]aggregate, len(s))
+	for id, agg := range s {
+		fieldCount := agg.FieldCount
+		if fieldCount == nil {
+			fieldCount = make(map[string]int)
+		}
+		vc.cache[id] = aggregate{
+			name:       agg.Name,
+			tags:       agg.Tags,
+			fieldCount: fieldCount,
+		}
+	}
+	return nil
+}
+
 func newValueCounter() telegraf.Aggregator {
 	vc := &ValueCounter{}
 	vc.Reset()
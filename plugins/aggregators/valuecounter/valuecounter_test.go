@@ -1,9 +1,12 @@
 package valuecounter
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/require"
+
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/testutil"
@@ -123,3 +126,31 @@ func TestWithReset(t *testing.T) {
 	}
 	acc.AssertContainsTaggedFields(t, "m1", expectedFields, expectedTags)
 }
+
+// Test continuing the count after restoring the state
+func TestState(t *testing.T) {
+	vc := newTestValueCounter([]string{"status"}).(*ValueCounter)
+	vc.Add(m1)
+	vc.Add(m2)
+
+	data, err := json.Marshal(vc.GetState())
+	require.NoError(t, err)
+	var s state
+	require.NoError(t, json.Unmarshal(data, &s))
+
+	restored := newTestValueCounter([]string{"status"}).(*ValueCounter)
+	require.NoError(t, restored.SetState(s))
+
+	acc := testutil.Accumulator{}
+	restored.Add(m1)
+	restored.Push(&acc)
+
+	expectedFields := map[string]interface{}{
+		"status_200": 2,
+		"status_OK":  1,
+	}
+	expectedTags := map[string]string{
+		"foo": "bar",
+	}
+	acc.AssertContainsTaggedFields(t, "m1", expectedFields, expectedTags)
+}
@@ -0,0 +1,81 @@
+package backpressure
+
+import (
+	"context"
+	"sync"
+)
+
+// closed is an always closed channel returned for open gates
+var closed = func() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}()
+
+// Gate allows service inputs to pause consuming new messages while the agent
+// signals backpressure. The zero value is an open gate, a nil gate is always
+// open.
+type Gate struct {
+	// Channel closed on resuming, nil while the gate is open
+	resume chan struct{}
+
+	sync.Mutex
+}
+
+// Pause closes the gate.
+func (g *Gate) Pause() {
+	g.Lock()
+	defer g.Unlock()
+
+	if g.resume == nil {
+		g.resume = make(chan struct{})
+	}
+}
+
+// Resume opens the gate releasing all waiting consumers.
+func (g *Gate) Resume() {
+	g.Lock()
+	defer g.Unlock()
+
+	if g.resume != nil {
+		close(g.resume)
+		g.resume = nil
+	}
+}
+
+// Paused returns true if the gate is closed.
+func (g *Gate) Paused() bool {
+	if g == nil {
+		return false
+	}
+	g.Lock()
+	defer g.Unlock()
+
+	return g.resume != nil
+}
+
+// Open returns a channel that is closed as soon as the gate is open. The
+// channel is intended for use in select statements and must be requested
+// again after it was closed.
+func (g *Gate) Open() <-chan struct{} {
+	if g == nil {
+		return closed
+	}
+	g.Lock()
+	defer g.Unlock()
+
+	if g.resume == nil {
+		return closed
+	}
+	return g.resume
+}
+
+// Wait blocks until the gate is open or the context is done.
+func (g *Gate) Wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-g.Open():
+		return nil
+	}
+}
@@ -0,0 +1,35 @@
+package backpressure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGate(t *testing.T) {
+	var g Gate
+	require.False(t, g.Paused())
+	require.NoError(t, g.Wait(t.Context()))
+
+	g.Pause()
+	g.Pause()
+	require.True(t, g.Paused())
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, g.Wait(ctx), context.DeadlineExceeded)
+
+	done := make(chan error)
+	go func() {
+		done <- g.Wait(t.Context())
+	}()
+	g.Resume()
+	require.NoError(t, <-done)
+	require.False(t, g.Paused())
+
+	// Resuming an open gate is a no-op
+	g.Resume()
+	require.False(t, g.Paused())
+}
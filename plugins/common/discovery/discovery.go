@@ -0,0 +1,245 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+)
+
+// Interval for checking target files for modifications
+const fileWatchInterval = time.Second
+
+// Config is the configuration of the dynamic target discovery usable by any
+// plugin scraping URLs. Discovered targets are added to the statically
+// configured ones. The discovery is supported by the http, jolokia2_agent
+// and nginx inputs.
+type Config struct {
+	Files           []string        `toml:"files"`
+	DNSSRVRecords   []string        `toml:"dns_srv_records"`
+	HTTPURL         string          `toml:"http_url"`
+	RefreshInterval config.Duration `toml:"refresh_interval"`
+	Scheme          string          `toml:"scheme"`
+	Path            string          `toml:"path"`
+}
+
+// Target is a discovered URL together with the labels attached to it by the
+// discovery source. Providers may return plain "host:port" addresses which
+// are completed using the configured scheme and path.
+type Target struct {
+	URL    string
+	Labels map[string]string
+}
+
+// Provider is a source of targets.
+type Provider interface {
+	// Name identifies the provider in log messages
+	Name() string
+	// Discover returns the current list of targets
+	Discover(ctx context.Context) ([]Target, error)
+}
+
+// watcher is implemented by providers detecting changes of their targets
+// without querying them, e.g. by checking the modification time of files.
+type watcher interface {
+	Changed() bool
+}
+
+// Discoverer periodically refreshes the targets of the configured providers.
+type Discoverer struct {
+	providers []Provider
+	interval  time.Duration
+	scheme    string
+	path      string
+	log       telegraf.Logger
+
+	targets map[string][]Target
+	sync.Mutex
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// CreateDiscoverer creates a discoverer for the configuration. The given
+// client is used to query HTTP service discovery endpoints and may be nil for
+// a default client.
+func (cfg *Config) CreateDiscoverer(client *http.Client, log telegraf.Logger) (*Discoverer, error) {
+	switch cfg.Scheme {
+	case "", "http", "https":
+	default:
+		return nil, fmt.Errorf("invalid scheme %q", cfg.Scheme)
+	}
+	if cfg.Path != "" && !strings.HasPrefix(cfg.Path, "/") {
+		cfg.Path = "/" + cfg.Path
+	}
+
+	var providers []Provider
+	if len(cfg.Files) > 0 {
+		providers = append(providers, &FileProvider{Patterns: cfg.Files})
+	}
+	if len(cfg.DNSSRVRecords) > 0 {
+		providers = append(providers, &DNSSRVProvider{Records: cfg.DNSSRVRecords})
+	}
+	if cfg.HTTPURL != "" {
+		if _, err := url.Parse(cfg.HTTPURL); err != nil {
+			return nil, fmt.Errorf("invalid HTTP discovery URL: %w", err)
+		}
+		if client == nil {
+			client = &http.Client{Timeout: 5 * time.Second}
+		}
+		providers = append(providers, &HTTPProvider{URL: cfg.HTTPURL, Client: client})
+	}
+	if len(providers) == 0 {
+		return nil, errors.New("no discovery source configured")
+	}
+
+	return New(cfg, log, providers...), nil
+}
+
+// New creates a discoverer for the given providers. The scheme and path of
+// the configuration are used to complete target addresses.
+func New(cfg *Config, log telegraf.Logger, providers ...Provider) *Discoverer {
+	interval := time.Duration(cfg.RefreshInterval)
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	scheme := cfg.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+	return &Discoverer{
+		providers: providers,
+		interval:  interval,
+		scheme:    scheme,
+		path:      cfg.Path,
+		log:       log,
+		targets:   make(map[string][]Target, len(providers)),
+	}
+}
+
+// Start performs an initial discovery and keeps the targets up-to-date in the
+// background until Stop is called. Failing providers are retried on the next
+// refresh and keep their previous targets.
+func (d *Discoverer) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+
+	for _, p := range d.providers {
+		d.refresh(ctx, p)
+	}
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		refresh := time.NewTicker(d.interval)
+		defer refresh.Stop()
+		watch := time.NewTicker(fileWatchInterval)
+		defer watch.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-refresh.C:
+				for _, p := range d.providers {
+					d.refresh(ctx, p)
+				}
+			case <-watch.C:
+				for _, p := range d.providers {
+					if w, ok := p.(watcher); ok && w.Changed() {
+						d.refresh(ctx, p)
+					}
+				}
+			}
+		}
+	}()
+}
+
+// Stop terminates the background discovery.
+func (d *Discoverer) Stop() {
+	if d.cancel != nil {
+		d.cancel()
+	}
+	d.wg.Wait()
+}
+
+// Targets returns the currently discovered targets sorted by URL. Targets
+// discovered by multiple providers are only returned once.
+func (d *Discoverer) Targets() []Target {
+	d.Lock()
+	defer d.Unlock()
+
+	seen := make(map[string]bool)
+	var targets []Target
+	for _, p := range d.providers {
+		for _, t := range d.targets[p.Name()] {
+			if seen[t.URL] {
+				continue
+			}
+			seen[t.URL] = true
+			targets = append(targets, t)
+		}
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].URL < targets[j].URL })
+	return targets
+}
+
+func (d *Discoverer) refresh(ctx context.Context, p Provider) {
+	targets, err := p.Discover(ctx)
+	if err != nil {
+		d.log.Errorf("Discovering targets using %s failed: %v", p.Name(), err)
+		return
+	}
+
+	valid := make([]Target, 0, len(targets))
+	for _, t := range targets {
+		u, err := targetURL(t.URL, d.scheme, d.path)
+		if err != nil {
+			d.log.Warnf("Ignoring invalid target %q discovered using %s: %v", t.URL, p.Name(), err)
+			continue
+		}
+		valid = append(valid, Target{URL: u, Labels: targetLabels(t.Labels)})
+	}
+
+	d.Lock()
+	d.targets[p.Name()] = valid
+	d.Unlock()
+	d.log.Debugf("Discovered %d targets using %s", len(valid), p.Name())
+}
+
+// targetURL completes the address of a target with the configured scheme and
+// path. Addresses with a scheme are used as is.
+func targetURL(address, scheme, path string) (string, error) {
+	if !strings.Contains(address, "://") {
+		address = scheme + "://" + address + path
+	}
+	u, err := url.Parse(address)
+	if err != nil {
+		return "", err
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("missing host in %q", address)
+	}
+	return u.String(), nil
+}
+
+// targetLabels removes labels with the reserved "__" prefix used by
+// Prometheus for internal labels.
+func targetLabels(labels map[string]string) map[string]string {
+	filtered := make(map[string]string, len(labels))
+	for k, v := range labels {
+		if !strings.HasPrefix(k, "__") {
+			filtered[k] = v
+		}
+	}
+	return filtered
+}
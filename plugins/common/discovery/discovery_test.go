@@ -0,0 +1,163 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	jsonFile := filepath.Join(dir, "targets.json")
+	require.NoError(t, os.WriteFile(jsonFile, []byte(`[
+		{"targets": ["host1:9100", "host2:9100"], "labels": {"env": "prod", "__meta_source": "test"}}
+	]`), 0600))
+	yamlFile := filepath.Join(dir, "targets.yaml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte(`
+- targets: ["https://host3:8443/status"]
+  labels:
+    env: dev
+`), 0600))
+
+	cfg := &Config{Files: []string{filepath.Join(dir, "*")}, Path: "/metrics"}
+	d, err := cfg.CreateDiscoverer(nil, testutil.Logger{})
+	require.NoError(t, err)
+	d.Start()
+	defer d.Stop()
+
+	expected := []Target{
+		{URL: "http://host1:9100/metrics", Labels: map[string]string{"env": "prod"}},
+		{URL: "http://host2:9100/metrics", Labels: map[string]string{"env": "prod"}},
+		{URL: "https://host3:8443/status", Labels: map[string]string{"env": "dev"}},
+	}
+	require.Equal(t, expected, d.Targets())
+
+	// Modifying the files must be picked up
+	require.NoError(t, os.Remove(yamlFile))
+	require.Eventually(t, func() bool {
+		return len(d.Targets()) == 2
+	}, 5*time.Second, 100*time.Millisecond)
+}
+
+func TestFileProviderInvalidFile(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "targets.json")
+	require.NoError(t, os.WriteFile(fn, []byte(`{"targets": "foo"}`), 0600))
+
+	p := &FileProvider{Patterns: []string{fn}}
+	_, err := p.Discover(t.Context())
+	require.ErrorContains(t, err, "parsing")
+
+	// The invalid file must only be read again after modifying it
+	require.False(t, p.Changed())
+	require.NoError(t, os.WriteFile(fn, []byte(`[{"targets": ["host1:9100"]}]`), 0600))
+	require.True(t, p.Changed())
+	targets, err := p.Discover(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []Target{{URL: "host1:9100"}}, targets)
+	require.False(t, p.Changed())
+}
+
+func TestFileProviderChanged(t *testing.T) {
+	dir := t.TempDir()
+	p := &FileProvider{Patterns: []string{filepath.Join(dir, "*.yml")}}
+	_, err := p.Discover(t.Context())
+	require.NoError(t, err)
+	require.False(t, p.Changed())
+
+	fn := filepath.Join(dir, "targets.yml")
+	require.NoError(t, os.WriteFile(fn, []byte("- targets: [\"localhost:80\"]\n"), 0600))
+	require.True(t, p.Changed())
+
+	targets, err := p.Discover(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []Target{{URL: "localhost:80"}}, targets)
+	require.False(t, p.Changed())
+}
+
+func TestDNSSRVProvider(t *testing.T) {
+	p := &DNSSRVProvider{
+		Records: []string{"_metrics._tcp.example.com"},
+		lookup: func(_ context.Context, name string) ([]*net.SRV, error) {
+			require.Equal(t, "_metrics._tcp.example.com", name)
+			return []*net.SRV{
+				{Target: "node1.example.com.", Port: 9100},
+				{Target: "node2.example.com.", Port: 9200},
+			}, nil
+		},
+	}
+
+	d := New(&Config{Scheme: "https"}, testutil.Logger{}, p)
+	d.Start()
+	defer d.Stop()
+
+	labels := map[string]string{"srv_record": "_metrics._tcp.example.com"}
+	expected := []Target{
+		{URL: "https://node1.example.com:9100", Labels: labels},
+		{URL: "https://node2.example.com:9200", Labels: labels},
+	}
+	require.Equal(t, expected, d.Targets())
+}
+
+func TestHTTPProvider(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if _, err := w.Write([]byte(`[{"targets": ["localhost:9100"], "labels": {"job": "node"}}]`)); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			t.Error(err)
+		}
+	}))
+	defer ts.Close()
+
+	cfg := &Config{HTTPURL: ts.URL, RefreshInterval: config.Duration(time.Minute)}
+	d, err := cfg.CreateDiscoverer(ts.Client(), testutil.Logger{})
+	require.NoError(t, err)
+	d.Start()
+	defer d.Stop()
+
+	expected := []Target{
+		{URL: "http://localhost:9100", Labels: map[string]string{"job": "node"}},
+	}
+	require.Equal(t, expected, d.Targets())
+}
+
+func TestFailingProviderKeepsTargets(t *testing.T) {
+	p := &mockProvider{targets: []Target{{URL: "localhost:80"}}}
+	d := New(&Config{}, testutil.Logger{}, p)
+	d.refresh(t.Context(), p)
+	require.Len(t, d.Targets(), 1)
+
+	p.err = errors.New("unavailable")
+	d.refresh(t.Context(), p)
+	require.Len(t, d.Targets(), 1)
+}
+
+func TestInvalidConfig(t *testing.T) {
+	_, err := (&Config{}).CreateDiscoverer(nil, testutil.Logger{})
+	require.ErrorContains(t, err, "no discovery source")
+
+	_, err = (&Config{Files: []string{"*.json"}, Scheme: "ftp"}).CreateDiscoverer(nil, testutil.Logger{})
+	require.ErrorContains(t, err, "invalid scheme")
+}
+
+type mockProvider struct {
+	targets []Target
+	err     error
+}
+
+func (*mockProvider) Name() string {
+	return "mock"
+}
+
+func (p *mockProvider) Discover(context.Context) ([]Target, error) {
+	return p.targets, p.err
+}
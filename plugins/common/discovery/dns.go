@@ -0,0 +1,48 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// DNSSRVProvider discovers targets by querying DNS SRV records. Each target
+// is labeled with the queried record in the "srv_record" label.
+type DNSSRVProvider struct {
+	Records []string
+
+	// Function to query the records, defaults to the system resolver
+	lookup func(ctx context.Context, name string) ([]*net.SRV, error)
+}
+
+func (*DNSSRVProvider) Name() string {
+	return "DNS SRV records"
+}
+
+func (p *DNSSRVProvider) Discover(ctx context.Context) ([]Target, error) {
+	lookup := p.lookup
+	if lookup == nil {
+		lookup = func(ctx context.Context, name string) ([]*net.SRV, error) {
+			_, addrs, err := net.DefaultResolver.LookupSRV(ctx, "", "", name)
+			return addrs, err
+		}
+	}
+
+	var targets []Target
+	for _, record := range p.Records {
+		addrs, err := lookup(ctx, record)
+		if err != nil {
+			return nil, fmt.Errorf("looking up %q failed: %w", record, err)
+		}
+		for _, addr := range addrs {
+			host := strings.TrimSuffix(addr.Target, ".")
+			targets = append(targets, Target{
+				URL:    net.JoinHostPort(host, strconv.Itoa(int(addr.Port))),
+				Labels: map[string]string{"srv_record": record},
+			})
+		}
+	}
+	return targets, nil
+}
@@ -0,0 +1,131 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// targetGroup is a group of targets sharing the same labels in the format
+// used by Prometheus file and HTTP service discovery, see
+// https://prometheus.io/docs/prometheus/latest/http_sd/#http_sd-format
+type targetGroup struct {
+	Targets []string          `json:"targets" yaml:"targets"`
+	Labels  map[string]string `json:"labels" yaml:"labels"`
+}
+
+// FileProvider discovers targets from JSON or YAML files matching the given
+// glob patterns. The files are watched for modifications.
+type FileProvider struct {
+	Patterns []string
+
+	// Modification time and size of the files read last
+	state map[string]fileState
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+func (*FileProvider) Name() string {
+	return "files"
+}
+
+func (p *FileProvider) Discover(context.Context) ([]Target, error) {
+	files, state, err := p.stat()
+	if err != nil {
+		return nil, err
+	}
+
+	// Record the state even if reading the files fails to not retry invalid
+	// files until they are modified
+	p.state = state
+
+	var targets []Target
+	for _, fn := range files {
+		groups, err := readTargetFile(fn)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, groupTargets(groups)...)
+	}
+
+	return targets, nil
+}
+
+// Changed checks if files matching the patterns were added, removed or
+// modified since the last discovery.
+func (p *FileProvider) Changed() bool {
+	_, state, err := p.stat()
+	if err != nil || len(state) != len(p.state) {
+		return true
+	}
+	for fn, s := range state {
+		if prev, found := p.state[fn]; !found || prev != s {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *FileProvider) stat() ([]string, map[string]fileState, error) {
+	var files []string
+	state := make(map[string]fileState)
+	for _, pattern := range p.Patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		for _, fn := range matches {
+			if _, found := state[fn]; found {
+				continue
+			}
+			info, err := os.Stat(fn)
+			if err != nil {
+				return nil, nil, err
+			}
+			if info.IsDir() {
+				continue
+			}
+			files = append(files, fn)
+			state[fn] = fileState{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return files, state, nil
+}
+
+func readTargetFile(fn string) ([]targetGroup, error) {
+	buf, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []targetGroup
+	switch strings.ToLower(filepath.Ext(fn)) {
+	case ".json":
+		err = json.Unmarshal(buf, &groups)
+	default:
+		err = yaml.Unmarshal(buf, &groups)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %q failed: %w", fn, err)
+	}
+	return groups, nil
+}
+
+func groupTargets(groups []targetGroup) []Target {
+	var targets []Target
+	for _, g := range groups {
+		for _, address := range g.Targets {
+			targets = append(targets, Target{URL: address, Labels: g.Labels})
+		}
+	}
+	return targets
+}
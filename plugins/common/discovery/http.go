@@ -0,0 +1,48 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// HTTPProvider discovers targets by querying an endpoint returning targets
+// in the Prometheus HTTP service discovery format.
+type HTTPProvider struct {
+	URL    string
+	Client *http.Client
+}
+
+func (*HTTPProvider) Name() string {
+	return "HTTP service discovery"
+}
+
+func (p *HTTPProvider) Discover(ctx context.Context) ([]Target, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request failed: %w", err)
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("discovery failed with status %q", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body failed: %w", err)
+	}
+
+	var groups []targetGroup
+	if err = json.Unmarshal(body, &groups); err != nil {
+		return nil, fmt.Errorf("unmarshalling JSON failed: %w", err)
+	}
+	return groupTargets(groups), nil
+}
@@ -14,7 +14,10 @@ import (
 )
 
 type Client struct {
-	URL    string
+	URL string
+	// Tags are added to all points gathered from the client, e.g. the
+	// labels of discovered agents
+	Tags   map[string]string
 	client *http.Client
 	config *ClientConfig
 }
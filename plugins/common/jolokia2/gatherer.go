@@ -32,6 +32,11 @@ func (g *Gatherer) Gather(client *Client, acc telegraf.Accumulator) error {
 	} else {
 		tags = map[string]string{"jolokia_agent_url": client.URL}
 	}
+	for k, v := range client.Tags {
+		if _, found := tags[k]; !found {
+			tags[k] = v
+		}
+	}
 
 	requests := makeReadRequests(g.metrics)
 	responses, err := client.read(requests)
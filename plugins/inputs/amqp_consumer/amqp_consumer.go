@@ -16,6 +16,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/common/backpressure"
 	"github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -61,6 +62,7 @@ type AMQPConsumer struct {
 	wg      *sync.WaitGroup
 	cancel  context.CancelFunc
 	decoder internal.ContentDecoder
+	gate    backpressure.Gate
 }
 
 // Mechanism represents the authentication mechanism used for AMQP connections.
@@ -177,6 +179,16 @@ func (*AMQPConsumer) Gather(_ telegraf.Accumulator) error {
 	return nil
 }
 
+// Pause stops consuming new messages while the agent applies backpressure.
+func (a *AMQPConsumer) Pause() {
+	a.gate.Pause()
+}
+
+// Resume continues consuming messages.
+func (a *AMQPConsumer) Resume() {
+	a.gate.Resume()
+}
+
 func (a *AMQPConsumer) Stop() {
 	// We did not connect successfully so there is nothing to do here.
 	if a.conn == nil || a.conn.IsClosed() {
@@ -406,14 +418,22 @@ func (a *AMQPConsumer) process(ctx context.Context, msgs <-chan amqp.Delivery, a
 	sem := make(semaphore, a.MaxUndeliveredMessages)
 
 	for {
+		// Stop taking new messages while paused but keep handling the
+		// deliveries
+		acquire, resumed := sem, (<-chan struct{})(nil)
+		if a.gate.Paused() {
+			acquire, resumed = nil, a.gate.Open()
+		}
+
 		select {
 		case <-ctx.Done():
 			return
+		case <-resumed:
 		case track := <-acc.Delivered():
 			if a.onDelivery(track) {
 				<-sem
 			}
-		case sem <- empty{}:
+		case acquire <- empty{}:
 			select {
 			case <-ctx.Done():
 				return
@@ -16,6 +16,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/common/discovery"
 	common_http "github.com/influxdata/telegraf/plugins/common/http"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -41,11 +42,13 @@ type HTTP struct {
 
 	Headers            map[string]*config.Secret `toml:"headers"`
 	SuccessStatusCodes []int                     `toml:"success_status_codes"`
+	Discovery          *discovery.Config         `toml:"discovery"`
 	Log                telegraf.Logger           `toml:"-"`
 
 	common_http.HTTPClientConfig
 
 	client     *http.Client
+	discoverer *discovery.Discoverer
 	parserFunc telegraf.ParserFunc
 }
 
@@ -71,6 +74,13 @@ func (h *HTTP) Init() error {
 	if len(h.SuccessStatusCodes) == 0 {
 		h.SuccessStatusCodes = []int{200}
 	}
+
+	if h.Discovery != nil {
+		h.discoverer, err = h.Discovery.CreateDiscoverer(client, h.Log)
+		if err != nil {
+			return fmt.Errorf("setting up discovery failed: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -78,7 +88,10 @@ func (h *HTTP) SetParserFunc(fn telegraf.ParserFunc) {
 	h.parserFunc = fn
 }
 
-func (*HTTP) Start(telegraf.Accumulator) error {
+func (h *HTTP) Start(telegraf.Accumulator) error {
+	if h.discoverer != nil {
+		h.discoverer.Start()
+	}
 	return nil
 }
 
@@ -88,18 +101,33 @@ func (h *HTTP) Gather(acc telegraf.Accumulator) error {
 		wg.Add(1)
 		go func(url string) {
 			defer wg.Done()
-			if err := h.gatherURL(acc, url); err != nil {
+			if err := h.gatherURL(acc, url, nil); err != nil {
 				acc.AddError(fmt.Errorf("[url=%s]: %w", url, err))
 			}
 		}(u)
 	}
 
+	if h.discoverer != nil {
+		for _, target := range h.discoverer.Targets() {
+			wg.Add(1)
+			go func(target discovery.Target) {
+				defer wg.Done()
+				if err := h.gatherURL(acc, target.URL, target.Labels); err != nil {
+					acc.AddError(fmt.Errorf("[url=%s]: %w", target.URL, err))
+				}
+			}(target)
+		}
+	}
+
 	wg.Wait()
 
 	return nil
 }
 
 func (h *HTTP) Stop() {
+	if h.discoverer != nil {
+		h.discoverer.Stop()
+	}
 	if h.client != nil {
 		h.client.CloseIdleConnections()
 	}
@@ -110,11 +138,12 @@ func (h *HTTP) Stop() {
 //
 //	acc    : The telegraf Accumulator to use
 //	url    : endpoint to send request to
+//	tags   : additional tags to add to the metrics, e.g. discovered labels
 //
 // Returns:
 //
 //	error: Any error that may have occurred
-func (h *HTTP) gatherURL(acc telegraf.Accumulator, url string) error {
+func (h *HTTP) gatherURL(acc telegraf.Accumulator, url string, tags map[string]string) error {
 	body := makeRequestBodyReader(h.ContentEncoding, h.Body)
 	if body != nil {
 		defer body.Close()
@@ -215,6 +244,11 @@ func (h *HTTP) gatherURL(acc telegraf.Accumulator, url string) error {
 		if !metric.HasTag("url") {
 			metric.AddTag("url", url)
 		}
+		for k, v := range tags {
+			if !metric.HasTag(k) {
+				metric.AddTag(k, v)
+			}
+		}
 		acc.AddFields(metric.Name(), metric.Fields(), metric.Tags(), metric.Time())
 	}
 
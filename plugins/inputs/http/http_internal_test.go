@@ -47,7 +47,7 @@ func TestGatherURLEarlyFailureWithGzipBody(t *testing.T) {
 
 	done := make(chan error, 1)
 	go func() {
-		done <- h.gatherURL(nil, "http://example.com")
+		done <- h.gatherURL(nil, "http://example.com", nil)
 	}()
 
 	select {
@@ -20,6 +20,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/discovery"
 	common_http "github.com/influxdata/telegraf/plugins/common/http"
 	"github.com/influxdata/telegraf/plugins/common/oauth"
 	httpplugin "github.com/influxdata/telegraf/plugins/inputs/http"
@@ -548,3 +549,58 @@ func TestConnectionOverUnixSocket(t *testing.T) {
 	require.NoError(t, acc.GatherError(plugin.Gather))
 	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
 }
+
+func TestDiscoveredTargets(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metrics" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if _, err := w.Write([]byte("cpu,region=eu value=42 22000000000000\n")); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			t.Error(err)
+		}
+	}))
+	defer fakeServer.Close()
+
+	u, err := url.Parse(fakeServer.URL)
+	require.NoError(t, err)
+
+	// The target file contains the "host:port" address of the server
+	fn := filepath.Join(t.TempDir(), "targets.json")
+	targets := fmt.Sprintf(`[{"targets": [%q], "labels": {"env": "prod", "region": "us"}}]`, u.Host)
+	require.NoError(t, os.WriteFile(fn, []byte(targets), 0600))
+
+	plugin := &httpplugin.HTTP{
+		Discovery: &discovery.Config{
+			Files: []string{fn},
+			Path:  "/metrics",
+		},
+		Log: testutil.Logger{},
+	}
+	plugin.SetParserFunc(func() (telegraf.Parser, error) {
+		parser := &influx.Parser{}
+		err := parser.Init()
+		return parser, err
+	})
+
+	// Tags of the metric take precedence over the discovered labels
+	expected := []telegraf.Metric{
+		metric.New("cpu",
+			map[string]string{
+				"url":    fakeServer.URL + "/metrics",
+				"env":    "prod",
+				"region": "eu",
+			},
+			map[string]interface{}{"value": 42.0},
+			time.Unix(22000, 0),
+		),
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+	require.NoError(t, acc.GatherError(plugin.Gather))
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
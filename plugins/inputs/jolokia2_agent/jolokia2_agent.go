@@ -1,14 +1,18 @@
+//go:generate ../../../tools/config_includer/generator
 //go:generate ../../../tools/readme_config_includer/generator
 package jolokia2_agent
 
 import (
 	_ "embed"
 	"fmt"
+	"net/http"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/plugins/common/discovery"
 	common "github.com/influxdata/telegraf/plugins/common/jolokia2"
 	"github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
@@ -30,15 +34,55 @@ type JolokiaAgent struct {
 
 	tls.ClientConfig
 
-	Metrics  []common.MetricConfig `toml:"metric"`
-	gatherer *common.Gatherer
-	clients  []*common.Client
+	Metrics   []common.MetricConfig `toml:"metric"`
+	Discovery *discovery.Config     `toml:"discovery"`
+	Log       telegraf.Logger       `toml:"-"`
+
+	gatherer   *common.Gatherer
+	clients    []*common.Client
+	discoverer *discovery.Discoverer
+	discovered map[string]*common.Client
 }
 
 func (*JolokiaAgent) SampleConfig() string {
 	return sampleConfig
 }
 
+func (ja *JolokiaAgent) Init() error {
+	if ja.Discovery == nil {
+		return nil
+	}
+
+	// Query the discovery endpoints using the same TLS settings as the agents
+	tlsCfg, err := ja.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+	client := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: tlsCfg},
+		Timeout:   time.Duration(ja.ResponseTimeout),
+	}
+
+	ja.discoverer, err = ja.Discovery.CreateDiscoverer(client, ja.Log)
+	if err != nil {
+		return fmt.Errorf("setting up discovery failed: %w", err)
+	}
+	return nil
+}
+
+func (ja *JolokiaAgent) Start(telegraf.Accumulator) error {
+	if ja.discoverer != nil {
+		ja.discoverer.Start()
+	}
+	return nil
+}
+
+func (ja *JolokiaAgent) Stop() {
+	if ja.discoverer != nil {
+		ja.discoverer.Stop()
+	}
+}
+
 func (ja *JolokiaAgent) Gather(acc telegraf.Accumulator) error {
 	if ja.gatherer == nil {
 		ja.gatherer = common.NewGatherer(ja.createMetrics())
@@ -57,9 +101,14 @@ func (ja *JolokiaAgent) Gather(acc telegraf.Accumulator) error {
 		}
 	}
 
+	clients := ja.clients
+	if ja.discoverer != nil {
+		clients = append(slices.Clone(clients), ja.discoveredClients(acc)...)
+	}
+
 	var wg sync.WaitGroup
 
-	for _, client := range ja.clients {
+	for _, client := range clients {
 		wg.Add(1)
 		go func(client *common.Client) {
 			defer wg.Done()
@@ -76,6 +125,31 @@ func (ja *JolokiaAgent) Gather(acc telegraf.Accumulator) error {
 	return nil
 }
 
+// discoveredClients returns the clients of the currently discovered agents
+// reusing the clients of agents discovered before.
+func (ja *JolokiaAgent) discoveredClients(acc telegraf.Accumulator) []*common.Client {
+	targets := ja.discoverer.Targets()
+	discovered := make(map[string]*common.Client, len(targets))
+	clients := make([]*common.Client, 0, len(targets))
+	for _, target := range targets {
+		client, found := ja.discovered[target.URL]
+		if !found {
+			var err error
+			client, err = ja.createClient(target.URL)
+			if err != nil {
+				acc.AddError(fmt.Errorf("unable to create client for %q: %w", target.URL, err))
+				continue
+			}
+		}
+		client.Tags = target.Labels
+		discovered[target.URL] = client
+		clients = append(clients, client)
+	}
+	ja.discovered = discovered
+
+	return clients
+}
+
 func (ja *JolokiaAgent) createMetrics() []common.Metric {
 	metrics := make([]common.Metric, 0, len(ja.Metrics))
 	for _, metricConfig := range ja.Metrics {
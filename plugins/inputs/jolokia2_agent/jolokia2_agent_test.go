@@ -6,7 +6,9 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -682,6 +684,54 @@ func TestFillFields(t *testing.T) {
 	require.Equal(t, map[string]interface{}{}, results)
 }
 
+func TestDiscoveredAgents(t *testing.T) {
+	response := `[{
+		"request": {
+			"mbean": "scalar_without_attribute",
+			"type": "read"
+		},
+		"value": 123,
+		"status": 200
+	  }]`
+
+	server := setupServer(response)
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	// The target file contains the "host:port" address of the agent
+	fn := filepath.Join(t.TempDir(), "targets.json")
+	targets := fmt.Sprintf(`[{"targets": [%q], "labels": {"env": "prod", "jolokia_agent_url": "foo"}}]`, u.Host)
+	require.NoError(t, os.WriteFile(fn, []byte(targets), 0600))
+
+	config := `
+	[jolokia2_agent]
+		[jolokia2_agent.discovery]
+			files = [%q]
+			path  = "/jolokia"
+
+	[[jolokia2_agent.metric]]
+		name  = "scalar_without_attribute"
+		mbean = "scalar_without_attribute"`
+
+	plugin := setupPlugin(t, fmt.Sprintf(config, fn)).(*jolokia2_agent.JolokiaAgent)
+	plugin.Log = testutil.Logger{}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+	require.NoError(t, plugin.Gather(&acc))
+
+	// The agent URL takes precedence over the discovered labels
+	acc.AssertContainsTaggedFields(t, "scalar_without_attribute", map[string]interface{}{
+		"value": 123.0,
+	}, map[string]string{
+		"jolokia_agent_url": server.URL + "/jolokia",
+		"env":               "prod",
+	})
+}
+
 func TestIntegrationArtemis(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration test in short mode")
@@ -17,6 +17,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/common/backpressure"
 	"github.com/influxdata/telegraf/plugins/common/kafka"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -68,6 +69,7 @@ type KafkaConsumer struct {
 	topicLock sync.Mutex
 	wg        sync.WaitGroup
 	cancel    context.CancelFunc
+	gate      backpressure.Gate
 }
 
 // consumerGroupHandler is a sarama.ConsumerGroupHandler implementation.
@@ -80,6 +82,7 @@ type consumerGroupHandler struct {
 
 	acc    telegraf.TrackingAccumulator
 	sem    semaphore
+	gate   *backpressure.Gate
 	parser telegraf.Parser
 	wg     sync.WaitGroup
 	cancel context.CancelFunc
@@ -263,6 +266,7 @@ func (k *KafkaConsumer) Start(acc telegraf.Accumulator) error {
 			}
 			handler.msgHeadersToTags = msgHeadersMap
 			handler.timestampSource = k.TimestampSource
+			handler.gate = &k.gate
 
 			// We need to copy allWantedTopics; the Consume() is
 			// long-running and we can easily deadlock if our
@@ -289,6 +293,16 @@ func (*KafkaConsumer) Gather(telegraf.Accumulator) error {
 	return nil
 }
 
+// Pause stops consuming new messages while the agent applies backpressure.
+func (k *KafkaConsumer) Pause() {
+	k.gate.Pause()
+}
+
+// Resume continues consuming messages.
+func (k *KafkaConsumer) Resume() {
+	k.gate.Resume()
+}
+
 func (k *KafkaConsumer) Stop() {
 	// Lock so that a topic refresh cannot start while we are stopping.
 	k.topicLock.Lock()
@@ -431,6 +445,9 @@ func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession,
 	ctx := session.Context()
 
 	for {
+		if err := h.gate.Wait(ctx); err != nil {
+			return err
+		}
 		err := h.reserve(ctx)
 		if err != nil {
 			return err
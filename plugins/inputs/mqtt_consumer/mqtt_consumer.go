@@ -16,6 +16,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/common/backpressure"
 	"github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/selfstat"
@@ -57,6 +58,7 @@ type MQTTConsumer struct {
 	opts          *mqtt.ClientOptions
 	acc           telegraf.TrackingAccumulator
 	sem           semaphore
+	gate          backpressure.Gate
 	messages      map[telegraf.TrackingID]mqtt.Message
 	messagesMutex sync.Mutex
 	topicTagParse string
@@ -160,7 +162,21 @@ func (*MQTTConsumer) Gather(telegraf.Accumulator) error {
 	return nil
 }
 
+// Pause stops consuming new messages while the agent applies backpressure.
+func (m *MQTTConsumer) Pause() {
+	m.gate.Pause()
+}
+
+// Resume continues consuming messages.
+func (m *MQTTConsumer) Resume() {
+	m.gate.Resume()
+}
+
 func (m *MQTTConsumer) Stop() {
+	// Release message handlers blocked due to backpressure so disconnecting
+	// does not wait for them
+	m.gate.Resume()
+
 	if m.client != nil {
 		// Disconnect is safe to call on an already-disconnected client;
 		// paho logs a warning and returns early in that case.
@@ -236,6 +252,10 @@ func (m *MQTTConsumer) onDelivered(track telegraf.DeliveryInfo) {
 }
 
 func (m *MQTTConsumer) onMessage(_ mqtt.Client, msg mqtt.Message) {
+	// Block consuming further messages while paused
+	if err := m.gate.Wait(m.ctx); err != nil {
+		return
+	}
 	m.sem <- empty{}
 
 	payloadBytes := len(msg.Payload())
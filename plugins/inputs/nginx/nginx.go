@@ -1,3 +1,4 @@
+//go:generate ../../../tools/config_includer/generator
 //go:generate ../../../tools/readme_config_includer/generator
 package nginx
 
@@ -16,6 +17,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/plugins/common/discovery"
 	common_http "github.com/influxdata/telegraf/plugins/common/http"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -24,18 +26,46 @@ import (
 var sampleConfig string
 
 type Nginx struct {
-	Urls []string        `toml:"urls"`
-	Log  telegraf.Logger `toml:"-"`
+	Urls      []string          `toml:"urls"`
+	Discovery *discovery.Config `toml:"discovery"`
+	Log       telegraf.Logger   `toml:"-"`
 	common_http.HTTPClientConfig
 
 	// HTTP client
-	client *http.Client
+	client     *http.Client
+	discoverer *discovery.Discoverer
 }
 
 func (*Nginx) SampleConfig() string {
 	return sampleConfig
 }
 
+func (n *Nginx) Init() error {
+	if n.Discovery == nil {
+		return nil
+	}
+
+	// Query the discovery endpoints using the same client settings
+	client, err := n.createHTTPClient()
+	if err != nil {
+		return err
+	}
+	n.client = client
+
+	n.discoverer, err = n.Discovery.CreateDiscoverer(client, n.Log)
+	if err != nil {
+		return fmt.Errorf("setting up discovery failed: %w", err)
+	}
+	return nil
+}
+
+func (n *Nginx) Start(telegraf.Accumulator) error {
+	if n.discoverer != nil {
+		n.discoverer.Start()
+	}
+	return nil
+}
+
 func (n *Nginx) Gather(acc telegraf.Accumulator) error {
 	var wg sync.WaitGroup
 
@@ -59,14 +89,36 @@ func (n *Nginx) Gather(acc telegraf.Accumulator) error {
 		wg.Add(1)
 		go func(addr *url.URL) {
 			defer wg.Done()
-			acc.AddError(n.gatherURL(addr, acc))
+			acc.AddError(n.gatherURL(addr, nil, acc))
 		}(addr)
 	}
 
+	if n.discoverer != nil {
+		for _, target := range n.discoverer.Targets() {
+			addr, err := url.Parse(target.URL)
+			if err != nil {
+				acc.AddError(fmt.Errorf("unable to parse address %q: %w", target.URL, err))
+				continue
+			}
+
+			wg.Add(1)
+			go func(addr *url.URL, labels map[string]string) {
+				defer wg.Done()
+				acc.AddError(n.gatherURL(addr, labels, acc))
+			}(addr, target.Labels)
+		}
+	}
+
 	wg.Wait()
 	return nil
 }
 
+func (n *Nginx) Stop() {
+	if n.discoverer != nil {
+		n.discoverer.Stop()
+	}
+}
+
 func (n *Nginx) createHTTPClient() (*http.Client, error) {
 	if n.HTTPClientConfig.ResponseHeaderTimeout < config.Duration(time.Second) {
 		n.HTTPClientConfig.ResponseHeaderTimeout = config.Duration(time.Second * 5)
@@ -82,7 +134,9 @@ func (n *Nginx) createHTTPClient() (*http.Client, error) {
 	return client, nil
 }
 
-func (n *Nginx) gatherURL(addr *url.URL, acc telegraf.Accumulator) error {
+// gatherURL gathers the status of the given address adding the given labels,
+// e.g. of discovered targets, as tags.
+func (n *Nginx) gatherURL(addr *url.URL, labels map[string]string, acc telegraf.Accumulator) error {
 	resp, err := n.client.Get(addr.String())
 	if err != nil {
 		return fmt.Errorf("error making HTTP request to %q: %w", addr.String(), err)
@@ -151,6 +205,11 @@ func (n *Nginx) gatherURL(addr *url.URL, acc telegraf.Accumulator) error {
 	}
 
 	tags := getTags(addr)
+	for k, v := range labels {
+		if _, found := tags[k]; !found {
+			tags[k] = v
+		}
+	}
 	fields := map[string]interface{}{
 		"active":   active,
 		"accepts":  accepts,
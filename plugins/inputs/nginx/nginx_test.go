@@ -6,10 +6,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/influxdata/telegraf/plugins/common/discovery"
 	"github.com/influxdata/telegraf/testutil"
 )
 
@@ -112,3 +115,53 @@ func TestNginxGeneratesMetrics(t *testing.T) {
 	accNginx.AssertContainsTaggedFields(t, "nginx", fieldsNginx, tags)
 	accTengine.AssertContainsTaggedFields(t, "nginx", fieldsTengine, tags)
 }
+
+func TestNginxDiscoveredTargets(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/stub_status" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if _, err := fmt.Fprintln(w, nginxSampleResponse); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			t.Error(err)
+		}
+	}))
+	defer ts.Close()
+
+	addr, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+	host, port, err := net.SplitHostPort(addr.Host)
+	require.NoError(t, err)
+
+	// The target file contains the "host:port" address of the server
+	fn := filepath.Join(t.TempDir(), "targets.json")
+	targets := fmt.Sprintf(`[{"targets": [%q], "labels": {"env": "prod", "port": "1234"}}]`, addr.Host)
+	require.NoError(t, os.WriteFile(fn, []byte(targets), 0600))
+
+	plugin := &Nginx{
+		Discovery: &discovery.Config{
+			Files: []string{fn},
+			Path:  "/stub_status",
+		},
+		Log: testutil.Logger{},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+	require.NoError(t, acc.GatherError(plugin.Gather))
+
+	// Tags of the plugin take precedence over the discovered labels
+	tags := map[string]string{"server": host, "port": port, "env": "prod"}
+	acc.AssertContainsTaggedFields(t, "nginx", map[string]interface{}{
+		"active":   uint64(585),
+		"accepts":  uint64(85340),
+		"handled":  uint64(85340),
+		"requests": uint64(35085),
+		"reading":  uint64(4),
+		"writing":  uint64(135),
+		"waiting":  uint64(446),
+	}, tags)
+}
@@ -0,0 +1,258 @@
+package opentelemetry
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+	pprofileotlp "go.opentelemetry.io/proto/otlp/collector/profiles/v1development"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/influxdata/telegraf"
+)
+
+const (
+	contentTypeProtobuf = "application/x-protobuf"
+	contentTypeJSON     = "application/json"
+)
+
+// otlpRequest is a request message decodable from OTLP/HTTP bodies
+type otlpRequest interface {
+	UnmarshalProto(data []byte) error
+	UnmarshalJSON(data []byte) error
+}
+
+// otlpResponse is a response message encodable for OTLP/HTTP
+type otlpResponse interface {
+	MarshalProto() ([]byte, error)
+	MarshalJSON() ([]byte, error)
+}
+
+// protoMessage adapts plain protobuf messages to the OTLP request and
+// response interfaces
+type protoMessage struct {
+	proto.Message
+}
+
+func (m protoMessage) UnmarshalProto(data []byte) error {
+	return proto.Unmarshal(data, m.Message)
+}
+
+func (m protoMessage) UnmarshalJSON(data []byte) error {
+	return protojson.Unmarshal(data, m.Message)
+}
+
+func (m protoMessage) MarshalProto() ([]byte, error) {
+	return proto.Marshal(m.Message)
+}
+
+func (m protoMessage) MarshalJSON() ([]byte, error) {
+	return protojson.Marshal(m.Message)
+}
+
+// httpService serves the OTLP/HTTP protocol using the same services as the
+// gRPC server so data arrives identically regardless of the transport.
+type httpService struct {
+	traces   *traceService
+	metrics  *metricsService
+	logs     *logsService
+	profiles *profileService
+	maxSize  int64
+	log      telegraf.Logger
+}
+
+func (s *httpService) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/traces", s.handleTraces)
+	mux.HandleFunc("POST /v1/metrics", s.handleMetrics)
+	mux.HandleFunc("POST /v1/logs", s.handleLogs)
+	mux.HandleFunc("POST /v1development/profiles", s.handleProfiles)
+	return mux
+}
+
+func (s *httpService) handleTraces(w http.ResponseWriter, r *http.Request) {
+	req := ptraceotlp.NewExportRequest()
+	s.serve(w, r, req, func(ctx context.Context) (otlpResponse, error) {
+		return s.traces.Export(ctx, req)
+	})
+}
+
+func (s *httpService) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	req := pmetricotlp.NewExportRequest()
+	s.serve(w, r, req, func(ctx context.Context) (otlpResponse, error) {
+		return s.metrics.Export(ctx, req)
+	})
+}
+
+func (s *httpService) handleLogs(w http.ResponseWriter, r *http.Request) {
+	req := plogotlp.NewExportRequest()
+	s.serve(w, r, req, func(ctx context.Context) (otlpResponse, error) {
+		return s.logs.Export(ctx, req)
+	})
+}
+
+func (s *httpService) handleProfiles(w http.ResponseWriter, r *http.Request) {
+	req := &pprofileotlp.ExportProfilesServiceRequest{}
+	s.serve(w, r, protoMessage{req}, func(ctx context.Context) (otlpResponse, error) {
+		resp, err := s.profiles.Export(ctx, req)
+		return protoMessage{resp}, err
+	})
+}
+
+// serve decodes the request, exports the data and writes the response using
+// the content type of the request.
+func (s *httpService) serve(
+	w http.ResponseWriter,
+	r *http.Request,
+	req otlpRequest,
+	export func(context.Context) (otlpResponse, error),
+) {
+	mediatype, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediatype != contentTypeProtobuf && mediatype != contentTypeJSON) {
+		s.writeError(w, contentTypeProtobuf, http.StatusUnsupportedMediaType, fmt.Sprintf("unsupported content type %q", mediatype))
+		return
+	}
+
+	body, err := s.readBody(w, r)
+	if err != nil {
+		code := http.StatusBadRequest
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			code = http.StatusRequestEntityTooLarge
+		}
+		s.writeError(w, mediatype, code, err.Error())
+		return
+	}
+
+	if mediatype == contentTypeJSON {
+		err = req.UnmarshalJSON(body)
+	} else {
+		err = req.UnmarshalProto(body)
+	}
+	if err != nil {
+		s.writeError(w, mediatype, http.StatusBadRequest, fmt.Sprintf("decoding request failed: %v", err))
+		return
+	}
+
+	resp, err := export(r.Context())
+	if err != nil {
+		s.writeError(w, mediatype, exportErrorCode(err), err.Error())
+		return
+	}
+
+	var buf []byte
+	if mediatype == contentTypeJSON {
+		buf, err = resp.MarshalJSON()
+	} else {
+		buf, err = resp.MarshalProto()
+	}
+	if err != nil {
+		s.writeError(w, mediatype, http.StatusInternalServerError, fmt.Sprintf("encoding response failed: %v", err))
+		return
+	}
+	s.write(w, mediatype, http.StatusOK, buf)
+}
+
+// exportErrorCode returns the HTTP status code for an error exporting the
+// data. Clients retry requests failing with 429, 502, 503 or 504 according to
+// the OTLP/HTTP specification, so those codes are only used for transient
+// errors. All other errors occur when converting the received data and are
+// thus caused by malformed payloads.
+func exportErrorCode(err error) int {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled):
+		return http.StatusServiceUnavailable
+	}
+
+	if st, ok := status.FromError(err); ok {
+		switch st.Code() {
+		case codes.ResourceExhausted:
+			return http.StatusTooManyRequests
+		case codes.Aborted:
+			return http.StatusBadGateway
+		case codes.Unavailable, codes.Canceled:
+			return http.StatusServiceUnavailable
+		case codes.DeadlineExceeded:
+			return http.StatusGatewayTimeout
+		case codes.Internal, codes.Unknown:
+			return http.StatusInternalServerError
+		}
+	}
+	return http.StatusBadRequest
+}
+
+// readBody reads the optionally gzip compressed body limited to the maximum
+// message size.
+func (s *httpService) readBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	defer r.Body.Close()
+
+	body := http.MaxBytesReader(w, r.Body, s.maxSize)
+	switch encoding := r.Header.Get("Content-Encoding"); encoding {
+	case "", "identity":
+		return io.ReadAll(body)
+	case "gzip":
+		gr, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("decompressing request failed: %w", err)
+		}
+		defer gr.Close()
+		return io.ReadAll(http.MaxBytesReader(w, gr, s.maxSize))
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+}
+
+// writeError responds with the error encoded as status message as required
+// by the OTLP/HTTP specification.
+func (s *httpService) writeError(w http.ResponseWriter, mediatype string, code int, msg string) {
+	s.log.Debugf("Rejecting OTLP/HTTP request: %s", msg)
+
+	var grpcCode codes.Code
+	switch {
+	case code == http.StatusTooManyRequests:
+		grpcCode = codes.ResourceExhausted
+	case code == http.StatusBadGateway, code == http.StatusServiceUnavailable:
+		grpcCode = codes.Unavailable
+	case code == http.StatusGatewayTimeout:
+		grpcCode = codes.DeadlineExceeded
+	case code >= http.StatusInternalServerError:
+		grpcCode = codes.Internal
+	default:
+		grpcCode = codes.InvalidArgument
+	}
+	resp := protoMessage{status.New(grpcCode, msg).Proto()}
+
+	var buf []byte
+	var err error
+	if mediatype == contentTypeJSON {
+		buf, err = resp.MarshalJSON()
+	} else {
+		buf, err = resp.MarshalProto()
+	}
+	if err != nil {
+		s.log.Errorf("Encoding error response failed: %v", err)
+		http.Error(w, msg, code)
+		return
+	}
+	s.write(w, mediatype, code, buf)
+}
+
+func (s *httpService) write(w http.ResponseWriter, mediatype string, code int, buf []byte) {
+	w.Header().Set("Content-Type", mediatype)
+	w.WriteHeader(code)
+	if _, err := w.Write(buf); err != nil {
+		s.log.Debugf("Writing OTLP/HTTP response failed: %v", err)
+	}
+}
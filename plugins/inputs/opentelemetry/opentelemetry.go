@@ -2,9 +2,13 @@
 package opentelemetry
 
 import (
+	"context"
+	gotls "crypto/tls"
 	_ "embed"
+	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"sync"
 	"time"
 
@@ -25,8 +29,13 @@ import (
 //go:embed sample.conf
 var sampleConfig string
 
+// defaultMaxMsgSize is the default maximum OTLP/HTTP message size matching
+// the default of gRPC.
+const defaultMaxMsgSize = 4 * 1024 * 1024
+
 type OpenTelemetry struct {
 	ServiceAddress      string          `toml:"service_address"`
+	HTTPServiceAddress  string          `toml:"http_service_address"`
 	SpanDimensions      []string        `toml:"span_dimensions"`
 	LogRecordDimensions []string        `toml:"log_record_dimensions"`
 	ProfileDimensions   []string        `toml:"profile_dimensions"`
@@ -36,8 +45,10 @@ type OpenTelemetry struct {
 	Log                 telegraf.Logger `toml:"-"`
 	tls.ServerConfig
 
-	listener   net.Listener // overridden in tests
-	grpcServer *grpc.Server
+	listener     net.Listener // overridden in tests
+	grpcServer   *grpc.Server
+	httpListener net.Listener
+	httpServer   *http.Server
 
 	wg sync.WaitGroup
 }
@@ -62,10 +73,13 @@ func (o *OpenTelemetry) Init() error {
 }
 
 func (o *OpenTelemetry) Start(acc telegraf.Accumulator) error {
-	var grpcOptions []grpc.ServerOption
-	if tlsConfig, err := o.ServerConfig.TLSConfig(); err != nil {
+	tlsConfig, err := o.ServerConfig.TLSConfig()
+	if err != nil {
 		return err
-	} else if tlsConfig != nil {
+	}
+
+	var grpcOptions []grpc.ServerOption
+	if tlsConfig != nil {
 		grpcOptions = append(grpcOptions, grpc.Creds(credentials.NewTLS(tlsConfig)))
 	}
 	if o.Timeout > 0 {
@@ -103,8 +117,27 @@ func (o *OpenTelemetry) Start(acc telegraf.Accumulator) error {
 	}
 	pprofileotlp.RegisterProfilesServiceServer(o.grpcServer, profileSvc)
 
+	if o.HTTPServiceAddress != "" {
+		maxSize := int64(o.MaxMsgSize)
+		if maxSize <= 0 {
+			maxSize = defaultMaxMsgSize
+		}
+		svc := &httpService{
+			traces:   traceSvc,
+			metrics:  metricsSvc,
+			logs:     logsSvc,
+			profiles: profileSvc,
+			maxSize:  maxSize,
+			log:      o.Log,
+		}
+		if err := o.startHTTP(acc, svc, tlsConfig); err != nil {
+			return err
+		}
+	}
+
 	o.listener, err = net.Listen("tcp", o.ServiceAddress)
 	if err != nil {
+		o.stopHTTP()
 		return err
 	}
 
@@ -119,6 +152,51 @@ func (o *OpenTelemetry) Start(acc telegraf.Accumulator) error {
 	return nil
 }
 
+func (o *OpenTelemetry) startHTTP(acc telegraf.Accumulator, svc *httpService, tlsConfig *gotls.Config) error {
+	listener, err := net.Listen("tcp", o.HTTPServiceAddress)
+	if err != nil {
+		return err
+	}
+	if tlsConfig != nil {
+		listener = gotls.NewListener(listener, tlsConfig)
+	}
+	o.httpListener = listener
+
+	o.httpServer = &http.Server{
+		Handler:           svc.handler(),
+		ReadHeaderTimeout: time.Duration(o.Timeout),
+	}
+
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		if err := o.httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			acc.AddError(fmt.Errorf("failed to stop OpenTelemetry HTTP service: %w", err))
+		}
+	}()
+
+	return nil
+}
+
+func (o *OpenTelemetry) stopHTTP() {
+	if o.httpServer == nil {
+		return
+	}
+
+	// Allow in-flight requests to finish
+	timeout := time.Duration(o.Timeout)
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := o.httpServer.Shutdown(ctx); err != nil {
+		o.Log.Errorf("Stopping HTTP service failed: %v", err)
+	}
+	o.httpServer = nil
+	o.httpListener = nil
+}
+
 func (*OpenTelemetry) Gather(telegraf.Accumulator) error {
 	return nil
 }
@@ -128,6 +206,7 @@ func (o *OpenTelemetry) Stop() {
 		o.grpcServer.Stop()
 	}
 	o.listener = nil
+	o.stopHTTP()
 
 	o.wg.Wait()
 }
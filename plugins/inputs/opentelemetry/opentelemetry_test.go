@@ -1,8 +1,13 @@
 package opentelemetry
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"errors"
+	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -14,6 +19,9 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/influxdata/influxdb-observability/otel2influx"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/metric/metricdata"
@@ -22,7 +30,9 @@ import (
 	otlpprofiles "go.opentelemetry.io/proto/otlp/collector/profiles/v1development"
 	otlptrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/protojson"
 
 	"github.com/influxdata/telegraf"
@@ -111,6 +121,118 @@ func TestOpenTelemetry(t *testing.T) {
 	testutil.RequireMetricsEqual(t, expected, actual, options...)
 }
 
+func TestOpenTelemetryHTTP(t *testing.T) {
+	// Setup and start the plugin
+	plugin := &OpenTelemetry{
+		ServiceAddress:     "127.0.0.1:0",
+		HTTPServiceAddress: "127.0.0.1:0",
+		MetricsSchema:      "prometheus-v1",
+		Timeout:            config.Duration(5 * time.Second),
+		Log:                testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	// Setup the metric to send
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "test")
+	m := rm.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	m.SetName("measurement-counter")
+	sum := m.SetEmptySum()
+	sum.SetIsMonotonic(true)
+	sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	dp := sum.DataPoints().AppendEmpty()
+	dp.SetIntValue(7)
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(time.Unix(1700000000, 0)))
+	req := pmetricotlp.NewExportRequestFromMetrics(md)
+
+	jsonBody, err := req.MarshalJSON()
+	require.NoError(t, err)
+	protoBody, err := req.MarshalProto()
+	require.NoError(t, err)
+	var compressed bytes.Buffer
+	gw := gzip.NewWriter(&compressed)
+	_, err = gw.Write(protoBody)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	// Send the metric via gRPC as reference
+	ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+	defer cancel()
+	grpcClient, err := grpc.NewClient(plugin.listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer grpcClient.Close()
+	var msg otlpmetrics.ExportMetricsServiceRequest
+	require.NoError(t, protojson.Unmarshal(jsonBody, &msg))
+	_, err = otlpmetrics.NewMetricsServiceClient(grpcClient).Export(ctx, &msg)
+	require.NoError(t, err)
+
+	// Send the metric via HTTP using JSON and gzip compressed protobuf
+	url := "http://" + plugin.httpListener.Addr().String() + "/v1/metrics"
+	resp, err := http.Post(url, "application/json", bytes.NewReader(jsonBody))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	httpReq, err := http.NewRequest(http.MethodPost, url, &compressed)
+	require.NoError(t, err)
+	httpReq.Header.Set("Content-Type", "application/x-protobuf")
+	httpReq.Header.Set("Content-Encoding", "gzip")
+	resp, err = http.DefaultClient.Do(httpReq)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/x-protobuf", resp.Header.Get("Content-Type"))
+
+	// Invalid requests must be rejected
+	resp, err = http.Post(url, "text/plain", bytes.NewReader(jsonBody))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+
+	resp, err = http.Post(url, "application/x-protobuf", strings.NewReader("invalid"))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// All transports must produce identical metrics
+	require.Eventually(t, func() bool {
+		return acc.NMetrics() >= 3
+	}, 3*time.Second, 100*time.Millisecond)
+	require.Empty(t, acc.Errors)
+
+	actual := acc.GetTelegrafMetrics()
+	require.Len(t, actual, 3)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{actual[0], actual[0]}, actual[1:])
+}
+
+func TestExportErrorCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"malformed", errors.New("unrecognized InfluxMetricValueType"), http.StatusBadRequest},
+		{"deadline", fmt.Errorf("writing failed: %w", context.DeadlineExceeded), http.StatusGatewayTimeout},
+		{"canceled", context.Canceled, http.StatusServiceUnavailable},
+		{"exhausted", status.Error(codes.ResourceExhausted, "full"), http.StatusTooManyRequests},
+		{"aborted", status.Error(codes.Aborted, "aborted"), http.StatusBadGateway},
+		{"unavailable", status.Error(codes.Unavailable, "down"), http.StatusServiceUnavailable},
+		{"internal", status.Error(codes.Internal, "bug"), http.StatusInternalServerError},
+		{"invalid", status.Error(codes.InvalidArgument, "invalid"), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, exportErrorCode(tt.err))
+		})
+	}
+}
+
 func TestCases(t *testing.T) {
 	// Get all directories in testdata
 	folders, err := os.ReadDir("testcases")
@@ -2,15 +2,13 @@ package prometheus
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
 
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/plugins/common/discovery"
 )
 
 type HTTPSDConfig struct {
@@ -19,12 +17,6 @@ type HTTPSDConfig struct {
 	QueryInterval config.Duration `toml:"query_interval"`
 }
 
-// standard output for http service discovery is here https://prometheus.io/docs/prometheus/latest/http_sd/#http_sd-format
-type httpSDOutput struct {
-	Targets []string          `json:"targets"`
-	Labels  map[string]string `json:"labels"`
-}
-
 func (p *Prometheus) startHTTPSD(ctx context.Context) error {
 	// default settings
 	var queryInterval time.Duration
@@ -73,57 +65,36 @@ func (p *Prometheus) startHTTPSD(ctx context.Context) error {
 }
 
 func (p *Prometheus) refreshHTTPServices(sdURL string, client *http.Client) error {
-	services := make(map[string]urlAndAddress)
-	req, err := http.NewRequest("GET", sdURL, nil)
-	if err != nil {
-		return fmt.Errorf("creating request failed: %w", err)
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("discovery failed with status %q", resp.Status)
-	}
-
-	var body []byte
-	body, err = io.ReadAll(resp.Body)
+	provider := &discovery.HTTPProvider{URL: sdURL, Client: client}
+	targets, err := provider.Discover(context.Background())
 	if err != nil {
-		return fmt.Errorf("reading response body failed: %w", err)
-	}
-
-	var result []httpSDOutput
-	if err := json.Unmarshal(body, &result); err != nil {
-		return fmt.Errorf("unmarshalling JSON failed: %w", err)
+		return err
 	}
 
 	// Validate the response
-	if len(result) == 0 {
+	if len(targets) == 0 {
 		p.Log.Warnf("Service discovery returned no results")
 	}
 
-	for _, sdOutputItem := range result {
-		for _, targetValue := range sdOutputItem.Targets {
-			if !strings.HasPrefix(targetValue, "http://") && !strings.HasPrefix(targetValue, "https://") {
-				targetValue = "http://" + targetValue
-			}
+	services := make(map[string]urlAndAddress)
+	for _, target := range targets {
+		targetValue := target.URL
+		if !strings.HasPrefix(targetValue, "http://") && !strings.HasPrefix(targetValue, "https://") {
+			targetValue = "http://" + targetValue
+		}
 
-			targetURL, err := url.Parse(targetValue)
-			if err != nil {
-				p.Log.Warnf("Failed to parse target %q", targetValue)
-				continue
-			}
-			service := urlAndAddress{
-				url:         targetURL,
-				originalURL: targetURL,
-				// in this case target labels should just be added to the tags
-				tags: sdOutputItem.Labels,
-			}
-			services[service.url.String()] = service
+		targetURL, err := url.Parse(targetValue)
+		if err != nil {
+			p.Log.Warnf("Failed to parse target %q", targetValue)
+			continue
+		}
+		service := urlAndAddress{
+			url:         targetURL,
+			originalURL: targetURL,
+			// in this case target labels should just be added to the tags
+			tags: target.Labels,
 		}
+		services[service.url.String()] = service
 	}
 
 	p.lock.Lock()
@@ -0,0 +1,114 @@
+package prometheus_remote_write
+
+import (
+	"fmt"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/prompb"
+	writev2 "github.com/prometheus/prometheus/prompb/io/prometheus/write/v2"
+)
+
+// writeStats counts the data written for a remote-write 2.0 request
+type writeStats struct {
+	samples    int
+	histograms int
+	// exemplars counts the exemplars of the request dropped as those are
+	// not supported
+	exemplars int
+}
+
+// convertV2 converts a remote-write 2.0 request into the equivalent 1.0 write
+// request by resolving the labels and metadata against the symbol table.
+func convertV2(req *writev2.Request) (*prompb.WriteRequest, writeStats, error) {
+	var stats writeStats
+
+	symbol := func(ref uint32) (string, error) {
+		if int(ref) >= len(req.Symbols) {
+			return "", fmt.Errorf("symbol reference %d out of range (%d symbols)", ref, len(req.Symbols))
+		}
+		return req.Symbols[ref], nil
+	}
+
+	out := &prompb.WriteRequest{
+		Timeseries: make([]prompb.TimeSeries, 0, len(req.Timeseries)),
+	}
+	metadata := make(map[string]bool)
+	for i, ts := range req.Timeseries {
+		if len(ts.LabelsRefs)%2 != 0 {
+			return nil, stats, fmt.Errorf("series %d: odd number of label references", i)
+		}
+
+		var name string
+		labels := make([]prompb.Label, 0, len(ts.LabelsRefs)/2)
+		for j := 0; j < len(ts.LabelsRefs); j += 2 {
+			key, err := symbol(ts.LabelsRefs[j])
+			if err != nil {
+				return nil, stats, fmt.Errorf("series %d: %w", i, err)
+			}
+			value, err := symbol(ts.LabelsRefs[j+1])
+			if err != nil {
+				return nil, stats, fmt.Errorf("series %d: %w", i, err)
+			}
+			if key == model.MetricNameLabel {
+				name = value
+			}
+			labels = append(labels, prompb.Label{Name: key, Value: value})
+		}
+
+		samples := make([]prompb.Sample, 0, len(ts.Samples))
+		for _, s := range ts.Samples {
+			samples = append(samples, prompb.Sample{Value: s.Value, Timestamp: s.Timestamp})
+		}
+		histograms := make([]prompb.Histogram, 0, len(ts.Histograms))
+		for _, h := range ts.Histograms {
+			if h.IsFloatHistogram() {
+				histograms = append(histograms, prompb.FromFloatHistogram(h.Timestamp, h.ToFloatHistogram()))
+			} else {
+				histograms = append(histograms, prompb.FromIntHistogram(h.Timestamp, h.ToIntHistogram()))
+			}
+		}
+		stats.samples += len(samples)
+		stats.histograms += len(histograms)
+		stats.exemplars += len(ts.Exemplars)
+
+		out.Timeseries = append(out.Timeseries, prompb.TimeSeries{
+			Labels:     labels,
+			Samples:    samples,
+			Histograms: histograms,
+		})
+
+		// Metadata is attached to each series in 2.0 but to the metric family
+		// in 1.0 so only keep the first occurrence
+		mdType, found := metadataTypes[ts.Metadata.Type]
+		if !found || name == "" || metadata[name] {
+			continue
+		}
+		help, err := symbol(ts.Metadata.HelpRef)
+		if err != nil {
+			return nil, stats, fmt.Errorf("series %d: help: %w", i, err)
+		}
+		unit, err := symbol(ts.Metadata.UnitRef)
+		if err != nil {
+			return nil, stats, fmt.Errorf("series %d: unit: %w", i, err)
+		}
+		out.Metadata = append(out.Metadata, prompb.MetricMetadata{
+			Type:             mdType,
+			MetricFamilyName: name,
+			Help:             help,
+			Unit:             unit,
+		})
+		metadata[name] = true
+	}
+
+	return out, stats, nil
+}
+
+var metadataTypes = map[writev2.Metadata_MetricType]prompb.MetricMetadata_MetricType{
+	writev2.Metadata_METRIC_TYPE_COUNTER:        prompb.MetricMetadata_COUNTER,
+	writev2.Metadata_METRIC_TYPE_GAUGE:          prompb.MetricMetadata_GAUGE,
+	writev2.Metadata_METRIC_TYPE_HISTOGRAM:      prompb.MetricMetadata_HISTOGRAM,
+	writev2.Metadata_METRIC_TYPE_GAUGEHISTOGRAM: prompb.MetricMetadata_GAUGEHISTOGRAM,
+	writev2.Metadata_METRIC_TYPE_SUMMARY:        prompb.MetricMetadata_SUMMARY,
+	writev2.Metadata_METRIC_TYPE_INFO:           prompb.MetricMetadata_INFO,
+	writev2.Metadata_METRIC_TYPE_STATESET:       prompb.MetricMetadata_STATESET,
+}
@@ -0,0 +1,380 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package prometheus_remote_write
+
+import (
+	"context"
+	"crypto/tls"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	writev2 "github.com/prometheus/prometheus/prompb/io/prometheus/write/v2"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
+	common_tls "github.com/influxdata/telegraf/plugins/common/tls"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/parsers/prometheusremotewrite"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+const (
+	defaultMaxBodySize  = 32 * 1024 * 1024
+	defaultReadTimeout  = 10 * time.Second
+	defaultWriteTimeout = 10 * time.Second
+
+	// Protobuf messages of the remote-write protocol versions as given in the
+	// "proto" parameter of the Content-Type header
+	protoV1 = "prometheus.WriteRequest"
+	protoV2 = "io.prometheus.write.v2.Request"
+)
+
+// Errors of the request that must not be retried by the sender
+var (
+	errUnsupportedMediaType = errors.New("unsupported media type")
+	errTooLarge             = errors.New("request body too large")
+)
+
+type PrometheusRemoteWrite struct {
+	ServiceAddress        string          `toml:"service_address"`
+	Path                  string          `toml:"path"`
+	ReadTimeout           config.Duration `toml:"read_timeout"`
+	WriteTimeout          config.Duration `toml:"write_timeout"`
+	MaxBodySize           config.Size     `toml:"max_body_size"`
+	MaxUndeliveredMetrics int             `toml:"max_undelivered_metrics"`
+	MetricVersion         int             `toml:"metric_version"`
+	TenantHeader          string          `toml:"tenant_header"`
+	TenantTag             string          `toml:"tenant_tag"`
+	BasicUsername         string          `toml:"basic_username"`
+	BasicPassword         config.Secret   `toml:"basic_password"`
+	Log                   telegraf.Logger `toml:"-"`
+	common_tls.ServerConfig
+
+	parser *prometheusremotewrite.Parser
+	server *http.Server
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+
+	acc         telegraf.Accumulator
+	trackingAcc telegraf.TrackingAccumulator
+	sem         chan struct{}
+	pending     map[telegraf.TrackingID]int
+	pendingLock sync.Mutex
+}
+
+func (*PrometheusRemoteWrite) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *PrometheusRemoteWrite) Init() error {
+	if p.ServiceAddress == "" {
+		return errors.New("service_address required")
+	}
+	if p.Path == "" {
+		p.Path = "/api/v1/write"
+	}
+	if p.MaxBodySize == 0 {
+		p.MaxBodySize = config.Size(defaultMaxBodySize)
+	}
+	if p.ReadTimeout < config.Duration(time.Second) {
+		p.ReadTimeout = config.Duration(defaultReadTimeout)
+	}
+	if p.WriteTimeout < config.Duration(time.Second) {
+		p.WriteTimeout = config.Duration(defaultWriteTimeout)
+	}
+	switch p.MetricVersion {
+	case 0, 1, 2:
+	default:
+		return fmt.Errorf("invalid metric_version %d", p.MetricVersion)
+	}
+	if p.TenantHeader != "" && p.TenantTag == "" {
+		return errors.New("tenant_tag required when setting tenant_header")
+	}
+
+	p.parser = &prometheusremotewrite.Parser{MetricVersion: p.MetricVersion}
+	return p.parser.Init()
+}
+
+func (p *PrometheusRemoteWrite) Start(acc telegraf.Accumulator) error {
+	p.acc = acc
+	if p.MaxUndeliveredMetrics > 0 {
+		p.trackingAcc = acc.WithTracking(p.MaxUndeliveredMetrics)
+		p.sem = make(chan struct{}, p.MaxUndeliveredMetrics)
+		p.pending = make(map[telegraf.TrackingID]int)
+
+		var ctx context.Context
+		ctx, p.cancel = context.WithCancel(context.Background())
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.releaseDelivered(ctx)
+		}()
+	}
+
+	password, err := p.BasicPassword.Get()
+	if err != nil {
+		return fmt.Errorf("getting password failed: %w", err)
+	}
+	authHandler := internal.BasicAuthHandler(p.BasicUsername, password.String(), "prometheus_remote_write", func(http.ResponseWriter) {})
+	password.Destroy()
+
+	tlsConf, err := p.ServerConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(p.Path, authHandler(http.HandlerFunc(p.handleWrite)))
+	p.server = &http.Server{
+		Addr:         p.ServiceAddress,
+		Handler:      mux,
+		TLSConfig:    tlsConf,
+		ReadTimeout:  time.Duration(p.ReadTimeout),
+		WriteTimeout: time.Duration(p.WriteTimeout),
+	}
+
+	var listener net.Listener
+	if tlsConf != nil {
+		listener, err = tls.Listen("tcp", p.ServiceAddress, tlsConf)
+	} else {
+		listener, err = net.Listen("tcp", p.ServiceAddress)
+	}
+	if err != nil {
+		return err
+	}
+	p.ServiceAddress = listener.Addr().String()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		if err := p.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			p.Log.Errorf("Serving remote-write requests failed: %v", err)
+		}
+	}()
+	p.Log.Infof("Listening for remote-write requests on %s", p.ServiceAddress)
+
+	return nil
+}
+
+func (*PrometheusRemoteWrite) Gather(telegraf.Accumulator) error {
+	return nil
+}
+
+func (p *PrometheusRemoteWrite) Stop() {
+	if p.server != nil {
+		if err := p.server.Shutdown(context.Background()); err != nil {
+			p.Log.Errorf("Shutting down server failed: %v", err)
+		}
+	}
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.wg.Wait()
+}
+
+// releaseDelivered frees the slots of delivered metrics for new requests.
+func (p *PrometheusRemoteWrite) releaseDelivered(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case info := <-p.trackingAcc.Delivered():
+			p.pendingLock.Lock()
+			n := p.pending[info.ID()]
+			delete(p.pending, info.ID())
+			p.pendingLock.Unlock()
+			for range n {
+				<-p.sem
+			}
+		}
+	}
+}
+
+// handleWrite processes a remote-write request. The status code tells the
+// sender whether to retry the request: 2xx codes signal success, 4xx codes
+// signal a permanent error and 5xx codes a temporary error to be retried.
+func (p *PrometheusRemoteWrite) handleWrite(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	proto, err := protoMessage(r.Header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	body, err := p.readBody(w, r)
+	if err != nil {
+		code := http.StatusBadRequest
+		switch {
+		case errors.Is(err, errUnsupportedMediaType):
+			code = http.StatusUnsupportedMediaType
+		case errors.Is(err, errTooLarge):
+			code = http.StatusRequestEntityTooLarge
+		}
+		p.Log.Debugf("Reading request from %s failed: %v", r.RemoteAddr, err)
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	var req *prompb.WriteRequest
+	var stats writeStats
+	switch proto {
+	case protoV1:
+		req = &prompb.WriteRequest{}
+		err = req.Unmarshal(body)
+	case protoV2:
+		var reqV2 writev2.Request
+		if err = reqV2.Unmarshal(body); err == nil {
+			req, stats, err = convertV2(&reqV2)
+		}
+	}
+	if err != nil {
+		p.Log.Debugf("Decoding request from %s failed: %v", r.RemoteAddr, err)
+		http.Error(w, "decoding request failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	metrics, err := p.parser.ParseWriteRequest(req)
+	if err != nil {
+		p.Log.Debugf("Converting request from %s failed: %v", r.RemoteAddr, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if p.TenantHeader != "" {
+		if tenant := r.Header.Get(p.TenantHeader); tenant != "" {
+			for _, m := range metrics {
+				m.AddTag(p.TenantTag, tenant)
+			}
+		}
+	}
+
+	if p.MaxUndeliveredMetrics > 0 && len(metrics) > 0 {
+		if len(metrics) > p.MaxUndeliveredMetrics {
+			// The request can never be accepted so do not ask for a retry
+			msg := fmt.Sprintf("request of %d metrics exceeds max_undelivered_metrics", len(metrics))
+			http.Error(w, msg, http.StatusRequestEntityTooLarge)
+			return
+		}
+		if !p.reserve(len(metrics)) {
+			// Ask the sender to retry the request later
+			http.Error(w, "too many undelivered metrics", http.StatusServiceUnavailable)
+			return
+		}
+		// Hold the lock while adding the metrics to not miss their delivery
+		p.pendingLock.Lock()
+		id := p.trackingAcc.AddTrackingMetricGroup(metrics)
+		p.pending[id] = len(metrics)
+		p.pendingLock.Unlock()
+	} else {
+		for _, m := range metrics {
+			p.acc.AddMetric(m)
+		}
+	}
+
+	if proto == protoV2 {
+		// Exemplars are not supported and thus never written, the sender can
+		// detect the dropped exemplars by comparing the count
+		if stats.exemplars > 0 {
+			p.Log.Debugf("Dropped %d unsupported exemplars of request from %s", stats.exemplars, r.RemoteAddr)
+		}
+		w.Header().Set("X-Prometheus-Remote-Write-Samples-Written", strconv.Itoa(stats.samples))
+		w.Header().Set("X-Prometheus-Remote-Write-Histograms-Written", strconv.Itoa(stats.histograms))
+		w.Header().Set("X-Prometheus-Remote-Write-Exemplars-Written", "0")
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// reserve reserves slots for the given number of undelivered metrics without
+// blocking and returns false if not enough slots are available.
+func (p *PrometheusRemoteWrite) reserve(n int) bool {
+	for i := range n {
+		select {
+		case p.sem <- struct{}{}:
+		default:
+			for range i {
+				<-p.sem
+			}
+			return false
+		}
+	}
+	return true
+}
+
+// readBody reads and decompresses the snappy-encoded request body.
+func (p *PrometheusRemoteWrite) readBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	if encoding := r.Header.Get("Content-Encoding"); encoding != "" && encoding != "snappy" {
+		return nil, fmt.Errorf("%w: content encoding %q", errUnsupportedMediaType, encoding)
+	}
+
+	maxSize := int64(p.MaxBodySize)
+	compressed, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, errTooLarge
+		}
+		return nil, err
+	}
+
+	size, err := snappy.DecodedLen(compressed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snappy encoding: %w", err)
+	}
+	if int64(size) > maxSize {
+		return nil, errTooLarge
+	}
+	return snappy.Decode(nil, compressed)
+}
+
+// protoMessage determines the protobuf message of the request from the
+// Content-Type header. A missing header denotes a remote-write 1.0 request.
+func protoMessage(header http.Header) (string, error) {
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		return protoV1, nil
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", fmt.Errorf("invalid content type %q: %w", contentType, err)
+	}
+	if mediaType != "application/x-protobuf" {
+		return "", fmt.Errorf("unsupported content type %q", mediaType)
+	}
+
+	switch proto := params["proto"]; proto {
+	case "", protoV1:
+		return protoV1, nil
+	case protoV2:
+		return protoV2, nil
+	default:
+		return "", fmt.Errorf("unsupported protobuf message %q", proto)
+	}
+}
+
+func init() {
+	inputs.Add("prometheus_remote_write", func() telegraf.Input {
+		return &PrometheusRemoteWrite{
+			ServiceAddress: ":9201",
+			Path:           "/api/v1/write",
+			MetricVersion:  2,
+		}
+	})
+}
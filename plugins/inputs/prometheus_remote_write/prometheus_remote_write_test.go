@@ -0,0 +1,328 @@
+package prometheus_remote_write
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	writev2 "github.com/prometheus/prometheus/prompb/io/prometheus/write/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+const (
+	contentTypeV1 = "application/x-protobuf;proto=prometheus.WriteRequest"
+	contentTypeV2 = "application/x-protobuf;proto=io.prometheus.write.v2.Request"
+)
+
+func newTestPlugin(t *testing.T, plugin *PrometheusRemoteWrite) (*testutil.Accumulator, string) {
+	t.Helper()
+
+	plugin.ServiceAddress = "localhost:0"
+	plugin.Log = testutil.Logger{}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	t.Cleanup(plugin.Stop)
+
+	return &acc, "http://" + plugin.ServiceAddress + plugin.Path
+}
+
+func post(t *testing.T, url, contentType string, body []byte, header map[string]string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(snappy.Encode(nil, body)))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Content-Encoding", "snappy")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	return resp
+}
+
+func TestWriteV1(t *testing.T) {
+	acc, url := newTestPlugin(t, &PrometheusRemoteWrite{MetricVersion: 2})
+
+	req := &prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{
+			{
+				Labels: []prompb.Label{
+					{Name: "__name__", Value: "go_goroutines"},
+					{Name: "job", Value: "prometheus"},
+				},
+				Samples: []prompb.Sample{{Value: 42, Timestamp: 1000}},
+			},
+		},
+	}
+	body, err := req.Marshal()
+	require.NoError(t, err)
+
+	resp := post(t, url, contentTypeV1, body, nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Empty(t, resp.Header.Get("X-Prometheus-Remote-Write-Samples-Written"))
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"prometheus_remote_write",
+			map[string]string{"job": "prometheus"},
+			map[string]interface{}{"go_goroutines": float64(42)},
+			time.Unix(1, 0),
+		),
+	}
+	acc.Wait(len(expected))
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestWriteV2(t *testing.T) {
+	acc, url := newTestPlugin(t, &PrometheusRemoteWrite{MetricVersion: 2})
+
+	req := &writev2.Request{
+		Symbols: []string{"", "__name__", "http_requests_total", "job", "api", "Total requests", "go_goroutines"},
+		Timeseries: []writev2.TimeSeries{
+			{
+				LabelsRefs: []uint32{1, 2, 3, 4},
+				Samples:    []writev2.Sample{{Value: 12, Timestamp: 1000}},
+				Metadata: writev2.Metadata{
+					Type:    writev2.Metadata_METRIC_TYPE_COUNTER,
+					HelpRef: 5,
+				},
+			},
+			{
+				LabelsRefs: []uint32{1, 6, 3, 4},
+				Samples:    []writev2.Sample{{Value: 7, Timestamp: 1000}},
+				// Exemplars are dropped and reported as not written
+				Exemplars: []writev2.Exemplar{{LabelsRefs: []uint32{3, 4}, Value: 7, Timestamp: 1000}},
+			},
+		},
+	}
+	body, err := req.Marshal()
+	require.NoError(t, err)
+
+	resp := post(t, url, contentTypeV2, body, nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Equal(t, "2", resp.Header.Get("X-Prometheus-Remote-Write-Samples-Written"))
+	require.Equal(t, "0", resp.Header.Get("X-Prometheus-Remote-Write-Histograms-Written"))
+	require.Equal(t, "0", resp.Header.Get("X-Prometheus-Remote-Write-Exemplars-Written"))
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"prometheus_remote_write",
+			map[string]string{"job": "api"},
+			map[string]interface{}{"http_requests_total": float64(12)},
+			time.Unix(1, 0),
+			telegraf.Counter,
+		),
+		metric.New(
+			"prometheus_remote_write",
+			map[string]string{"job": "api"},
+			map[string]interface{}{"go_goroutines": float64(7)},
+			time.Unix(1, 0),
+		),
+	}
+	acc.Wait(len(expected))
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestWriteV2InvalidSymbolReference(t *testing.T) {
+	acc, url := newTestPlugin(t, &PrometheusRemoteWrite{})
+
+	req := &writev2.Request{
+		Symbols: []string{"", "__name__", "up"},
+		Timeseries: []writev2.TimeSeries{
+			{
+				LabelsRefs: []uint32{1, 5},
+				Samples:    []writev2.Sample{{Value: 1, Timestamp: 1000}},
+			},
+		},
+	}
+	body, err := req.Marshal()
+	require.NoError(t, err)
+
+	resp := post(t, url, contentTypeV2, body, nil)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	require.Empty(t, acc.GetTelegrafMetrics())
+}
+
+func TestTenantTag(t *testing.T) {
+	acc, url := newTestPlugin(t, &PrometheusRemoteWrite{
+		MetricVersion: 2,
+		TenantHeader:  "X-Scope-OrgID",
+		TenantTag:     "tenant",
+	})
+
+	req := &prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{
+			{
+				Labels:  []prompb.Label{{Name: "__name__", Value: "up"}},
+				Samples: []prompb.Sample{{Value: 1, Timestamp: 1000}},
+			},
+		},
+	}
+	body, err := req.Marshal()
+	require.NoError(t, err)
+
+	resp := post(t, url, contentTypeV1, body, map[string]string{"X-Scope-OrgID": "team-a"})
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"prometheus_remote_write",
+			map[string]string{"tenant": "team-a"},
+			map[string]interface{}{"up": float64(1)},
+			time.Unix(1, 0),
+		),
+	}
+	acc.Wait(len(expected))
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestTenantTagRequired(t *testing.T) {
+	plugin := &PrometheusRemoteWrite{
+		ServiceAddress: "localhost:0",
+		TenantHeader:   "X-Scope-OrgID",
+	}
+	require.ErrorContains(t, plugin.Init(), "tenant_tag required")
+}
+
+func TestInvalidRequests(t *testing.T) {
+	valid, err := (&prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{
+			{
+				Labels:  []prompb.Label{{Name: "__name__", Value: "up"}},
+				Samples: []prompb.Sample{{Value: 1, Timestamp: 1000}},
+			},
+		},
+	}).Marshal()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		encoding    string
+		body        []byte
+		expected    int
+	}{
+		{
+			name:        "wrong method",
+			method:      http.MethodGet,
+			contentType: contentTypeV1,
+			encoding:    "snappy",
+			body:        snappy.Encode(nil, valid),
+			expected:    http.StatusMethodNotAllowed,
+		},
+		{
+			name:        "unsupported content type",
+			method:      http.MethodPost,
+			contentType: "application/json",
+			encoding:    "snappy",
+			body:        snappy.Encode(nil, valid),
+			expected:    http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "unsupported protobuf message",
+			method:      http.MethodPost,
+			contentType: "application/x-protobuf;proto=foo.Bar",
+			encoding:    "snappy",
+			body:        snappy.Encode(nil, valid),
+			expected:    http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "unsupported encoding",
+			method:      http.MethodPost,
+			contentType: contentTypeV1,
+			encoding:    "gzip",
+			body:        snappy.Encode(nil, valid),
+			expected:    http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "not snappy encoded",
+			method:      http.MethodPost,
+			contentType: contentTypeV1,
+			encoding:    "snappy",
+			body:        []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+			expected:    http.StatusBadRequest,
+		},
+		{
+			name:        "invalid protobuf",
+			method:      http.MethodPost,
+			contentType: contentTypeV1,
+			encoding:    "snappy",
+			body:        snappy.Encode(nil, []byte("not a protobuf message")),
+			expected:    http.StatusBadRequest,
+		},
+		{
+			name:        "decoded body too large",
+			method:      http.MethodPost,
+			contentType: contentTypeV1,
+			encoding:    "snappy",
+			body:        snappy.Encode(nil, make([]byte, 2048)),
+			expected:    http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acc, url := newTestPlugin(t, &PrometheusRemoteWrite{MaxBodySize: config.Size(1024)})
+
+			req, err := http.NewRequest(tt.method, url, bytes.NewReader(tt.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", tt.contentType)
+			req.Header.Set("Content-Encoding", tt.encoding)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+			require.Equal(t, tt.expected, resp.StatusCode)
+			require.Empty(t, acc.GetTelegrafMetrics())
+		})
+	}
+}
+
+func TestMaxUndeliveredMetrics(t *testing.T) {
+	acc, url := newTestPlugin(t, &PrometheusRemoteWrite{MaxUndeliveredMetrics: 1})
+
+	body, err := (&prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{
+			{
+				Labels:  []prompb.Label{{Name: "__name__", Value: "up"}},
+				Samples: []prompb.Sample{{Value: 1, Timestamp: 1000}},
+			},
+		},
+	}).Marshal()
+	require.NoError(t, err)
+
+	resp := post(t, url, contentTypeV1, body, nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// The sender must retry while the first metric is undelivered
+	resp = post(t, url, contentTypeV1, body, nil)
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	acc.Wait(1)
+	metrics := acc.GetTelegrafMetrics()
+	require.Len(t, metrics, 1)
+	metrics[0].Accept()
+
+	require.Eventually(t, func() bool {
+		resp, err := http.Post(url, contentTypeV1, bytes.NewReader(snappy.Encode(nil, body)))
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusNoContent
+	}, 3*time.Second, 100*time.Millisecond)
+}
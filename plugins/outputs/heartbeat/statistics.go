@@ -155,8 +155,9 @@ func (s *statistics) variables() map[string]interface{} {
 			index := ids[id]
 
 			for k, raw := range old {
-				// Ignore known non-accumulated fields
-				if k == "gather_time_ns" {
+				// Ignore known non-accumulated fields including the
+				// gather time quantiles
+				if strings.HasPrefix(k, "gather_time_") {
 					continue
 				}
 
@@ -190,7 +191,10 @@ func (s *statistics) variables() map[string]interface{} {
 			for k, raw := range old {
 				// Ignore known non-accumulated fields
 				switch k {
-				case "write_time_ns", "buffer_size", "buffer_limit", "buffer_fullness":
+				case "buffer_size", "buffer_limit", "buffer_fullness":
+					continue
+				}
+				if strings.HasPrefix(k, "write_time_") {
 					continue
 				}
 
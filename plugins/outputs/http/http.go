@@ -9,6 +9,7 @@ import (
 	"crypto/sha256"
 	_ "embed"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -120,15 +121,38 @@ func (h *HTTP) Write(metrics []telegraf.Metric) error {
 		return h.writeMetric(reqBody)
 	}
 
-	for _, metric := range metrics {
+	// Send the metrics one by one and report the outcome of each metric to
+	// avoid dropping or resending metrics already delivered when a later
+	// metric is rejected.
+	werr := &internal.PartialWriteError{
+		MetricsAccept: make([]int, 0, len(metrics)),
+	}
+	for i, metric := range metrics {
 		reqBody, err := h.serializer.Serialize(metric)
 		if err != nil {
-			return err
+			werr.Err = internal.ErrSerialization
+			werr.MetricsReject = append(werr.MetricsReject, i)
+			werr.MetricsRejectErrors = append(werr.MetricsRejectErrors, err)
+			continue
 		}
 
 		if err := h.writeMetric(reqBody); err != nil {
-			return err
+			var permErr *internal.PermanentWriteError
+			if !errors.As(err, &permErr) {
+				// Keep the current and all remaining metrics for retrying
+				werr.Err = err
+				return werr
+			}
+			werr.Err = err
+			werr.MetricsReject = append(werr.MetricsReject, i)
+			werr.MetricsRejectErrors = append(werr.MetricsRejectErrors, permErr.Err)
+			continue
 		}
+		werr.MetricsAccept = append(werr.MetricsAccept, i)
+	}
+
+	if werr.Err != nil {
+		return werr
 	}
 	return nil
 }
@@ -240,8 +264,9 @@ func (h *HTTP) writeMetric(reqBody []byte) error {
 
 		for _, nonRetryableStatusCode := range h.NonRetryableStatusCodes {
 			if resp.StatusCode == nonRetryableStatusCode {
-				h.Log.Errorf("Received non-retryable status %v. Metrics are lost. body: %s", resp.StatusCode, errorLine)
-				return nil
+				return &internal.PermanentWriteError{
+					Err: fmt.Errorf("when writing to [%s] received non-retryable status code: %d. body: %s", h.URL, resp.StatusCode, errorLine),
+				}
 			}
 		}
 
@@ -262,11 +262,13 @@ func TestStatusCode(t *testing.T) {
 			name: "Do not retry on configured non-retryable statuscode",
 			plugin: &HTTP{
 				URL:                     u.String(),
+				UseBatchFormat:          true,
 				NonRetryableStatusCodes: []int{409},
 			},
 			statusCode: http.StatusConflict,
 			errFunc: func(t *testing.T, err error) {
-				require.NoError(t, err)
+				var permErr *internal.PermanentWriteError
+				require.ErrorAs(t, err, &permErr)
 			},
 		},
 	}
@@ -771,6 +773,49 @@ func TestBatchedUnbatched(t *testing.T) {
 	}
 }
 
+func TestNonRetryableStatusCodeUnbatched(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		switch requests {
+		case 2:
+			w.WriteHeader(http.StatusConflict)
+		case 4:
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse("http://" + ts.Listener.Addr().String())
+	require.NoError(t, err)
+
+	serializer := &influx.Serializer{}
+	require.NoError(t, serializer.Init())
+
+	plugin := &HTTP{
+		URL:                     u.String(),
+		Method:                  defaultMethod,
+		NonRetryableStatusCodes: []int{409},
+		Log:                     testutil.Logger{},
+	}
+	plugin.SetSerializer(serializer)
+	require.NoError(t, plugin.Connect())
+
+	// The first and third metric are accepted, the second one is rejected
+	// permanently and the fourth and fifth are kept for retrying.
+	err = plugin.Write(getMetrics(5))
+	var writeErr *internal.PartialWriteError
+	require.ErrorAs(t, err, &writeErr)
+	require.Equal(t, []int{0, 2}, writeErr.MetricsAccept)
+	require.Equal(t, []int{1}, writeErr.MetricsReject)
+	require.Len(t, writeErr.MetricsRejectErrors, 1)
+	require.ErrorContains(t, writeErr.MetricsRejectErrors[0], "non-retryable status code: 409")
+	require.ErrorContains(t, writeErr, "status code: 500")
+	require.Equal(t, 4, requests)
+}
+
 func TestAwsCredentials(t *testing.T) {
 	ts := httptest.NewServer(http.NotFoundHandler())
 	defer ts.Close()
@@ -7,6 +7,7 @@ import (
 	"net"
 
 	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
@@ -15,6 +16,7 @@ import (
 type gRPCClient struct {
 	grpcClientConn       *grpc.ClientConn
 	metricsServiceClient pmetricotlp.GRPCClient
+	tracesServiceClient  ptraceotlp.GRPCClient
 	callOptions          []grpc.CallOption
 }
 
@@ -49,6 +51,7 @@ func (g *gRPCClient) Connect(cfg *clientConfig) error {
 
 	g.grpcClientConn = grpcClientConn
 	g.metricsServiceClient = pmetricotlp.NewGRPCClient(grpcClientConn)
+	g.tracesServiceClient = ptraceotlp.NewGRPCClient(grpcClientConn)
 
 	if cfg.Compression != "" && cfg.Compression != "none" {
 		g.callOptions = append(g.callOptions, grpc.UseCompressor(cfg.Compression))
@@ -61,6 +64,10 @@ func (g *gRPCClient) Export(ctx context.Context, request pmetricotlp.ExportReque
 	return g.metricsServiceClient.Export(ctx, request, g.callOptions...)
 }
 
+func (g *gRPCClient) ExportTraces(ctx context.Context, request ptraceotlp.ExportRequest) (ptraceotlp.ExportResponse, error) {
+	return g.tracesServiceClient.Export(ctx, request, g.callOptions...)
+}
+
 func (g *gRPCClient) Close() error {
 	if g == nil {
 		return nil
@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
 
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/internal"
@@ -55,6 +57,36 @@ func (h *httpClient) Connect(cfg *clientConfig) error {
 }
 
 func (h *httpClient) Export(ctx context.Context, request pmetricotlp.ExportRequest) (pmetricotlp.ExportResponse, error) {
+	response := pmetricotlp.NewExportResponse()
+	if err := h.post(ctx, h.url, request, response); err != nil {
+		return pmetricotlp.ExportResponse{}, err
+	}
+	return response, nil
+}
+
+// ExportTraces sends the traces to the OTLP traces endpoint derived from the
+// service address, i.e. a "/v1/metrics" path is replaced by "/v1/traces".
+func (h *httpClient) ExportTraces(ctx context.Context, request ptraceotlp.ExportRequest) (ptraceotlp.ExportResponse, error) {
+	url := strings.TrimSuffix(strings.TrimSuffix(h.url, "/"), "/v1/metrics") + "/v1/traces"
+
+	response := ptraceotlp.NewExportResponse()
+	if err := h.post(ctx, url, request, response); err != nil {
+		return ptraceotlp.ExportResponse{}, err
+	}
+	return response, nil
+}
+
+type otlpRequest interface {
+	MarshalProto() ([]byte, error)
+	MarshalJSON() ([]byte, error)
+}
+
+type otlpResponse interface {
+	UnmarshalProto(data []byte) error
+	UnmarshalJSON(data []byte) error
+}
+
+func (h *httpClient) post(ctx context.Context, url string, request otlpRequest, response otlpResponse) error {
 	var err error
 	var requestBytes []byte
 	var encoding string
@@ -63,17 +95,17 @@ func (h *httpClient) Export(ctx context.Context, request pmetricotlp.ExportReque
 	case "protobuf":
 		requestBytes, err = request.MarshalProto()
 		if err != nil {
-			return pmetricotlp.ExportResponse{}, err
+			return err
 		}
 		encoding = "application/x-protobuf"
 	case "json":
 		requestBytes, err = request.MarshalJSON()
 		if err != nil {
-			return pmetricotlp.ExportResponse{}, err
+			return err
 		}
 		encoding = "application/json"
 	default:
-		return pmetricotlp.ExportResponse{}, fmt.Errorf("unsupported content type '%s'", h.encodingType)
+		return fmt.Errorf("unsupported content type '%s'", h.encodingType)
 	}
 	var reader io.Reader
 	reader = bytes.NewReader(requestBytes)
@@ -83,9 +115,9 @@ func (h *httpClient) Export(ctx context.Context, request pmetricotlp.ExportReque
 		defer reader.(io.ReadCloser).Close()
 	}
 
-	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, reader)
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, url, reader)
 	if err != nil {
-		return pmetricotlp.ExportResponse{}, err
+		return err
 	}
 	for key, value := range h.headers {
 		httpRequest.Header.Set(key, value)
@@ -93,7 +125,7 @@ func (h *httpClient) Export(ctx context.Context, request pmetricotlp.ExportReque
 	if h.token != nil && !h.token.Empty() {
 		secret, err := h.token.Get()
 		if err != nil {
-			return pmetricotlp.ExportResponse{}, fmt.Errorf("getting token secret failed: %w", err)
+			return fmt.Errorf("getting token secret failed: %w", err)
 		}
 		httpRequest.Header.Set("Authorization", "Bearer "+secret.String())
 		secret.Destroy()
@@ -107,36 +139,28 @@ func (h *httpClient) Export(ctx context.Context, request pmetricotlp.ExportReque
 
 	httpResponse, err := h.httpClient.Do(httpRequest)
 	if err != nil {
-		return pmetricotlp.ExportResponse{}, err
+		return err
 	}
 	defer httpResponse.Body.Close()
 
 	if httpResponse.StatusCode < 200 || httpResponse.StatusCode >= 300 {
-		return pmetricotlp.ExportResponse{}, fmt.Errorf("received unexpected status: %s (%d)",
+		return fmt.Errorf("received unexpected status: %s (%d)",
 			http.StatusText(httpResponse.StatusCode), httpResponse.StatusCode)
 	}
 
 	r := io.LimitReader(httpResponse.Body, 64*1024)
 	responseBytes, err := io.ReadAll(r)
 	if err != nil {
-		return pmetricotlp.ExportResponse{}, err
+		return err
 	}
 
-	exportResponse := pmetricotlp.NewExportResponse()
 	switch httpResponse.Header.Get("Content-Type") {
 	case "application/x-protobuf":
-		err = exportResponse.UnmarshalProto(responseBytes)
-		if err != nil {
-			return pmetricotlp.ExportResponse{}, err
-		}
+		return response.UnmarshalProto(responseBytes)
 	case "application/json":
-		err = exportResponse.UnmarshalJSON(responseBytes)
-		if err != nil {
-			return pmetricotlp.ExportResponse{}, err
-		}
+		return response.UnmarshalJSON(responseBytes)
 	}
-
-	return exportResponse, nil
+	return nil
 }
 
 func (h *httpClient) Close() error {
@@ -3,6 +3,7 @@ package opentelemetry
 
 import (
 	"context"
+	"crypto/rand"
 	_ "embed"
 	"fmt"
 	"maps"
@@ -12,7 +13,10 @@ import (
 
 	"github.com/influxdata/influxdb-observability/common"
 	"github.com/influxdata/influxdb-observability/influx2otel"
+	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
 	_ "google.golang.org/grpc/encoding/gzip" // Blank import to allow gzip encoding
 	"google.golang.org/grpc/metadata"
 
@@ -64,6 +68,7 @@ type clientConfig struct {
 type otlpMetricClient interface {
 	Connect(cfg *clientConfig) error
 	Export(ctx context.Context, request pmetricotlp.ExportRequest) (pmetricotlp.ExportResponse, error)
+	ExportTraces(ctx context.Context, request ptraceotlp.ExportRequest) (ptraceotlp.ExportResponse, error)
 	Close() error
 }
 
@@ -209,14 +214,91 @@ func (o *OpenTelemetry) sendBatch(metrics []telegraf.Metric) error {
 		}
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(o.Timeout))
+	ctx, cancel, err := o.requestContext()
+	if err != nil {
+		return err
+	}
 	defer cancel()
 
+	_, err = o.otlpMetricClient.Export(ctx, md)
+	return err
+}
+
+// ExportTraces sends the traces of metrics sampled by the agent's pipeline
+// tracing. Each trace consists of a root span covering the whole pipeline
+// with a child span per stage.
+func (o *OpenTelemetry) ExportTraces(traces [][]telegraf.Span) error {
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("service.name", "telegraf")
+	for k, v := range o.Attributes {
+		rs.Resource().Attributes().PutStr(k, v)
+	}
+	ss := rs.ScopeSpans().AppendEmpty()
+	ss.Scope().SetName("telegraf")
+
+	for _, spans := range traces {
+		if len(spans) == 0 {
+			continue
+		}
+		var traceID pcommon.TraceID
+		if _, err := rand.Read(traceID[:]); err != nil {
+			return fmt.Errorf("generating trace ID failed: %w", err)
+		}
+		rootID, err := newSpanID()
+		if err != nil {
+			return err
+		}
+
+		root := ss.Spans().AppendEmpty()
+		root.SetTraceID(traceID)
+		root.SetSpanID(rootID)
+		root.SetName("pipeline")
+		root.SetKind(ptrace.SpanKindInternal)
+		root.SetStartTimestamp(pcommon.NewTimestampFromTime(spans[0].Queued))
+		root.SetEndTimestamp(pcommon.NewTimestampFromTime(spans[len(spans)-1].End))
+
+		for _, s := range spans {
+			spanID, err := newSpanID()
+			if err != nil {
+				return err
+			}
+			span := ss.Spans().AppendEmpty()
+			span.SetTraceID(traceID)
+			span.SetSpanID(spanID)
+			span.SetParentSpanID(rootID)
+			span.SetName(s.Stage + " " + s.Plugin)
+			span.SetKind(ptrace.SpanKindInternal)
+			span.SetStartTimestamp(pcommon.NewTimestampFromTime(s.Start))
+			span.SetEndTimestamp(pcommon.NewTimestampFromTime(s.End))
+			span.Attributes().PutStr("telegraf.stage", s.Stage)
+			span.Attributes().PutStr("telegraf.plugin", s.Plugin)
+			span.Attributes().PutStr("telegraf.plugin_id", s.PluginID)
+			span.Attributes().PutInt("telegraf.queue_wait_ns", s.Start.Sub(s.Queued).Nanoseconds())
+		}
+	}
+	if ss.Spans().Len() == 0 {
+		return nil
+	}
+
+	ctx, cancel, err := o.requestContext()
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	_, err = o.otlpMetricClient.ExportTraces(ctx, ptraceotlp.NewExportRequestFromTraces(td))
+	return err
+}
+
+// requestContext returns the context for a request to the service including
+// the timeout and the headers passed as gRPC metadata.
+func (o *OpenTelemetry) requestContext() (context.Context, context.CancelFunc, error) {
 	headers := maps.Clone(o.Headers)
 	if !o.Token.Empty() {
 		token, err := o.Token.Get()
 		if err != nil {
-			return fmt.Errorf("getting token secret failed: %w", err)
+			return nil, nil, fmt.Errorf("getting token secret failed: %w", err)
 		}
 		if headers == nil {
 			headers = make(map[string]string, 1)
@@ -224,11 +306,20 @@ func (o *OpenTelemetry) sendBatch(metrics []telegraf.Metric) error {
 		headers["authorization"] = "Bearer " + token.String()
 		token.Destroy()
 	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(o.Timeout))
 	if len(headers) > 0 {
 		ctx = metadata.NewOutgoingContext(ctx, metadata.New(headers))
 	}
-	_, err := o.otlpMetricClient.Export(ctx, md)
-	return err
+	return ctx, cancel, nil
+}
+
+func newSpanID() (pcommon.SpanID, error) {
+	var id pcommon.SpanID
+	if _, err := rand.Read(id[:]); err != nil {
+		return id, fmt.Errorf("generating span ID failed: %w", err)
+	}
+	return id, nil
 }
 
 const (
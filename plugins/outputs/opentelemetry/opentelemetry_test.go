@@ -15,6 +15,8 @@ import (
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
@@ -285,6 +287,81 @@ func TestOpenTelemetryHTTPJSON(t *testing.T) {
 	require.JSONEq(t, string(expectJSON), string(gotJSON))
 }
 
+func TestOpenTelemetryHTTPExportTraces(t *testing.T) {
+	var receivedPath string
+	var received ptrace.Traces
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		req := ptraceotlp.NewExportRequest()
+		if err := req.UnmarshalProto(body); err != nil {
+			t.Error(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		received = ptrace.NewTraces()
+		req.Traces().CopyTo(received)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	plugin := &OpenTelemetry{
+		ServiceAddress: server.URL + "/v1/metrics",
+		EncodingType:   "protobuf",
+		Timeout:        config.Duration(time.Second),
+		Compression:    "none",
+		Log:            testutil.Logger{},
+	}
+	require.NoError(t, plugin.Connect())
+
+	start := time.Unix(1622848686, 0)
+	traces := [][]telegraf.Span{
+		{
+			{
+				Stage:    "gather",
+				Plugin:   "inputs.cpu",
+				PluginID: "a",
+				Queued:   start,
+				Start:    start,
+				End:      start.Add(10 * time.Millisecond),
+			},
+			{
+				Stage:    "write",
+				Plugin:   "outputs.opentelemetry",
+				PluginID: "b",
+				Queued:   start.Add(10 * time.Millisecond),
+				Start:    start.Add(30 * time.Millisecond),
+				End:      start.Add(50 * time.Millisecond),
+			},
+		},
+	}
+	require.NoError(t, plugin.ExportTraces(traces))
+	require.Equal(t, "/v1/traces", receivedPath)
+
+	require.Equal(t, 1, received.ResourceSpans().Len())
+	spans := received.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	require.Equal(t, 3, spans.Len())
+
+	root := spans.At(0)
+	require.Equal(t, "pipeline", root.Name())
+	require.Equal(t, pcommon.NewTimestampFromTime(start), root.StartTimestamp())
+	require.Equal(t, pcommon.NewTimestampFromTime(start.Add(50*time.Millisecond)), root.EndTimestamp())
+
+	write := spans.At(2)
+	require.Equal(t, "write outputs.opentelemetry", write.Name())
+	require.Equal(t, root.TraceID(), write.TraceID())
+	require.Equal(t, root.SpanID(), write.ParentSpanID())
+	wait, found := write.Attributes().Get("telegraf.queue_wait_ns")
+	require.True(t, found)
+	require.Equal(t, (20 * time.Millisecond).Nanoseconds(), wait.Int())
+}
+
 func TestConnectInvalidProxy(t *testing.T) {
 	tests := []struct {
 		name   string
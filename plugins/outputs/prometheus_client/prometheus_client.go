@@ -60,6 +60,7 @@ type PrometheusClient struct {
 	CollectorsExclude  []string                           `toml:"collectors_exclude"`
 	StringAsLabel      bool                               `toml:"string_as_label"`
 	ExportTimestamp    bool                               `toml:"export_timestamp"`
+	EnableOpenMetrics  bool                               `toml:"enable_openmetrics"`
 	TypeMappings       serializers_prometheus.MetricTypes `toml:"metric_types"`
 	NameSanitization   string                             `toml:"name_sanitization"`
 	HTTPHeaders        map[string]*config.Secret          `toml:"http_headers"`
@@ -166,7 +167,10 @@ func (p *PrometheusClient) Init() error {
 
 	authHandler := internal.BasicAuthHandler(p.BasicUsername, password, "prometheus", onAuthError)
 	rangeHandler := internal.IPRangeHandler(ipRange, onError)
-	promHandler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError})
+	promHandler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+		ErrorHandling:     promhttp.ContinueOnError,
+		EnableOpenMetrics: p.EnableOpenMetrics,
+	})
 	landingPageHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		_, err := w.Write([]byte("Telegraf Output Plugin: Prometheus Client "))
 		if err != nil {
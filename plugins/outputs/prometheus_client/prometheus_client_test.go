@@ -1,16 +1,27 @@
 package prometheus_client
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	inputs "github.com/influxdata/telegraf/plugins/inputs/prometheus"
 	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/selfstat"
 	"github.com/influxdata/telegraf/testutil"
 )
 
@@ -182,3 +193,165 @@ func TestNameSanitizationDefaultFromConstructor(t *testing.T) {
 	require.Equal(t, defaultNameSanitization, plugin.NameSanitization)
 	require.NoError(t, plugin.Init())
 }
+
+func TestRoundTripNativeHistogram(t *testing.T) {
+	logger := testutil.Logger{Name: "outputs.prometheus_client"}
+	exemplarTime := timestamppb.New(time.Unix(1700000000, 0))
+
+	counter := &dto.Counter{
+		Value: proto.Float64(42),
+		Exemplar: &dto.Exemplar{
+			Label:     []*dto.LabelPair{{Name: proto.String("trace_id"), Value: proto.String("def")}},
+			Value:     proto.Float64(1),
+			Timestamp: exemplarTime,
+		},
+	}
+	histogram := &dto.Histogram{
+		SampleCount:   proto.Uint64(6),
+		SampleSum:     proto.Float64(3.5),
+		Schema:        proto.Int32(0),
+		ZeroThreshold: proto.Float64(0.001),
+		ZeroCount:     proto.Uint64(1),
+		PositiveSpan: []*dto.BucketSpan{
+			{Offset: proto.Int32(0), Length: proto.Uint32(2)},
+			{Offset: proto.Int32(1), Length: proto.Uint32(1)},
+		},
+		PositiveDelta: []int64{2, 0, -1},
+		Exemplars: []*dto.Exemplar{
+			{
+				Label:     []*dto.LabelPair{{Name: proto.String("trace_id"), Value: proto.String("abc")}},
+				Value:     proto.Float64(0.9),
+				Timestamp: exemplarTime,
+			},
+		},
+	}
+	labels := []*dto.LabelPair{{Name: proto.String("service"), Value: proto.String("api")}}
+	families := []*dto.MetricFamily{
+		{
+			Name:   proto.String("requests_total"),
+			Type:   dto.MetricType_COUNTER.Enum(),
+			Metric: []*dto.Metric{{Label: labels, Counter: counter}},
+		},
+		{
+			Name:   proto.String("rpc_latency_seconds"),
+			Type:   dto.MetricType_HISTOGRAM.Enum(),
+			Metric: []*dto.Metric{{Label: labels, Histogram: histogram}},
+		},
+	}
+
+	// Serve the metrics in the protobuf format supporting native histograms
+	format := expfmt.NewFormat(expfmt.TypeProtoDelim)
+	var buf bytes.Buffer
+	encoder := expfmt.NewEncoder(&buf, format)
+	for _, mf := range families {
+		require.NoError(t, encoder.Encode(mf))
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", string(format))
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer ts.Close()
+
+	for _, version := range []int{1, 2} {
+		t.Run(fmt.Sprintf("metric_version %d", version), func(t *testing.T) {
+			input := &inputs.Prometheus{
+				Log:           logger,
+				URLs:          []string{ts.URL},
+				MetricVersion: version,
+				Statistics:    selfstat.NewCollector(make(map[string]string)),
+			}
+			require.NoError(t, input.Init())
+
+			var acc testutil.Accumulator
+			require.NoError(t, input.Start(&acc))
+			require.NoError(t, input.Gather(&acc))
+			input.Stop()
+
+			output := &PrometheusClient{
+				Listen:            "127.0.0.1:0",
+				Path:              defaultPath,
+				MetricVersion:     version,
+				StringAsLabel:     true,
+				Log:               logger,
+				CollectorsExclude: []string{"gocollector", "process"},
+			}
+			require.NoError(t, output.Init())
+			require.NoError(t, output.Connect())
+			defer func() {
+				require.NoError(t, output.Close())
+			}()
+			require.NoError(t, output.Write(acc.GetTelegrafMetrics()))
+
+			req, err := http.NewRequest(http.MethodGet, output.URL(), nil)
+			require.NoError(t, err)
+			req.Header.Set("Accept", string(format))
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			var actual []*dto.MetricFamily
+			decoder := expfmt.NewDecoder(resp.Body, expfmt.ResponseFormat(resp.Header))
+			for {
+				var mf dto.MetricFamily
+				if err := decoder.Decode(&mf); err != nil {
+					require.ErrorIs(t, err, io.EOF)
+					break
+				}
+				actual = append(actual, &mf)
+			}
+
+			require.Len(t, actual, 2)
+			require.Equal(t, "requests_total", actual[0].GetName())
+			require.Len(t, actual[0].Metric, 1)
+			require.Truef(t, proto.Equal(counter, actual[0].Metric[0].Counter), "unexpected counter %v", actual[0].Metric[0].Counter)
+			require.Equal(t, "rpc_latency_seconds", actual[1].GetName())
+			require.Len(t, actual[1].Metric, 1)
+			require.Truef(t, proto.Equal(histogram, actual[1].Metric[0].Histogram), "unexpected histogram %v", actual[1].Metric[0].Histogram)
+		})
+	}
+}
+
+func TestOpenMetricsExemplars(t *testing.T) {
+	plugin := &PrometheusClient{
+		Listen:            "127.0.0.1:0",
+		Path:              defaultPath,
+		MetricVersion:     2,
+		EnableOpenMetrics: true,
+		Log:               testutil.Logger{Name: "outputs.prometheus_client"},
+		CollectorsExclude: []string{"gocollector", "process"},
+	}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer func() {
+		require.NoError(t, plugin.Close())
+	}()
+
+	require.NoError(t, plugin.Write([]telegraf.Metric{
+		metric.New(
+			"prometheus",
+			map[string]string{"service": "api"},
+			map[string]interface{}{
+				"requests_total":                         42.0,
+				"requests_total_exemplar":                1.0,
+				"requests_total_exemplar_timestamp":      int64(1700000000000),
+				"requests_total_exemplar_label_trace_id": "def",
+			},
+			time.Unix(0, 0),
+			telegraf.Counter,
+		),
+	}))
+
+	req, err := http.NewRequest(http.MethodGet, plugin.URL(), nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Contains(t, resp.Header.Get("Content-Type"), "application/openmetrics-text")
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), `requests_total{service="api"} 42.0 # {trace_id="def"} 1.0`)
+}
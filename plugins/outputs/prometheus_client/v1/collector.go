@@ -2,6 +2,8 @@ package v1
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -9,6 +11,8 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/influxdata/telegraf"
 	serializers_prometheus "github.com/influxdata/telegraf/plugins/serializers/prometheus"
@@ -28,12 +32,104 @@ type Sample struct {
 	// Histograms and Summaries need a count and a sum
 	Count uint64
 	Sum   float64
+	// Native histograms additionally carry their sparse buckets
+	NativeHistogram *NativeHistogram
+	// Exemplars attached to counters and histogram buckets
+	Exemplars []prometheus.Exemplar
 	// Metric timestamp
 	Timestamp time.Time
 	// Expiration is the deadline that this Sample is valid until.
 	Expiration time.Time
 }
 
+// NativeHistogram contains the schema, the zero bucket and the sparse buckets
+// of a native histogram. The buckets are indexed by their bucket key and hold
+// the number of observations in the bucket.
+type NativeHistogram struct {
+	Schema          int32
+	ZeroThreshold   float64
+	ZeroCount       uint64
+	PositiveBuckets map[int]int64
+	NegativeBuckets map[int]int64
+}
+
+// add sets the property of the native histogram represented by the given
+// field and returns false if the field is not part of a native histogram.
+func (h *NativeHistogram) add(key string, value float64) bool {
+	switch key {
+	case "schema":
+		h.Schema = int32(value)
+		return true
+	case "zero_threshold":
+		h.ZeroThreshold = value
+		return true
+	case "zero_count":
+		h.ZeroCount = uint64(value)
+		return true
+	}
+
+	buckets := h.PositiveBuckets
+	index, found := strings.CutPrefix(key, "positive_")
+	if !found {
+		buckets = h.NegativeBuckets
+		if index, found = strings.CutPrefix(key, "negative_"); !found {
+			return false
+		}
+	}
+	i, err := strconv.Atoi(index)
+	if err != nil {
+		return false
+	}
+	buckets[i] = int64(value)
+	return true
+}
+
+// mixedHistogram exposes the classic buckets of a histogram in addition to
+// its native representation.
+type mixedHistogram struct {
+	prometheus.Metric
+	buckets map[float64]uint64
+}
+
+func (m *mixedHistogram) Write(out *dto.Metric) error {
+	if err := m.Metric.Write(out); err != nil {
+		return err
+	}
+
+	bounds := make([]float64, 0, len(m.buckets))
+	for bound := range m.buckets {
+		bounds = append(bounds, bound)
+	}
+	sort.Float64s(bounds)
+	for _, bound := range bounds {
+		out.Histogram.Bucket = append(out.Histogram.Bucket, &dto.Bucket{
+			UpperBound:      proto.Float64(bound),
+			CumulativeCount: proto.Uint64(m.buckets[bound]),
+		})
+	}
+	return nil
+}
+
+func newNativeHistogram(desc *prometheus.Desc, sample *Sample, labels []string) (prometheus.Metric, error) {
+	h := sample.NativeHistogram
+	metric, err := prometheus.NewConstNativeHistogram(
+		desc,
+		sample.Count,
+		sample.Sum,
+		h.PositiveBuckets,
+		h.NegativeBuckets,
+		h.ZeroCount,
+		h.Schema,
+		h.ZeroThreshold,
+		time.Time{},
+		labels...,
+	)
+	if err != nil || len(sample.HistogramValue) == 0 {
+		return metric, err
+	}
+	return &mixedHistogram{Metric: metric, buckets: sample.HistogramValue}, nil
+}
+
 // MetricFamily contains the data required to build valid prometheus Metrics.
 type MetricFamily struct {
 	// Samples are the Sample belonging to this MetricFamily.
@@ -127,7 +223,11 @@ func (c *Collector) Collect(ch chan<- prometheus.Metric) {
 			case telegraf.Summary:
 				metric, err = prometheus.NewConstSummary(desc, sample.Count, sample.Sum, sample.SummaryValue, labels...)
 			case telegraf.Histogram:
-				metric, err = prometheus.NewConstHistogram(desc, sample.Count, sample.Sum, sample.HistogramValue, labels...)
+				if sample.NativeHistogram != nil {
+					metric, err = newNativeHistogram(desc, sample, labels)
+				} else {
+					metric, err = prometheus.NewConstHistogram(desc, sample.Count, sample.Sum, sample.HistogramValue, labels...)
+				}
 			default:
 				metric, err = prometheus.NewConstMetric(desc, getPromValueType(family.TelegrafValueType), sample.Value, labels...)
 			}
@@ -138,6 +238,17 @@ func (c *Collector) Collect(ch chan<- prometheus.Metric) {
 				continue
 			}
 
+			// Only counters and histograms support exemplars
+			if len(sample.Exemplars) > 0 && (family.TelegrafValueType == telegraf.Counter || family.TelegrafValueType == telegraf.Histogram) {
+				if m, err := prometheus.NewMetricWithExemplars(metric, sample.Exemplars...); err != nil {
+					c.Log.Errorf("Error adding exemplars to prometheus metric: "+
+						"key: %s, labels: %v, err: %v",
+						name, labels, err)
+				} else {
+					metric = m
+				}
+			}
+
 			if c.ExportTimestamp {
 				metric = prometheus.NewMetricWithTimestamp(sample.Timestamp, metric)
 			}
@@ -167,6 +278,26 @@ func CreateSampleID(tags map[string]string) SampleID {
 	return SampleID(strings.Join(pairs, ","))
 }
 
+// sampleExemplars converts the given exemplars to prometheus exemplars ordered
+// by their value.
+func sampleExemplars(exemplars ...*serializers_prometheus.Exemplar) []prometheus.Exemplar {
+	result := make([]prometheus.Exemplar, 0, len(exemplars))
+	for _, e := range exemplars {
+		if e == nil {
+			continue
+		}
+		result = append(result, prometheus.Exemplar{
+			Value:     e.Value,
+			Labels:    e.Labels,
+			Timestamp: e.Timestamp,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Value < result[j].Value
+	})
+	return result
+}
+
 func addSample(fam *MetricFamily, sample *Sample, sampleID SampleID) {
 	for k := range sample.Labels {
 		fam.LabelSet[k]++
@@ -226,6 +357,7 @@ func (c *Collector) addMetrics(metrics []telegraf.Metric) {
 	for _, point := range sorted(metrics) {
 		tags := point.Tags()
 		sampleID := CreateSampleID(tags)
+		exemplars := serializers_prometheus.Exemplars(point)
 
 		labels := make(map[string]string)
 		for k, v := range tags {
@@ -241,7 +373,7 @@ func (c *Collector) addMetrics(metrics []telegraf.Metric) {
 		if c.StringAsLabel {
 			for fn, fv := range point.Fields() {
 				sfv, ok := fv.(string)
-				if !ok {
+				if !ok || serializers_prometheus.IsExemplarField(fn) {
 					continue
 				}
 
@@ -303,8 +435,17 @@ func (c *Collector) addMetrics(metrics []telegraf.Metric) {
 			var mname string
 			var sum float64
 			var count uint64
+			var isNative bool
 			histogramvalue := make(map[float64]uint64)
+			native := &NativeHistogram{
+				PositiveBuckets: make(map[int]int64),
+				NegativeBuckets: make(map[int]int64),
+			}
 			for fn, fv := range point.Fields() {
+				if serializers_prometheus.IsExemplarField(fn) {
+					continue
+				}
+
 				var value float64
 				switch fv := fv.(type) {
 				case int64:
@@ -323,6 +464,10 @@ func (c *Collector) addMetrics(metrics []telegraf.Metric) {
 				case "count":
 					count = uint64(value)
 				default:
+					if native.add(fn, value) {
+						isNative = true
+						continue
+					}
 					limit, err := strconv.ParseFloat(fn, 64)
 					if err == nil {
 						histogramvalue[limit] = uint64(value)
@@ -337,6 +482,10 @@ func (c *Collector) addMetrics(metrics []telegraf.Metric) {
 				Timestamp:      point.Time(),
 				Expiration:     now.Add(c.ExpirationInterval),
 			}
+			if isNative {
+				sample.NativeHistogram = native
+			}
+			sample.Exemplars = sampleExemplars(slices.Collect(maps.Values(exemplars))...)
 			mname, ok := c.sanitizeMetricName(point.Name())
 			if !ok {
 				continue
@@ -346,6 +495,10 @@ func (c *Collector) addMetrics(metrics []telegraf.Metric) {
 
 		default:
 			for fn, fv := range point.Fields() {
+				if serializers_prometheus.IsExemplarField(fn) {
+					continue
+				}
+
 				// Ignore string and bool fields.
 				var value float64
 				switch fv := fv.(type) {
@@ -362,6 +515,7 @@ func (c *Collector) addMetrics(metrics []telegraf.Metric) {
 				sample := &Sample{
 					Labels:     labels,
 					Value:      value,
+					Exemplars:  sampleExemplars(exemplars[fn]),
 					Timestamp:  point.Time(),
 					Expiration: now.Add(c.ExpirationInterval),
 				}
@@ -53,28 +53,35 @@ func (p *Parser) extractMetricsV1(prommetrics *dto.MetricFamily) []telegraf.Metr
 
 			// Collect the fields
 			fields := make(map[string]interface{}, len(histogram.Bucket)+2)
-			fields["count"] = float64(pm.GetHistogram().GetSampleCount())
-			fields["sum"] = pm.GetHistogram().GetSampleSum()
+			fields["count"] = histogramCount(histogram)
+			fields["sum"] = histogram.GetSampleSum()
 			for _, b := range histogram.Bucket {
 				fname := strconv.FormatFloat(b.GetUpperBound(), 'g', -1, 64)
 				fields[fname] = float64(b.GetCumulativeCount())
+				addExemplar(fields, fname, b.GetExemplar())
+			}
+			if isNativeHistogram(histogram) {
+				addNativeHistogramFields(fields, histogram)
 			}
 			metrics = append(metrics, metric.New(metricName, tags, fields, t, telegraf.Histogram))
 		default:
 			var fname string
 			var v float64
+			var exemplar *dto.Exemplar
 			if gauge := pm.GetGauge(); gauge != nil {
 				fname = "gauge"
 				v = gauge.GetValue()
 			} else if counter := pm.GetCounter(); counter != nil {
 				fname = "counter"
 				v = counter.GetValue()
+				exemplar = counter.GetExemplar()
 			} else if untyped := pm.GetUntyped(); untyped != nil {
 				fname = "value"
 				v = untyped.GetValue()
 			}
 			if fname != "" && !math.IsNaN(v) {
 				fields := map[string]interface{}{fname: v}
+				addExemplar(fields, fname, exemplar)
 				vtype := mapValueType(metricType)
 				metrics = append(metrics, metric.New(metricName, tags, fields, t, vtype))
 			}
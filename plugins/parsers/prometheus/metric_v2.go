@@ -57,8 +57,14 @@ func (p *Parser) extractMetricsV2(prommetrics *dto.MetricFamily) []telegraf.Metr
 
 			// Add an overall metric containing the number of samples and and its sum
 			histFields := make(map[string]interface{})
-			histFields[metricName+"_count"] = float64(histogram.GetSampleCount())
+			histFields[metricName+"_count"] = histogramCount(histogram)
 			histFields[metricName+"_sum"] = histogram.GetSampleSum()
+
+			// Add one metric per sparse bucket of native histograms
+			native := isNativeHistogram(histogram)
+			if native {
+				metrics = append(metrics, nativeHistogramMetrics(metricName, tags, histFields, histogram, t)...)
+			}
 			metrics = append(metrics, metric.New("prometheus", tags, histFields, t, telegraf.Histogram))
 
 			// Add one metric per histogram bucket
@@ -69,6 +75,7 @@ func (p *Parser) extractMetricsV2(prommetrics *dto.MetricFamily) []telegraf.Metr
 				bucketFields := map[string]interface{}{
 					metricName + "_bucket": float64(b.GetCumulativeCount()),
 				}
+				addExemplar(bucketFields, metricName+"_bucket", b.GetExemplar())
 				m := metric.New("prometheus", bucketTags, bucketFields, t, telegraf.Histogram)
 				metrics = append(metrics, m)
 
@@ -76,8 +83,9 @@ func (p *Parser) extractMetricsV2(prommetrics *dto.MetricFamily) []telegraf.Metr
 				infSeen = infSeen || math.IsInf(b.GetUpperBound(), +1)
 			}
 
-			// Infinity bucket is required for proper function of histogram in prometheus
-			if !infSeen {
+			// Infinity bucket is required for proper function of histogram in
+			// prometheus unless it is a native histogram without classic buckets
+			if !infSeen && (!native || len(histogram.Bucket) > 0) {
 				infTags := tags
 				infTags["le"] = "+Inf"
 				infFields := map[string]interface{}{
@@ -88,15 +96,18 @@ func (p *Parser) extractMetricsV2(prommetrics *dto.MetricFamily) []telegraf.Metr
 			}
 		default:
 			v := math.Inf(1)
+			var exemplar *dto.Exemplar
 			if gauge := pm.GetGauge(); gauge != nil {
 				v = gauge.GetValue()
 			} else if counter := pm.GetCounter(); counter != nil {
 				v = counter.GetValue()
+				exemplar = counter.GetExemplar()
 			} else if untyped := pm.GetUntyped(); untyped != nil {
 				v = untyped.GetValue()
 			}
 			if !math.IsNaN(v) {
 				fields := map[string]interface{}{metricName: v}
+				addExemplar(fields, metricName, exemplar)
 				vtype := mapValueType(metricType)
 				metrics = append(metrics, metric.New("prometheus", tags, fields, t, vtype))
 			}
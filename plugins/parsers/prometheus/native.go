@@ -0,0 +1,177 @@
+package prometheus
+
+import (
+	"maps"
+	"math"
+	"strconv"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// isNativeHistogram checks if the histogram contains a native histogram, the
+// same way Prometheus does when scraping.
+func isNativeHistogram(h *dto.Histogram) bool {
+	return h.GetZeroThreshold() > 0 ||
+		h.GetZeroCount() > 0 ||
+		h.GetZeroCountFloat() > 0 ||
+		len(h.GetPositiveSpan()) > 0 ||
+		len(h.GetNegativeSpan()) > 0
+}
+
+// histogramCount returns the number of observations of integer and float
+// histograms.
+func histogramCount(h *dto.Histogram) float64 {
+	if h.SampleCountFloat != nil {
+		return h.GetSampleCountFloat()
+	}
+	return float64(h.GetSampleCount())
+}
+
+// zeroCount returns the number of observations in the zero bucket of integer
+// and float native histograms.
+func zeroCount(h *dto.Histogram) float64 {
+	if h.ZeroCountFloat != nil {
+		return h.GetZeroCountFloat()
+	}
+	return float64(h.GetZeroCount())
+}
+
+// nativeBuckets decodes the spans and the delta-encoded (integer histograms)
+// or absolute (float histograms) counts of the sparse buckets into the number
+// of observations per bucket index.
+func nativeBuckets(spans []*dto.BucketSpan, deltas []int64, counts []float64) map[int]float64 {
+	buckets := make(map[int]float64)
+
+	var index int32
+	var count int64
+	var i int
+	for _, span := range spans {
+		index += span.GetOffset()
+		for range span.GetLength() {
+			switch {
+			case i < len(deltas):
+				count += deltas[i]
+				buckets[int(index)] = float64(count)
+			case i < len(counts):
+				buckets[int(index)] = counts[i]
+			}
+			index++
+			i++
+		}
+	}
+	return buckets
+}
+
+// exemplarBucket returns the index and the sign of the sparse bucket
+// containing the exemplar value. Values within the zero bucket are reported
+// as not found.
+func exemplarBucket(h *dto.Histogram, value float64) (index int, positive, found bool) {
+	if math.Abs(value) <= h.GetZeroThreshold() {
+		return 0, false, false
+	}
+
+	// The upper bound of bucket i is base^i with base = 2^(2^-schema)
+	index = int(math.Ceil(math.Log2(math.Abs(value)) * math.Exp2(float64(h.GetSchema()))))
+	return index, value > 0, true
+}
+
+// addNativeHistogramFields adds the schema, the zero bucket and the sparse
+// buckets of a native histogram as fields of a metric_version 1 histogram.
+// Exemplars are attached to the bucket containing them.
+func addNativeHistogramFields(fields map[string]interface{}, h *dto.Histogram) {
+	fields["schema"] = int64(h.GetSchema())
+	fields["zero_threshold"] = h.GetZeroThreshold()
+	fields["zero_count"] = zeroCount(h)
+	for index, count := range nativeBuckets(h.PositiveSpan, h.PositiveDelta, h.PositiveCount) {
+		fields["positive_"+strconv.Itoa(index)] = count
+	}
+	for index, count := range nativeBuckets(h.NegativeSpan, h.NegativeDelta, h.NegativeCount) {
+		fields["negative_"+strconv.Itoa(index)] = count
+	}
+
+	for _, e := range h.Exemplars {
+		key := "zero_count"
+		if index, positive, found := exemplarBucket(h, e.GetValue()); found {
+			key = "negative_" + strconv.Itoa(index)
+			if positive {
+				key = "positive_" + strconv.Itoa(index)
+			}
+			// Add an empty bucket to be able to attach the exemplar
+			if _, exists := fields[key]; !exists {
+				fields[key] = float64(0)
+			}
+		}
+		addExemplar(fields, key, e)
+	}
+}
+
+// nativeHistogramMetrics adds the schema and the zero bucket of a native
+// histogram to the fields of a metric_version 2 histogram and returns one
+// metric per sparse bucket tagged with the bucket index. Exemplars are
+// attached to the bucket containing them.
+func nativeHistogramMetrics(name string, tags map[string]string, fields map[string]interface{}, h *dto.Histogram, t time.Time) []telegraf.Metric {
+	fields[name+"_schema"] = int64(h.GetSchema())
+	fields[name+"_zero_threshold"] = h.GetZeroThreshold()
+	fields[name+"_zero_count"] = zeroCount(h)
+
+	bucketFields := func(counts map[int]float64) map[int]map[string]interface{} {
+		buckets := make(map[int]map[string]interface{}, len(counts))
+		for index, count := range counts {
+			buckets[index] = map[string]interface{}{name + "_bucket": count}
+		}
+		return buckets
+	}
+	positive := bucketFields(nativeBuckets(h.PositiveSpan, h.PositiveDelta, h.PositiveCount))
+	negative := bucketFields(nativeBuckets(h.NegativeSpan, h.NegativeDelta, h.NegativeCount))
+
+	for _, e := range h.Exemplars {
+		index, isPositive, found := exemplarBucket(h, e.GetValue())
+		if !found {
+			addExemplar(fields, name+"_zero_count", e)
+			continue
+		}
+
+		buckets := negative
+		if isPositive {
+			buckets = positive
+		}
+		// Add an empty bucket to be able to attach the exemplar
+		if _, exists := buckets[index]; !exists {
+			buckets[index] = map[string]interface{}{name + "_bucket": float64(0)}
+		}
+		addExemplar(buckets[index], name+"_bucket", e)
+	}
+
+	metrics := make([]telegraf.Metric, 0, len(positive)+len(negative))
+	for tag, buckets := range map[string]map[int]map[string]interface{}{
+		"native_positive": positive,
+		"native_negative": negative,
+	} {
+		for index, bf := range buckets {
+			bucketTags := maps.Clone(tags)
+			bucketTags[tag] = strconv.Itoa(index)
+			metrics = append(metrics, metric.New("prometheus", bucketTags, bf, t, telegraf.Histogram))
+		}
+	}
+	return metrics
+}
+
+// addExemplar adds the fields of the exemplar attached to the field with the
+// given key.
+func addExemplar(fields map[string]interface{}, key string, e *dto.Exemplar) {
+	if e == nil {
+		return
+	}
+
+	fields[key+"_exemplar"] = e.GetValue()
+	if ts := e.GetTimestamp(); ts != nil {
+		fields[key+"_exemplar_timestamp"] = ts.AsTime().UnixMilli()
+	}
+	for _, label := range e.Label {
+		fields[key+"_exemplar_label_"+label.GetName()] = label.GetValue()
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/prompb"
 
 	"github.com/influxdata/telegraf"
@@ -35,14 +36,30 @@ func (p *Parser) Init() error {
 }
 
 func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
-	var err error
-	var metrics []telegraf.Metric
 	var req prompb.WriteRequest
-
 	if err := req.Unmarshal(buf); err != nil {
 		return nil, fmt.Errorf("unable to unmarshal request body: %w", err)
 	}
 
+	return p.ParseWriteRequest(&req)
+}
+
+// ParseWriteRequest converts the time series of a decoded remote-write
+// request. Samples of series with counter or gauge metadata in the request
+// are typed accordingly.
+func (p *Parser) ParseWriteRequest(req *prompb.WriteRequest) ([]telegraf.Metric, error) {
+	types := make(map[string]telegraf.ValueType, len(req.Metadata))
+	for _, md := range req.Metadata {
+		switch md.Type {
+		case prompb.MetricMetadata_COUNTER:
+			types[md.MetricFamilyName] = telegraf.Counter
+		case prompb.MetricMetadata_GAUGE:
+			types[md.MetricFamilyName] = telegraf.Gauge
+		}
+	}
+
+	var err error
+	var metrics []telegraf.Metric
 	for _, ts := range req.Timeseries {
 		var metricsFromTS []telegraf.Metric
 		switch p.MetricVersion {
@@ -56,6 +73,14 @@ func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
 		if err != nil {
 			return nil, err
 		}
+
+		if vt, found := types[seriesName(&ts)]; found {
+			for _, m := range metricsFromTS {
+				if m.Type() == telegraf.Untyped {
+					m.SetType(vt)
+				}
+			}
+		}
 		metrics = append(metrics, metricsFromTS...)
 	}
 
@@ -79,6 +104,15 @@ func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
 	return metrics[0], nil
 }
 
+func seriesName(ts *prompb.TimeSeries) string {
+	for _, l := range ts.Labels {
+		if l.Name == model.MetricNameLabel {
+			return l.Value
+		}
+	}
+	return ""
+}
+
 func init() {
 	parsers.Add("prometheusremotewrite",
 		func(string) telegraf.Parser {
@@ -333,6 +333,60 @@ func TestMetricsWithTimestamp(t *testing.T) {
 	testutil.RequireMetricsEqual(t, expected, metrics, testutil.SortMetrics())
 }
 
+func TestParseWriteRequestMetadata(t *testing.T) {
+	testTime := time.Date(2020, time.October, 4, 17, 0, 0, 0, time.UTC)
+	req := &prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{
+			{
+				Labels:  []prompb.Label{{Name: "__name__", Value: "requests_total"}},
+				Samples: []prompb.Sample{{Value: 42, Timestamp: testTime.UnixMilli()}},
+			},
+			{
+				Labels:  []prompb.Label{{Name: "__name__", Value: "temperature"}},
+				Samples: []prompb.Sample{{Value: 21.5, Timestamp: testTime.UnixMilli()}},
+			},
+			{
+				Labels:  []prompb.Label{{Name: "__name__", Value: "unknown"}},
+				Samples: []prompb.Sample{{Value: 1, Timestamp: testTime.UnixMilli()}},
+			},
+		},
+		Metadata: []prompb.MetricMetadata{
+			{Type: prompb.MetricMetadata_COUNTER, MetricFamilyName: "requests_total"},
+			{Type: prompb.MetricMetadata_GAUGE, MetricFamilyName: "temperature"},
+		},
+	}
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"prometheus_remote_write",
+			map[string]string{},
+			map[string]interface{}{"requests_total": float64(42)},
+			testTime,
+			telegraf.Counter,
+		),
+		metric.New(
+			"prometheus_remote_write",
+			map[string]string{},
+			map[string]interface{}{"temperature": 21.5},
+			testTime,
+			telegraf.Gauge,
+		),
+		metric.New(
+			"prometheus_remote_write",
+			map[string]string{},
+			map[string]interface{}{"unknown": float64(1)},
+			testTime,
+		),
+	}
+
+	parser := &Parser{}
+	require.NoError(t, parser.Init())
+
+	metrics, err := parser.ParseWriteRequest(req)
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
 func TestBenchmarkData(t *testing.T) {
 	expected := []telegraf.Metric{
 		metric.New(
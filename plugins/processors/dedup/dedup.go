@@ -4,6 +4,7 @@ package dedup
 import (
 	_ "embed"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -22,6 +23,7 @@ type Dedup struct {
 
 	flushTime time.Time
 	cache     map[uint64]telegraf.Metric
+	mu        sync.Mutex
 }
 
 func (*Dedup) SampleConfig() string {
@@ -29,6 +31,9 @@ func (*Dedup) SampleConfig() string {
 }
 
 func (d *Dedup) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	idx := 0
 	for _, metric := range metrics {
 		id := metric.HashID()
@@ -100,6 +105,9 @@ func (d *Dedup) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
 }
 
 func (d *Dedup) GetState() interface{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	s := &serializers_influx.Serializer{}
 	v := make([]telegraf.Metric, 0, len(d.cache))
 	for _, value := range d.cache {
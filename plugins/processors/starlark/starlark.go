@@ -5,6 +5,7 @@ import (
 	_ "embed"
 	"errors"
 	"fmt"
+	"sync"
 
 	"go.starlark.net/starlark"
 
@@ -20,6 +21,10 @@ type Starlark struct {
 	common.Common
 
 	results []telegraf.Metric
+
+	// Protects the script state when checkpointing the state while
+	// processing metrics
+	mu sync.Mutex
 }
 
 func (*Starlark) SampleConfig() string {
@@ -46,7 +51,24 @@ func (*Starlark) Start(telegraf.Accumulator) error {
 	return nil
 }
 
+func (s *Starlark) GetState() interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.Common.GetState()
+}
+
+func (s *Starlark) SetState(state interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.Common.SetState(state)
+}
+
 func (s *Starlark) Add(origMetric telegraf.Metric, acc telegraf.Accumulator) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	parameters, found := s.GetParameters("apply")
 	if !found {
 		return errors.New("the parameters of the apply function could not be found")
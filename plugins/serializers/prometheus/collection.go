@@ -15,6 +15,13 @@ import (
 
 const helpString = "Telegraf collected metric"
 
+// Tags denoting the index of a positive or negative bucket of a native
+// histogram. They replace the "le" tag of classic histogram buckets.
+const (
+	nativePositiveTag = "native_positive"
+	nativeNegativeTag = "native_negative"
+)
+
 type metricFamily struct {
 	name string
 	typ  telegraf.ValueType
@@ -35,12 +42,14 @@ type labelPair struct {
 }
 
 type scaler struct {
-	value float64
+	value    float64
+	exemplar *Exemplar
 }
 
 type bucket struct {
-	bound float64
-	count uint64
+	bound    float64
+	count    uint64
+	exemplar *Exemplar
 }
 
 type quantile struct {
@@ -52,18 +61,130 @@ type histogram struct {
 	buckets []bucket
 	count   uint64
 	sum     float64
+	native  *nativeHistogram
 }
 
 func (h *histogram) merge(b bucket) {
 	for i := range h.buckets {
 		if h.buckets[i].bound == b.bound {
 			h.buckets[i].count = b.count
+			if b.exemplar != nil {
+				h.buckets[i].exemplar = b.exemplar
+			}
 			return
 		}
 	}
 	h.buckets = append(h.buckets, b)
 }
 
+// nativeAt returns the native part of the histogram for a sample at the given
+// time. The sparse buckets are not merged across samples as buckets might
+// vanish e.g. on counter resets, so a newer sample replaces all buckets.
+func (h *histogram) nativeAt(t time.Time) *nativeHistogram {
+	if h.native == nil || t.After(h.native.time) {
+		h.native = &nativeHistogram{
+			time:     t,
+			positive: make(map[int]nativeBucket),
+			negative: make(map[int]nativeBucket),
+		}
+	}
+	return h.native
+}
+
+type nativeBucket struct {
+	count    uint64
+	exemplar *Exemplar
+}
+
+type nativeHistogram struct {
+	time          time.Time
+	schema        int32
+	zeroThreshold float64
+	zeroCount     uint64
+	zeroExemplar  *Exemplar
+	positive      map[int]nativeBucket
+	negative      map[int]nativeBucket
+}
+
+func (n *nativeHistogram) fill(h *dto.Histogram) {
+	h.Schema = proto.Int32(n.schema)
+	h.ZeroThreshold = proto.Float64(n.zeroThreshold)
+	h.ZeroCount = proto.Uint64(n.zeroCount)
+
+	var positiveExemplars, negativeExemplars []*dto.Exemplar
+	h.PositiveSpan, h.PositiveDelta, positiveExemplars = nativeSpans(n.positive)
+	h.NegativeSpan, h.NegativeDelta, negativeExemplars = nativeSpans(n.negative)
+
+	// A histogram without any sparse bucket needs an empty span to be
+	// recognized as native histogram
+	if len(h.PositiveSpan) == 0 && len(h.NegativeSpan) == 0 {
+		h.PositiveSpan = []*dto.BucketSpan{{Offset: proto.Int32(0), Length: proto.Uint32(0)}}
+	}
+
+	if n.zeroExemplar != nil {
+		h.Exemplars = append(h.Exemplars, n.zeroExemplar.proto())
+	}
+	h.Exemplars = append(h.Exemplars, negativeExemplars...)
+	h.Exemplars = append(h.Exemplars, positiveExemplars...)
+}
+
+// nativeSpans encodes the given buckets as spans of consecutive bucket indices
+// and the count deltas between neighboring buckets as expected by the protobuf
+// exposition format.
+func nativeSpans(buckets map[int]nativeBucket) ([]*dto.BucketSpan, []int64, []*dto.Exemplar) {
+	indices := make([]int, 0, len(buckets))
+	for index := range buckets {
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+
+	var spans []*dto.BucketSpan
+	var deltas []int64
+	var exemplars []*dto.Exemplar
+	var next int
+	var previous int64
+	for i, index := range indices {
+		// Start a new span on the first bucket and on gaps; the offset of the
+		// first span is absolute, the following ones are relative to the end
+		// of the previous span
+		if i == 0 || index != next {
+			spans = append(spans, &dto.BucketSpan{
+				Offset: proto.Int32(int32(index - next)),
+				Length: proto.Uint32(0),
+			})
+		}
+		span := spans[len(spans)-1]
+		span.Length = proto.Uint32(span.GetLength() + 1)
+
+		b := buckets[index]
+		deltas = append(deltas, int64(b.count)-previous)
+		previous = int64(b.count)
+		next = index + 1
+
+		if b.exemplar != nil {
+			exemplars = append(exemplars, b.exemplar.proto())
+		}
+	}
+	return spans, deltas, exemplars
+}
+
+// nativeBucketIndex returns the bucket index and the sign of the native
+// histogram bucket the metric represents.
+func nativeBucketIndex(m telegraf.Metric) (index int, positive, ok bool) {
+	tag, positive := m.GetTag(nativePositiveTag)
+	if !positive {
+		if tag, ok = m.GetTag(nativeNegativeTag); !ok {
+			return 0, false, false
+		}
+	}
+
+	index, err := strconv.Atoi(tag)
+	if err != nil {
+		return 0, false, false
+	}
+	return index, positive, true
+}
+
 type summary struct {
 	quantiles []quantile
 	count     uint64
@@ -136,7 +257,7 @@ func (c *Collection) createLabels(metric telegraf.Metric) []labelPair {
 		// Ignore special tags for histogram and summary types.
 		switch metric.Type() {
 		case telegraf.Histogram:
-			if tag.Key == "le" {
+			if tag.Key == "le" || tag.Key == nativePositiveTag || tag.Key == nativeNegativeTag {
 				continue
 			}
 		case telegraf.Summary:
@@ -160,7 +281,7 @@ func (c *Collection) createLabels(metric telegraf.Metric) []labelPair {
 	addedFieldLabel := false
 	for _, field := range metric.FieldList() {
 		value, ok := field.Value.(string)
-		if !ok {
+		if !ok || IsExemplarField(field.Key) {
 			continue
 		}
 
@@ -191,7 +312,12 @@ func (c *Collection) createLabels(metric telegraf.Metric) []labelPair {
 // Add adds a metric to the collection. It will create a new entry if the metric is not already present.
 func (c *Collection) Add(m telegraf.Metric, now time.Time) {
 	labels := c.createLabels(m)
+	exemplars := Exemplars(m)
 	for _, field := range m.FieldList() {
+		if IsExemplarField(field.Key) {
+			continue
+		}
+
 		metricName := MetricName(m.Name(), field.Key, m.Type())
 		metricName, ok := c.sanitizeMetricName(metricName)
 		if !ok {
@@ -240,7 +366,7 @@ func (c *Collection) Add(m telegraf.Metric, now time.Time) {
 				labels:  labels,
 				time:    m.Time(),
 				addTime: now,
-				scaler:  &scaler{value: value},
+				scaler:  &scaler{value: value, exemplar: exemplars[field.Key]},
 			}
 
 			singleEntry.metrics[metricKey] = existingMetric
@@ -258,24 +384,58 @@ func (c *Collection) Add(m telegraf.Metric, now time.Time) {
 			}
 			switch {
 			case strings.HasSuffix(field.Key, "_bucket"):
-				le, ok := m.GetTag("le")
+				count, ok := SampleCount(field.Value)
 				if !ok {
 					continue
 				}
-				bound, err := strconv.ParseFloat(le, 64)
-				if err != nil {
+
+				if index, positive, ok := nativeBucketIndex(m); ok {
+					native := existingMetric.histogram.nativeAt(m.Time())
+					b := nativeBucket{count: count, exemplar: exemplars[field.Key]}
+					if positive {
+						native.positive[index] = b
+					} else {
+						native.negative[index] = b
+					}
+				} else {
+					le, ok := m.GetTag("le")
+					if !ok {
+						continue
+					}
+					bound, err := strconv.ParseFloat(le, 64)
+					if err != nil {
+						continue
+					}
+
+					existingMetric.histogram.merge(bucket{
+						bound:    bound,
+						count:    count,
+						exemplar: exemplars[field.Key],
+					})
+				}
+			case strings.HasSuffix(field.Key, "_schema"):
+				schema, ok := SampleValue(field.Value)
+				if !ok {
+					continue
+				}
+
+				existingMetric.histogram.nativeAt(m.Time()).schema = int32(schema)
+			case strings.HasSuffix(field.Key, "_zero_threshold"):
+				threshold, ok := SampleValue(field.Value)
+				if !ok {
 					continue
 				}
 
+				existingMetric.histogram.nativeAt(m.Time()).zeroThreshold = threshold
+			case strings.HasSuffix(field.Key, "_zero_count"):
 				count, ok := SampleCount(field.Value)
 				if !ok {
 					continue
 				}
 
-				existingMetric.histogram.merge(bucket{
-					bound: bound,
-					count: count,
-				})
+				native := existingMetric.histogram.nativeAt(m.Time())
+				native.zeroCount = count
+				native.zeroExemplar = exemplars[field.Key]
 			case strings.HasSuffix(field.Key, "_sum"):
 				sum, ok := SampleSum(field.Value)
 				if !ok {
@@ -455,15 +615,22 @@ func (c *Collection) GetProto() []*dto.MetricFamily {
 				m.Gauge = &dto.Gauge{Value: proto.Float64(metric.scaler.value)}
 			case telegraf.Counter:
 				m.Counter = &dto.Counter{Value: proto.Float64(metric.scaler.value)}
+				if metric.scaler.exemplar != nil {
+					m.Counter.Exemplar = metric.scaler.exemplar.proto()
+				}
 			case telegraf.Untyped:
 				m.Untyped = &dto.Untyped{Value: proto.Float64(metric.scaler.value)}
 			case telegraf.Histogram:
 				buckets := make([]*dto.Bucket, 0, len(metric.histogram.buckets))
 				for _, bucket := range metric.histogram.buckets {
-					buckets = append(buckets, &dto.Bucket{
+					b := &dto.Bucket{
 						UpperBound:      proto.Float64(bucket.bound),
 						CumulativeCount: proto.Uint64(bucket.count),
-					})
+					}
+					if bucket.exemplar != nil {
+						b.Exemplar = bucket.exemplar.proto()
+					}
+					buckets = append(buckets, b)
 				}
 
 				m.Histogram = &dto.Histogram{
@@ -471,6 +638,9 @@ func (c *Collection) GetProto() []*dto.MetricFamily {
 					SampleCount: proto.Uint64(metric.histogram.count),
 					SampleSum:   proto.Float64(metric.histogram.sum),
 				}
+				if metric.histogram.native != nil {
+					metric.histogram.native.fill(m.Histogram)
+				}
 			case telegraf.Summary:
 				quantiles := make([]*dto.Quantile, 0, len(metric.summary.quantiles))
 				for _, quantile := range metric.summary.quantiles {
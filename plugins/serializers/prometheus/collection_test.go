@@ -8,6 +8,7 @@ import (
 	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
@@ -989,3 +990,170 @@ func TestCollectionUTF8DropWhenFallbackBecomesEmpty(t *testing.T) {
 		})
 	}
 }
+
+func TestCollectionNativeHistogram(t *testing.T) {
+	histogramMetric := func(ts int64, fields map[string]interface{}) telegraf.Metric {
+		return metric.New("prometheus", map[string]string{"host": "example.org"}, fields, time.Unix(ts, 0), telegraf.Histogram)
+	}
+	bucketMetric := func(ts int64, tag, index string, fields map[string]interface{}) telegraf.Metric {
+		m := histogramMetric(ts, fields)
+		m.AddTag(tag, index)
+		return m
+	}
+
+	c := NewCollection(FormatConfig{})
+	for _, m := range []telegraf.Metric{
+		histogramMetric(0, map[string]interface{}{
+			"latency_count":          6.0,
+			"latency_sum":            3.5,
+			"latency_schema":         int64(0),
+			"latency_zero_threshold": 0.001,
+			"latency_zero_count":     1.0,
+		}),
+		bucketMetric(0, "native_positive", "0", map[string]interface{}{
+			"latency_bucket":                         2.0,
+			"latency_bucket_exemplar":                0.9,
+			"latency_bucket_exemplar_label_trace_id": "abc",
+		}),
+		bucketMetric(0, "native_positive", "1", map[string]interface{}{"latency_bucket": 2.0}),
+		bucketMetric(0, "native_positive", "3", map[string]interface{}{"latency_bucket": 1.0}),
+	} {
+		c.Add(m, time.Unix(0, 0))
+	}
+
+	expected := []*dto.MetricFamily{
+		{
+			Name: proto.String("latency"),
+			Help: proto.String(helpString),
+			Type: dto.MetricType_HISTOGRAM.Enum(),
+			Metric: []*dto.Metric{
+				{
+					Label: []*dto.LabelPair{
+						{Name: proto.String("host"), Value: proto.String("example.org")},
+					},
+					Histogram: &dto.Histogram{
+						SampleCount:   proto.Uint64(6),
+						SampleSum:     proto.Float64(3.5),
+						Bucket:        []*dto.Bucket{},
+						Schema:        proto.Int32(0),
+						ZeroThreshold: proto.Float64(0.001),
+						ZeroCount:     proto.Uint64(1),
+						PositiveSpan: []*dto.BucketSpan{
+							{Offset: proto.Int32(0), Length: proto.Uint32(2)},
+							{Offset: proto.Int32(1), Length: proto.Uint32(1)},
+						},
+						PositiveDelta: []int64{2, 0, -1},
+						Exemplars: []*dto.Exemplar{
+							{
+								Label: []*dto.LabelPair{
+									{Name: proto.String("trace_id"), Value: proto.String("abc")},
+								},
+								Value: proto.Float64(0.9),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	require.Equal(t, expected, c.GetProto())
+
+	// A newer sample replaces all sparse buckets
+	c.Add(histogramMetric(1, map[string]interface{}{
+		"latency_count":          1.0,
+		"latency_sum":            -2.0,
+		"latency_schema":         int64(0),
+		"latency_zero_threshold": 0.001,
+		"latency_zero_count":     0.0,
+	}), time.Unix(1, 0))
+	c.Add(bucketMetric(1, "native_negative", "1", map[string]interface{}{"latency_bucket": 1.0}), time.Unix(1, 0))
+
+	h := c.GetProto()[0].Metric[0].Histogram
+	require.Empty(t, h.PositiveSpan)
+	require.Empty(t, h.PositiveDelta)
+	require.Equal(t, []*dto.BucketSpan{{Offset: proto.Int32(1), Length: proto.Uint32(1)}}, h.NegativeSpan)
+	require.Equal(t, []int64{1}, h.NegativeDelta)
+	require.Empty(t, h.Exemplars)
+}
+
+func TestCollectionExemplars(t *testing.T) {
+	c := NewCollection(FormatConfig{StringAsLabel: true, SortMetrics: true})
+	c.Add(
+		metric.New(
+			"prometheus",
+			map[string]string{},
+			map[string]interface{}{
+				"requests_total":                         42.0,
+				"requests_total_exemplar":                1.0,
+				"requests_total_exemplar_timestamp":      int64(1500),
+				"requests_total_exemplar_label_trace_id": "abc",
+				"requests_total_exemplar_label_span_id":  "def",
+			},
+			time.Unix(0, 0),
+			telegraf.Counter,
+		),
+		time.Unix(0, 0),
+	)
+	c.Add(
+		metric.New(
+			"prometheus",
+			map[string]string{"le": "0.5"},
+			map[string]interface{}{
+				"latency_bucket":          3.0,
+				"latency_bucket_exemplar": 0.25,
+			},
+			time.Unix(0, 0),
+			telegraf.Histogram,
+		),
+		time.Unix(0, 0),
+	)
+
+	expected := []*dto.MetricFamily{
+		{
+			Name: proto.String("latency"),
+			Help: proto.String(helpString),
+			Type: dto.MetricType_HISTOGRAM.Enum(),
+			Metric: []*dto.Metric{
+				{
+					Label: make([]*dto.LabelPair, 0),
+					Histogram: &dto.Histogram{
+						SampleCount: proto.Uint64(0),
+						SampleSum:   proto.Float64(0),
+						Bucket: []*dto.Bucket{
+							{
+								UpperBound:      proto.Float64(0.5),
+								CumulativeCount: proto.Uint64(3),
+								Exemplar: &dto.Exemplar{
+									Label: make([]*dto.LabelPair, 0),
+									Value: proto.Float64(0.25),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: proto.String("requests_total"),
+			Help: proto.String(helpString),
+			Type: dto.MetricType_COUNTER.Enum(),
+			Metric: []*dto.Metric{
+				{
+					Label: make([]*dto.LabelPair, 0),
+					Counter: &dto.Counter{
+						Value: proto.Float64(42),
+						Exemplar: &dto.Exemplar{
+							Label: []*dto.LabelPair{
+								{Name: proto.String("span_id"), Value: proto.String("def")},
+								{Name: proto.String("trace_id"), Value: proto.String("abc")},
+							},
+							Value:     proto.Float64(1),
+							Timestamp: timestamppb.New(time.UnixMilli(1500)),
+						},
+					},
+				},
+			},
+		},
+	}
+	require.Equal(t, expected, c.GetProto())
+}
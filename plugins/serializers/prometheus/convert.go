@@ -150,6 +150,12 @@ func MetricName(measurement, fieldKey string, valueType telegraf.ValueType) stri
 		switch {
 		case strings.HasSuffix(fieldKey, "_bucket"):
 			fieldKey = strings.TrimSuffix(fieldKey, "_bucket")
+		case valueType == telegraf.Histogram && strings.HasSuffix(fieldKey, "_schema"):
+			fieldKey = strings.TrimSuffix(fieldKey, "_schema")
+		case valueType == telegraf.Histogram && strings.HasSuffix(fieldKey, "_zero_threshold"):
+			fieldKey = strings.TrimSuffix(fieldKey, "_zero_threshold")
+		case valueType == telegraf.Histogram && strings.HasSuffix(fieldKey, "_zero_count"):
+			fieldKey = strings.TrimSuffix(fieldKey, "_zero_count")
 		case strings.HasSuffix(fieldKey, "_sum"):
 			fieldKey = strings.TrimSuffix(fieldKey, "_sum")
 		case strings.HasSuffix(fieldKey, "_count"):
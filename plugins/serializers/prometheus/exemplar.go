@@ -0,0 +1,120 @@
+package prometheus
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Exemplars are attached to the field holding the sample they belong to. The
+// exemplar value is stored in a field with the "_exemplar" suffix, the
+// optional timestamp in milliseconds since epoch in a field with the
+// "_exemplar_timestamp" suffix and each exemplar label in a string field with
+// the "_exemplar_label_<name>" suffix.
+const (
+	exemplarSuffix          = "_exemplar"
+	exemplarTimestampSuffix = "_exemplar_timestamp"
+	exemplarLabelInfix      = "_exemplar_label_"
+)
+
+// Exemplar is an exemplar attached to a sample.
+type Exemplar struct {
+	Value     float64
+	Timestamp time.Time
+	Labels    map[string]string
+}
+
+// IsExemplarField returns true if the field with the given key is part of an
+// exemplar and does not represent a sample.
+func IsExemplarField(key string) bool {
+	return strings.HasSuffix(key, exemplarSuffix) ||
+		strings.HasSuffix(key, exemplarTimestampSuffix) ||
+		strings.Contains(key, exemplarLabelInfix)
+}
+
+// Exemplars extracts the exemplars of the metric indexed by the key of the
+// field they are attached to. Incomplete exemplars without a value are
+// ignored.
+func Exemplars(m telegraf.Metric) map[string]*Exemplar {
+	var exemplars map[string]*Exemplar
+	get := func(key string) *Exemplar {
+		if exemplars == nil {
+			exemplars = make(map[string]*Exemplar)
+		}
+		e, found := exemplars[key]
+		if !found {
+			e = &Exemplar{}
+			exemplars[key] = e
+		}
+		return e
+	}
+
+	values := make(map[string]bool)
+	for _, field := range m.FieldList() {
+		switch {
+		case strings.HasSuffix(field.Key, exemplarSuffix):
+			value, ok := SampleValue(field.Value)
+			if !ok {
+				continue
+			}
+			key := strings.TrimSuffix(field.Key, exemplarSuffix)
+			get(key).Value = value
+			values[key] = true
+		case strings.HasSuffix(field.Key, exemplarTimestampSuffix):
+			ts, ok := field.Value.(int64)
+			if !ok {
+				continue
+			}
+			get(strings.TrimSuffix(field.Key, exemplarTimestampSuffix)).Timestamp = time.UnixMilli(ts)
+		default:
+			key, name, found := strings.Cut(field.Key, exemplarLabelInfix)
+			if !found {
+				continue
+			}
+			value, ok := field.Value.(string)
+			if !ok {
+				continue
+			}
+			e := get(key)
+			if e.Labels == nil {
+				e.Labels = make(map[string]string)
+			}
+			e.Labels[name] = value
+		}
+	}
+
+	for key := range exemplars {
+		if !values[key] {
+			delete(exemplars, key)
+		}
+	}
+	return exemplars
+}
+
+func (e *Exemplar) proto() *dto.Exemplar {
+	labels := make([]*dto.LabelPair, 0, len(e.Labels))
+	for name, value := range e.Labels {
+		labels = append(labels, &dto.LabelPair{
+			Name:  proto.String(name),
+			Value: proto.String(value),
+		})
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].GetName() < labels[j].GetName()
+	})
+
+	pb := &dto.Exemplar{
+		Label: labels,
+		Value: proto.Float64(e.Value),
+	}
+	if !e.Timestamp.IsZero() {
+		pb.Timestamp = timestamppb.New(e.Timestamp)
+	}
+	return pb
+}
@@ -0,0 +1,115 @@
+package selfstat
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDurationBuckets are the upper bounds of the buckets used for
+// histograms of durations if no buckets are given
+var DefaultDurationBuckets = []time.Duration{
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+	10 * time.Second,
+}
+
+// fieldsStat is implemented by stats reporting multiple fields
+type fieldsStat interface {
+	Fields() map[string]interface{}
+}
+
+type histogramStat struct {
+	measurement string
+	field       string
+	tags        map[string]string
+	bounds      []time.Duration
+	names       []string
+	buckets     []int64
+	count       int64
+	sum         int64
+	mu          sync.Mutex
+}
+
+func newHistogramStat(measurement, field string, tags map[string]string, bounds []time.Duration) *histogramStat {
+	if len(bounds) == 0 {
+		bounds = DefaultDurationBuckets
+	}
+	names := make([]string, 0, len(bounds))
+	for _, b := range bounds {
+		names = append(names, field+"_le_"+strings.ReplaceAll(b.String(), "µ", "u"))
+	}
+	return &histogramStat{
+		measurement: measurement,
+		field:       field,
+		tags:        tags,
+		bounds:      bounds,
+		names:       names,
+		buckets:     make([]int64, len(bounds)),
+	}
+}
+
+// Incr adds the observation of the given duration in nanoseconds.
+func (s *histogramStat) Incr(v int64) {
+	s.mu.Lock()
+	for i, b := range s.bounds {
+		if v <= b.Nanoseconds() {
+			s.buckets[i]++
+		}
+	}
+	s.count++
+	s.sum += v
+	s.mu.Unlock()
+}
+
+func (s *histogramStat) Set(v int64) {
+	s.Incr(v)
+}
+
+// Get returns the number of observations.
+func (s *histogramStat) Get() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count
+}
+
+// Fields returns the cumulative bucket counts, the number of observations and
+// the sum of the observed durations in nanoseconds.
+func (s *histogramStat) Fields() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fields := make(map[string]interface{}, len(s.buckets)+2)
+	for i, n := range s.buckets {
+		fields[s.names[i]] = n
+	}
+	fields[s.field+"_count"] = s.count
+	fields[s.field+"_sum_ns"] = s.sum
+	return fields
+}
+
+func (s *histogramStat) Name() string {
+	return s.measurement
+}
+
+func (s *histogramStat) FieldName() string {
+	return s.field
+}
+
+// Tags returns a copy of the histogramStat's tags.
+// NOTE this allocates a new map every time it is called.
+func (s *histogramStat) Tags() map[string]string {
+	m := make(map[string]string, len(s.tags))
+	for k, v := range s.tags {
+		m[k] = v
+	}
+	return m
+}
+
+// Unregister removes this stat from the registry only
+func (s *histogramStat) Unregister() {
+	registry.remove(s.measurement, s.field, s.tags)
+}
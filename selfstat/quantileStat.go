@@ -0,0 +1,147 @@
+package selfstat
+
+import (
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/caio/go-tdigest"
+)
+
+// Compression of the t-digest used for estimating the quantiles, see the
+// quantile aggregator for details
+const quantileCompression = 100
+
+// Quantiles reported by quantile timing stats in addition to the maximum
+var quantiles = []struct {
+	suffix string
+	q      float64
+}{
+	{"p50", 0.5},
+	{"p90", 0.9},
+	{"p99", 0.99},
+}
+
+type quantileTimingStat struct {
+	measurement string
+	field       string
+	tags        map[string]string
+
+	// Names of the quantile and maximum fields
+	names []string
+
+	digest *tdigest.TDigest
+	sum    int64
+	count  int64
+	max    int64
+	prev   map[string]interface{}
+	mu     sync.Mutex
+}
+
+func newQuantileTimingStat(measurement, field string, tags map[string]string) *quantileTimingStat {
+	// Keep the unit suffix at the end of the field name, e.g. the quantiles
+	// of "gather_time_ns" are reported as "gather_time_p50_ns"
+	base, unit := field, ""
+	if strings.HasSuffix(field, "_ns") {
+		base, unit = strings.TrimSuffix(field, "_ns"), "_ns"
+	}
+	names := make([]string, 0, len(quantiles)+1)
+	for _, q := range quantiles {
+		names = append(names, base+"_"+q.suffix+unit)
+	}
+	names = append(names, base+"_max"+unit)
+
+	s := &quantileTimingStat{
+		measurement: measurement,
+		field:       field,
+		tags:        tags,
+		names:       names,
+		prev:        make(map[string]interface{}, len(names)+1),
+	}
+	s.reset()
+	for _, name := range s.names {
+		s.prev[name] = int64(0)
+	}
+	s.prev[field] = int64(0)
+	return s
+}
+
+func (s *quantileTimingStat) reset() {
+	// Creating a digest only fails for invalid options
+	digest, err := tdigest.New(tdigest.Compression(quantileCompression))
+	if err != nil {
+		panic(err)
+	}
+	s.digest = digest
+	s.sum = 0
+	s.count = 0
+	s.max = 0
+}
+
+// Incr adds the observation of the given duration in nanoseconds.
+func (s *quantileTimingStat) Incr(v int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Adding only fails for NaN or infinite values
+	_ = s.digest.Add(float64(v))
+	s.sum += v
+	s.count++
+	s.max = max(s.max, v)
+}
+
+func (s *quantileTimingStat) Set(v int64) {
+	s.Incr(v)
+}
+
+// Get returns the average of the observations since the last read like
+// timing stats do.
+func (s *quantileTimingStat) Get() int64 {
+	return s.Fields()[s.field].(int64)
+}
+
+// Fields returns the average, the quantiles and the maximum of the
+// observations since the last read. Without new observations the previous
+// values are returned.
+func (s *quantileTimingStat) Fields() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.count > 0 {
+		s.prev[s.field] = s.sum / s.count
+		for i, q := range quantiles {
+			s.prev[s.names[i]] = int64(math.Round(s.digest.Quantile(q.q)))
+		}
+		s.prev[s.names[len(quantiles)]] = s.max
+		s.reset()
+	}
+
+	fields := make(map[string]interface{}, len(s.prev))
+	for k, v := range s.prev {
+		fields[k] = v
+	}
+	return fields
+}
+
+func (s *quantileTimingStat) Name() string {
+	return s.measurement
+}
+
+func (s *quantileTimingStat) FieldName() string {
+	return s.field
+}
+
+// Tags returns a copy of the quantileTimingStat's tags.
+// NOTE this allocates a new map every time it is called.
+func (s *quantileTimingStat) Tags() map[string]string {
+	m := make(map[string]string, len(s.tags))
+	for k, v := range s.tags {
+		m[k] = v
+	}
+	return m
+}
+
+// Unregister removes this stat from the registry only
+func (s *quantileTimingStat) Unregister() {
+	registry.remove(s.measurement, s.field, s.tags)
+}
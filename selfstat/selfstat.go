@@ -76,6 +76,33 @@ func RegisterTiming(measurement, field string, tags map[string]string) Stat {
 	return registry.registerTiming("internal_"+measurement, field, tags)
 }
 
+// RegisterQuantileTiming registers the given measurement, field, and tags in
+// the selfstat registry. If given an identical measurement, it will return the
+// stat that's already been registered.
+//
+// Quantile timing stats behave like timing stats but in addition to the
+// average report the estimated 50th, 90th and 99th percentile and the maximum
+// of the timings added since the last call to Get() or Metrics(). For a field
+// named "write_time_ns" these are reported as "write_time_p50_ns",
+// "write_time_p90_ns", "write_time_p99_ns" and "write_time_max_ns".
+func RegisterQuantileTiming(measurement, field string, tags map[string]string) Stat {
+	return registry.registerQuantileTiming("internal_"+measurement, field, tags)
+}
+
+// RegisterHistogram registers the given measurement, field, and tags in the
+// selfstat registry. If given an identical measurement, it will return the stat
+// that's already been registered.
+//
+// Histogram stats count the durations in nanoseconds added via Incr() or Set()
+// in buckets with the given upper bounds, using DefaultDurationBuckets if no
+// bounds are given. The stat reports the cumulative count of each bucket as
+// '<field>_le_<bound>' together with the total number of observations as
+// '<field>_count' and their sum as '<field>_sum_ns'. Get() returns the number
+// of observations.
+func RegisterHistogram(measurement, field string, tags map[string]string, bounds ...time.Duration) Stat {
+	return registry.registerHistogram("internal_"+measurement, field, tags, bounds)
+}
+
 // Unregister removes the specified statistic from the registry
 func Unregister(measurement, field string, tags map[string]string) {
 	registry.remove("internal_"+measurement, field, tags)
@@ -97,7 +124,13 @@ func Metrics() []telegraf.Metric {
 					tags = stat.Tags()
 					name = stat.Name()
 				}
-				fields[fieldname] = stat.Get()
+				if fs, ok := stat.(fieldsStat); ok {
+					for k, v := range fs.Fields() {
+						fields[k] = v
+					}
+				} else {
+					fields[fieldname] = stat.Get()
+				}
 				j++
 			}
 			m := metric.New(name, tags, fields, now)
@@ -159,6 +192,44 @@ func (r *Registry) registerTiming(measurement, field string, tags map[string]str
 	return s
 }
 
+func (r *Registry) registerQuantileTiming(measurement, field string, tags map[string]string) Stat {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := key(measurement, tags)
+	if stat, ok := registry.get(key, field); ok {
+		return stat
+	}
+
+	t := make(map[string]string, len(tags))
+	for k, v := range tags {
+		t[k] = v
+	}
+
+	s := newQuantileTimingStat(measurement, field, t)
+	registry.set(key, s)
+	return s
+}
+
+func (r *Registry) registerHistogram(measurement, field string, tags map[string]string, bounds []time.Duration) Stat {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := key(measurement, tags)
+	if stat, ok := registry.get(key, field); ok {
+		return stat
+	}
+
+	t := make(map[string]string, len(tags))
+	for k, v := range tags {
+		t[k] = v
+	}
+
+	s := newHistogramStat(measurement, field, t, bounds)
+	registry.set(key, s)
+	return s
+}
+
 func (r *Registry) remove(measurement, field string, tags map[string]string) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -2,6 +2,7 @@ package selfstat
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -95,6 +96,59 @@ func TestRegisterTimingAndIncrAndSet(t *testing.T) {
 	require.Equal(t, "internal_test", foo.Name())
 }
 
+func TestRegisterHistogram(t *testing.T) {
+	defer testCleanup()
+	s := RegisterHistogram("test", "latency", map[string]string{"test": "foo"}, time.Millisecond, time.Second)
+	s.Incr(time.Microsecond.Nanoseconds())
+	s.Incr(time.Millisecond.Nanoseconds())
+	s.Incr((500 * time.Millisecond).Nanoseconds())
+	s.Incr(time.Minute.Nanoseconds())
+	require.Equal(t, int64(4), s.Get())
+
+	// make sure that the same field returns the same metric
+	require.Same(t, s, RegisterHistogram("test", "latency", map[string]string{"test": "foo"}))
+
+	acc := testutil.Accumulator{}
+	acc.AddMetrics(Metrics())
+	acc.AssertContainsTaggedFields(t, "internal_test",
+		map[string]interface{}{
+			"latency_le_1ms": int64(2),
+			"latency_le_1s":  int64(3),
+			"latency_count":  int64(4),
+			"latency_sum_ns": (time.Microsecond + time.Millisecond + 500*time.Millisecond + time.Minute).Nanoseconds(),
+		},
+		map[string]string{
+			"test": "foo",
+		},
+	)
+}
+
+func TestRegisterQuantileTiming(t *testing.T) {
+	defer testCleanup()
+	s := RegisterQuantileTiming("test", "test_time_ns", map[string]string{"test": "foo"})
+	for i := int64(1); i <= 100; i++ {
+		s.Incr(i * 1000)
+	}
+
+	// make sure that the same field returns the same metric
+	require.Same(t, s, RegisterQuantileTiming("test", "test_time_ns", map[string]string{"test": "foo"}))
+
+	metrics := Metrics()
+	require.Len(t, metrics, 1)
+	fields := metrics[0].Fields()
+	require.Equal(t, int64(50500), fields["test_time_ns"])
+	require.InDelta(t, 50000, fields["test_time_p50_ns"], 1000)
+	require.InDelta(t, 90000, fields["test_time_p90_ns"], 1000)
+	require.InDelta(t, 99000, fields["test_time_p99_ns"], 1000)
+	require.Equal(t, int64(100000), fields["test_time_max_ns"])
+
+	// The previous values are kept until new timings are added
+	require.Equal(t, fields, Metrics()[0].Fields())
+	s.Incr(5)
+	require.Equal(t, int64(5), s.Get())
+	require.Equal(t, int64(5), Metrics()[0].Fields()["test_time_max_ns"])
+}
+
 func TestStatKeyConsistency(t *testing.T) {
 	lhs := key("internal_stats", map[string]string{
 		"foo":   "bar",